@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+
+	"github.com/azolfagharj/gajin/internal/audit"
+	"github.com/azolfagharj/gajin/internal/config"
+	"github.com/azolfagharj/gajin/internal/github"
+	"github.com/azolfagharj/gajin/internal/logger"
+)
+
+// auditRecorder records the changes made through the GitHub client in the
+// audit log (settings.audit.file). The log is opened once the client can
+// tell whom the changes are made as, before anything is changed.
+type auditRecorder struct {
+	log *audit.Log
+	// actors maps the lowercase owners of targets with a token of their own
+	// to the identity of that token
+	actors map[string]string
+}
+
+// newAuditRecorder returns the recorder of the audit log, or nil if it is
+// disabled or nothing is changed.
+func newAuditRecorder(cfg *config.Config, dryRun bool) *auditRecorder {
+	if !cfg.Settings.Audit.IsEnabled() || dryRun {
+		return nil
+	}
+	return &auditRecorder{}
+}
+
+// changes returns the recorder as the ChangeRecorder of the GitHub client.
+func (r *auditRecorder) changes() github.ChangeRecorder {
+	if r == nil {
+		return nil
+	}
+	return r
+}
+
+// open opens the audit log, attributing the changes to the identity of
+// ghClient, or of the client of their owner for targets with a token of their
+// own, and the local user.
+func (r *auditRecorder) open(ctx context.Context, log *logger.Logger, cfg *config.Config, ghClient github.Client) error {
+	if r == nil {
+		return nil
+	}
+	r.actors = make(map[string]string)
+	for owner := range cfg.OwnerTokens() {
+		r.actors[strings.ToLower(owner)] = tokenActor(ctx, log, github.ClientForOwner(ghClient, owner))
+	}
+	auditLog, err := audit.Open(cfg.Settings.Audit.File, auditActor(ctx, log, cfg, ghClient), auditOperator())
+	if err != nil {
+		return err
+	}
+	r.log = auditLog
+	log.Debug("Recording changes in the audit log", "path", cfg.Settings.Audit.File, "run_id", auditLog.RunID())
+	return nil
+}
+
+func (r *auditRecorder) RecordChange(change github.Change) {
+	record := audit.Record{
+		Operation:   change.Operation,
+		Resource:    change.Resource,
+		Owner:       change.Owner,
+		Repo:        change.Repo,
+		Environment: change.Environment,
+		Name:        change.Name,
+		Result:      audit.ResultSuccess,
+	}
+	// Changes of other owners may be made with the token of their target
+	if actor, ok := r.actors[strings.ToLower(change.Owner)]; ok {
+		record.Actor = actor
+	}
+	if change.Err != nil {
+		record.Result = audit.ResultFailure
+		record.Error = change.Err.Error()
+	}
+	r.log.Record(record)
+}
+
+// close closes the audit log, returning the first error recording a change.
+func (r *auditRecorder) close() error {
+	if r == nil {
+		return nil
+	}
+	return r.log.Close()
+}
+
+// auditActor returns the GitHub identity changes are made as: the GitHub App
+// installation, or the user the token belongs to.
+func auditActor(ctx context.Context, log *logger.Logger, cfg *config.Config, ghClient github.Client) string {
+	if cfg.UsesApp() {
+		return fmt.Sprintf("app/%d installation/%d", cfg.GitHub.App.AppID, cfg.GitHub.App.InstallationID)
+	}
+	return tokenActor(ctx, log, ghClient)
+}
+
+// tokenActor returns the user the token of ghClient belongs to.
+func tokenActor(ctx context.Context, log *logger.Logger, ghClient github.Client) string {
+	login, err := ghClient.GetAuthenticatedUser(ctx)
+	if err != nil {
+		// e.g. the GITHUB_TOKEN of a workflow, which belongs to no user
+		log.Warn("Failed to identify the token for the audit log", "error", err)
+		return "unknown"
+	}
+	return login
+}
+
+// auditOperator returns who ran gajin: the user who triggered the workflow
+// in GitHub Actions, or the local user.
+func auditOperator() string {
+	if actor := os.Getenv("GITHUB_ACTOR"); actor != "" && os.Getenv("GITHUB_ACTIONS") == "true" {
+		return actor
+	}
+	if current, err := user.Current(); err == nil {
+		return current.Username
+	}
+	return os.Getenv("USER")
+}
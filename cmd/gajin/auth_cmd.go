@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/azolfagharj/gajin/internal/auth"
+	"github.com/azolfagharj/gajin/internal/config"
+)
+
+func newAuthCmd() *cobra.Command {
+	authCmd := &cobra.Command{
+		Use:   "auth",
+		Short: "Manage the GitHub token stored in the system keychain",
+	}
+
+	authCmd.AddCommand(&cobra.Command{
+		Use:   "set-token",
+		Short: "Store a GitHub token in the system keychain",
+		Long: `Store a GitHub token in the system keychain (macOS Keychain, Windows
+Credential Manager or the Secret Service on Linux), so it does not have to be
+kept in the configuration file or a shell profile.
+
+The token is prompted for without echo, or read from standard input:
+
+  gh auth token | gajin auth set-token
+
+A token in the keychain takes precedence over GH_TOKEN_WITH_ACTIONS_WRITE and
+github.token; only --token overrides it.`,
+		Args:         cobra.NoArgs,
+		RunE:         runAuthSetToken,
+		SilenceUsage: true,
+	})
+
+	authCmd.AddCommand(&cobra.Command{
+		Use:          "delete-token",
+		Short:        "Remove the GitHub token from the system keychain",
+		Args:         cobra.NoArgs,
+		RunE:         runAuthDeleteToken,
+		SilenceUsage: true,
+	})
+
+	authCmd.AddCommand(&cobra.Command{
+		Use:          "status",
+		Short:        "Show the available GitHub tokens and which one is used",
+		Args:         cobra.NoArgs,
+		RunE:         runAuthStatus,
+		SilenceUsage: true,
+	})
+
+	return authCmd
+}
+
+func runAuthSetToken(cmd *cobra.Command, args []string) error {
+	flags := readFlags(cmd)
+	log := newLogger(flags)
+
+	token, err := readToken()
+	if err != nil {
+		return err
+	}
+	if token == "" {
+		return fmt.Errorf("no token given")
+	}
+	if err := auth.SetKeychainToken(token); err != nil {
+		log.Error("Failed to store the token", "error", err)
+		return err
+	}
+	log.Info("Stored the GitHub token in the system keychain")
+	return nil
+}
+
+// readToken prompts for a token without echo on a terminal, or reads the
+// first line of standard input.
+func readToken() (string, error) {
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		fmt.Fprint(os.Stderr, "Paste your GitHub token: ")
+		data, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("failed to read token: %w", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read token from standard input: %w", err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+func runAuthDeleteToken(cmd *cobra.Command, args []string) error {
+	flags := readFlags(cmd)
+	log := newLogger(flags)
+
+	if err := auth.DeleteKeychainToken(); err != nil {
+		log.Error("Failed to remove the token", "error", err)
+		return err
+	}
+	log.Info("Removed the GitHub token from the system keychain")
+	return nil
+}
+
+// tokenStatus describes one token source for auth status.
+type tokenStatus struct {
+	source string
+	token  string
+	err    error
+}
+
+func runAuthStatus(cmd *cobra.Command, args []string) error {
+	flags := readFlags(cmd)
+
+	// Sources in order of precedence; the configuration file is not read here
+	var statuses []tokenStatus
+	if flags.Token != "" {
+		statuses = append(statuses, tokenStatus{source: "flag (--token)", token: flags.Token})
+	}
+	keychainToken, keychainErr := auth.LookupKeychainToken()
+	statuses = append(statuses,
+		tokenStatus{source: "keychain", token: keychainToken, err: keychainErr},
+		tokenStatus{source: "env (" + config.EnvTokenKey + ")", token: os.Getenv(config.EnvTokenKey)},
+	)
+	for _, provider := range []struct {
+		source string
+		lookup config.TokenProvider
+	}{
+		{"credentials (gajin login)", auth.StoredToken},
+		{"gh CLI", auth.GHCLIToken},
+	} {
+		token, _, err := provider.lookup()
+		statuses = append(statuses, tokenStatus{source: provider.source, token: token, err: err})
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SOURCE\tTOKEN\tSTATUS")
+	used := false
+	for _, status := range statuses {
+		switch {
+		case status.err != nil:
+			fmt.Fprintf(w, "%s\t-\tunavailable: %v\n", status.source, status.err)
+		case status.token == "":
+			fmt.Fprintf(w, "%s\t-\tnot set\n", status.source)
+		case !used:
+			used = true
+			fmt.Fprintf(w, "%s\t%s\tin use\n", status.source, maskSecret(status.token))
+		default:
+			fmt.Fprintf(w, "%s\t%s\toverridden\n", status.source, maskSecret(status.token))
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if !used {
+		fmt.Println("\nNo token found outside the configuration file; run gajin auth set-token or gajin login.")
+	}
+	return nil
+}
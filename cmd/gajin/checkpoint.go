@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/azolfagharj/gajin/internal/cli"
+	"github.com/azolfagharj/gajin/internal/config"
+	"github.com/azolfagharj/gajin/internal/logger"
+	"github.com/azolfagharj/gajin/internal/state"
+)
+
+// checkpointFile is the name of the checkpoint file in the cache directory.
+const checkpointFile = "checkpoint.json"
+
+// kindRepository identifies the checkpoint entry of a completed repository.
+const kindRepository = "repository"
+
+// openCheckpoint returns the checkpoint recording the progress of the run,
+// in the same format as the state file. With --resume it holds the progress
+// of the interrupted run; otherwise any earlier checkpoint is replaced. Dry
+// runs never write the checkpoint, so nil is returned unless they resume.
+func openCheckpoint(cfg *config.Config, flags *cli.Flags, log *logger.Logger) (*state.Store, error) {
+	if flags.DryRun && !flags.Resume {
+		return nil, nil
+	}
+	dir, err := cfg.Settings.Cache.Directory()
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, checkpointFile)
+
+	if flags.Resume {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			log.Warn("No interrupted run to resume; applying everything", "checkpoint", path)
+		}
+		return state.Load(path)
+	}
+
+	checkpoint, err := state.Load(path)
+	if err != nil {
+		// A corrupt checkpoint is replaced like any other
+		log.Debug("Replacing unreadable checkpoint", "path", path, "error", err)
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove checkpoint: %w", err)
+		}
+		return state.Load(path)
+	}
+	if err := checkpoint.Remove(); err != nil {
+		return nil, err
+	}
+	return checkpoint, nil
+}
+
+// repoFingerprint returns the configuration applied to a repository by the
+// run, so that a resumed run only skips a completed repository if its
+// configuration did not change since. It holds secret values, but is only
+// ever stored as a salted hash.
+func repoFingerprint(cfg *config.Config, repo string, res, absent config.Resources, flags *cli.Flags) string {
+	environments := make(map[string]config.EnvironmentSettings)
+	for _, envName := range cfg.EnvironmentsFor(repo) {
+		environments[envName] = cfg.Environments[envName]
+	}
+	data, _ := json.Marshal(struct {
+		Resources                 config.Resources
+		Absent                    config.Resources
+		ActionsPermissions        *config.ActionsPermissions
+		WorkflowPermissions       *config.WorkflowPermissions
+		Environments              map[string]config.EnvironmentSettings
+		Filtered                  bool
+		PruneState                bool
+		CreateMissingEnvironments bool
+	}{res, absent, cfg.ActionsPermissionsFor(repo), cfg.WorkflowPermissionsFor(repo), environments,
+		newEntryFilter(flags).forRepo(repo).active(), flags.PruneState, flags.CreateMissingEnvironments})
+	return string(data)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/azolfagharj/gajin/internal/cli"
+	"github.com/azolfagharj/gajin/internal/config"
+)
+
+func TestRepoFingerprint(t *testing.T) {
+	newConfig := func() *config.Config {
+		return &config.Config{
+			GitHub:       config.GitHubConfig{Owner: "my-org"},
+			Environments: map[string]config.EnvironmentSettings{"production": {WaitTimer: 5, Repos: []string{"api"}}},
+		}
+	}
+	newResources := func() config.Resources {
+		return config.Resources{
+			RepositorySecrets:   map[string]string{"TOKEN": "secret"},
+			RepositoryVariables: map[string]string{"REGION": "eu"},
+		}
+	}
+	base := repoFingerprint(newConfig(), "api", newResources(), config.Resources{}, &cli.Flags{})
+
+	tests := []struct {
+		name    string
+		change  func(cfg *config.Config, res, absent *config.Resources, flags *cli.Flags)
+		repo    string
+		changed bool
+	}{
+		{
+			name:   "same configuration",
+			change: func(cfg *config.Config, res, absent *config.Resources, flags *cli.Flags) {},
+		},
+		{
+			name: "settings of other repositories",
+			change: func(cfg *config.Config, res, absent *config.Resources, flags *cli.Flags) {
+				cfg.Environments["staging"] = config.EnvironmentSettings{WaitTimer: 1, Repos: []string{"web"}}
+			},
+		},
+		{
+			name: "changed secret value",
+			change: func(cfg *config.Config, res, absent *config.Resources, flags *cli.Flags) {
+				res.RepositorySecrets["TOKEN"] = "rotated"
+			},
+			changed: true,
+		},
+		{
+			name: "entry marked absent",
+			change: func(cfg *config.Config, res, absent *config.Resources, flags *cli.Flags) {
+				absent.RepositoryVariables = map[string]string{"OLD": ""}
+			},
+			changed: true,
+		},
+		{
+			name: "changed environment settings",
+			change: func(cfg *config.Config, res, absent *config.Resources, flags *cli.Flags) {
+				cfg.Environments["production"] = config.EnvironmentSettings{WaitTimer: 10, Repos: []string{"api"}}
+			},
+			changed: true,
+		},
+		{
+			name: "filtered run",
+			change: func(cfg *config.Config, res, absent *config.Resources, flags *cli.Flags) {
+				flags.Only = cli.OnlySecrets
+			},
+			changed: true,
+		},
+		{
+			name: "pruned state",
+			change: func(cfg *config.Config, res, absent *config.Resources, flags *cli.Flags) {
+				flags.PruneState = true
+			},
+			changed: true,
+		},
+		{
+			name: "created missing environments",
+			change: func(cfg *config.Config, res, absent *config.Resources, flags *cli.Flags) {
+				flags.CreateMissingEnvironments = true
+			},
+			changed: true,
+		},
+		{
+			name:    "other repository",
+			change:  func(cfg *config.Config, res, absent *config.Resources, flags *cli.Flags) {},
+			repo:    "web",
+			changed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, res, absent, flags := newConfig(), newResources(), config.Resources{}, &cli.Flags{}
+			tt.change(cfg, &res, &absent, flags)
+			repo := tt.repo
+			if repo == "" {
+				repo = "api"
+			}
+			fingerprint := repoFingerprint(cfg, repo, res, absent, flags)
+			if tt.changed {
+				assert.NotEqual(t, base, fingerprint)
+			} else {
+				assert.Equal(t, base, fingerprint)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/azolfagharj/gajin/internal/cli"
+	"github.com/azolfagharj/gajin/internal/config"
+)
+
+func newConfigCmd() *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the gajin configuration",
+	}
+
+	configCmd.AddCommand(&cobra.Command{
+		Use:   "resolve",
+		Short: "Print the effective configuration with the source of every key",
+		Long: `Print the effective configuration after merging the config file,
+environment variables and CLI flags, along with the source each value came from.
+
+Precedence (lowest to highest): default < file < env < keychain < flag`,
+		RunE:         runConfigResolve,
+		SilenceUsage: true,
+	})
+
+	return configCmd
+}
+
+func runConfigResolve(cmd *cobra.Command, args []string) error {
+	flags := readFlags(cmd)
+	log := newLogger(flags)
+
+	cfg, err := config.ReadConfigFromPath(flags.ConfigPath, loadOptions(flags))
+	if err != nil {
+		log.Error("Failed to load configuration", "error", err)
+		return err
+	}
+	cfg.ApplyOverrides(flags.Token, flags.Owner, cli.ParseRepos(flags.Repos))
+	logReplacedToken(log, cfg, flags)
+	cfg.ApplyExcludeOverride(flags.ExcludeRepos)
+	cfg.ApplyHTTPOverrides(flags.Proxy, flags.CAFile, flags.InsecureSkipVerify)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tVALUE\tSOURCE")
+	for _, key := range cfg.Resolve() {
+		value := key.Value
+		if key.Secret && value != "" {
+			value = maskSecret(value)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", key.Key, value, key.Origin)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	// Still report invalid configurations so the output is not mistaken for a usable one
+	if err := cfg.Validate(); err != nil {
+		log.Warn("Effective configuration is invalid", "error", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+
+	"github.com/azolfagharj/gajin/internal/cli"
+	"github.com/azolfagharj/gajin/internal/config"
+	"github.com/azolfagharj/gajin/internal/state"
+)
+
+// errAborted is returned when the user declines the confirmation prompt.
+var errAborted = errors.New("aborted: changes were not confirmed")
+
+// changeCount is an upper bound of the changes a run makes.
+type changeCount struct {
+	Repos   int
+	Sets    int
+	Deletes int
+}
+
+// countChanges counts the secrets and variables a run sets and deletes
+// without contacting GitHub: every configured entry counts as set, and every
+// entry marked absent or, with --prune-state, pruned counts as deleted.
+// Entries excluded by --only, --environment, --name and --target are not
+// counted.
+func countChanges(cfg *config.Config, flags *cli.Flags, store *state.Store) changeCount {
+	filter := newEntryFilter(flags)
+	count := changeCount{Repos: len(cfg.GitHub.Repos)}
+	count.Sets = len(filter.organizationEntries(true, cfg.OrganizationSecretsList())) + len(filter.organizationEntries(false, cfg.OrganizationVariablesList()))
+	for _, secret := range cfg.UserCodespacesSecretsList() {
+		if filter.includesAccount(true, secret.Name) {
+			count.Sets++
+		}
+	}
+	count.Deletes = len(filter.accountNames(true, cfg.AbsentOrganizationSecrets())) + len(filter.accountNames(false, cfg.AbsentOrganizationVariables())) +
+		len(filter.accountNames(true, cfg.AbsentUserCodespacesSecrets()))
+
+	for _, repo := range cfg.GitHub.Repos {
+		repoFilter := filter.forRepo(repo)
+		configured, absent := repoFilter.resources(cfg.ResourcesFor(repo)), repoFilter.resources(cfg.AbsentFor(repo))
+		count.Sets += configured.Len()
+		count.Deletes += absent.Len()
+		if !flags.PruneState {
+			continue
+		}
+		owner, name := cfg.SplitRepo(repo)
+		for _, resource := range store.Resources(owner) {
+			if resource.Repo == name && repoFilter.includes(resource.Kind, resource.Environment, resource.Name) && !hasEntry(configured, resource.ID) && !hasEntry(absent, resource.ID) {
+				count.Deletes++
+			}
+		}
+	}
+	return count
+}
+
+// needsConfirmation reports whether a run must be confirmed: it is
+// interactive (see isInteractive), not a dry run nor confirmed with --yes,
+// and it deletes entries or sets more than threshold of them.
+func needsConfirmation(flags *cli.Flags, count changeCount, threshold int, interactive bool) bool {
+	if flags.DryRun || flags.Yes || !interactive {
+		return false
+	}
+	return count.Deletes > 0 || count.Sets > threshold
+}
+
+// isInteractive reports whether the confirmation can be typed on stdin.
+func isInteractive() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// confirm shows the changes of the run on out and asks to type "yes" on in.
+func confirm(in io.Reader, out io.Writer, owner string, count changeCount) error {
+	fmt.Fprintf(out, "\nThis run sets up to %d secrets and variables and deletes up to %d in %d repositories of %s.\n", count.Sets, count.Deletes, count.Repos, owner)
+	fmt.Fprintln(out, "Run with --dry-run to see the plan, or --yes to skip this prompt.")
+	fmt.Fprint(out, "Type 'yes' to apply: ")
+
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && line == "" {
+		return fmt.Errorf("failed to read confirmation: %w", err)
+	}
+	if strings.TrimSpace(line) != "yes" {
+		return errAborted
+	}
+	return nil
+}
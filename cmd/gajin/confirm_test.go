@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/azolfagharj/gajin/internal/cli"
+	"github.com/azolfagharj/gajin/internal/config"
+	"github.com/azolfagharj/gajin/internal/state"
+)
+
+const confirmConfig = `github:
+  token: t
+  owner: my-org
+  repos: [api, web]
+organization_secrets:
+  NPM_TOKEN: npm
+  OLD_ORG_TOKEN: { state: absent }
+repository_secrets:
+  TOKEN: t
+  OLD_TOKEN: { state: absent }
+repository_variables:
+  REGION: eu
+environment_secrets:
+  production:
+    DB: d
+`
+
+func TestCountChanges(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(confirmConfig), 0o600))
+
+	// The state file holds entries gajin applied earlier, some of them no
+	// longer configured
+	store, err := state.Load(filepath.Join(dir, "state.json"))
+	require.NoError(t, err)
+	store.Record(state.ID{Owner: "my-org", Repo: "api", Kind: kindRepositorySecret, Name: "TOKEN"}, "t")
+	store.Record(state.ID{Owner: "my-org", Repo: "api", Kind: kindRepositorySecret, Name: "STALE"}, "s")
+	store.Record(state.ID{Owner: "my-org", Repo: "web", Kind: kindRepositoryVariable, Name: "GONE"}, "g")
+	store.Record(state.ID{Owner: "my-org", Repo: "other", Kind: kindRepositoryVariable, Name: "GONE"}, "g")
+
+	tests := []struct {
+		name     string
+		flags    cli.Flags
+		repos    []string
+		expected changeCount
+	}{
+		{
+			name:     "every entry",
+			expected: changeCount{Repos: 2, Sets: 7, Deletes: 3},
+		},
+		{
+			name:     "only variables",
+			flags:    cli.Flags{Only: cli.OnlyVariables},
+			expected: changeCount{Repos: 2, Sets: 2, Deletes: 0},
+		},
+		{
+			name:     "names",
+			flags:    cli.Flags{Names: []string{"*token"}},
+			expected: changeCount{Repos: 2, Sets: 3, Deletes: 3},
+		},
+		{
+			name:     "targeted environment",
+			flags:    cli.Flags{Targets: []string{"api@production"}},
+			repos:    []string{"api"},
+			expected: changeCount{Repos: 1, Sets: 2, Deletes: 1},
+		},
+		{
+			name:     "pruned state",
+			flags:    cli.Flags{PruneState: true},
+			expected: changeCount{Repos: 2, Sets: 7, Deletes: 5},
+		},
+		{
+			name:     "pruned state of filtered entries",
+			flags:    cli.Flags{PruneState: true, Only: cli.OnlySecrets},
+			expected: changeCount{Repos: 2, Sets: 5, Deletes: 4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := config.LoadConfig(configPath)
+			require.NoError(t, err)
+			cfg.ApplyOverrides("", "", tt.repos)
+			assert.Equal(t, tt.expected, countChanges(cfg, &tt.flags, store))
+		})
+	}
+}
+
+func TestNeedsConfirmation(t *testing.T) {
+	tests := []struct {
+		name        string
+		flags       cli.Flags
+		count       changeCount
+		interactive bool
+		expected    bool
+	}{
+		{
+			name:        "sets below the threshold",
+			count:       changeCount{Repos: 1, Sets: 10},
+			interactive: true,
+			expected:    false,
+		},
+		{
+			name:        "sets above the threshold",
+			count:       changeCount{Repos: 1, Sets: 11},
+			interactive: true,
+			expected:    true,
+		},
+		{
+			name:        "deletions",
+			count:       changeCount{Repos: 1, Deletes: 1},
+			interactive: true,
+			expected:    true,
+		},
+		{
+			name:        "not interactive",
+			count:       changeCount{Repos: 1, Sets: 11, Deletes: 1},
+			interactive: false,
+			expected:    false,
+		},
+		{
+			name:        "dry run",
+			flags:       cli.Flags{DryRun: true},
+			count:       changeCount{Repos: 1, Sets: 11, Deletes: 1},
+			interactive: true,
+			expected:    false,
+		},
+		{
+			name:        "confirmed with --yes",
+			flags:       cli.Flags{Yes: true},
+			count:       changeCount{Repos: 1, Sets: 11, Deletes: 1},
+			interactive: true,
+			expected:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, needsConfirmation(&tt.flags, tt.count, 10, tt.interactive))
+		})
+	}
+}
+
+func TestConfirm(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{name: "yes", input: "yes\n"},
+		{name: "yes with spaces", input: "  yes \r\n"},
+		{name: "yes at end of input", input: "yes"},
+		{name: "y", input: "y\n", wantErr: errAborted.Error()},
+		{name: "uppercase", input: "YES\n", wantErr: errAborted.Error()},
+		{name: "no", input: "no\n", wantErr: errAborted.Error()},
+		{name: "empty line", input: "\n", wantErr: errAborted.Error()},
+		{name: "end of input", input: "", wantErr: "failed to read confirmation: EOF"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := confirm(strings.NewReader(tt.input), &out, "my-org", changeCount{Repos: 3, Sets: 12, Deletes: 2})
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Contains(t, out.String(), "sets up to 12 secrets and variables and deletes up to 2 in 3 repositories of my-org")
+			assert.Contains(t, out.String(), "Type 'yes' to apply: ")
+		})
+	}
+}
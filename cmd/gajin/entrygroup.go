@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// entryGroup applies the secrets and variables of a repository concurrently,
+// at most settings.concurrency.operations at once. Every entry records its
+// outcome in a result of its own, which is merged into the repository's
+// result in the order the entries were started, so that the summary and plan
+// do not depend on which request finished first.
+type entryGroup struct {
+	result   *repoResult
+	group    errgroup.Group
+	outcomes []*entryOutcome
+}
+
+// entryOutcome holds the outcome of applying one entry.
+type entryOutcome struct {
+	result repoResult
+	err    error
+}
+
+// newEntryGroup returns a group applying at most limit entries of the
+// repository of result at once.
+func newEntryGroup(result *repoResult, limit int) *entryGroup {
+	g := &entryGroup{result: result}
+	g.group.SetLimit(limit)
+	return g
+}
+
+// run applies an entry with apply, which records its outcome in the result it
+// is passed, unless ctx is done. It blocks while limit entries are applied.
+func (g *entryGroup) run(ctx context.Context, apply func(result *repoResult) error) {
+	if ctx.Err() != nil {
+		return
+	}
+	outcome := &entryOutcome{result: repoResult{Repo: g.result.Repo, onEntry: g.result.onEntry}}
+	g.outcomes = append(g.outcomes, outcome)
+	g.group.Go(func() error {
+		outcome.err = apply(&outcome.result)
+		return nil
+	})
+}
+
+// wait waits for the entries being applied and merges their outcomes into
+// the repository's result, returning their errors.
+func (g *entryGroup) wait() []error {
+	g.group.Wait()
+	var errs []error
+	for _, outcome := range g.outcomes {
+		// Entries were reported to onEntry when they were recorded
+		g.result.Entries = append(g.result.Entries, outcome.result.Entries...)
+		g.result.Drift = g.result.Drift || outcome.result.Drift
+		if outcome.err != nil {
+			errs = append(errs, outcome.err)
+		}
+	}
+	g.outcomes = nil
+	return errs
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEntryGroup_MergeOrder(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit int
+	}{
+		{name: "sequential", limit: 1},
+		{name: "concurrent", limit: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Entries are reported as they are recorded, from the workers
+			var mu sync.Mutex
+			var reported []string
+			result := &repoResult{Repo: "api", onEntry: func(repo string, entry entryResult) {
+				mu.Lock()
+				defer mu.Unlock()
+				reported = append(reported, repo+"/"+entry.Name)
+			}}
+			group := newEntryGroup(result, tt.limit)
+			names := []string{"FIRST", "SECOND", "THIRD", "FOURTH"}
+			for i, name := range names {
+				group.run(context.Background(), func(result *repoResult) error {
+					// Later entries finish first when run concurrently
+					time.Sleep(time.Duration(len(names)-i) * 5 * time.Millisecond)
+					result.record(kindRepositoryVariable, "", name, false, nil)
+					if name == "THIRD" {
+						result.Drift = true
+						return fmt.Errorf("failed %s", name)
+					}
+					return nil
+				})
+			}
+			errs := group.wait()
+
+			var merged []string
+			for _, entry := range result.Entries {
+				merged = append(merged, entry.Name)
+			}
+			assert.Equal(t, names, merged)
+			assert.True(t, result.Drift)
+			assert.Equal(t, []error{errors.New("failed THIRD")}, errs)
+			assert.Len(t, reported, len(names))
+			if tt.limit == 1 {
+				assert.Equal(t, []string{"api/FIRST", "api/SECOND", "api/THIRD", "api/FOURTH"}, reported)
+			}
+		})
+	}
+}
+
+func TestEntryGroup_Cancelled(t *testing.T) {
+	result := &repoResult{Repo: "api"}
+	group := newEntryGroup(result, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	group.run(ctx, func(result *repoResult) error {
+		result.record(kindRepositoryVariable, "", "APPLIED", false, nil)
+		return nil
+	})
+	group.wait()
+	cancel()
+	group.run(ctx, func(result *repoResult) error {
+		result.record(kindRepositoryVariable, "", "SKIPPED", false, nil)
+		return nil
+	})
+
+	assert.Empty(t, group.wait())
+	assert.Len(t, result.Entries, 1)
+	assert.Equal(t, "APPLIED", result.Entries[0].Name)
+}
@@ -0,0 +1,52 @@
+package main
+
+import "errors"
+
+// Exit codes of gajin, so that wrapper scripts can branch on the kind of failure.
+const (
+	exitOK = 0
+	// exitFailure reports any failure without a more specific code
+	exitFailure = 1
+	// exitConfigError reports invalid flags or an invalid configuration
+	exitConfigError = 2
+	// exitAuthError reports credentials rejected by GitHub
+	exitAuthError = 3
+	// exitPartialFailure reports a run in which some operations failed
+	exitPartialFailure = 4
+	// exitDrift reports a dry run with --fail-on-drift that found changes to apply
+	exitDrift = 5
+)
+
+// exitError is an error that ends gajin with a specific exit code.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// withExitCode attaches an exit code to err.
+func withExitCode(code int, err error) error {
+	return &exitError{code: code, err: err}
+}
+
+// exitCode returns the exit code for the error returned by a command.
+func exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		return exitErr.code
+	}
+	return exitFailure
+}
+
+// errDrift is returned by a dry run with --fail-on-drift that found changes.
+var errDrift = errors.New("drift detected: the configuration differs from GitHub")
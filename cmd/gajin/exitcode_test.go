@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExitCode(t *testing.T) {
+	failure := errors.New("failure")
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{
+			name:     "success",
+			err:      nil,
+			expected: exitOK,
+		},
+		{
+			name:     "error without exit code",
+			err:      failure,
+			expected: exitFailure,
+		},
+		{
+			name:     "error with exit code",
+			err:      withExitCode(exitAuthError, failure),
+			expected: exitAuthError,
+		},
+		{
+			name:     "wrapped error with exit code",
+			err:      fmt.Errorf("run: %w", withExitCode(exitConfigError, failure)),
+			expected: exitConfigError,
+		},
+		{
+			name:     "outermost exit code wins",
+			err:      withExitCode(exitFailure, withExitCode(exitPartialFailure, failure)),
+			expected: exitFailure,
+		},
+		{
+			name:     "drift",
+			err:      withExitCode(exitDrift, errDrift),
+			expected: exitDrift,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, exitCode(tt.err))
+		})
+	}
+}
+
+func TestWithExitCode(t *testing.T) {
+	failure := errors.New("failure")
+	err := withExitCode(exitConfigError, failure)
+	assert.EqualError(t, err, "failure")
+	assert.ErrorIs(t, err, failure)
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/azolfagharj/gajin/internal/cli"
+	"github.com/azolfagharj/gajin/internal/config"
+)
+
+// entryFilter restricts a run to some of the configured secrets and
+// variables, as selected by --only, --environment, --name and --target.
+type entryFilter struct {
+	// only is cli.OnlySecrets, cli.OnlyVariables or empty for both
+	only string
+	// environments restricts the run to entries of these environments (nil: every entry)
+	environments []string
+	// names holds glob patterns of the entry names to apply (nil: every entry)
+	names []string
+	// targets maps the repositories of --target to their targeted
+	// environments, nil for repositories targeted in full
+	targets map[string][]string
+	// targetEnvironments restricts the entries of one repository to the
+	// environments it is targeted with (see forRepo)
+	targetEnvironments []string
+}
+
+// newEntryFilter returns the filter selected by the flags.
+func newEntryFilter(flags *cli.Flags) entryFilter {
+	filter := entryFilter{only: flags.Only, environments: flags.Environments, names: flags.Names}
+	// Targets were checked by cli.ValidateTargets
+	targets, _ := cli.ParseTargets(flags.Targets)
+	if len(targets) > 0 {
+		filter.targets = make(map[string][]string)
+	}
+	for _, target := range targets {
+		environments, seen := filter.targets[target.Repo]
+		switch {
+		case target.Environment == "":
+			filter.targets[target.Repo] = nil
+		case !seen || environments != nil:
+			filter.targets[target.Repo] = append(environments, target.Environment)
+		}
+	}
+	return filter
+}
+
+// forRepo returns the filter of the entries of repo, which --target may
+// restrict to some of its environments.
+func (f entryFilter) forRepo(repo string) entryFilter {
+	f.targetEnvironments = f.targets[repo]
+	return f
+}
+
+// active reports whether the filter excludes any entry. Filtered runs only
+// apply secrets and variables, not permissions or environment settings.
+// Targeting repositories in full does not filter their entries.
+func (f entryFilter) active() bool {
+	return f.only != "" || len(f.environments) > 0 || len(f.names) > 0 || len(f.targetEnvironments) > 0
+}
+
+// includes reports whether the entry of the given kind, environment (empty
+// for repository-level entries) and name is selected.
+func (f entryFilter) includes(kind, environment, name string) bool {
+	secret := strings.HasSuffix(kind, "_secret")
+	if (f.only == cli.OnlySecrets && !secret) || (f.only == cli.OnlyVariables && secret) {
+		return false
+	}
+	if len(f.environments) > 0 && !slices.Contains(f.environments, environment) {
+		return false
+	}
+	if len(f.targetEnvironments) > 0 && !slices.Contains(f.targetEnvironments, environment) {
+		return false
+	}
+	return f.matchesName(name)
+}
+
+// matchesName reports whether name matches one of the --name patterns.
+// GitHub ignores the case of names, so matching does too.
+func (f entryFilter) matchesName(name string) bool {
+	if len(f.names) == 0 {
+		return true
+	}
+	for _, pattern := range f.names {
+		// Patterns were checked by cli.ValidateNames
+		if ok, _ := path.Match(strings.ToUpper(pattern), strings.ToUpper(name)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// includesAccount reports whether the organization or user entry name is
+// selected, a secret if secret is true and a variable otherwise. These
+// entries belong to no environment.
+func (f entryFilter) includesAccount(secret bool, name string) bool {
+	if secret {
+		return f.includes("organization_secret", "", name)
+	}
+	return f.includes("organization_variable", "", name)
+}
+
+// accountNames returns the organization or user entry names selected by the filter.
+func (f entryFilter) accountNames(secret bool, names []string) []string {
+	return slices.DeleteFunc(names, func(name string) bool { return !f.includesAccount(secret, name) })
+}
+
+// organizationEntries returns the organization entries selected by the filter.
+func (f entryFilter) organizationEntries(secret bool, entries []config.OrganizationEntry) []config.OrganizationEntry {
+	return slices.DeleteFunc(entries, func(entry config.OrganizationEntry) bool { return !f.includesAccount(secret, entry.Name) })
+}
+
+// resources returns the entries of res selected by the filter.
+func (f entryFilter) resources(res config.Resources) config.Resources {
+	if !f.active() {
+		return res
+	}
+	return config.Resources{
+		RepositorySecrets:    f.entries(kindRepositorySecret, "", res.RepositorySecrets),
+		EnvironmentSecrets:   f.environmentEntries(kindEnvironmentSecret, res.EnvironmentSecrets),
+		RepositoryVariables:  f.entries(kindRepositoryVariable, "", res.RepositoryVariables),
+		EnvironmentVariables: f.environmentEntries(kindEnvironmentVariable, res.EnvironmentVariables),
+		CodespacesSecrets:    f.entries(kindCodespacesSecret, "", res.CodespacesSecrets),
+	}
+}
+
+// entries returns the entries of one kind and environment selected by the filter.
+func (f entryFilter) entries(kind, environment string, values map[string]string) map[string]string {
+	filtered := make(map[string]string)
+	for name, value := range values {
+		if f.includes(kind, environment, name) {
+			filtered[name] = value
+		}
+	}
+	return filtered
+}
+
+// environmentEntries returns the environment entries selected by the filter,
+// leaving out environments without any.
+func (f entryFilter) environmentEntries(kind string, entries map[string]map[string]string) map[string]map[string]string {
+	filtered := make(map[string]map[string]string)
+	for envName, values := range entries {
+		if selected := f.entries(kind, envName, values); len(selected) > 0 {
+			filtered[envName] = selected
+		}
+	}
+	return filtered
+}
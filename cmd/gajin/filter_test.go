@@ -0,0 +1,261 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/azolfagharj/gajin/internal/cli"
+	"github.com/azolfagharj/gajin/internal/config"
+)
+
+func TestNewEntryFilter_Targets(t *testing.T) {
+	tests := []struct {
+		name     string
+		targets  []string
+		expected map[string][]string
+	}{
+		{
+			name:     "no targets",
+			targets:  nil,
+			expected: nil,
+		},
+		{
+			name:     "repository",
+			targets:  []string{"api"},
+			expected: map[string][]string{"api": nil},
+		},
+		{
+			name:     "environments of a repository",
+			targets:  []string{"api@production", "api@staging", "web"},
+			expected: map[string][]string{"api": {"production", "staging"}, "web": nil},
+		},
+		{
+			name:     "repository after its environment",
+			targets:  []string{"api@production", "api"},
+			expected: map[string][]string{"api": nil},
+		},
+		{
+			name:     "repository before its environment",
+			targets:  []string{"api", "api@production"},
+			expected: map[string][]string{"api": nil},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := newEntryFilter(&cli.Flags{Targets: tt.targets})
+			assert.Equal(t, tt.expected, filter.targets)
+		})
+	}
+}
+
+func TestEntryFilter_Includes(t *testing.T) {
+	tests := []struct {
+		name        string
+		flags       cli.Flags
+		repo        string
+		kind        string
+		environment string
+		entry       string
+		expected    bool
+	}{
+		{
+			name:     "no filter",
+			kind:     kindRepositoryVariable,
+			entry:    "REGION",
+			expected: true,
+		},
+		{
+			name:     "only secrets includes secrets",
+			flags:    cli.Flags{Only: cli.OnlySecrets},
+			kind:     kindCodespacesSecret,
+			entry:    "TOKEN",
+			expected: true,
+		},
+		{
+			name:     "only secrets excludes variables",
+			flags:    cli.Flags{Only: cli.OnlySecrets},
+			kind:     kindRepositoryVariable,
+			entry:    "REGION",
+			expected: false,
+		},
+		{
+			name:        "only variables excludes secrets",
+			flags:       cli.Flags{Only: cli.OnlyVariables},
+			kind:        kindEnvironmentSecret,
+			environment: "production",
+			entry:       "TOKEN",
+			expected:    false,
+		},
+		{
+			name:        "environment includes its entries",
+			flags:       cli.Flags{Environments: []string{"production"}},
+			kind:        kindEnvironmentVariable,
+			environment: "production",
+			entry:       "STAGE",
+			expected:    true,
+		},
+		{
+			name:        "environment excludes other environments",
+			flags:       cli.Flags{Environments: []string{"production"}},
+			kind:        kindEnvironmentVariable,
+			environment: "staging",
+			entry:       "STAGE",
+			expected:    false,
+		},
+		{
+			name:     "environment excludes repository entries",
+			flags:    cli.Flags{Environments: []string{"production"}},
+			kind:     kindRepositoryVariable,
+			entry:    "REGION",
+			expected: false,
+		},
+		{
+			name:     "name glob",
+			flags:    cli.Flags{Names: []string{"AWS_*"}},
+			kind:     kindRepositorySecret,
+			entry:    "AWS_ACCESS_KEY_ID",
+			expected: true,
+		},
+		{
+			name:     "name glob ignores case",
+			flags:    cli.Flags{Names: []string{"aws_*"}},
+			kind:     kindRepositorySecret,
+			entry:    "AWS_ACCESS_KEY_ID",
+			expected: true,
+		},
+		{
+			name:     "name glob excludes other names",
+			flags:    cli.Flags{Names: []string{"AWS_*", "NPM_?OKEN"}},
+			kind:     kindRepositorySecret,
+			entry:    "GCP_KEY",
+			expected: false,
+		},
+		{
+			name:     "one of several names",
+			flags:    cli.Flags{Names: []string{"AWS_*", "NPM_?OKEN"}},
+			kind:     kindRepositorySecret,
+			entry:    "npm_token",
+			expected: true,
+		},
+		{
+			name:        "targeted environment",
+			flags:       cli.Flags{Targets: []string{"api@production"}},
+			repo:        "api",
+			kind:        kindEnvironmentSecret,
+			environment: "production",
+			entry:       "TOKEN",
+			expected:    true,
+		},
+		{
+			name:        "environment not targeted",
+			flags:       cli.Flags{Targets: []string{"api@production"}},
+			repo:        "api",
+			kind:        kindEnvironmentSecret,
+			environment: "staging",
+			entry:       "TOKEN",
+			expected:    false,
+		},
+		{
+			name:     "repository entries of a targeted environment",
+			flags:    cli.Flags{Targets: []string{"api@production"}},
+			repo:     "api",
+			kind:     kindRepositorySecret,
+			entry:    "TOKEN",
+			expected: false,
+		},
+		{
+			name:        "other repositories are not restricted",
+			flags:       cli.Flags{Targets: []string{"api@production", "web"}},
+			repo:        "web",
+			kind:        kindEnvironmentSecret,
+			environment: "staging",
+			entry:       "TOKEN",
+			expected:    true,
+		},
+		{
+			name:        "full target overrides environment target",
+			flags:       cli.Flags{Targets: []string{"api@production", "api"}},
+			repo:        "api",
+			kind:        kindEnvironmentSecret,
+			environment: "staging",
+			entry:       "TOKEN",
+			expected:    true,
+		},
+		{
+			name:        "filters combine",
+			flags:       cli.Flags{Only: cli.OnlyVariables, Names: []string{"STAGE"}, Targets: []string{"api@production"}},
+			repo:        "api",
+			kind:        kindEnvironmentVariable,
+			environment: "production",
+			entry:       "stage",
+			expected:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := newEntryFilter(&tt.flags).forRepo(tt.repo)
+			assert.Equal(t, tt.expected, filter.includes(tt.kind, tt.environment, tt.entry))
+		})
+	}
+}
+
+func TestEntryFilter_Active(t *testing.T) {
+	assert.False(t, newEntryFilter(&cli.Flags{}).active())
+	assert.True(t, newEntryFilter(&cli.Flags{Only: cli.OnlySecrets}).active())
+	assert.True(t, newEntryFilter(&cli.Flags{Environments: []string{"production"}}).active())
+	assert.True(t, newEntryFilter(&cli.Flags{Names: []string{"AWS_*"}}).active())
+
+	// Targeting a repository in full does not filter its entries
+	targeted := newEntryFilter(&cli.Flags{Targets: []string{"api", "web@production"}})
+	assert.False(t, targeted.forRepo("api").active())
+	assert.True(t, targeted.forRepo("web").active())
+}
+
+func TestEntryFilter_MatchesName(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		entry    string
+		expected bool
+	}{
+		{name: "no patterns", patterns: nil, entry: "TOKEN", expected: true},
+		{name: "exact name", patterns: []string{"TOKEN"}, entry: "TOKEN", expected: true},
+		{name: "lowercase pattern", patterns: []string{"token"}, entry: "TOKEN", expected: true},
+		{name: "lowercase name", patterns: []string{"TOKEN"}, entry: "token", expected: true},
+		{name: "star", patterns: []string{"*_TOKEN"}, entry: "NPM_TOKEN", expected: true},
+		{name: "question mark", patterns: []string{"DB_?"}, entry: "DB_1", expected: true},
+		{name: "character class", patterns: []string{"DB_[0-9]"}, entry: "DB_X", expected: false},
+		{name: "prefix only", patterns: []string{"NPM"}, entry: "NPM_TOKEN", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := newEntryFilter(&cli.Flags{Names: tt.patterns})
+			assert.Equal(t, tt.expected, filter.matchesName(tt.entry))
+		})
+	}
+}
+
+func TestEntryFilter_Resources(t *testing.T) {
+	res := config.Resources{
+		RepositorySecrets:    map[string]string{"TOKEN": "t"},
+		EnvironmentSecrets:   map[string]map[string]string{"production": {"DB": "d"}, "staging": {"DB": "s"}},
+		RepositoryVariables:  map[string]string{"REGION": "eu"},
+		EnvironmentVariables: map[string]map[string]string{"production": {"STAGE": "prod"}},
+	}
+
+	// An inactive filter returns the resources as they are
+	assert.Equal(t, res, newEntryFilter(&cli.Flags{}).resources(res))
+
+	filtered := newEntryFilter(&cli.Flags{Only: cli.OnlySecrets, Targets: []string{"api@production"}}).forRepo("api").resources(res)
+	assert.Equal(t, config.Resources{
+		RepositorySecrets:    map[string]string{},
+		EnvironmentSecrets:   map[string]map[string]string{"production": {"DB": "d"}},
+		RepositoryVariables:  map[string]string{},
+		EnvironmentVariables: map[string]map[string]string{},
+		CodespacesSecrets:    map[string]string{},
+	}, filtered)
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/azolfagharj/gajin/internal/cli"
+	"github.com/azolfagharj/gajin/internal/config"
+	"github.com/azolfagharj/gajin/internal/logger"
+)
+
+// Stages of hooks, passed to them as GAJIN_HOOK.
+const (
+	hookPreApply  = "pre_apply"
+	hookPostApply = "post_apply"
+)
+
+// errHooksDisabled is returned for configured hooks without --allow-commands.
+var errHooksDisabled = errors.New("hooks execute arbitrary commands and are disabled; pass --allow-commands to enable them")
+
+// runHooks runs the hooks of a stage in order with env added to their
+// environment, and returns the errors of the hooks that failed. abort reports
+// that a hook with on_failure: abort failed, which skips the hooks after it.
+func runHooks(ctx context.Context, log *logger.Logger, stage string, hooks []config.Hook, env []string, flags *cli.Flags) (errs []error, abort bool) {
+	for i, hook := range hooks {
+		log.Info("Running hook", "stage", stage, "command", hook.Run)
+		start := time.Now()
+		err := runHook(ctx, hook, env, flags)
+		if err == nil {
+			log.Debug("Hook completed", "stage", stage, "command", hook.Run, "duration", time.Since(start))
+			continue
+		}
+		errs = append(errs, fmt.Errorf("%s hook %d (%s): %w", stage, i, hook.Run, err))
+		if hook.OnFailureOrDefault() == config.HookAbort {
+			log.Error("Hook failed", "stage", stage, "command", hook.Run, "error", err)
+			return errs, true
+		}
+		log.Warn("Hook failed", "stage", stage, "command", hook.Run, "error", err)
+	}
+	return errs, false
+}
+
+// runHook runs a hook through the system shell. Its output goes to standard
+// error, since standard output is reserved for the report; --quiet drops its
+// standard output.
+func runHook(ctx context.Context, hook config.Hook, env []string, flags *cli.Flags) error {
+	timeout := hook.Timeout
+	if timeout == 0 {
+		timeout = flags.CommandTimeout
+	}
+	if timeout <= 0 {
+		timeout = config.DefaultCommandTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", hook.Run)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", hook.Run)
+	}
+	cmd.Dir = hook.Dir
+	cmd.Env = append(os.Environ(), env...)
+	// Do not wait for grandchildren that keep the output pipes open after a timeout
+	cmd.WaitDelay = time.Second
+	cmd.Stdout = os.Stderr
+	if flags.Quiet {
+		cmd.Stdout = io.Discard
+	}
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("command timed out after %s", timeout)
+		}
+		return fmt.Errorf("command failed: %w", err)
+	}
+	return nil
+}
+
+// hookEnv returns the metadata of the run passed to the hooks of stage as
+// environment variables.
+func hookEnv(stage string, cfg *config.Config, flags *cli.Flags) []string {
+	// Hooks run in the directory of the configuration file
+	configPath, err := filepath.Abs(flags.ConfigPath)
+	if err != nil {
+		configPath = flags.ConfigPath
+	}
+	auditLog := cfg.Settings.Audit.File
+	if auditLog != "" {
+		if path, err := filepath.Abs(auditLog); err == nil {
+			auditLog = path
+		}
+	}
+	return []string{
+		"GAJIN_HOOK=" + stage,
+		"GAJIN_OWNER=" + cfg.GitHub.Owner,
+		"GAJIN_REPOS=" + strings.Join(cfg.GitHub.Repos, ","),
+		"GAJIN_PROFILE=" + cfg.Profile,
+		"GAJIN_CONFIG=" + configPath,
+		"GAJIN_AUDIT_LOG=" + auditLog,
+	}
+}
+
+// postApplyEnv returns the metadata passed to post_apply hooks: the outcome
+// of the run and what it changed.
+func postApplyEnv(cfg *config.Config, flags *cli.Flags, report *runReport, results []*repoResult) []string {
+	status := "success"
+	if report.len() > 0 {
+		status = "failure"
+	}
+	created, updated, deleted, changed := changeCounts(results)
+	return append(hookEnv(hookPostApply, cfg, flags),
+		"GAJIN_STATUS="+status,
+		"GAJIN_ERROR_COUNT="+strconv.Itoa(report.len()),
+		"GAJIN_CREATED="+strconv.Itoa(created),
+		"GAJIN_UPDATED="+strconv.Itoa(updated),
+		"GAJIN_DELETED="+strconv.Itoa(deleted),
+		"GAJIN_CHANGED_REPOS="+strings.Join(changed, ","),
+	)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/oauth2"
+
+	"github.com/azolfagharj/gajin/internal/auth"
+)
+
+// EnvOAuthClientID overrides the OAuth app client ID used by gajin login.
+const EnvOAuthClientID = "GAJIN_OAUTH_CLIENT_ID"
+
+// AZ_OAUTH_CLIENT_ID is the client ID of the gajin OAuth app, set at build time.
+var AZ_OAUTH_CLIENT_ID string = ""
+
+func newLoginCmd() *cobra.Command {
+	loginCmd := &cobra.Command{
+		Use:   "login",
+		Short: "Authenticate with GitHub in the browser and store the token",
+		Long: `Authenticate with the GitHub device flow: gajin prints a one-time code to
+enter at https://github.com/login/device, waits for the authorization and
+stores the resulting token in the system keychain, or in the gajin
+configuration directory where no keychain is available.
+
+The stored token is used when neither github.token nor
+GH_TOKEN_WITH_ACTIONS_WRITE is set.`,
+		Args:         cobra.NoArgs,
+		RunE:         runLogin,
+		SilenceUsage: true,
+	}
+	loginCmd.Flags().String("client-id", "", "Client ID of the OAuth app to authorize (default: $"+EnvOAuthClientID+" or the built-in app)")
+	loginCmd.Flags().StringSlice("scopes", auth.DefaultScopes, "OAuth scopes to request")
+	return loginCmd
+}
+
+func runLogin(cmd *cobra.Command, args []string) error {
+	flags := readFlags(cmd)
+	log := newLogger(flags)
+
+	clientID, _ := cmd.Flags().GetString("client-id")
+	if clientID == "" {
+		clientID = os.Getenv(EnvOAuthClientID)
+	}
+	if clientID == "" {
+		clientID = AZ_OAUTH_CLIENT_ID
+	}
+	if clientID == "" {
+		return fmt.Errorf("no OAuth app client ID: pass --client-id or set %s", EnvOAuthClientID)
+	}
+	scopes, _ := cmd.Flags().GetStringSlice("scopes")
+
+	flow := auth.DeviceFlow{ClientID: clientID, Scopes: scopes}
+	token, err := flow.Login(context.Background(), func(code *oauth2.DeviceAuthResponse) {
+		fmt.Printf("First copy your one-time code: %s\n", code.UserCode)
+		fmt.Printf("Then open %s in your browser and enter the code.\n", code.VerificationURI)
+		fmt.Println("Waiting for authorization...")
+	})
+	if err != nil {
+		log.Error("Login failed", "error", err)
+		return err
+	}
+
+	// The token is only written to a file where no keychain is available,
+	// e.g. on headless servers
+	keychainErr := auth.SetKeychainLoginToken(token.AccessToken)
+	if keychainErr == nil {
+		// A token of an earlier login must not remain in plaintext
+		if err := auth.RemoveCredentials(); err != nil {
+			log.Warn("Failed to remove the credentials of an earlier login", "error", err)
+		}
+		log.Info("Logged in to GitHub", "credentials", "system keychain")
+		return nil
+	}
+	log.Debug("System keychain unavailable; storing the token in the credentials file", "error", keychainErr)
+	if err := auth.SaveCredentials(auth.Credentials{Token: token.AccessToken, Scopes: grantedScopes(token, scopes)}); err != nil {
+		log.Error("Failed to store the token", "error", err)
+		return err
+	}
+	path, _ := auth.CredentialsPath()
+	log.Info("Logged in to GitHub", "credentials", path)
+	return nil
+}
+
+// grantedScopes returns the scopes GitHub granted, which may be fewer than
+// requested, falling back to the requested ones.
+func grantedScopes(token *oauth2.Token, requested []string) []string {
+	if scope, ok := token.Extra("scope").(string); ok && scope != "" {
+		return strings.Split(scope, ",")
+	}
+	return requested
+}
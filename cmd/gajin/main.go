@@ -2,16 +2,26 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
-	"sync"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
 
+	"github.com/azolfagharj/gajin/internal/auth"
 	"github.com/azolfagharj/gajin/internal/cli"
 	"github.com/azolfagharj/gajin/internal/config"
 	"github.com/azolfagharj/gajin/internal/github"
 	"github.com/azolfagharj/gajin/internal/logger"
+	"github.com/azolfagharj/gajin/internal/state"
 )
 
 var (
@@ -26,36 +36,265 @@ func main() {
 		Long:         `GajIn (GitHub Actions Secrets & Variables Injector) is a CLI tool to manage GitHub Actions secrets and variables across multiple repositories using a YAML configuration file.`,
 		RunE:         run,
 		SilenceUsage: true,
+		// The log format applies to every command, so it is checked first
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := cli.ValidateLogFormat(readFlags(cmd).LogFormat); err != nil {
+				return withExitCode(exitConfigError, err)
+			}
+			return nil
+		},
 	}
 
 	flags := &cli.Flags{}
-	rootCmd.Flags().StringVarP(&flags.ConfigPath, "config", "c", "config.yaml", "Path to configuration file")
-	rootCmd.Flags().StringVar(&flags.Token, "token", "", "GitHub token (overrides config file)")
-	rootCmd.Flags().StringVar(&flags.Owner, "owner", "", "GitHub owner/organization (overrides config file)")
-	rootCmd.Flags().StringVar(&flags.Repos, "repo", "", "Comma-separated list of repositories (overrides config file)")
+	rootCmd.PersistentFlags().StringVarP(&flags.ConfigPath, "config", "c", "config.yaml", "Path to configuration file")
+	rootCmd.PersistentFlags().StringVar(&flags.Token, "token", "", "GitHub token (overrides config file)")
+	rootCmd.PersistentFlags().StringVar(&flags.Owner, "owner", "", "GitHub owner/organization (overrides config file)")
+	rootCmd.PersistentFlags().StringVar(&flags.Repos, "repo", "", "Comma-separated list of repositories, as name or owner/name (overrides config file)")
+	rootCmd.PersistentFlags().StringSliceVar(&flags.ExcludeRepos, "exclude-repo", nil, "Skip these repositories, in addition to github.exclude_repos (repeatable or comma-separated)")
 	rootCmd.Flags().BoolVar(&flags.DryRun, "dry-run", false, "Show what would be done without making changes")
+	rootCmd.Flags().BoolVarP(&flags.Yes, "yes", "y", false, "Apply without asking for confirmation")
+	rootCmd.Flags().BoolVar(&flags.FailOnDrift, "fail-on-drift", false, "With --dry-run, exit with code 5 when changes would be made")
 	rootCmd.Flags().BoolVar(&flags.ContinueOnError, "continue-on-error", false, "Continue processing other repositories on error")
-	rootCmd.Flags().BoolVarP(&flags.Verbose, "verbose", "v", false, "Enable verbose logging")
+	rootCmd.Flags().BoolVar(&flags.PruneState, "prune-state", false, "Delete secrets and variables applied by earlier runs that were removed from the config file")
+	rootCmd.Flags().BoolVar(&flags.Force, "force", false, "Set every secret and variable, even if its value did not change")
+	rootCmd.Flags().BoolVar(&flags.RollbackOnError, "rollback-on-error", false, "Restore the variables changed by a run that ends with errors")
+	rootCmd.Flags().StringVar(&flags.MetricsFile, "metrics-file", "", "Write Prometheus metrics of the run to this file, e.g. for the node_exporter textfile collector")
+	rootCmd.Flags().BoolVar(&flags.Resume, "resume", false, "Resume an interrupted or failed run, skipping the operations it completed")
+	rootCmd.Flags().StringVar(&flags.Only, "only", "", "Only apply secrets or only variables: secrets or variables")
+	rootCmd.Flags().StringSliceVar(&flags.Environments, "environment", nil, "Only apply the secrets and variables of these environments (repeatable or comma-separated)")
+	rootCmd.Flags().StringSliceVar(&flags.Targets, "target", nil, "Only apply to these repositories, or environments of repositories: repo or repo@environment (repeatable; replaces --repo)")
+	rootCmd.Flags().StringSliceVar(&flags.Names, "name", nil, "Only apply the secrets and variables with these names; glob patterns such as 'AWS_*' are allowed (repeatable)")
+	rootCmd.Flags().IntVar(&flags.Concurrency, "concurrency", 0, "Maximum number of repositories processed in parallel (overrides config file; default: 5)")
+	rootCmd.Flags().Int("max-retries", 0, "Number of times a secret or variable write failing with a transient error is retried (overrides config file; default: 2)")
+	rootCmd.Flags().BoolVar(&flags.CreateMissingEnvironments, "create-missing-environments", false, "Create environments that do not exist instead of failing")
+	rootCmd.PersistentFlags().BoolVarP(&flags.Verbose, "verbose", "v", false, "Enable verbose logging")
+	rootCmd.PersistentFlags().BoolVarP(&flags.Quiet, "quiet", "q", false, "Only log errors, followed by the run summary")
+	rootCmd.PersistentFlags().StringVar(&flags.LogFormat, "log-format", cli.LogFormatText, "Format of the log on standard error: text or json")
 	rootCmd.Flags().BoolVar(&flags.ShowVersion, "version", false, "Show version information")
+	rootCmd.Flags().StringVar(&flags.Output, "output", cli.OutputText, "Format of the run results on standard output: text or json")
+	rootCmd.PersistentFlags().BoolVar(&flags.AllowCommands, "allow-commands", false, "Allow from_command values to execute commands")
+	rootCmd.PersistentFlags().DurationVar(&flags.CommandTimeout, "command-timeout", config.DefaultCommandTimeout, "Timeout for each from_command value and secret store lookup")
+	rootCmd.PersistentFlags().StringVar(&flags.Format, "format", "", "Configuration file format: yaml, json or toml (default: detected from the file extension)")
+	rootCmd.PersistentFlags().StringVar(&flags.Profile, "profile", "", "Configuration profile to apply on top of the base configuration")
+	rootCmd.PersistentFlags().StringVar(&flags.Identity, "identity", "", "Path to an age identity file used to decrypt encrypted values")
+	rootCmd.PersistentFlags().StringVar(&flags.Proxy, "proxy", "", "Proxy URL for GitHub API requests (overrides config file)")
+	rootCmd.PersistentFlags().StringVar(&flags.CAFile, "ca-file", "", "PEM file of additional trusted CA certificates (overrides config file)")
+	rootCmd.PersistentFlags().BoolVar(&flags.InsecureSkipVerify, "insecure-skip-verify", false, "Disable TLS certificate verification (unsafe)")
+
+	rootCmd.AddCommand(newConfigCmd())
+	rootCmd.AddCommand(newSelftestCmd())
+	rootCmd.AddCommand(newSchemaCmd())
+	rootCmd.AddCommand(newLoginCmd())
+	rootCmd.AddCommand(newAuthCmd())
+	rootCmd.AddCommand(newStateCmd())
+	rootCmd.AddCommand(newSetCmd())
+
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return withExitCode(exitConfigError, err)
+	})
 
 	if err := rootCmd.Execute(); err != nil {
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
 }
 
-func run(cmd *cobra.Command, args []string) error {
+// readFlags collects the flag values of a command into cli.Flags.
+// Flags that are not defined on the command keep their zero value.
+func readFlags(cmd *cobra.Command) *cli.Flags {
 	flags := &cli.Flags{}
 	flags.ConfigPath, _ = cmd.Flags().GetString("config")
 	flags.Token, _ = cmd.Flags().GetString("token")
 	flags.Owner, _ = cmd.Flags().GetString("owner")
 	flags.Repos, _ = cmd.Flags().GetString("repo")
+	flags.ExcludeRepos, _ = cmd.Flags().GetStringSlice("exclude-repo")
 	flags.DryRun, _ = cmd.Flags().GetBool("dry-run")
+	flags.FailOnDrift, _ = cmd.Flags().GetBool("fail-on-drift")
+	flags.Yes, _ = cmd.Flags().GetBool("yes")
 	flags.ContinueOnError, _ = cmd.Flags().GetBool("continue-on-error")
 	flags.Verbose, _ = cmd.Flags().GetBool("verbose")
+	flags.Quiet, _ = cmd.Flags().GetBool("quiet")
 	flags.ShowVersion, _ = cmd.Flags().GetBool("version")
+	flags.Output, _ = cmd.Flags().GetString("output")
+	flags.LogFormat, _ = cmd.Flags().GetString("log-format")
+	flags.AllowCommands, _ = cmd.Flags().GetBool("allow-commands")
+	flags.CommandTimeout, _ = cmd.Flags().GetDuration("command-timeout")
+	flags.Profile, _ = cmd.Flags().GetString("profile")
+	flags.Format, _ = cmd.Flags().GetString("format")
+	flags.Identity, _ = cmd.Flags().GetString("identity")
+	flags.CreateMissingEnvironments, _ = cmd.Flags().GetBool("create-missing-environments")
+	flags.Concurrency, _ = cmd.Flags().GetInt("concurrency")
+	if cmd.Flags().Changed("max-retries") {
+		maxRetries, _ := cmd.Flags().GetInt("max-retries")
+		flags.MaxRetries = &maxRetries
+	}
+	flags.Force, _ = cmd.Flags().GetBool("force")
+	flags.RollbackOnError, _ = cmd.Flags().GetBool("rollback-on-error")
+	flags.MetricsFile, _ = cmd.Flags().GetString("metrics-file")
+	flags.PruneState, _ = cmd.Flags().GetBool("prune-state")
+	flags.Only, _ = cmd.Flags().GetString("only")
+	flags.Environments, _ = cmd.Flags().GetStringSlice("environment")
+	flags.Names, _ = cmd.Flags().GetStringSlice("name")
+	flags.Targets, _ = cmd.Flags().GetStringSlice("target")
+	flags.Resume, _ = cmd.Flags().GetBool("resume")
+	flags.Proxy, _ = cmd.Flags().GetString("proxy")
+	flags.CAFile, _ = cmd.Flags().GetString("ca-file")
+	flags.InsecureSkipVerify, _ = cmd.Flags().GetBool("insecure-skip-verify")
+	return flags
+}
+
+// verbosity returns the log verbosity selected by --quiet and --verbose.
+func verbosity(flags *cli.Flags) logger.Verbosity {
+	switch {
+	case flags.Verbose:
+		return logger.Verbose
+	case flags.Quiet:
+		return logger.Quiet
+	}
+	return logger.Normal
+}
+
+// newLogger returns the logger for the verbosity and log format flags.
+func newLogger(flags *cli.Flags) *logger.Logger {
+	log := logger.New(verbosity(flags))
+	if flags.LogFormat == cli.LogFormatJSON {
+		log.SetFormat(logger.JSON)
+	}
+	return log
+}
+
+// logReplacedToken notes that the token of the keychain is used instead of a
+// token of the environment or the configuration file, unless --token replaced
+// both.
+func logReplacedToken(log *logger.Logger, cfg *config.Config, flags *cli.Flags) {
+	if replaced := cfg.ReplacedToken(); replaced != nil && flags.Token == "" {
+		log.Debug("Using the token of the system keychain instead of another token", "replaced", replaced.String())
+	}
+}
+
+// loadOptions builds the config loading options from CLI flags.
+func loadOptions(flags *cli.Flags) config.LoadOptions {
+	return config.LoadOptions{
+		AllowCommands:  flags.AllowCommands,
+		CommandTimeout: flags.CommandTimeout,
+		Profile:        flags.Profile,
+		Format:         flags.Format,
+		Identity:       flags.Identity,
+		KeychainToken:  auth.KeychainToken,
+		TokenProviders: []config.TokenProvider{auth.StoredToken, auth.GHCLIToken},
+	}
+}
+
+// clientOptions returns the GitHub client options for the settings of cfg.
+func clientOptions(cfg *config.Config, log *logger.Logger) github.Options {
+	retry := cfg.Settings.Retry.WithDefaults()
+	return github.Options{
+		RateLimit: github.RateLimit{
+			RequestsPerSecond: cfg.Settings.RateLimit.RequestsPerSecond,
+			Burst:             cfg.Settings.RateLimit.Burst,
+			OnExhausted:       cfg.Settings.RateLimit.OnExhausted,
+		},
+		Retry: github.Retry{
+			MaxAttempts:    retry.MaxAttempts,
+			InitialBackoff: retry.InitialBackoff,
+			MaxBackoff:     retry.MaxBackoff,
+			Jitter:         *retry.Jitter,
+			MaxWait:        retry.MaxWait,
+		},
+		OperationRetries: *retry.OperationRetries,
+		Pacing: github.Pacing{
+			Enabled: true,
+			Reserve: cfg.Settings.RateLimit.PauseThreshold(),
+		},
+		Network: github.Network{
+			Proxy:              cfg.Settings.HTTP.Proxy,
+			CAFile:             cfg.Settings.HTTP.CAFile,
+			InsecureSkipVerify: cfg.Settings.HTTP.InsecureSkipVerify,
+		},
+		RequestTimeout:   cfg.Settings.RequestTimeoutOrDefault(),
+		OperationTimeout: cfg.Settings.OperationTimeoutOrDefault(),
+		BaseURL:          cfg.GitHub.APIURL,
+		UploadURL:        cfg.GitHub.UploadURL,
+		APIVersion:       cfg.GitHub.APIVersion,
+		Observer:         &logObserver{log: log},
+	}
+}
+
+// newClient creates the GitHub client, authenticated as the configured GitHub
+// App installation or with the token. Its requests are counted in metrics
+// and its writes recorded in changes unless they are nil.
+func newClient(cfg *config.Config, log *logger.Logger, metrics *runMetrics, changes github.ChangeRecorder) (github.Client, error) {
+	opts := clientOptions(cfg, log)
+	if metrics != nil {
+		opts.Observer = multiObserver{opts.Observer, metrics}
+	}
+	opts.Changes = changes
+	if opts.Network.InsecureSkipVerify {
+		log.Warn("TLS certificate verification is disabled")
+	}
+	if cfg.Settings.Cache.ETagsEnabled() {
+		if dir, err := cfg.Settings.Cache.Directory(); err != nil {
+			log.Warn("ETag cache disabled", "error", err)
+		} else {
+			opts.ETagCache = github.NewFileETagCache(filepath.Join(dir, "etags"))
+		}
+	}
+
+	// Targets with their own token get a client of their own
+	owners := make(map[string]github.Client)
+	for owner, token := range cfg.OwnerTokens() {
+		client, err := github.NewClientWithOptions(token, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create client for owner %s: %w", owner, err)
+		}
+		owners[owner] = client
+	}
+
+	var client github.Client
+	var err error
+	if cfg.UsesApp() {
+		client, err = github.NewAppClient(github.AppCredentials{
+			AppID:          cfg.GitHub.App.AppID,
+			InstallationID: cfg.GitHub.App.InstallationID,
+			PrivateKeyPath: cfg.GitHub.App.PrivateKeyPath,
+		}, opts)
+	} else {
+		if github.IsFineGrainedToken(cfg.GitHub.Token) {
+			log.Debug("Using a fine-grained personal access token")
+		}
+		client, err = github.NewClientWithOptions(cfg.GitHub.Token, opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return github.NewOwnerClient(client, owners), nil
+}
+
+func run(cmd *cobra.Command, args []string) error {
+	flags := readFlags(cmd)
 
 	// Initialize logger
-	log := logger.New(flags.Verbose)
+	log := newLogger(flags)
+
+	if err := cli.ValidateOutput(flags.Output); err != nil {
+		log.Error("Invalid flag", "error", err)
+		return withExitCode(exitConfigError, err)
+	}
+	if err := cli.ValidateVerbosity(flags.Verbose, flags.Quiet); err != nil {
+		log.Error("Invalid flag", "error", err)
+		return withExitCode(exitConfigError, err)
+	}
+	if err := cli.ValidateOnly(flags.Only); err != nil {
+		log.Error("Invalid flag", "error", err)
+		return withExitCode(exitConfigError, err)
+	}
+	if err := cli.ValidateNames(flags.Names); err != nil {
+		log.Error("Invalid flag", "error", err)
+		return withExitCode(exitConfigError, err)
+	}
+	if err := cli.ValidateTargets(flags.Targets, flags.Repos); err != nil {
+		log.Error("Invalid flag", "error", err)
+		return withExitCode(exitConfigError, err)
+	}
 
 	// Show version if requested
 	if flags.ShowVersion {
@@ -64,32 +303,199 @@ func run(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	// Load configuration
-	cfg, err := config.LoadConfigFromPath(flags.ConfigPath)
+	cfg, err := loadRunConfig(log, flags)
 	if err != nil {
-		log.Error("Failed to load configuration", "error", err)
 		return err
 	}
 
-	// Apply CLI flag overrides
-	repos := cli.ParseRepos(flags.Repos)
-	cfg.ApplyOverrides(flags.Token, flags.Owner, repos)
+	// Hooks execute commands like from_command values; dry runs skip them
+	if !cfg.Hooks.IsEmpty() && !flags.DryRun && !flags.AllowCommands {
+		log.Error("Configuration validation failed", "error", errHooksDisabled)
+		return withExitCode(exitConfigError, errHooksDisabled)
+	}
+	// Targets are matched against the normalized repositories (owner/name of
+	// github.owner is name)
+	for i, target := range flags.Targets {
+		flags.Targets[i] = cfg.NormalizeRepo(target)
+	}
+
+	// Interrupting a run cancels the remaining operations, so that its
+	// progress is saved and it can be resumed; a second interrupt exits
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	metrics := newRunMetrics(flags.MetricsFile)
+
+	// Create GitHub client
+	recorder := newAuditRecorder(cfg, flags.DryRun)
+	ghClient, err := newClient(cfg, log, metrics, recorder.changes())
+	if err != nil {
+		log.Error("Failed to create GitHub client", "error", err)
+		return withExitCode(exitConfigError, err)
+	}
+
+	// Resolve the repositories to process
+	if err := resolveTargets(ctx, log, ghClient, cfg); err != nil {
+		log.Error("Failed to resolve target repositories", "error", err)
+		if github.IsAuthenticationError(err) {
+			return withExitCode(exitAuthError, err)
+		}
+		return err
+	}
+
+	// Pruning relies on the state file to tell which entries gajin applied
+	store := loadState(cfg, log)
+	if flags.PruneState && store == nil {
+		err := fmt.Errorf("--prune-state requires the state file (settings.state)")
+		log.Error("Invalid flag", "error", err)
+		return withExitCode(exitConfigError, err)
+	}
+
+	// A mistyped --name, --environment or --target would otherwise silently
+	// do nothing
+	count := countChanges(cfg, flags, store)
+	if count.Sets+count.Deletes == 0 && (newEntryFilter(flags).active() || len(flags.Targets) > 0) {
+		log.Warn("No configured secrets or variables match --only, --environment, --name and --target")
+	}
+	warnUnmatchedTargets(log, cfg, flags)
+
+	// Ask before large or destructive changes when run interactively
+	if needsConfirmation(flags, count, cfg.Settings.ConfirmThresholdOrDefault(), isInteractive()) {
+		if err := confirm(os.Stdin, os.Stderr, cfg.GitHub.Owner, count); err != nil {
+			log.Error("Not applying the configuration", "error", err)
+			return err
+		}
+	}
+
+	// The checkpoint records the progress of the run for --resume
+	checkpoint, err := openCheckpoint(cfg, flags, log)
+	if err != nil {
+		if flags.Resume {
+			log.Error("Failed to load the checkpoint", "error", err)
+			return withExitCode(exitConfigError, err)
+		}
+		log.Warn("Checkpoint disabled; the run cannot be resumed", "error", err)
+	}
+
+	// With settings.audit, every change is recorded in the audit log
+	if err := recorder.open(ctx, log, cfg, ghClient); err != nil {
+		log.Error("Failed to open the audit log", "error", err)
+		return withExitCode(exitConfigError, err)
+	}
+
+	// Execute the main logic
+	err = execute(ctx, log, ghClient, cfg, flags, store, checkpoint, metrics)
+	// A run whose changes are missing from the audit log fails
+	if auditErr := recorder.close(); auditErr != nil {
+		log.Error("Changes are missing from the audit log", "error", auditErr)
+		if err == nil {
+			err = withExitCode(exitFailure, auditErr)
+		}
+	}
+	return err
+}
+
+// runRepos returns the repositories selected by --repo or --target.
+func runRepos(flags *cli.Flags) []string {
+	if targets, _ := cli.ParseTargets(flags.Targets); len(targets) > 0 {
+		return cli.TargetRepos(targets)
+	}
+	return cli.ParseRepos(flags.Repos)
+}
+
+// loadRunConfig loads the configuration of a run, applies the command line
+// flags and validates the result. The values are resolved by execute, after
+// the pre_apply hooks that may prepare them.
+func loadRunConfig(log *logger.Logger, flags *cli.Flags) (*config.Config, error) {
+	opts := loadOptions(flags)
+	opts.SkipValues = true
+	cfg, err := config.LoadConfigFromPath(flags.ConfigPath, opts)
+	if err != nil {
+		log.Error("Failed to load configuration", "error", err)
+		return nil, withExitCode(exitConfigError, err)
+	}
+
+	// Apply CLI flag overrides; --target selects repositories like --repo
+	cfg.ApplyOverrides(flags.Token, flags.Owner, runRepos(flags))
+	logReplacedToken(log, cfg, flags)
+	cfg.ApplyExcludeOverride(flags.ExcludeRepos)
+	cfg.ApplyHTTPOverrides(flags.Proxy, flags.CAFile, flags.InsecureSkipVerify)
+	cfg.ApplyConcurrencyOverride(flags.Concurrency)
+	cfg.ApplyOperationRetriesOverride(flags.MaxRetries)
 
 	// Validate configuration again after overrides
 	if err := cfg.Validate(); err != nil {
 		log.Error("Configuration validation failed", "error", err)
-		return err
+		return nil, withExitCode(exitConfigError, err)
 	}
+	return cfg, nil
+}
 
-	// Create GitHub client
-	ghClient := github.NewClient(cfg.GitHub.Token)
+// loadState loads the state file, or returns nil if it is disabled. Without
+// it, every secret is set again, so failing to load it is not fatal.
+func loadState(cfg *config.Config, log *logger.Logger) *state.Store {
+	if !cfg.Settings.State.IsEnabled() {
+		return nil
+	}
+	path, err := cfg.Settings.State.Path(cfg.Settings.Cache)
+	if err != nil {
+		log.Warn("State file disabled", "error", err)
+		return nil
+	}
+	store, err := state.Load(path)
+	if err != nil {
+		log.Warn("State file disabled", "error", err)
+		return nil
+	}
+	log.Debug("Using state file", "path", path)
+	return store
+}
 
-	// Execute the main logic
-	ctx := context.Background()
-	return execute(ctx, log, ghClient, cfg, flags)
+// recordState records value as applied to id in store and the checkpoint,
+// or forgets id in store if setting it failed, since the value on GitHub is
+// then unknown.
+func recordState(store, checkpoint *state.Store, id state.ID, value string, err error) {
+	if err != nil {
+		store.Forget(id)
+		return
+	}
+	store.Record(id, value)
+	checkpoint.Record(id, value)
 }
 
-func execute(ctx context.Context, log *logger.Logger, ghClient github.Client, cfg *config.Config, flags *cli.Flags) error {
+// execute runs the pre_apply hooks, resolves the values of the configuration
+// and applies it to the target repositories.
+func execute(ctx context.Context, log *logger.Logger, ghClient github.Client, cfg *config.Config, flags *cli.Flags, store, checkpoint *state.Store, metrics *runMetrics) error {
+	started := time.Now()
+	report := &runReport{}
+
+	// pre_apply hooks run once the run is validated and confirmed, and may
+	// prepare values, e.g. write the file of a from_file secret or log in to
+	// a secret store. The failures of hooks with on_failure: report are added
+	// to the report once the run completed, so that they neither stop it nor
+	// roll it back
+	var hookErrors []error
+	if !flags.DryRun && len(cfg.Hooks.PreApply) > 0 {
+		errs, abort := runHooks(ctx, log, hookPreApply, cfg.Hooks.PreApply, hookEnv(hookPreApply, cfg, flags), flags)
+		if abort {
+			log.Error("Not applying the configuration", "error", errs[len(errs)-1])
+			return withExitCode(exitFailure, finishRun(ctx, log, cfg, flags, metrics, nil, report, nil, nil, errs, started))
+		}
+		hookErrors = errs
+	}
+	if err := cfg.ResolveValues(loadOptions(flags)); err != nil {
+		log.Error("Failed to resolve values", "error", err)
+		// post_apply hooks clean up after the pre_apply hooks that ran
+		if len(cfg.Hooks.PreApply) > 0 && !flags.DryRun {
+			report.add("", err)
+			return withExitCode(exitConfigError, finishRun(ctx, log, cfg, flags, metrics, nil, report, nil, nil, hookErrors, started))
+		}
+		return withExitCode(exitConfigError, err)
+	}
+
 	repoSecretsCount := len(cfg.RepositorySecrets)
 	envSecretsCount := 0
 	for _, secrets := range cfg.EnvironmentSecrets {
@@ -107,7 +513,20 @@ func execute(ctx context.Context, log *logger.Logger, ghClient github.Client, cf
 		"repository_secrets", repoSecretsCount,
 		"environment_secrets", envSecretsCount,
 		"repository_variables", repoVarsCount,
-		"environment_variables", envVarsCount)
+		"environment_variables", envVarsCount,
+		"codespaces_secrets", len(cfg.CodespacesSecrets),
+		"organization_secrets", len(cfg.OrganizationSecrets),
+		"organization_variables", len(cfg.OrganizationVariables),
+		"user_codespaces_secrets", len(cfg.UserCodespacesSecrets),
+		"environments", len(cfg.Environments),
+		"actions_permissions", cfg.ActionsPermissions != nil,
+		"workflow_permissions", cfg.WorkflowPermissions != nil,
+		"groups", len(cfg.Groups),
+		"repo_overrides", len(cfg.RepoOverrides))
+
+	if cfg.Profile != "" {
+		log.Info("Using configuration profile", "profile", cfg.Profile)
+	}
 
 	if flags.DryRun {
 		log.Info("DRY RUN MODE - No changes will be made")
@@ -117,162 +536,1110 @@ func execute(ctx context.Context, log *logger.Logger, ghClient github.Client, cf
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	var wg sync.WaitGroup
-	var errorMutex sync.Mutex
-	var errors []error
+	journal := newRollbackJournal(flags.RollbackOnError, flags.DryRun)
 
-	// Process repositories concurrently
-	for _, repo := range cfg.GitHub.Repos {
-		wg.Add(1)
-		go func(repoName string) {
-			defer wg.Done()
+	// Inaccessible repositories and missing environments are reported before
+	// anything is applied; the plan of a dry run lists missing environments
+	preflightRepositories(ctx, log, ghClient, cfg, report)
+	if !flags.DryRun {
+		preflightEnvironments(ctx, log, ghClient, cfg, flags, report)
+	}
+	if report.len() > 0 && !flags.ContinueOnError {
+		cancel()
+	}
+
+	// Organization-level entries are processed once, before the repositories;
+	// their outcomes are recorded in account
+	filter := newEntryFilter(flags)
+	account := &repoResult{}
+	report.add("", processOrganization(ctx, log, ghClient, cfg, filter, flags.DryRun, account)...)
+	report.add("", processUserCodespaces(ctx, log, ghClient, cfg, filter, flags.DryRun, account)...)
+	if report.len() > 0 && !flags.ContinueOnError {
+		cancel()
+	}
+
+	// Process repositories concurrently, at most settings.concurrency.repos at once
+	limit := cfg.Settings.Concurrency.MaxRepos()
+	log.Debug("Limiting concurrent repositories", "limit", limit)
+	var group errgroup.Group
+	group.SetLimit(limit)
+	// On a terminal, a status line shows how far the run got, unless --quiet;
+	// it would break up JSON log entries
+	var bar *progress
+	if !flags.Quiet && flags.LogFormat != cli.LogFormatJSON {
+		bar = newProgress(os.Stderr, len(cfg.GitHub.Repos))
+	}
+	if bar != nil {
+		log.SetOutput(bar)
+	}
+	results := make([]*repoResult, len(cfg.GitHub.Repos))
+	for i, repo := range cfg.GitHub.Repos {
+		result := &repoResult{Repo: repo, onEntry: bar.entry}
+		results[i] = result
+		group.Go(func() error {
+			defer bar.finish()
 
 			// Check if context is cancelled
 			if ctx.Err() != nil {
-				return
+				result.Skipped = true
+				return nil
 			}
 
-			log.Info("Processing repository", "repo", repoName)
+			log.Info("Processing repository", "repo", repo)
 
-			repoErrors := processRepository(ctx, log, ghClient, cfg.GitHub.Owner, repoName, cfg, flags.DryRun)
+			start := time.Now()
+			repoErrors := processRepository(ctx, log, ghClient, repo, cfg, flags, store, checkpoint, journal, result)
+			result.Duration = time.Since(start)
+			result.Errors = repoErrors
+			if !flags.DryRun {
+				if err := checkpoint.Save(); err != nil {
+					log.Warn("Failed to save the checkpoint", "error", err)
+				}
+			}
 
 			if len(repoErrors) > 0 {
-				errorMutex.Lock()
-				errors = append(errors, repoErrors...)
-				errorMutex.Unlock()
+				report.add(repo, repoErrors...)
 
 				if !flags.ContinueOnError {
-					// Cancel context to stop other goroutines
+					// Cancel context to stop other workers
 					cancel()
 				}
 			}
-		}(repo)
+			return nil
+		})
+	}
+
+	// Wait for all workers to complete; their errors are collected above
+	group.Wait()
+	if bar != nil {
+		bar.stop()
+		log.SetOutput(os.Stderr)
+	}
+
+	// With --rollback-on-error, a failed run leaves the variables as they were
+	if report.len() > 0 && journal.len() > 0 {
+		log.Warn("Rolling back variable changes", "count", journal.len())
+		// The context may have been cancelled by the failure
+		for repo, errs := range journal.rollback(context.WithoutCancel(ctx), log, ghClient, cfg, store, checkpoint) {
+			report.add(repo, errs...)
+		}
+		if err := checkpoint.Save(); err != nil {
+			log.Warn("Failed to save the checkpoint", "error", err)
+		}
+	}
+
+	if !flags.DryRun {
+		if err := store.Save(); err != nil {
+			log.Warn("Failed to save the state file; unchanged secrets will be set again", "error", err)
+		}
+		// A completed run has nothing to resume
+		if report.len() == 0 {
+			if err := checkpoint.Remove(); err != nil {
+				log.Warn("Failed to remove the checkpoint", "error", err)
+			}
+		}
+	}
+
+	return finishRun(ctx, log, cfg, flags, metrics, checkpoint, report, account, results, hookErrors, started)
+}
+
+// finishRun completes a run started at started, also one stopped by a
+// pre_apply hook: it adds hookErrors to the report, runs the post_apply
+// hooks, shows the outcome, notifies and writes the metrics. account holds
+// the outcomes of organization and user entries (nil: none processed).
+func finishRun(ctx context.Context, log *logger.Logger, cfg *config.Config, flags *cli.Flags, metrics *runMetrics, checkpoint *state.Store, report *runReport, account *repoResult, results []*repoResult, hookErrors []error, started time.Time) error {
+	// post_apply hooks also run after a failed or interrupted run
+	report.add("", hookErrors...)
+	if !flags.DryRun && len(cfg.Hooks.PostApply) > 0 {
+		errs, _ := runHooks(context.WithoutCancel(ctx), log, hookPostApply, cfg.Hooks.PostApply, postApplyEnv(cfg, flags, report, results), flags)
+		report.add("", errs...)
+	}
+
+	// Show at a glance which repositories need attention
+	switch {
+	case flags.DryRun && flags.Output == cli.OutputJSON:
+		if err := newPlan(cfg.GitHub.Owner, flags.CreateMissingEnvironments, report, account, results).writeJSON(os.Stdout); err != nil {
+			log.Warn("Failed to write the plan", "error", err)
+		}
+	case flags.Output == cli.OutputJSON:
+		if err := writeJSONReport(os.Stdout, cfg.GitHub.Owner, flags.DryRun, report, account, results); err != nil {
+			log.Warn("Failed to write the JSON report", "error", err)
+		}
+	case flags.DryRun:
+		if err := newPlan(cfg.GitHub.Owner, flags.CreateMissingEnvironments, report, account, results).writeText(os.Stdout); err != nil {
+			log.Warn("Failed to print the plan", "error", err)
+		}
+	case len(results) > 0:
+		if err := printSummary(os.Stdout, results); err != nil {
+			log.Warn("Failed to print the summary", "error", err)
+		}
 	}
 
-	// Wait for all goroutines to complete
-	wg.Wait()
+	// Scheduled runs nobody watches report to chat
+	sendNotifications(context.WithoutCancel(ctx), log, cfg, runSummary(cfg, flags.DryRun, report, results, time.Since(started)))
+	metrics.write(log, report, results, time.Since(started))
 
 	// Report results
-	if len(errors) > 0 {
-		log.Error("Completed with errors", "error_count", len(errors))
-		for _, err := range errors {
-			log.Error("Error", "error", err)
+	if err := report.err(); err != nil {
+		log.Error("Completed with errors", "error_count", report.len())
+		if flags.Output != cli.OutputJSON {
+			if err := report.printSummary(os.Stderr); err != nil {
+				log.Warn("Failed to print the error summary", "error", err)
+			}
+		}
+		if checkpoint != nil && !flags.DryRun {
+			log.Info("Run again with --resume to skip the operations that completed")
+		}
+		return withExitCode(report.exitCode(account, results), fmt.Errorf("failed with %d error(s)", report.len()))
+	}
+
+	if flags.DryRun && flags.FailOnDrift {
+		if account != nil && account.Drift {
+			log.Warn("Drift detected", "owner", cfg.GitHub.Owner)
+			return withExitCode(exitDrift, errDrift)
+		}
+		for _, result := range results {
+			if result.Drift {
+				log.Warn("Drift detected", "repo", result.Repo)
+				return withExitCode(exitDrift, errDrift)
+			}
 		}
-		return fmt.Errorf("failed with %d error(s)", len(errors))
 	}
 
 	log.Info("Successfully completed")
 	return nil
 }
 
-func processRepository(ctx context.Context, log *logger.Logger, ghClient github.Client, owner, repo string, cfg *config.Config, dryRun bool) []error {
+// processRepository applies the configuration to the target repository (a
+// name or owner/name, see config.SplitRepo), recording the outcome of every
+// secret and variable in result. Applied secrets and variables are recorded
+// in store (nil: no state file).
+func processRepository(ctx context.Context, log *logger.Logger, ghClient github.Client, target string, cfg *config.Config, flags *cli.Flags, store, checkpoint *state.Store, journal *rollbackJournal, result *repoResult) []error {
+	// The configuration is looked up by target, GitHub by owner and repo
+	owner, repo := cfg.SplitRepo(target)
 	var errors []error
+	dryRun := flags.DryRun
+	// Entries whose value did not change are skipped unless --force is given
+	skipUnchanged := cfg.Settings.State.SkipUnchangedEnabled() && !flags.Force
 
-	// Repository ID will be fetched automatically by environment operations when needed
+	// Repository IDs needed by environment operations were resolved in bulk by
+	// the repository listing or preflightRepositories, or are fetched on demand
 
-	// Process Repository Secrets
-	for secretName, secretValue := range cfg.RepositorySecrets {
+	// Global sections merged with this repository's overrides, with templates rendered
+	res, err := cfg.RenderedResourcesFor(target)
+	if err != nil {
+		log.Error("Failed to render configuration values", "repo", repo, "error", err)
+		return []error{fmt.Errorf("repo %s/%s: %w", owner, repo, err)}
+	}
+
+	// --only, --environment, --name and --target restrict the run to some
+	// secrets and variables
+	filter := newEntryFilter(flags).forRepo(target)
+	res = filter.resources(res)
+	absent := filter.resources(cfg.AbsentFor(target))
+
+	// A resumed run skips repositories completed with the same configuration
+	repoID := state.ID{Owner: owner, Repo: repo, Kind: kindRepository}
+	fingerprint := repoFingerprint(cfg, target, res, absent, flags)
+	if checkpoint.Unchanged(repoID, fingerprint) {
+		log.Info("Skipping repository completed by the resumed run", "repo", repo)
+		result.Skipped = true
+		return nil
+	}
+
+	// Secrets of a repository with Actions disabled are never used
+	if cfg.ActionsPermissionsFor(target) == nil {
+		enabled, err := ghClient.GetActionsEnabled(ctx, owner, repo)
+		switch {
+		case err != nil:
+			// Reading the policy requires the Administration permission
+			log.Debug("Could not check whether GitHub Actions is enabled", "repo", repo, "error", err)
+		case enabled:
+		case !cfg.Settings.EnsureActionsEnabled:
+			log.Warn("Skipping repository with GitHub Actions disabled (set settings.ensure_actions_enabled to enable it)", "repo", repo)
+			result.Skipped = true
+			return nil
+		case dryRun:
+			log.Info("Would enable GitHub Actions", "repo", repo)
+		default:
+			if err := ghClient.SetActionsPermissions(ctx, owner, repo, github.ActionsPermissions{Enabled: true}); err != nil {
+				log.Error("Failed to enable GitHub Actions", "repo", repo, "error", err)
+				return []error{fmt.Errorf("repo %s/%s actions permissions: %w", owner, repo, err)}
+			}
+			log.Info("Enabled GitHub Actions", "repo", repo)
+		}
+	}
+
+	if policy := cfg.ActionsPermissionsFor(target); policy != nil && !filter.active() && ctx.Err() == nil {
+		permissions := github.ActionsPermissions{
+			Enabled:            policy.IsEnabled(),
+			AllowedActions:     policy.AllowedActionsOrDefault(),
+			GitHubOwnedAllowed: policy.GitHubOwnedAllowed,
+			VerifiedAllowed:    policy.VerifiedAllowed,
+			PatternsAllowed:    policy.PatternsAllowed,
+		}
+		if dryRun {
+			log.Info("Would set Actions permissions", "repo", repo, "enabled", permissions.Enabled, "allowed_actions", permissions.AllowedActions, "patterns_allowed", permissions.PatternsAllowed)
+		} else if err := ghClient.SetActionsPermissions(ctx, owner, repo, permissions); err != nil {
+			log.Error("Failed to set Actions permissions", "repo", repo, "error", err)
+			errors = append(errors, fmt.Errorf("repo %s/%s actions permissions: %w", owner, repo, err))
+		} else {
+			log.Info("Successfully set Actions permissions", "repo", repo)
+		}
+	}
+
+	if policy := cfg.WorkflowPermissionsFor(target); policy != nil && !filter.active() && ctx.Err() == nil {
+		permissions := github.WorkflowPermissions{
+			Default:                policy.Default,
+			CanApprovePullRequests: policy.CanApprovePullRequests,
+		}
+		if dryRun {
+			log.Info("Would set workflow permissions", "repo", repo, "default", permissions.Default, "can_approve_pull_requests", permissions.CanApprovePullRequests)
+		} else if err := ghClient.SetWorkflowPermissions(ctx, owner, repo, permissions); err != nil {
+			log.Error("Failed to set workflow permissions", "repo", repo, "error", err)
+			errors = append(errors, fmt.Errorf("repo %s/%s workflow permissions: %w", owner, repo, err))
+		} else {
+			log.Info("Successfully set workflow permissions", "repo", repo)
+		}
+	}
+
+	// Configure environments first, so their secrets and variables can be set.
+	// Filtered runs leave environment settings alone.
+	environments := cfg.EnvironmentsFor(target)
+	if filter.active() {
+		environments = nil
+	}
+	for _, envName := range environments {
 		if ctx.Err() != nil {
 			return errors
 		}
 
+		env := cfg.Environments[envName]
 		if dryRun {
-			existingSecret, err := ghClient.GetRepositorySecret(ctx, owner, repo, secretName)
-			if err != nil {
-				log.Info("Would create repository secret", "repo", repo, "secret", secretName, "value", maskSecret(secretValue))
-			} else {
-				log.Info("Would update repository secret", "repo", repo, "secret", secretName, "existing", existingSecret.Name, "new_value", maskSecret(secretValue))
+			log.Info("Would configure environment", "repo", repo, "environment", envName, "wait_timer", env.WaitTimer, "reviewer_users", env.Reviewers.Users, "reviewer_teams", env.Reviewers.Teams, "prevent_self_review", env.PreventSelfReview)
+			continue
+		}
+		protection := github.EnvironmentProtection{
+			WaitTimer:         env.WaitTimer,
+			ReviewerUsers:     env.Reviewers.Users,
+			ReviewerTeams:     env.Reviewers.Teams,
+			PreventSelfReview: env.PreventSelfReview,
+		}
+		if err := ghClient.SetEnvironmentProtection(ctx, owner, repo, envName, protection); err != nil {
+			log.Error("Failed to configure environment", "repo", repo, "environment", envName, "error", err)
+			errors = append(errors, fmt.Errorf("repo %s/%s environment %s: %w", owner, repo, envName, err))
+			continue
+		}
+		log.Info("Successfully configured environment", "repo", repo, "environment", envName)
+	}
+
+	// Environments holding entries but not configured themselves must exist
+	if dryRun {
+		configured := cfg.EnvironmentsFor(target)
+		for _, envName := range entryEnvironments(res) {
+			if slices.Contains(configured, envName) || ctx.Err() != nil {
+				continue
 			}
-		} else {
-			if err := ghClient.SetRepositorySecret(ctx, owner, repo, secretName, secretValue); err != nil {
-				log.Error("Failed to set repository secret", "repo", repo, "secret", secretName, "error", err)
-				errors = append(errors, fmt.Errorf("repo %s/%s repository secret %s: %w", owner, repo, secretName, err))
+			exists, err := ghClient.EnvironmentExists(ctx, owner, repo, envName)
+			if err != nil {
+				log.Debug("Could not check whether the environment exists", "repo", repo, "environment", envName, "error", err)
 				continue
 			}
-			log.Info("Successfully set repository secret", "repo", repo, "secret", secretName)
+			if !exists {
+				log.Warn("Environment does not exist", "repo", repo, "environment", envName, "create_missing_environments", flags.CreateMissingEnvironments)
+				result.MissingEnvironments = append(result.MissingEnvironments, envName)
+				result.Drift = true
+			}
 		}
 	}
 
-	// Process Environment Secrets
-	for envName, secrets := range cfg.EnvironmentSecrets {
-		for secretName, secretValue := range secrets {
-			if ctx.Err() != nil {
-				return errors
+	// Entries are applied in parallel, at most settings.concurrency.operations at once
+	entries := newEntryGroup(result, cfg.Settings.Concurrency.MaxOperations())
+
+	// Process Repository Secrets
+	for _, secretName := range sortedKeys(res.RepositorySecrets) {
+		secretValue := res.RepositorySecrets[secretName]
+		entries.run(ctx, func(result *repoResult) error {
+			id := state.ID{Owner: owner, Repo: repo, Kind: kindRepositorySecret, Name: secretName}
+			if checkpoint.Unchanged(id, secretValue) {
+				log.Info("Repository secret already set by the resumed run", "repo", repo, "secret", secretName)
+				result.recordResumed(kindRepositorySecret, "", secretName)
+				return nil
+			}
+			if skipUnchanged && store.Unchanged(id, secretValue) {
+				// The secret may have been deleted on GitHub since it was set
+				if _, err := ghClient.GetRepositorySecret(ctx, owner, repo, secretName); err == nil {
+					log.Info("Repository secret unchanged", "repo", repo, "secret", secretName)
+					result.recordUnchanged(kindRepositorySecret, "", secretName)
+					return nil
+				}
 			}
 
 			if dryRun {
-				existingSecret, err := ghClient.GetEnvironmentSecret(ctx, owner, repo, envName, secretName)
+				existingSecret, err := ghClient.GetRepositorySecret(ctx, owner, repo, secretName)
 				if err != nil {
-					log.Info("Would create environment secret", "repo", repo, "environment", envName, "secret", secretName, "value", maskSecret(secretValue))
+					log.Info("Would create repository secret", "repo", repo, "secret", secretName, "value", maskSecret(secretValue))
+					result.Drift = true
 				} else {
-					log.Info("Would update environment secret", "repo", repo, "environment", envName, "secret", secretName, "existing", existingSecret.Name, "new_value", maskSecret(secretValue))
+					log.Info("Would update repository secret", "repo", repo, "secret", secretName, "existing", existingSecret.Name, "new_value", maskSecret(secretValue))
+					// Secrets cannot be read; the state file tells whether the value changed
+					result.Drift = result.Drift || store.Changed(id, secretValue)
 				}
+				result.record(kindRepositorySecret, "", secretName, err != nil, nil)
 			} else {
-				if err := ghClient.SetEnvironmentSecret(ctx, owner, repo, envName, secretName, secretValue); err != nil {
-					log.Error("Failed to set environment secret", "repo", repo, "environment", envName, "secret", secretName, "error", err)
-					errors = append(errors, fmt.Errorf("repo %s/%s environment secret %s in environment %s: %w", owner, repo, secretName, envName, err))
-					continue
+				created, err := ghClient.SetRepositorySecret(ctx, owner, repo, secretName, secretValue)
+				result.record(kindRepositorySecret, "", secretName, created, err)
+				recordState(store, checkpoint, id, secretValue, err)
+				if err != nil {
+					log.Error("Failed to set repository secret", "repo", repo, "secret", secretName, "error", err)
+					return fmt.Errorf("repo %s/%s repository secret %s: %w", owner, repo, secretName, err)
 				}
-				log.Info("Successfully set environment secret", "repo", repo, "environment", envName, "secret", secretName)
+				log.Info("Successfully set repository secret", "repo", repo, "secret", secretName)
 			}
+			return nil
+		})
+	}
+
+	// Process Environment Secrets
+	for _, envName := range sortedKeys(res.EnvironmentSecrets) {
+		secrets := res.EnvironmentSecrets[envName]
+		for _, secretName := range sortedKeys(secrets) {
+			secretValue := secrets[secretName]
+			entries.run(ctx, func(result *repoResult) error {
+				id := state.ID{Owner: owner, Repo: repo, Environment: envName, Kind: kindEnvironmentSecret, Name: secretName}
+				if checkpoint.Unchanged(id, secretValue) {
+					log.Info("Environment secret already set by the resumed run", "repo", repo, "environment", envName, "secret", secretName)
+					result.recordResumed(kindEnvironmentSecret, envName, secretName)
+					return nil
+				}
+				if skipUnchanged && store.Unchanged(id, secretValue) {
+					if _, err := ghClient.GetEnvironmentSecret(ctx, owner, repo, envName, secretName); err == nil {
+						log.Info("Environment secret unchanged", "repo", repo, "environment", envName, "secret", secretName)
+						result.recordUnchanged(kindEnvironmentSecret, envName, secretName)
+						return nil
+					}
+				}
+
+				if dryRun {
+					existingSecret, err := ghClient.GetEnvironmentSecret(ctx, owner, repo, envName, secretName)
+					if err != nil {
+						log.Info("Would create environment secret", "repo", repo, "environment", envName, "secret", secretName, "value", maskSecret(secretValue))
+						result.Drift = true
+					} else {
+						log.Info("Would update environment secret", "repo", repo, "environment", envName, "secret", secretName, "existing", existingSecret.Name, "new_value", maskSecret(secretValue))
+						result.Drift = result.Drift || store.Changed(id, secretValue)
+					}
+					result.record(kindEnvironmentSecret, envName, secretName, err != nil, nil)
+				} else {
+					var created bool
+					err := setInEnvironment(ctx, log, ghClient, owner, repo, envName, flags.CreateMissingEnvironments, func() (err error) {
+						created, err = ghClient.SetEnvironmentSecret(ctx, owner, repo, envName, secretName, secretValue)
+						return err
+					})
+					result.record(kindEnvironmentSecret, envName, secretName, created, err)
+					recordState(store, checkpoint, id, secretValue, err)
+					if err != nil {
+						log.Error("Failed to set environment secret", "repo", repo, "environment", envName, "secret", secretName, "error", err)
+						return fmt.Errorf("repo %s/%s environment secret %s in environment %s: %w", owner, repo, secretName, envName, err)
+					}
+					log.Info("Successfully set environment secret", "repo", repo, "environment", envName, "secret", secretName)
+				}
+				return nil
+			})
 		}
 	}
 
 	// Process Repository Variables
-	for varName, varValue := range cfg.RepositoryVariables {
+	for _, varName := range sortedKeys(res.RepositoryVariables) {
+		varValue := res.RepositoryVariables[varName]
+		entries.run(ctx, func(result *repoResult) error {
+			id := state.ID{Owner: owner, Repo: repo, Kind: kindRepositoryVariable, Name: varName}
+			if checkpoint.Unchanged(id, varValue) {
+				log.Info("Repository variable already set by the resumed run", "repo", repo, "variable", varName)
+				result.recordResumed(kindRepositoryVariable, "", varName)
+				return nil
+			}
+			if skipUnchanged || dryRun || journal.enabled() {
+				existingVar, err := ghClient.GetRepositoryVariable(ctx, owner, repo, varName)
+				if skipUnchanged && err == nil && existingVar.Value == varValue {
+					log.Info("Repository variable unchanged", "repo", repo, "variable", varName)
+					result.recordUnchanged(kindRepositoryVariable, "", varName)
+					if !dryRun {
+						store.Record(id, varValue)
+					}
+					return nil
+				}
+				if dryRun {
+					if err != nil {
+						log.Info("Would create repository variable", "repo", repo, "variable", varName, "value", varValue)
+						result.Drift = true
+					} else {
+						log.Info("Would update repository variable", "repo", repo, "variable", varName, "existing", existingVar.Name, "new_value", varValue)
+						result.Drift = result.Drift || existingVar.Value != varValue
+					}
+					result.record(kindRepositoryVariable, "", varName, err != nil, nil)
+					return nil
+				}
+				journal.record(id, existingVar, err)
+			}
+
+			created, err := ghClient.SetRepositoryVariable(ctx, owner, repo, varName, varValue)
+			result.record(kindRepositoryVariable, "", varName, created, err)
+			recordState(store, checkpoint, id, varValue, err)
+			if err != nil {
+				log.Error("Failed to set repository variable", "repo", repo, "variable", varName, "error", err)
+				return fmt.Errorf("repo %s/%s repository variable %s: %w", owner, repo, varName, err)
+			}
+			log.Info("Successfully set repository variable", "repo", repo, "variable", varName)
+			return nil
+		})
+	}
+
+	// Process Environment Variables
+	for _, envName := range sortedKeys(res.EnvironmentVariables) {
+		variables := res.EnvironmentVariables[envName]
+		for _, varName := range sortedKeys(variables) {
+			varValue := variables[varName]
+			entries.run(ctx, func(result *repoResult) error {
+				id := state.ID{Owner: owner, Repo: repo, Environment: envName, Kind: kindEnvironmentVariable, Name: varName}
+				if checkpoint.Unchanged(id, varValue) {
+					log.Info("Environment variable already set by the resumed run", "repo", repo, "environment", envName, "variable", varName)
+					result.recordResumed(kindEnvironmentVariable, envName, varName)
+					return nil
+				}
+				if skipUnchanged || dryRun || journal.enabled() {
+					existingVar, err := ghClient.GetEnvironmentVariable(ctx, owner, repo, envName, varName)
+					if skipUnchanged && err == nil && existingVar.Value == varValue {
+						log.Info("Environment variable unchanged", "repo", repo, "environment", envName, "variable", varName)
+						result.recordUnchanged(kindEnvironmentVariable, envName, varName)
+						if !dryRun {
+							store.Record(id, varValue)
+						}
+						return nil
+					}
+					if dryRun {
+						if err != nil {
+							log.Info("Would create environment variable", "repo", repo, "environment", envName, "variable", varName, "value", varValue)
+							result.Drift = true
+						} else {
+							log.Info("Would update environment variable", "repo", repo, "environment", envName, "variable", varName, "existing", existingVar.Name, "new_value", varValue)
+							result.Drift = result.Drift || existingVar.Value != varValue
+						}
+						result.record(kindEnvironmentVariable, envName, varName, err != nil, nil)
+						return nil
+					}
+					journal.record(id, existingVar, err)
+				}
+
+				var created bool
+				err := setInEnvironment(ctx, log, ghClient, owner, repo, envName, flags.CreateMissingEnvironments, func() (err error) {
+					created, err = ghClient.SetEnvironmentVariable(ctx, owner, repo, envName, varName, varValue)
+					return err
+				})
+				result.record(kindEnvironmentVariable, envName, varName, created, err)
+				recordState(store, checkpoint, id, varValue, err)
+				if err != nil {
+					log.Error("Failed to set environment variable", "repo", repo, "environment", envName, "variable", varName, "error", err)
+					return fmt.Errorf("repo %s/%s environment variable %s in environment %s: %w", owner, repo, varName, envName, err)
+				}
+				log.Info("Successfully set environment variable", "repo", repo, "environment", envName, "variable", varName)
+				return nil
+			})
+		}
+	}
+
+	// Process Codespaces Secrets
+	for _, secretName := range sortedKeys(res.CodespacesSecrets) {
+		secretValue := res.CodespacesSecrets[secretName]
+		entries.run(ctx, func(result *repoResult) error {
+			id := state.ID{Owner: owner, Repo: repo, Kind: kindCodespacesSecret, Name: secretName}
+			if checkpoint.Unchanged(id, secretValue) {
+				log.Info("Codespaces secret already set by the resumed run", "repo", repo, "secret", secretName)
+				result.recordResumed(kindCodespacesSecret, "", secretName)
+				return nil
+			}
+			if skipUnchanged && store.Unchanged(id, secretValue) {
+				if _, err := ghClient.GetCodespacesSecret(ctx, owner, repo, secretName); err == nil {
+					log.Info("Codespaces secret unchanged", "repo", repo, "secret", secretName)
+					result.recordUnchanged(kindCodespacesSecret, "", secretName)
+					return nil
+				}
+			}
+
+			if dryRun {
+				existingSecret, err := ghClient.GetCodespacesSecret(ctx, owner, repo, secretName)
+				if err != nil {
+					log.Info("Would create codespaces secret", "repo", repo, "secret", secretName, "value", maskSecret(secretValue))
+					result.Drift = true
+				} else {
+					log.Info("Would update codespaces secret", "repo", repo, "secret", secretName, "existing", existingSecret.Name, "new_value", maskSecret(secretValue))
+					result.Drift = result.Drift || store.Changed(id, secretValue)
+				}
+				result.record(kindCodespacesSecret, "", secretName, err != nil, nil)
+			} else {
+				created, err := ghClient.SetCodespacesSecret(ctx, owner, repo, secretName, secretValue)
+				result.record(kindCodespacesSecret, "", secretName, created, err)
+				recordState(store, checkpoint, id, secretValue, err)
+				if err != nil {
+					log.Error("Failed to set codespaces secret", "repo", repo, "secret", secretName, "error", err)
+					return fmt.Errorf("repo %s/%s codespaces secret %s: %w", owner, repo, secretName, err)
+				}
+				log.Info("Successfully set codespaces secret", "repo", repo, "secret", secretName)
+			}
+			return nil
+		})
+	}
+
+	errors = append(errors, entries.wait()...)
+
+	// Delete entries marked with state: absent
+	errors = append(errors, deleteAbsent(ctx, log, ghClient, owner, repo, absent, dryRun, store, journal, result)...)
+
+	// Delete entries applied by earlier runs that were removed from the configuration
+	if flags.PruneState {
+		errors = append(errors, pruneState(ctx, log, ghClient, owner, repo, res, absent, filter, dryRun, store, journal, result)...)
+	}
+
+	if len(errors) == 0 && ctx.Err() == nil && !dryRun {
+		checkpoint.Record(repoID, fingerprint)
+	}
+	return errors
+}
+
+// sortedKeys returns the keys of m in ascending order, so that entries are
+// applied and logged in the same order on every run.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// entryEnvironments returns the sorted names of the environments holding
+// secrets or variables in res.
+func entryEnvironments(res config.Resources) []string {
+	var names []string
+	for envName := range res.EnvironmentSecrets {
+		names = append(names, envName)
+	}
+	for envName := range res.EnvironmentVariables {
+		if _, ok := res.EnvironmentSecrets[envName]; !ok {
+			names = append(names, envName)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// setInEnvironment runs set, which sets an entry of an environment. If the
+// environment does not exist and create is true, the environment is created
+// and set is run again.
+func setInEnvironment(ctx context.Context, log *logger.Logger, ghClient github.Client, owner, repo, envName string, create bool, set func() error) error {
+	err := set()
+	var envErr *github.EnvironmentNotFoundError
+	if err == nil || !create || !errors.As(err, &envErr) {
+		return err
+	}
+
+	if err := ghClient.CreateEnvironment(ctx, owner, repo, envName); err != nil {
+		return fmt.Errorf("failed to create missing environment: %w", err)
+	}
+	log.Info("Created missing environment", "repo", repo, "environment", envName)
+	return set()
+}
+
+// processOrganization sets and deletes the organization secrets and variables
+// of the owner selected by filter, recording their outcomes in result.
+func processOrganization(ctx context.Context, log *logger.Logger, ghClient github.Client, cfg *config.Config, filter entryFilter, dryRun bool, result *repoResult) []error {
+	var errors []error
+	org := cfg.GitHub.Owner
+
+	secrets, absentSecrets := filter.organizationEntries(true, cfg.OrganizationSecretsList()), filter.accountNames(true, cfg.AbsentOrganizationSecrets())
+	variables, absentVariables := filter.organizationEntries(false, cfg.OrganizationVariablesList()), filter.accountNames(false, cfg.AbsentOrganizationVariables())
+
+	for _, secret := range secrets {
 		if ctx.Err() != nil {
 			return errors
 		}
 
 		if dryRun {
-			existingVar, err := ghClient.GetRepositoryVariable(ctx, owner, repo, varName)
+			existingSecret, err := ghClient.GetOrganizationSecret(ctx, org, secret.Name)
+			result.record(kindOrganizationSecret, "", secret.Name, err != nil, nil)
 			if err != nil {
-				log.Info("Would create repository variable", "repo", repo, "variable", varName, "value", varValue)
+				log.Info("Would create organization secret", "org", org, "secret", secret.Name, "visibility", secret.Visibility, "selected_repos", secret.SelectedRepos, "value", maskSecret(secret.Value))
+				result.Drift = true
 			} else {
-				log.Info("Would update repository variable", "repo", repo, "variable", varName, "existing", existingVar.Name, "new_value", varValue)
+				log.Info("Would update organization secret", "org", org, "secret", secret.Name, "existing_visibility", existingSecret.Visibility, "visibility", secret.Visibility, "selected_repos", secret.SelectedRepos, "new_value", maskSecret(secret.Value))
+				// Only the visibility of a secret can be compared
+				result.Drift = result.Drift || existingSecret.Visibility != secret.Visibility
+				if existingSecret.Visibility == github.VisibilitySelected {
+					if current, err := ghClient.ListOrganizationSecretRepositories(ctx, org, secret.Name); err == nil {
+						log.Info("Organization secret repository access", "org", org, "secret", secret.Name, "current_repos", repositoryNames(current))
+					}
+				}
 			}
-		} else {
-			if err := ghClient.SetRepositoryVariable(ctx, owner, repo, varName, varValue); err != nil {
-				log.Error("Failed to set repository variable", "repo", repo, "variable", varName, "error", err)
-				errors = append(errors, fmt.Errorf("repo %s/%s repository variable %s: %w", owner, repo, varName, err))
+			continue
+		}
+
+		repoIDs, resolveErr := resolveSelectedRepos(ctx, ghClient, org, secret.SelectedRepos)
+		if resolveErr != nil {
+			log.Error("Failed to resolve selected repositories", "org", org, "secret", secret.Name, "error", resolveErr)
+			result.recordSet(kindOrganizationSecret, secret.Name, resolveErr)
+			errors = append(errors, fmt.Errorf("org %s organization secret %s: %w", org, secret.Name, resolveErr))
+			continue
+		}
+
+		err := ghClient.SetOrganizationSecret(ctx, org, secret.Name, secret.Value, secret.Visibility, repoIDs)
+		result.recordSet(kindOrganizationSecret, secret.Name, err)
+		if err != nil {
+			log.Error("Failed to set organization secret", "org", org, "secret", secret.Name, "error", err)
+			errors = append(errors, fmt.Errorf("org %s organization secret %s: %w", org, secret.Name, err))
+			continue
+		}
+		log.Info("Successfully set organization secret", "org", org, "secret", secret.Name, "visibility", secret.Visibility)
+	}
+
+	for _, secretName := range absentSecrets {
+		if ctx.Err() != nil {
+			return errors
+		}
+
+		if dryRun {
+			if _, err := ghClient.GetOrganizationSecret(ctx, org, secretName); err != nil {
+				log.Info("Organization secret already absent", "org", org, "secret", secretName)
+			} else {
+				log.Info("Would delete organization secret", "org", org, "secret", secretName)
+				result.recordDeleted(kindOrganizationSecret, "", secretName, nil)
+				result.Drift = true
+			}
+			continue
+		}
+		err := ghClient.DeleteOrganizationSecret(ctx, org, secretName)
+		result.recordDeleted(kindOrganizationSecret, "", secretName, err)
+		if err != nil {
+			log.Error("Failed to delete organization secret", "org", org, "secret", secretName, "error", err)
+			errors = append(errors, fmt.Errorf("org %s delete organization secret %s: %w", org, secretName, err))
+			continue
+		}
+		log.Info("Successfully deleted organization secret", "org", org, "secret", secretName)
+	}
+
+	for _, variable := range variables {
+		if ctx.Err() != nil {
+			return errors
+		}
+
+		if dryRun {
+			existingVar, err := ghClient.GetOrganizationVariable(ctx, org, variable.Name)
+			result.record(kindOrganizationVariable, "", variable.Name, err != nil, nil)
+			if err != nil {
+				log.Info("Would create organization variable", "org", org, "variable", variable.Name, "visibility", variable.Visibility, "selected_repos", variable.SelectedRepos, "value", variable.Value)
+				result.Drift = true
+			} else {
+				log.Info("Would update organization variable", "org", org, "variable", variable.Name, "existing_visibility", existingVar.Visibility, "visibility", variable.Visibility, "selected_repos", variable.SelectedRepos, "old_value", existingVar.Value, "new_value", variable.Value)
+				result.Drift = result.Drift || existingVar.Value != variable.Value || existingVar.Visibility != variable.Visibility
+				if existingVar.Visibility == github.VisibilitySelected {
+					if current, err := ghClient.ListOrganizationVariableRepositories(ctx, org, variable.Name); err == nil {
+						log.Info("Organization variable repository access", "org", org, "variable", variable.Name, "current_repos", repositoryNames(current))
+					}
+				}
+			}
+			continue
+		}
+
+		repoIDs, resolveErr := resolveSelectedRepos(ctx, ghClient, org, variable.SelectedRepos)
+		if resolveErr != nil {
+			log.Error("Failed to resolve selected repositories", "org", org, "variable", variable.Name, "error", resolveErr)
+			result.recordSet(kindOrganizationVariable, variable.Name, resolveErr)
+			errors = append(errors, fmt.Errorf("org %s organization variable %s: %w", org, variable.Name, resolveErr))
+			continue
+		}
+
+		err := ghClient.SetOrganizationVariable(ctx, org, variable.Name, variable.Value, variable.Visibility, repoIDs)
+		result.recordSet(kindOrganizationVariable, variable.Name, err)
+		if err != nil {
+			log.Error("Failed to set organization variable", "org", org, "variable", variable.Name, "error", err)
+			errors = append(errors, fmt.Errorf("org %s organization variable %s: %w", org, variable.Name, err))
+			continue
+		}
+		log.Info("Successfully set organization variable", "org", org, "variable", variable.Name, "visibility", variable.Visibility)
+	}
+
+	for _, varName := range absentVariables {
+		if ctx.Err() != nil {
+			return errors
+		}
+
+		if dryRun {
+			if _, err := ghClient.GetOrganizationVariable(ctx, org, varName); err != nil {
+				log.Info("Organization variable already absent", "org", org, "variable", varName)
+			} else {
+				log.Info("Would delete organization variable", "org", org, "variable", varName)
+				result.recordDeleted(kindOrganizationVariable, "", varName, nil)
+				result.Drift = true
+			}
+			continue
+		}
+		err := ghClient.DeleteOrganizationVariable(ctx, org, varName)
+		result.recordDeleted(kindOrganizationVariable, "", varName, err)
+		if err != nil {
+			log.Error("Failed to delete organization variable", "org", org, "variable", varName, "error", err)
+			errors = append(errors, fmt.Errorf("org %s delete organization variable %s: %w", org, varName, err))
+			continue
+		}
+		log.Info("Successfully deleted organization variable", "org", org, "variable", varName)
+	}
+
+	return errors
+}
+
+// processUserCodespaces sets and deletes the Codespaces secrets of the
+// authenticated user selected by filter, recording their outcomes in result.
+func processUserCodespaces(ctx context.Context, log *logger.Logger, ghClient github.Client, cfg *config.Config, filter entryFilter, dryRun bool, result *repoResult) []error {
+	var errors []error
+
+	for _, secret := range cfg.UserCodespacesSecretsList() {
+		if ctx.Err() != nil {
+			return errors
+		}
+		if !filter.includesAccount(true, secret.Name) {
+			continue
+		}
+
+		if dryRun {
+			_, err := ghClient.GetUserCodespacesSecret(ctx, secret.Name)
+			result.record(kindUserCodespacesSecret, "", secret.Name, err != nil, nil)
+			if err != nil {
+				log.Info("Would create user codespaces secret", "secret", secret.Name, "selected_repos", secret.SelectedRepos, "value", maskSecret(secret.Value))
+				result.Drift = true
+			} else {
+				log.Info("Would update user codespaces secret", "secret", secret.Name, "selected_repos", secret.SelectedRepos, "new_value", maskSecret(secret.Value))
+			}
+			continue
+		}
+
+		var repoIDs []int64
+		if secret.SelectedRepos != nil {
+			var resolveErr error
+			repoIDs, resolveErr = resolveUserRepos(ctx, ghClient, cfg.GitHub.Owner, secret.SelectedRepos)
+			if resolveErr != nil {
+				log.Error("Failed to resolve selected repositories", "secret", secret.Name, "error", resolveErr)
+				result.recordSet(kindUserCodespacesSecret, secret.Name, resolveErr)
+				errors = append(errors, fmt.Errorf("user codespaces secret %s: %w", secret.Name, resolveErr))
 				continue
 			}
-			log.Info("Successfully set repository variable", "repo", repo, "variable", varName)
 		}
+
+		err := ghClient.SetUserCodespacesSecret(ctx, secret.Name, secret.Value, repoIDs)
+		result.recordSet(kindUserCodespacesSecret, secret.Name, err)
+		if err != nil {
+			log.Error("Failed to set user codespaces secret", "secret", secret.Name, "error", err)
+			errors = append(errors, fmt.Errorf("user codespaces secret %s: %w", secret.Name, err))
+			continue
+		}
+		log.Info("Successfully set user codespaces secret", "secret", secret.Name)
 	}
 
-	// Process Environment Variables
-	for envName, variables := range cfg.EnvironmentVariables {
-		for varName, varValue := range variables {
+	for _, secretName := range filter.accountNames(true, cfg.AbsentUserCodespacesSecrets()) {
+		if ctx.Err() != nil {
+			return errors
+		}
+
+		if dryRun {
+			if _, err := ghClient.GetUserCodespacesSecret(ctx, secretName); err != nil {
+				log.Info("User codespaces secret already absent", "secret", secretName)
+			} else {
+				log.Info("Would delete user codespaces secret", "secret", secretName)
+				result.recordDeleted(kindUserCodespacesSecret, "", secretName, nil)
+				result.Drift = true
+			}
+			continue
+		}
+		err := ghClient.DeleteUserCodespacesSecret(ctx, secretName)
+		result.recordDeleted(kindUserCodespacesSecret, "", secretName, err)
+		if err != nil {
+			log.Error("Failed to delete user codespaces secret", "secret", secretName, "error", err)
+			errors = append(errors, fmt.Errorf("delete user codespaces secret %s: %w", secretName, err))
+			continue
+		}
+		log.Info("Successfully deleted user codespaces secret", "secret", secretName)
+	}
+
+	return errors
+}
+
+// resolveUserRepos resolves repository names, either owner/name or a name of
+// the configured owner, to the IDs the API expects.
+func resolveUserRepos(ctx context.Context, ghClient github.Client, owner string, repos []string) ([]int64, error) {
+	repoIDs := make([]int64, 0, len(repos))
+	for _, repo := range repos {
+		repoOwner, name := owner, repo
+		if o, n, ok := strings.Cut(repo, "/"); ok {
+			repoOwner, name = o, n
+		}
+		id, err := ghClient.GetRepositoryID(ctx, repoOwner, name)
+		if err != nil {
+			return nil, err
+		}
+		repoIDs = append(repoIDs, id)
+	}
+	return repoIDs, nil
+}
+
+// resolveSelectedRepos resolves repository names to the IDs the API expects.
+func resolveSelectedRepos(ctx context.Context, ghClient github.Client, org string, repos []string) ([]int64, error) {
+	var repoIDs []int64
+	for _, repo := range repos {
+		id, err := ghClient.GetRepositoryID(ctx, org, repo)
+		if err != nil {
+			return nil, err
+		}
+		repoIDs = append(repoIDs, id)
+	}
+	return repoIDs, nil
+}
+
+// repositoryNames returns the names of repos.
+func repositoryNames(repos []github.Repository) []string {
+	names := make([]string, 0, len(repos))
+	for _, repo := range repos {
+		names = append(names, repo.Name)
+	}
+	return names
+}
+
+// deleteAbsent deletes the secrets and variables of a repository marked with
+// state: absent, recording every deletion in result. Dry runs record the
+// entries that would be deleted, as drift. Deleted entries are removed from store.
+func deleteAbsent(ctx context.Context, log *logger.Logger, ghClient github.Client, owner, repo string, absent config.Resources, dryRun bool, store *state.Store, journal *rollbackJournal, result *repoResult) []error {
+	var errors []error
+
+	for _, secretName := range sortedKeys(absent.RepositorySecrets) {
+		if ctx.Err() != nil {
+			return errors
+		}
+
+		if dryRun {
+			if _, err := ghClient.GetRepositorySecret(ctx, owner, repo, secretName); err != nil {
+				log.Info("Repository secret already absent", "repo", repo, "secret", secretName)
+			} else {
+				log.Info("Would delete repository secret", "repo", repo, "secret", secretName)
+				result.recordDeleted(kindRepositorySecret, "", secretName, nil)
+				result.Drift = true
+			}
+			continue
+		}
+		err := ghClient.DeleteRepositorySecret(ctx, owner, repo, secretName)
+		result.recordDeleted(kindRepositorySecret, "", secretName, err)
+		if err != nil {
+			log.Error("Failed to delete repository secret", "repo", repo, "secret", secretName, "error", err)
+			errors = append(errors, fmt.Errorf("repo %s/%s delete repository secret %s: %w", owner, repo, secretName, err))
+			continue
+		}
+		store.Forget(state.ID{Owner: owner, Repo: repo, Kind: kindRepositorySecret, Name: secretName})
+		log.Info("Successfully deleted repository secret", "repo", repo, "secret", secretName)
+	}
+
+	for _, envName := range sortedKeys(absent.EnvironmentSecrets) {
+		secrets := absent.EnvironmentSecrets[envName]
+		for _, secretName := range sortedKeys(secrets) {
 			if ctx.Err() != nil {
 				return errors
 			}
 
 			if dryRun {
-				existingVar, err := ghClient.GetEnvironmentVariable(ctx, owner, repo, envName, varName)
-				if err != nil {
-					log.Info("Would create environment variable", "repo", repo, "environment", envName, "variable", varName, "value", varValue)
+				if _, err := ghClient.GetEnvironmentSecret(ctx, owner, repo, envName, secretName); err != nil {
+					log.Info("Environment secret already absent", "repo", repo, "environment", envName, "secret", secretName)
 				} else {
-					log.Info("Would update environment variable", "repo", repo, "environment", envName, "variable", varName, "existing", existingVar.Name, "new_value", varValue)
+					log.Info("Would delete environment secret", "repo", repo, "environment", envName, "secret", secretName)
+					result.recordDeleted(kindEnvironmentSecret, envName, secretName, nil)
+					result.Drift = true
 				}
+				continue
+			}
+			err := ghClient.DeleteEnvironmentSecret(ctx, owner, repo, envName, secretName)
+			result.recordDeleted(kindEnvironmentSecret, envName, secretName, err)
+			if err != nil {
+				log.Error("Failed to delete environment secret", "repo", repo, "environment", envName, "secret", secretName, "error", err)
+				errors = append(errors, fmt.Errorf("repo %s/%s delete environment secret %s in environment %s: %w", owner, repo, secretName, envName, err))
+				continue
+			}
+			store.Forget(state.ID{Owner: owner, Repo: repo, Environment: envName, Kind: kindEnvironmentSecret, Name: secretName})
+			log.Info("Successfully deleted environment secret", "repo", repo, "environment", envName, "secret", secretName)
+		}
+	}
+
+	for _, varName := range sortedKeys(absent.RepositoryVariables) {
+		if ctx.Err() != nil {
+			return errors
+		}
+
+		if dryRun {
+			if _, err := ghClient.GetRepositoryVariable(ctx, owner, repo, varName); err != nil {
+				log.Info("Repository variable already absent", "repo", repo, "variable", varName)
 			} else {
-				if err := ghClient.SetEnvironmentVariable(ctx, owner, repo, envName, varName, varValue); err != nil {
-					log.Error("Failed to set environment variable", "repo", repo, "environment", envName, "variable", varName, "error", err)
-					errors = append(errors, fmt.Errorf("repo %s/%s environment variable %s in environment %s: %w", owner, repo, varName, envName, err))
-					continue
+				log.Info("Would delete repository variable", "repo", repo, "variable", varName)
+				result.recordDeleted(kindRepositoryVariable, "", varName, nil)
+				result.Drift = true
+			}
+			continue
+		}
+		journal.recordDeletion(ctx, ghClient, state.ID{Owner: owner, Repo: repo, Kind: kindRepositoryVariable, Name: varName})
+		err := ghClient.DeleteRepositoryVariable(ctx, owner, repo, varName)
+		result.recordDeleted(kindRepositoryVariable, "", varName, err)
+		if err != nil {
+			log.Error("Failed to delete repository variable", "repo", repo, "variable", varName, "error", err)
+			errors = append(errors, fmt.Errorf("repo %s/%s delete repository variable %s: %w", owner, repo, varName, err))
+			continue
+		}
+		store.Forget(state.ID{Owner: owner, Repo: repo, Kind: kindRepositoryVariable, Name: varName})
+		log.Info("Successfully deleted repository variable", "repo", repo, "variable", varName)
+	}
+
+	for _, envName := range sortedKeys(absent.EnvironmentVariables) {
+		variables := absent.EnvironmentVariables[envName]
+		for _, varName := range sortedKeys(variables) {
+			if ctx.Err() != nil {
+				return errors
+			}
+
+			if dryRun {
+				if _, err := ghClient.GetEnvironmentVariable(ctx, owner, repo, envName, varName); err != nil {
+					log.Info("Environment variable already absent", "repo", repo, "environment", envName, "variable", varName)
+				} else {
+					log.Info("Would delete environment variable", "repo", repo, "environment", envName, "variable", varName)
+					result.recordDeleted(kindEnvironmentVariable, envName, varName, nil)
+					result.Drift = true
 				}
-				log.Info("Successfully set environment variable", "repo", repo, "environment", envName, "variable", varName)
+				continue
+			}
+			journal.recordDeletion(ctx, ghClient, state.ID{Owner: owner, Repo: repo, Environment: envName, Kind: kindEnvironmentVariable, Name: varName})
+			err := ghClient.DeleteEnvironmentVariable(ctx, owner, repo, envName, varName)
+			result.recordDeleted(kindEnvironmentVariable, envName, varName, err)
+			if err != nil {
+				log.Error("Failed to delete environment variable", "repo", repo, "environment", envName, "variable", varName, "error", err)
+				errors = append(errors, fmt.Errorf("repo %s/%s delete environment variable %s in environment %s: %w", owner, repo, varName, envName, err))
+				continue
 			}
+			store.Forget(state.ID{Owner: owner, Repo: repo, Environment: envName, Kind: kindEnvironmentVariable, Name: varName})
+			log.Info("Successfully deleted environment variable", "repo", repo, "environment", envName, "variable", varName)
 		}
 	}
 
+	for _, secretName := range sortedKeys(absent.CodespacesSecrets) {
+		if ctx.Err() != nil {
+			return errors
+		}
+
+		if dryRun {
+			if _, err := ghClient.GetCodespacesSecret(ctx, owner, repo, secretName); err != nil {
+				log.Info("Codespaces secret already absent", "repo", repo, "secret", secretName)
+			} else {
+				log.Info("Would delete codespaces secret", "repo", repo, "secret", secretName)
+				result.recordDeleted(kindCodespacesSecret, "", secretName, nil)
+				result.Drift = true
+			}
+			continue
+		}
+		err := ghClient.DeleteCodespacesSecret(ctx, owner, repo, secretName)
+		result.recordDeleted(kindCodespacesSecret, "", secretName, err)
+		if err != nil {
+			log.Error("Failed to delete codespaces secret", "repo", repo, "secret", secretName, "error", err)
+			errors = append(errors, fmt.Errorf("repo %s/%s delete codespaces secret %s: %w", owner, repo, secretName, err))
+			continue
+		}
+		store.Forget(state.ID{Owner: owner, Repo: repo, Kind: kindCodespacesSecret, Name: secretName})
+		log.Info("Successfully deleted codespaces secret", "repo", repo, "secret", secretName)
+	}
+
+	return errors
+}
+
+// pruneState deletes the entries of a repository recorded in store that are
+// neither configured nor marked absent, i.e. entries gajin applied in earlier
+// runs that were since removed from the configuration. Entries gajin never
+// applied or not selected by filter are left alone. Deletions are recorded in
+// result like those of deleteAbsent.
+func pruneState(ctx context.Context, log *logger.Logger, ghClient github.Client, owner, repo string, configured, absent config.Resources, filter entryFilter, dryRun bool, store *state.Store, journal *rollbackJournal, result *repoResult) []error {
+	var errors []error
+
+	for _, resource := range store.Resources(owner) {
+		if resource.Repo != repo || !filter.includes(resource.Kind, resource.Environment, resource.Name) || hasEntry(configured, resource.ID) || hasEntry(absent, resource.ID) {
+			continue
+		}
+		if ctx.Err() != nil {
+			return errors
+		}
+
+		if dryRun {
+			log.Info("Would prune entry removed from the configuration", "repo", repo, "environment", resource.Environment, "kind", resource.Kind, "name", resource.Name)
+			result.recordPruned(resource.Kind, resource.Environment, resource.Name, nil)
+			result.Drift = true
+			continue
+		}
+		journal.recordDeletion(ctx, ghClient, resource.ID)
+		err := deleteEntry(ctx, ghClient, resource.ID)
+		result.recordPruned(resource.Kind, resource.Environment, resource.Name, err)
+		if err != nil {
+			log.Error("Failed to prune entry", "repo", repo, "environment", resource.Environment, "kind", resource.Kind, "name", resource.Name, "error", err)
+			errors = append(errors, fmt.Errorf("repo %s/%s prune %s %s: %w", owner, repo, resource.Kind, resource.Name, err))
+			continue
+		}
+		store.Forget(resource.ID)
+		log.Info("Successfully pruned entry", "repo", repo, "environment", resource.Environment, "kind", resource.Kind, "name", resource.Name)
+	}
+
 	return errors
 }
 
+// hasEntry reports whether resources contain the entry id.
+func hasEntry(resources config.Resources, id state.ID) bool {
+	var ok bool
+	switch id.Kind {
+	case kindRepositorySecret:
+		_, ok = resources.RepositorySecrets[id.Name]
+	case kindEnvironmentSecret:
+		_, ok = resources.EnvironmentSecrets[id.Environment][id.Name]
+	case kindRepositoryVariable:
+		_, ok = resources.RepositoryVariables[id.Name]
+	case kindEnvironmentVariable:
+		_, ok = resources.EnvironmentVariables[id.Environment][id.Name]
+	case kindCodespacesSecret:
+		_, ok = resources.CodespacesSecrets[id.Name]
+	}
+	return ok
+}
+
+// deleteEntry deletes the secret or variable id. Entries of an environment
+// that no longer exists are already gone.
+func deleteEntry(ctx context.Context, ghClient github.Client, id state.ID) error {
+	var err error
+	switch id.Kind {
+	case kindRepositorySecret:
+		err = ghClient.DeleteRepositorySecret(ctx, id.Owner, id.Repo, id.Name)
+	case kindEnvironmentSecret:
+		err = ghClient.DeleteEnvironmentSecret(ctx, id.Owner, id.Repo, id.Environment, id.Name)
+	case kindRepositoryVariable:
+		err = ghClient.DeleteRepositoryVariable(ctx, id.Owner, id.Repo, id.Name)
+	case kindEnvironmentVariable:
+		err = ghClient.DeleteEnvironmentVariable(ctx, id.Owner, id.Repo, id.Environment, id.Name)
+	case kindCodespacesSecret:
+		err = ghClient.DeleteCodespacesSecret(ctx, id.Owner, id.Repo, id.Name)
+	default:
+		return fmt.Errorf("unknown kind '%s'", id.Kind)
+	}
+	var envErr *github.EnvironmentNotFoundError
+	if errors.As(err, &envErr) {
+		return nil
+	}
+	return err
+}
+
 func maskSecret(secret string) string {
 	if len(secret) <= 4 {
 		return "****"
 	}
 	return secret[:2] + "****" + secret[len(secret)-2:]
 }
-
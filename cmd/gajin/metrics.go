@@ -0,0 +1,131 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/azolfagharj/gajin/internal/github"
+	"github.com/azolfagharj/gajin/internal/logger"
+	"github.com/azolfagharj/gajin/internal/metrics"
+)
+
+// Buckets of the duration histograms, in seconds.
+var (
+	requestDurationBuckets    = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+	repositoryDurationBuckets = []float64{0.5, 1, 2.5, 5, 10, 30, 60, 120, 300}
+)
+
+// runMetrics collects the metrics of a run for --metrics-file, including the
+// API requests of the GitHub client it observes. A nil runMetrics collects
+// nothing.
+type runMetrics struct {
+	github.NoopObserver
+	path     string
+	registry *metrics.Registry
+
+	entries            *metrics.Counter
+	repositories       *metrics.Counter
+	errors             *metrics.Counter
+	apiRequests        *metrics.Counter
+	apiRequestDuration *metrics.Histogram
+	retries            *metrics.Counter
+	rateLimitRemaining *metrics.Gauge
+	rateLimitPauses    *metrics.Counter
+	repositoryDuration *metrics.Histogram
+	runDuration        *metrics.Gauge
+	driftRepositories  *metrics.Gauge
+	lastRun            *metrics.Gauge
+	lastRunSuccess     *metrics.Gauge
+}
+
+// newRunMetrics returns the metrics written to path, or nil if path is empty.
+func newRunMetrics(path string) *runMetrics {
+	if path == "" {
+		return nil
+	}
+	r := metrics.NewRegistry()
+	return &runMetrics{
+		path:               path,
+		registry:           r,
+		entries:            r.Counter("gajin_entries_total", "Secrets and variables processed by the last run, by kind and status (created, updated, deleted, unchanged or failed).", "kind", "status"),
+		repositories:       r.Counter("gajin_repositories_total", "Repositories processed by the last run, by status (ok, failed or skipped).", "status"),
+		errors:             r.Counter("gajin_errors_total", "Errors of the last run, by category.", "category"),
+		apiRequests:        r.Counter("gajin_api_requests_total", "GitHub API request attempts of the last run, by method and status code (error: no response).", "method", "code"),
+		apiRequestDuration: r.Histogram("gajin_api_request_duration_seconds", "Duration of the GitHub API request attempts of the last run.", requestDurationBuckets),
+		retries:            r.Counter("gajin_retries_total", "Retries of the last run, of single requests or of whole operations.", "type"),
+		rateLimitRemaining: r.Gauge("gajin_rate_limit_remaining", "GitHub API requests left in the current rate limit window, by resource, as of the last response.", "resource"),
+		rateLimitPauses:    r.Counter("gajin_rate_limit_pauses_total", "Pauses of the last run for GitHub rate limits, by resource.", "resource"),
+		repositoryDuration: r.Histogram("gajin_repository_duration_seconds", "Time the last run took to process each repository.", repositoryDurationBuckets),
+		runDuration:        r.Gauge("gajin_run_duration_seconds", "Duration of the last run."),
+		driftRepositories:  r.Gauge("gajin_drift_repositories", "Repositories in which the last dry run found drift."),
+		lastRun:            r.Gauge("gajin_last_run_timestamp_seconds", "Time the last run completed, as a Unix timestamp."),
+		lastRunSuccess:     r.Gauge("gajin_last_run_success", "Whether the last run completed without errors (1) or not (0)."),
+	}
+}
+
+func (m *runMetrics) RequestFinished(req *http.Request, resp *http.Response, err error, duration time.Duration) {
+	m.apiRequestDuration.Observe(duration.Seconds())
+	if err != nil {
+		m.apiRequests.Inc(req.Method, "error")
+		return
+	}
+	m.apiRequests.Inc(req.Method, strconv.Itoa(resp.StatusCode))
+	if remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining")); err == nil {
+		resource := resp.Header.Get("X-RateLimit-Resource")
+		if resource == "" {
+			resource = "core"
+		}
+		m.rateLimitRemaining.Set(float64(remaining), resource)
+	}
+}
+
+func (m *runMetrics) RequestRetried(*http.Request, int, time.Duration) {
+	m.retries.Inc("request")
+}
+
+func (m *runMetrics) OperationRetried(string, int, time.Duration, error) {
+	m.retries.Inc("operation")
+}
+
+func (m *runMetrics) RateLimited(event github.RateLimitEvent) {
+	m.rateLimitPauses.Inc(event.Resource)
+}
+
+// write records the outcome of the run and writes the metrics file. Failing
+// to write it does not fail the run.
+func (m *runMetrics) write(log *logger.Logger, report *runReport, results []*repoResult, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	drift := 0
+	for _, result := range results {
+		m.repositories.Inc(result.status())
+		if !result.Skipped {
+			m.repositoryDuration.Observe(result.Duration.Seconds())
+		}
+		for _, entry := range result.Entries {
+			m.entries.Inc(entry.Kind, entry.Status)
+		}
+		if result.Drift {
+			drift++
+		}
+	}
+	for category, count := range report.categoryCounts() {
+		m.errors.Add(float64(count), category)
+	}
+	success := 0.0
+	if report.len() == 0 {
+		success = 1
+	}
+	m.runDuration.Set(duration.Seconds())
+	m.driftRepositories.Set(float64(drift))
+	m.lastRun.Set(float64(time.Now().Unix()))
+	m.lastRunSuccess.Set(success)
+
+	if err := m.registry.WriteFile(m.path); err != nil {
+		log.Warn("Failed to write the metrics file", "path", m.path, "error", err)
+		return
+	}
+	log.Debug("Wrote the metrics file", "path", m.path)
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/azolfagharj/gajin/internal/config"
+	"github.com/azolfagharj/gajin/internal/github"
+	"github.com/azolfagharj/gajin/internal/logger"
+	"github.com/azolfagharj/gajin/internal/notify"
+)
+
+// notificationTimeout bounds posting a notification, including retries of
+// the transport.
+const notificationTimeout = 30 * time.Second
+
+// notification is a configured notification target.
+type notification struct {
+	name     string
+	on       string
+	notifier notify.Notifier
+}
+
+// notifications returns the notification targets of cfg, posting through
+// the proxy and certificates of settings.http.
+func notifications(cfg *config.Config) ([]notification, error) {
+	if cfg.Notifications.IsEmpty() {
+		return nil, nil
+	}
+	transport, err := github.NewTransport(github.Network{
+		Proxy:              cfg.Settings.HTTP.Proxy,
+		CAFile:             cfg.Settings.HTTP.CAFile,
+		InsecureSkipVerify: cfg.Settings.HTTP.InsecureSkipVerify,
+	})
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{Transport: transport, Timeout: notificationTimeout}
+
+	var targets []notification
+	if slack := cfg.Notifications.Slack; slack != nil {
+		targets = append(targets, notification{name: "slack", on: slack.OnOrDefault(), notifier: notify.NewSlack(slack.WebhookURL, client)})
+	}
+	if teams := cfg.Notifications.Teams; teams != nil {
+		targets = append(targets, notification{name: "teams", on: teams.OnOrDefault(), notifier: notify.NewTeams(teams.WebhookURL, client)})
+	}
+	for i, webhook := range cfg.Notifications.Webhooks {
+		name := fmt.Sprintf("webhooks[%d]", i)
+		targets = append(targets, notification{name: name, on: webhook.OnOrDefault(), notifier: notify.NewWebhook(webhook.URL, webhook.Headers, client)})
+	}
+	return targets, nil
+}
+
+// shouldNotify reports whether a notification sent on on is sent for summary.
+func shouldNotify(on string, summary notify.Summary) bool {
+	switch on {
+	case config.NotifyFailure:
+		return summary.Status == notify.StatusFailure
+	case config.NotifyChange:
+		return summary.Status != notify.StatusSuccess || len(summary.Changed) > 0
+	}
+	return true
+}
+
+// runSummary summarizes the run for notifications.
+func runSummary(cfg *config.Config, dryRun bool, report *runReport, results []*repoResult, duration time.Duration) notify.Summary {
+	summary := notify.Summary{
+		Owner:        cfg.GitHub.Owner,
+		Profile:      cfg.Profile,
+		DryRun:       dryRun,
+		Status:       notify.StatusSuccess,
+		Repositories: len(cfg.GitHub.Repos),
+		Duration:     duration,
+	}
+	summary.Created, summary.Updated, summary.Deleted, summary.Changed = changeCounts(results)
+	for _, result := range sortedResults(results) {
+		if result.Drift {
+			summary.Drift = append(summary.Drift, result.Repo)
+		}
+	}
+	failures := report.snapshot()
+	sort.SliceStable(failures, func(i, j int) bool { return failures[i].Repo < failures[j].Repo })
+	for _, failure := range failures {
+		summary.Failures = append(summary.Failures, notify.Failure{Repo: failure.Repo, Category: failure.Category, Error: failure.Err.Error()})
+	}
+
+	switch {
+	case len(summary.Failures) > 0:
+		summary.Status = notify.StatusFailure
+	case dryRun && len(summary.Drift) > 0:
+		summary.Status = notify.StatusDrift
+	}
+	return summary
+}
+
+// sendNotifications posts summary to the configured targets. Failing to
+// notify does not fail the run, which already completed.
+func sendNotifications(ctx context.Context, log *logger.Logger, cfg *config.Config, summary notify.Summary) {
+	targets, err := notifications(cfg)
+	if err != nil {
+		log.Warn("Failed to send notifications", "error", err)
+		return
+	}
+	for _, target := range targets {
+		if !shouldNotify(target.on, summary) {
+			log.Debug("Skipping notification", "target", target.name, "on", target.on, "status", summary.Status)
+			continue
+		}
+		if err := target.notifier.Notify(ctx, summary); err != nil {
+			log.Warn("Failed to send notification", "target", target.name, "error", err)
+			continue
+		}
+		log.Debug("Sent notification", "target", target.name)
+	}
+}
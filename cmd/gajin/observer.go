@@ -0,0 +1,74 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/azolfagharj/gajin/internal/github"
+	"github.com/azolfagharj/gajin/internal/logger"
+)
+
+// logObserver logs the API requests of the GitHub client: every request in
+// verbose mode, and retries and rate limit pauses always.
+type logObserver struct {
+	github.NoopObserver
+	log *logger.Logger
+}
+
+func (o *logObserver) RequestFinished(req *http.Request, resp *http.Response, err error, duration time.Duration) {
+	if err != nil {
+		o.log.Debug("GitHub API request failed", "method", req.Method, "path", req.URL.Path, "duration", duration.Round(time.Millisecond), "error", err)
+		return
+	}
+	o.log.Debug("GitHub API request", "method", req.Method, "path", req.URL.Path, "status", resp.StatusCode, "duration", duration.Round(time.Millisecond))
+}
+
+func (o *logObserver) RequestRetried(req *http.Request, attempt int, delay time.Duration) {
+	o.log.Warn("Retrying GitHub API request", "method", req.Method, "path", req.URL.Path, "attempt", attempt, "delay", delay.Round(time.Millisecond))
+}
+
+func (o *logObserver) OperationRetried(operation string, attempt int, delay time.Duration, err error) {
+	o.log.Warn("Retrying failed operation", "operation", operation, "attempt", attempt, "delay", delay.Round(time.Millisecond), "error", err)
+}
+
+func (o *logObserver) RateLimited(event github.RateLimitEvent) {
+	if event.Secondary {
+		o.log.Warn("GitHub secondary rate limit hit, pausing as instructed", "resource", event.Resource, "wait", event.Wait.Round(time.Second))
+		return
+	}
+	o.log.Warn("GitHub rate limit nearly exhausted, pausing until it resets",
+		"resource", event.Resource, "remaining", event.Remaining, "reset", time.Now().Add(event.Wait).Format(time.RFC3339), "wait", event.Wait.Round(time.Second))
+}
+
+// multiObserver passes the events of the GitHub client to every observer.
+type multiObserver []github.ClientObserver
+
+func (o multiObserver) RequestStarted(req *http.Request) {
+	for _, observer := range o {
+		observer.RequestStarted(req)
+	}
+}
+
+func (o multiObserver) RequestFinished(req *http.Request, resp *http.Response, err error, duration time.Duration) {
+	for _, observer := range o {
+		observer.RequestFinished(req, resp, err, duration)
+	}
+}
+
+func (o multiObserver) RequestRetried(req *http.Request, attempt int, delay time.Duration) {
+	for _, observer := range o {
+		observer.RequestRetried(req, attempt, delay)
+	}
+}
+
+func (o multiObserver) RateLimited(event github.RateLimitEvent) {
+	for _, observer := range o {
+		observer.RateLimited(event)
+	}
+}
+
+func (o multiObserver) OperationRetried(operation string, attempt int, delay time.Duration, err error) {
+	for _, observer := range o {
+		observer.OperationRetried(operation, attempt, delay, err)
+	}
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// planVersion is the version of the JSON plan. It only changes when fields
+// are removed or change their meaning; new fields may be added at any time.
+const planVersion = 1
+
+// plan holds the changes computed by a dry run. It is written as JSON by
+// --dry-run --output json for other tools, e.g. to post it on a pull request
+// or have it approved, and rendered as text otherwise.
+type plan struct {
+	Version int    `json:"version"`
+	Owner   string `json:"owner"`
+	// CreateMissingEnvironments reports whether missing environments would
+	// be created (--create-missing-environments)
+	CreateMissingEnvironments bool `json:"create_missing_environments"`
+	// Account holds the operations on the organization secrets and
+	// variables of the owner and the Codespaces secrets of the user
+	Account      planAccount      `json:"account"`
+	Repositories []planRepository `json:"repositories"`
+	// Errors are the errors not tied to a processed repository, e.g. of
+	// preflight checks or organization entries
+	Errors  []string    `json:"errors"`
+	Summary planSummary `json:"summary"`
+}
+
+// planRepository holds the planned operations of one repository.
+type planRepository struct {
+	Repo string `json:"repo"`
+	// Skipped reports that the repository was not processed
+	Skipped bool `json:"skipped"`
+	// MissingEnvironments are the environments that do not exist, but hold
+	// entries of the configuration
+	MissingEnvironments []string        `json:"missing_environments"`
+	Operations          []planOperation `json:"operations"`
+	Errors              []string        `json:"errors"`
+}
+
+// planAccount holds the planned operations of the entries that belong to no
+// repository.
+type planAccount struct {
+	Operations []planOperation `json:"operations"`
+}
+
+// planOperation is the operation planned for one secret or variable.
+type planOperation struct {
+	// Action is create, update, delete or unchanged
+	Action string     `json:"action"`
+	Target planTarget `json:"target"`
+	// Reason explains the action, e.g. missing or state_absent
+	Reason string `json:"reason"`
+}
+
+// planTarget identifies a secret or variable. Repo is empty for the entries
+// of the account.
+type planTarget struct {
+	Repo        string `json:"repo,omitempty"`
+	Type        string `json:"type"`
+	Environment string `json:"environment,omitempty"`
+	Name        string `json:"name"`
+}
+
+// Actions of plan operations.
+const (
+	actionCreate    = "create"
+	actionUpdate    = "update"
+	actionDelete    = "delete"
+	actionUnchanged = "unchanged"
+)
+
+// planActions map the statuses recorded by a dry run to plan actions.
+var planActions = map[string]string{
+	statusCreated:   actionCreate,
+	statusUpdated:   actionUpdate,
+	statusDeleted:   actionDelete,
+	statusUnchanged: actionUnchanged,
+}
+
+// planSummary counts the operations of a plan by action.
+type planSummary struct {
+	Create    int `json:"create"`
+	Update    int `json:"update"`
+	Delete    int `json:"delete"`
+	Unchanged int `json:"unchanged"`
+}
+
+// add counts an operation.
+func (s *planSummary) add(action string) {
+	switch action {
+	case actionCreate:
+		s.Create++
+	case actionUpdate:
+		s.Update++
+	case actionDelete:
+		s.Delete++
+	case actionUnchanged:
+		s.Unchanged++
+	}
+}
+
+// newPlan builds the plan of a dry run from its results, sorted by
+// repository name, and account, the result of the organization and user
+// entries (nil: none processed). runErrors holds every error of the run.
+func newPlan(owner string, createMissing bool, runErrors *runReport, account *repoResult, results []*repoResult) plan {
+	p := plan{
+		Version:                   planVersion,
+		Owner:                     owner,
+		CreateMissingEnvironments: createMissing,
+		Account:                   planAccount{Operations: []planOperation{}},
+		Repositories:              make([]planRepository, 0, len(results)),
+		Errors:                    errorStrings(runErrors.unprocessedErrors(results)),
+	}
+	if account != nil {
+		p.Account.Operations = p.operations(account)
+	}
+	for _, result := range sortedResults(results) {
+		p.Repositories = append(p.Repositories, planRepository{
+			Repo:                result.Repo,
+			Skipped:             result.Skipped,
+			MissingEnvironments: append([]string{}, result.MissingEnvironments...),
+			Operations:          p.operations(result),
+			Errors:              errorStrings(result.Errors),
+		})
+	}
+	return p
+}
+
+// operations returns the planned operations of result, counting them in the
+// summary of the plan.
+func (p *plan) operations(result *repoResult) []planOperation {
+	operations := make([]planOperation, 0, len(result.Entries))
+	for _, entry := range result.Entries {
+		// Failed entries are reported as errors
+		action, ok := planActions[entry.Status]
+		if !ok {
+			continue
+		}
+		operations = append(operations, planOperation{
+			Action: action,
+			Target: planTarget{Repo: result.Repo, Type: entry.Kind, Environment: entry.Environment, Name: entry.Name},
+			Reason: entry.Reason,
+		})
+		p.Summary.add(action)
+	}
+	return operations
+}
+
+// writeJSON writes the plan as one JSON document to w.
+func (p plan) writeJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(p)
+}
+
+// planSymbols mark the operations of a plan by their action.
+var planSymbols = map[string]string{
+	actionCreate: "+",
+	actionUpdate: "~",
+	actionDelete: "-",
+}
+
+// writeText writes the changes of the plan to w, grouped by repository; those
+// of the account come first.
+func (p plan) writeText(w io.Writer) error {
+	sections := p.Repositories
+	if len(p.Account.Operations) > 0 {
+		account := planRepository{Repo: p.Owner + " (organization and user)", Operations: p.Account.Operations}
+		sections = append([]planRepository{account}, sections...)
+	}
+	for _, repo := range sections {
+		var summary planSummary
+		var lines []string
+		for _, operation := range repo.Operations {
+			summary.add(operation.Action)
+			if operation.Action == actionUnchanged {
+				continue
+			}
+			name := operation.Target.Name
+			if operation.Target.Environment != "" {
+				name = operation.Target.Environment + "/" + operation.Target.Name
+			}
+			lines = append(lines, fmt.Sprintf("  %s %-21s %s", planSymbols[operation.Action], operation.Target.Type, name))
+		}
+
+		switch {
+		case repo.Skipped:
+			fmt.Fprintf(w, "%s: skipped\n", repo.Repo)
+			continue
+		case len(lines) == 0 && len(repo.MissingEnvironments) == 0:
+			fmt.Fprintf(w, "%s: no changes (%d unchanged)\n", repo.Repo, summary.Unchanged)
+			continue
+		}
+		fmt.Fprintf(w, "%s: %d to create, %d to update, %d to delete, %d unchanged\n", repo.Repo, summary.Create, summary.Update, summary.Delete, summary.Unchanged)
+		for _, environment := range repo.MissingEnvironments {
+			if p.CreateMissingEnvironments {
+				fmt.Fprintf(w, "  ! environment %s does not exist and would be created\n", environment)
+			} else {
+				fmt.Fprintf(w, "  ! environment %s does not exist; its entries would fail (see --create-missing-environments)\n", environment)
+			}
+		}
+		for _, line := range lines {
+			fmt.Fprintln(w, line)
+		}
+	}
+	_, err := fmt.Fprintf(w, "\nPlan: %d to create, %d to update, %d to delete.\n", p.Summary.Create, p.Summary.Update, p.Summary.Delete)
+	return err
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPlanResults returns the results of a dry run over the organization and
+// three repositories, one of them skipped, and the errors of the run.
+func testPlanResults() (*runReport, *repoResult, []*repoResult) {
+	failure := errors.New("failure")
+	account := &repoResult{}
+	account.record(kindOrganizationVariable, "", "ORG_VAR", true, nil)
+
+	web := &repoResult{Repo: "web", MissingEnvironments: []string{"staging"}}
+	web.record(kindRepositorySecret, "", "TOKEN", false, nil)
+	web.record(kindEnvironmentSecret, "production", "DB", true, nil)
+	web.recordDeleted(kindRepositoryVariable, "", "OLD", nil)
+
+	api := &repoResult{Repo: "api", Errors: []error{failure}}
+	api.recordUnchanged(kindRepositoryVariable, "", "REGION")
+	api.record(kindRepositoryVariable, "", "BROKEN", true, failure)
+
+	docs := &repoResult{Repo: "docs", Skipped: true}
+
+	report := &runReport{}
+	report.add("api", failure)
+	report.add("gone", errors.New("repository not found"))
+	return report, account, []*repoResult{web, api, docs}
+}
+
+func TestNewPlan(t *testing.T) {
+	report, account, results := testPlanResults()
+	p := newPlan("my-org", false, report, account, results)
+
+	assert.Equal(t, planVersion, p.Version)
+	assert.Equal(t, "my-org", p.Owner)
+	assert.Equal(t, planSummary{Create: 2, Update: 1, Delete: 1, Unchanged: 1}, p.Summary)
+	assert.Equal(t, []string{"repository not found"}, p.Errors)
+	assert.Equal(t, []planOperation{
+		{Action: actionCreate, Target: planTarget{Type: kindOrganizationVariable, Name: "ORG_VAR"}, Reason: reasonMissing},
+	}, p.Account.Operations)
+
+	// Repositories are sorted by name; failed entries are left to the errors
+	require.Len(t, p.Repositories, 3)
+	assert.Equal(t, "api", p.Repositories[0].Repo)
+	assert.Equal(t, []planOperation{
+		{Action: actionUnchanged, Target: planTarget{Repo: "api", Type: kindRepositoryVariable, Name: "REGION"}, Reason: reasonValueUnchanged},
+	}, p.Repositories[0].Operations)
+	assert.Equal(t, []string{"failure"}, p.Repositories[0].Errors)
+	assert.True(t, p.Repositories[1].Skipped)
+	assert.Empty(t, p.Repositories[1].Operations)
+	assert.Equal(t, []string{"staging"}, p.Repositories[2].MissingEnvironments)
+	assert.Equal(t, []planOperation{
+		{Action: actionUpdate, Target: planTarget{Repo: "web", Type: kindRepositorySecret, Name: "TOKEN"}, Reason: reasonSecretExists},
+		{Action: actionCreate, Target: planTarget{Repo: "web", Type: kindEnvironmentSecret, Environment: "production", Name: "DB"}, Reason: reasonMissing},
+		{Action: actionDelete, Target: planTarget{Repo: "web", Type: kindRepositoryVariable, Name: "OLD"}, Reason: reasonStateAbsent},
+	}, p.Repositories[2].Operations)
+}
+
+func TestNewPlan_Empty(t *testing.T) {
+	p := newPlan("my-org", false, &runReport{}, nil, nil)
+	assert.Empty(t, p.Account.Operations)
+	assert.Empty(t, p.Repositories)
+	assert.Equal(t, planSummary{}, p.Summary)
+
+	// Empty lists are written as [], not null
+	var buf bytes.Buffer
+	require.NoError(t, p.writeJSON(&buf))
+	assert.Contains(t, buf.String(), `"repositories": []`)
+	assert.Contains(t, buf.String(), `"operations": []`)
+	assert.NotContains(t, buf.String(), "null")
+}
+
+func TestPlan_WriteText(t *testing.T) {
+	tests := []struct {
+		name          string
+		createMissing bool
+		expected      string
+	}{
+		{
+			name: "missing environments fail",
+			expected: "my-org (organization and user): 1 to create, 0 to update, 0 to delete, 0 unchanged\n" +
+				"  + organization_variable ORG_VAR\n" +
+				"api: no changes (1 unchanged)\n" +
+				"docs: skipped\n" +
+				"web: 1 to create, 1 to update, 1 to delete, 0 unchanged\n" +
+				"  ! environment staging does not exist; its entries would fail (see --create-missing-environments)\n" +
+				"  ~ repository_secret     TOKEN\n" +
+				"  + environment_secret    production/DB\n" +
+				"  - repository_variable   OLD\n" +
+				"\nPlan: 2 to create, 1 to update, 1 to delete.\n",
+		},
+		{
+			name:          "missing environments created",
+			createMissing: true,
+			expected: "my-org (organization and user): 1 to create, 0 to update, 0 to delete, 0 unchanged\n" +
+				"  + organization_variable ORG_VAR\n" +
+				"api: no changes (1 unchanged)\n" +
+				"docs: skipped\n" +
+				"web: 1 to create, 1 to update, 1 to delete, 0 unchanged\n" +
+				"  ! environment staging does not exist and would be created\n" +
+				"  ~ repository_secret     TOKEN\n" +
+				"  + environment_secret    production/DB\n" +
+				"  - repository_variable   OLD\n" +
+				"\nPlan: 2 to create, 1 to update, 1 to delete.\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, account, results := testPlanResults()
+			var buf bytes.Buffer
+			require.NoError(t, newPlan("my-org", tt.createMissing, report, account, results).writeText(&buf))
+			assert.Equal(t, tt.expected, buf.String())
+		})
+	}
+}
+
+func TestPlan_WriteJSON(t *testing.T) {
+	report, account, results := testPlanResults()
+	var buf bytes.Buffer
+	require.NoError(t, newPlan("my-org", true, report, account, results).writeJSON(&buf))
+
+	// The field names are the interface of other tools
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+	assert.ElementsMatch(t, []string{"version", "owner", "create_missing_environments", "account", "repositories", "errors", "summary"}, keys(doc))
+	assert.Equal(t, float64(planVersion), doc["version"])
+	assert.Equal(t, true, doc["create_missing_environments"])
+	assert.Equal(t, map[string]any{"create": float64(2), "update": float64(1), "delete": float64(1), "unchanged": float64(1)}, doc["summary"])
+
+	accountOperations := doc["account"].(map[string]any)["operations"].([]any)
+	require.Len(t, accountOperations, 1)
+	assert.Equal(t, map[string]any{
+		"action": "create",
+		"target": map[string]any{"type": "organization_variable", "name": "ORG_VAR"},
+		"reason": "missing",
+	}, accountOperations[0])
+
+	repositories := doc["repositories"].([]any)
+	require.Len(t, repositories, 3)
+	web := repositories[2].(map[string]any)
+	assert.ElementsMatch(t, []string{"repo", "skipped", "missing_environments", "operations", "errors"}, keys(web))
+	operations := web["operations"].([]any)
+	require.Len(t, operations, 3)
+	assert.Equal(t, map[string]any{
+		"action": "create",
+		"target": map[string]any{"repo": "web", "type": "environment_secret", "environment": "production", "name": "DB"},
+		"reason": "missing",
+	}, operations[1])
+}
+
+// keys returns the keys of a JSON object.
+func keys(object map[string]any) []string {
+	var names []string
+	for name := range object {
+		names = append(names, name)
+	}
+	return names
+}
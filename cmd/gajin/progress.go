@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// progressInterval limits how often the status line is redrawn.
+const progressInterval = 100 * time.Millisecond
+
+// progressBarWidth is the number of cells of the progress bar.
+const progressBarWidth = 20
+
+// progress draws a status line at the bottom of the terminal while the
+// repositories are processed: how many of them completed, and the last
+// operation. Logs written through it are printed above the status line. A
+// nil progress draws nothing, so that it can be used unconditionally.
+type progress struct {
+	mu    sync.Mutex
+	out   *os.File
+	total int
+	done  int
+	// operation describes the last entry recorded by any repository
+	operation string
+	// drawn reports that the status line is on the screen
+	drawn    bool
+	lastDraw time.Time
+}
+
+// newProgress returns the progress of processing total repositories, drawn
+// on out, or nil when out is not a terminal.
+func newProgress(out *os.File, total int) *progress {
+	if !term.IsTerminal(int(out.Fd())) {
+		return nil
+	}
+	return &progress{out: out, total: total}
+}
+
+// entry shows an entry recorded by repo as the current operation.
+func (p *progress) entry(repo string, entry entryResult) {
+	if p == nil {
+		return
+	}
+	name := entry.Name
+	if entry.Environment != "" {
+		name = entry.Environment + "/" + entry.Name
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.operation = fmt.Sprintf("%s: %s %s %s", repo, entry.Status, entry.Kind, name)
+	if time.Since(p.lastDraw) >= progressInterval {
+		p.draw()
+	}
+}
+
+// finish counts a repository as completed.
+func (p *progress) finish() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done++
+	p.draw()
+}
+
+// Write prints log output above the status line.
+func (p *progress) Write(data []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clear()
+	n, err := p.out.Write(data)
+	p.draw()
+	return n, err
+}
+
+// stop removes the status line.
+func (p *progress) stop() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clear()
+}
+
+// clear removes the status line from the screen.
+func (p *progress) clear() {
+	if p.drawn {
+		fmt.Fprint(p.out, "\r\033[K")
+		p.drawn = false
+	}
+}
+
+// draw replaces the status line, cut to the width of the terminal since a
+// wrapped line could not be cleared.
+func (p *progress) draw() {
+	filled := 0
+	if p.total > 0 {
+		filled = p.done * progressBarWidth / p.total
+	}
+	line := fmt.Sprintf("[%s%s] %d/%d repositories", strings.Repeat("#", filled), strings.Repeat("-", progressBarWidth-filled), p.done, p.total)
+	if p.operation != "" {
+		line += " · " + p.operation
+	}
+	if width, _, err := term.GetSize(int(p.out.Fd())); err == nil && width > 1 {
+		if runes := []rune(line); len(runes) >= width {
+			line = string(runes[:width-1])
+		}
+	}
+	fmt.Fprint(p.out, "\r\033[K"+line)
+	p.drawn = true
+	p.lastDraw = time.Now()
+}
@@ -0,0 +1,357 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Kinds of entries applied to a repository.
+const (
+	kindRepositorySecret    = "repository_secret"
+	kindEnvironmentSecret   = "environment_secret"
+	kindCodespacesSecret    = "codespaces_secret"
+	kindRepositoryVariable  = "repository_variable"
+	kindEnvironmentVariable = "environment_variable"
+)
+
+// Kinds of entries applied to the owner or the authenticated user.
+const (
+	kindOrganizationSecret   = "organization_secret"
+	kindOrganizationVariable = "organization_variable"
+	kindUserCodespacesSecret = "user_codespaces_secret"
+)
+
+// Statuses of an entry applied to a repository. In dry-run mode, created,
+// updated and deleted report what would be done. Unchanged entries were
+// skipped because their value did not change.
+const (
+	statusCreated   = "created"
+	statusUpdated   = "updated"
+	statusDeleted   = "deleted"
+	statusUnchanged = "unchanged"
+	statusFailed    = "failed"
+	// statusSet: the entry was written, but GitHub does not tell whether it
+	// existed, e.g. for organization secrets
+	statusSet = "set"
+)
+
+// Reasons for the status of an entry, part of the JSON plan.
+const (
+	// reasonMissing: the entry does not exist on GitHub
+	reasonMissing = "missing"
+	// reasonSecretExists: the secret exists, but its value cannot be read
+	// to compare it, so it is set again
+	reasonSecretExists = "secret_exists"
+	// reasonValueDiffers: the variable's value differs from the
+	// configuration, or was not compared (--force)
+	reasonValueDiffers = "value_differs"
+	// reasonValueUnchanged: the value is the one on GitHub or in the state file
+	reasonValueUnchanged = "value_unchanged"
+	// reasonResumed: the entry was set by the run being resumed
+	reasonResumed = "resumed"
+	// reasonStateAbsent: the entry is marked state: absent
+	reasonStateAbsent = "state_absent"
+	// reasonPruned: the entry was removed from the configuration (--prune-state)
+	reasonPruned = "pruned"
+)
+
+// entryResult is the outcome of applying one secret or variable.
+type entryResult struct {
+	Kind        string
+	Environment string
+	Name        string
+	Status      string
+	// Reason explains the status, one of the reason constants
+	Reason string
+	Err    error
+}
+
+// isSecret reports whether the entry is a secret rather than a variable.
+func (e entryResult) isSecret() bool {
+	return strings.HasSuffix(e.Kind, "_secret")
+}
+
+// repoResult collects the outcomes of processing one repository. It is only
+// written by the worker processing the repository.
+type repoResult struct {
+	Repo     string
+	Duration time.Duration
+	Entries  []entryResult
+	// Errors are the errors of the repository, including those not tied to
+	// an entry, e.g. of environments or deletions
+	Errors []error
+	// Skipped reports that the repository was not processed
+	Skipped bool
+	// Drift reports that a dry run found entries to create, variables with
+	// different values or entries to delete
+	Drift bool
+	// MissingEnvironments are the environments a dry run found missing, which
+	// hold entries of the configuration but are not configured themselves
+	MissingEnvironments []string
+	// onEntry is called with every recorded entry (nil: none)
+	onEntry func(repo string, entry entryResult)
+}
+
+// add appends entry to the results.
+func (r *repoResult) add(entry entryResult) {
+	r.Entries = append(r.Entries, entry)
+	if r.onEntry != nil {
+		r.onEntry(r.Repo, entry)
+	}
+}
+
+// record adds the outcome of applying an entry; a non-nil err marks it failed.
+func (r *repoResult) record(kind, environment, name string, created bool, err error) {
+	status, reason := statusUpdated, reasonValueDiffers
+	switch {
+	case created:
+		status, reason = statusCreated, reasonMissing
+	case strings.HasSuffix(kind, "_secret"):
+		reason = reasonSecretExists
+	}
+	if err != nil {
+		status = statusFailed
+	}
+	r.add(entryResult{Kind: kind, Environment: environment, Name: name, Status: status, Reason: reason, Err: err})
+}
+
+// recordSet adds the outcome of writing an entry without knowing whether it
+// existed; a non-nil err marks it failed.
+func (r *repoResult) recordSet(kind, name string, err error) {
+	status := statusSet
+	if err != nil {
+		status = statusFailed
+	}
+	r.add(entryResult{Kind: kind, Name: name, Status: status, Err: err})
+}
+
+// recordDeleted adds the outcome of deleting an entry marked absent; a
+// non-nil err marks it failed.
+func (r *repoResult) recordDeleted(kind, environment, name string, err error) {
+	r.recordDeletion(kind, environment, name, reasonStateAbsent, err)
+}
+
+// recordPruned adds the outcome of deleting an entry removed from the
+// configuration; a non-nil err marks it failed.
+func (r *repoResult) recordPruned(kind, environment, name string, err error) {
+	r.recordDeletion(kind, environment, name, reasonPruned, err)
+}
+
+// recordDeletion adds the outcome of deleting an entry for reason.
+func (r *repoResult) recordDeletion(kind, environment, name, reason string, err error) {
+	status := statusDeleted
+	if err != nil {
+		status = statusFailed
+	}
+	r.add(entryResult{Kind: kind, Environment: environment, Name: name, Status: status, Reason: reason, Err: err})
+}
+
+// recordUnchanged adds an entry skipped because its value did not change.
+func (r *repoResult) recordUnchanged(kind, environment, name string) {
+	r.add(entryResult{Kind: kind, Environment: environment, Name: name, Status: statusUnchanged, Reason: reasonValueUnchanged})
+}
+
+// recordResumed adds an entry skipped because the resumed run set it.
+func (r *repoResult) recordResumed(kind, environment, name string) {
+	r.add(entryResult{Kind: kind, Environment: environment, Name: name, Status: statusUnchanged, Reason: reasonResumed})
+}
+
+// unchanged returns the number of secrets and variables skipped because their
+// value did not change.
+func (r *repoResult) unchanged() int {
+	count := 0
+	for _, entry := range r.Entries {
+		if entry.Status == statusUnchanged {
+			count++
+		}
+	}
+	return count
+}
+
+// counts returns the number of created, updated and failed secrets, or
+// variables when secrets is false.
+func (r *repoResult) counts(secrets bool) (created, updated, failed int) {
+	for _, entry := range r.Entries {
+		if entry.isSecret() != secrets {
+			continue
+		}
+		switch entry.Status {
+		case statusCreated:
+			created++
+		case statusUpdated:
+			updated++
+		case statusFailed:
+			failed++
+		}
+	}
+	return created, updated, failed
+}
+
+// status summarizes the outcome of the repository.
+func (r *repoResult) status() string {
+	switch {
+	case len(r.Errors) > 0:
+		return "failed"
+	case r.Skipped:
+		return "skipped"
+	}
+	return "ok"
+}
+
+// changeCounts returns the number of entries created, updated and deleted
+// across results, and the repositories in which any was, sorted by name.
+func changeCounts(results []*repoResult) (created, updated, deleted int, changed []string) {
+	for _, result := range sortedResults(results) {
+		changes := 0
+		for _, entry := range result.Entries {
+			switch entry.Status {
+			case statusCreated:
+				created++
+			case statusUpdated:
+				updated++
+			case statusDeleted:
+				deleted++
+			default:
+				continue
+			}
+			changes++
+		}
+		if changes > 0 {
+			changed = append(changed, result.Repo)
+		}
+	}
+	return created, updated, deleted, changed
+}
+
+// sortedResults returns the results sorted by repository name.
+func sortedResults(results []*repoResult) []*repoResult {
+	sorted := make([]*repoResult, len(results))
+	copy(sorted, results)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Repo < sorted[j].Repo })
+	return sorted
+}
+
+// printSummary writes a table of the results of every repository to w,
+// sorted by repository name. Counts are given as created/updated/failed,
+// followed by the number of unchanged secrets and variables.
+func printSummary(w io.Writer, results []*repoResult) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "REPO\tSECRETS C/U/F\tVARIABLES C/U/F\tUNCHANGED\tDURATION\tSTATUS")
+	for _, result := range sortedResults(results) {
+		secretsCreated, secretsUpdated, secretsFailed := result.counts(true)
+		varsCreated, varsUpdated, varsFailed := result.counts(false)
+		fmt.Fprintf(tw, "%s\t%d/%d/%d\t%d/%d/%d\t%d\t%s\t%s\n", result.Repo,
+			secretsCreated, secretsUpdated, secretsFailed,
+			varsCreated, varsUpdated, varsFailed, result.unchanged(),
+			result.Duration.Round(time.Millisecond), result.status())
+	}
+	return tw.Flush()
+}
+
+// jsonReport is the document written by --output json.
+type jsonReport struct {
+	Owner   string `json:"owner"`
+	DryRun  bool   `json:"dry_run"`
+	Success bool   `json:"success"`
+	// Errors are the errors not tied to a processed repository, e.g. of
+	// preflight checks or organization entries
+	Errors []string `json:"errors"`
+	// ErrorCategories counts the errors of the run by category
+	ErrorCategories map[string]int `json:"error_categories"`
+	// Account holds the results of the organization secrets and variables
+	// of the owner and the Codespaces secrets of the user
+	Account      jsonAccount      `json:"account"`
+	Repositories []jsonRepository `json:"repositories"`
+}
+
+// jsonAccount holds the results of the entries that belong to no repository.
+type jsonAccount struct {
+	Resources []jsonEntry `json:"resources"`
+}
+
+// jsonRepository holds the results of one repository.
+type jsonRepository struct {
+	Repo       string      `json:"repo"`
+	Status     string      `json:"status"`
+	DurationMS int64       `json:"duration_ms"`
+	Errors     []string    `json:"errors"`
+	Resources  []jsonEntry `json:"resources"`
+	// MissingEnvironments are the environments a dry run found missing
+	MissingEnvironments []string `json:"missing_environments,omitempty"`
+}
+
+// jsonEntry holds the result of one secret or variable.
+type jsonEntry struct {
+	Type        string `json:"type"`
+	Environment string `json:"environment,omitempty"`
+	Name        string `json:"name"`
+	Status      string `json:"status"`
+	Reason      string `json:"reason,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// writeJSONReport writes the results of a run as one JSON document to w.
+// runErrors holds every error of the run, including those of repositories,
+// and account the results of the organization and user entries (nil: none
+// processed).
+func writeJSONReport(w io.Writer, owner string, dryRun bool, runErrors *runReport, account *repoResult, results []*repoResult) error {
+	report := jsonReport{
+		Owner:           owner,
+		DryRun:          dryRun,
+		Success:         runErrors.len() == 0,
+		Errors:          errorStrings(runErrors.unprocessedErrors(results)),
+		ErrorCategories: runErrors.categoryCounts(),
+		Account:         jsonAccount{Resources: []jsonEntry{}},
+		Repositories:    make([]jsonRepository, 0, len(results)),
+	}
+	if account != nil {
+		report.Account.Resources = jsonEntries(account.Entries)
+	}
+	for _, result := range sortedResults(results) {
+		repo := jsonRepository{
+			Repo:       result.Repo,
+			Status:     result.status(),
+			DurationMS: result.Duration.Milliseconds(),
+			Errors:     errorStrings(result.Errors),
+			Resources:  jsonEntries(result.Entries),
+
+			MissingEnvironments: result.MissingEnvironments,
+		}
+		if len(result.Errors) > 0 {
+			report.Success = false
+		}
+		report.Repositories = append(report.Repositories, repo)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// jsonEntries returns the results of entries, never nil so that JSON shows an
+// empty list.
+func jsonEntries(entries []entryResult) []jsonEntry {
+	resources := make([]jsonEntry, 0, len(entries))
+	for _, entry := range entries {
+		resource := jsonEntry{Type: entry.Kind, Environment: entry.Environment, Name: entry.Name, Status: entry.Status, Reason: entry.Reason}
+		if entry.Err != nil {
+			resource.Error = entry.Err.Error()
+		}
+		resources = append(resources, resource)
+	}
+	return resources
+}
+
+// errorStrings returns the messages of errs, never nil so that JSON shows an empty list.
+func errorStrings(errs []error) []string {
+	messages := make([]string, 0, len(errs))
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+	return messages
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/azolfagharj/gajin/internal/config"
+	"github.com/azolfagharj/gajin/internal/github"
+	"github.com/azolfagharj/gajin/internal/logger"
+	"github.com/azolfagharj/gajin/internal/state"
+)
+
+// rollbackJournal records the previous values of the repository and
+// environment variables a run changes, so that --rollback-on-error can
+// restore them when the run fails. Secrets are not recorded since their
+// values cannot be read. It is safe for concurrent use; a nil journal records
+// nothing.
+type rollbackJournal struct {
+	mu      sync.Mutex
+	changes []variableChange
+}
+
+// variableChange records the state of a variable before the run changed it.
+type variableChange struct {
+	ID state.ID
+	// Previous is the value of the variable, if Existed
+	Previous string
+	Existed  bool
+	// Unknown reports that the previous value could not be read, so the
+	// change cannot be rolled back
+	Unknown error
+}
+
+// newRollbackJournal returns the journal of a run, or nil unless
+// --rollback-on-error is given. Dry runs change nothing to roll back.
+func newRollbackJournal(rollbackOnError, dryRun bool) *rollbackJournal {
+	if !rollbackOnError || dryRun {
+		return nil
+	}
+	return &rollbackJournal{}
+}
+
+// enabled reports whether previous values must be looked up for the journal.
+func (j *rollbackJournal) enabled() bool {
+	return j != nil
+}
+
+// record records the variable id as looked up before it is changed: existing
+// holds its value unless err reports it missing or unreadable.
+func (j *rollbackJournal) record(id state.ID, existing *github.VariableMetadata, err error) {
+	if j == nil {
+		return
+	}
+	change := variableChange{ID: id}
+	switch {
+	case err == nil:
+		change.Previous, change.Existed = existing.Value, true
+	case !github.IsNotFoundError(err):
+		change.Unknown = err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.changes = append(j.changes, change)
+}
+
+// recordDeletion looks up and records the variable id before it is deleted;
+// other kinds of entries are ignored.
+func (j *rollbackJournal) recordDeletion(ctx context.Context, ghClient github.Client, id state.ID) {
+	if j == nil {
+		return
+	}
+	var variable *github.VariableMetadata
+	var err error
+	switch id.Kind {
+	case kindRepositoryVariable:
+		variable, err = ghClient.GetRepositoryVariable(ctx, id.Owner, id.Repo, id.Name)
+	case kindEnvironmentVariable:
+		variable, err = ghClient.GetEnvironmentVariable(ctx, id.Owner, id.Repo, id.Environment, id.Name)
+	default:
+		return
+	}
+	j.record(id, variable, err)
+}
+
+// len returns the number of recorded changes.
+func (j *rollbackJournal) len() int {
+	if j == nil {
+		return 0
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return len(j.changes)
+}
+
+// rollback restores the recorded variables, the latest change first:
+// variables that existed get their previous value back and the others are
+// deleted. Restored entries are forgotten by the checkpoint, together with
+// their repository, so that a resumed run applies them again. It returns the
+// errors of the variables that could not be restored, by target repository
+// (see config.NormalizeRepo), as the run reports the errors of repositories.
+func (j *rollbackJournal) rollback(ctx context.Context, log *logger.Logger, ghClient github.Client, cfg *config.Config, store, checkpoint *state.Store) map[string][]error {
+	j.mu.Lock()
+	changes := slices.Clone(j.changes)
+	j.mu.Unlock()
+	slices.Reverse(changes)
+
+	errs := make(map[string][]error)
+	for _, change := range changes {
+		id := change.ID
+		target := cfg.NormalizeRepo(id.Owner + "/" + id.Repo)
+		var err error
+		switch {
+		case change.Unknown != nil:
+			err = fmt.Errorf("previous value could not be read: %w", change.Unknown)
+		case change.Existed && id.Kind == kindRepositoryVariable:
+			_, err = ghClient.SetRepositoryVariable(ctx, id.Owner, id.Repo, id.Name, change.Previous)
+		case change.Existed:
+			_, err = ghClient.SetEnvironmentVariable(ctx, id.Owner, id.Repo, id.Environment, id.Name, change.Previous)
+		default:
+			// The variable may not have been created if setting it failed
+			if err = deleteEntry(ctx, ghClient, id); err == nil || github.IsNotFoundError(err) {
+				err = nil
+				store.Forget(id)
+			}
+		}
+		if err != nil {
+			log.Error("Failed to roll back variable", "repo", target, "environment", id.Environment, "variable", id.Name, "error", err)
+			errs[target] = append(errs[target], fmt.Errorf("repo %s/%s rollback %s %s: %w", id.Owner, id.Repo, id.Kind, id.Name, err))
+			continue
+		}
+		checkpoint.Forget(id)
+		checkpoint.Forget(state.ID{Owner: id.Owner, Repo: id.Repo, Kind: kindRepository})
+		log.Info("Rolled back variable", "repo", target, "environment", id.Environment, "variable", id.Name, "restored", change.Existed)
+	}
+	return errs
+}
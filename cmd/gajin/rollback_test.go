@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/azolfagharj/gajin/internal/config"
+	"github.com/azolfagharj/gajin/internal/github"
+	"github.com/azolfagharj/gajin/internal/logger"
+	"github.com/azolfagharj/gajin/internal/state"
+	"github.com/azolfagharj/gajin/test/mocks"
+)
+
+// rollbackClient records the variables written by a rollback in order.
+type rollbackClient struct {
+	*mocks.MockClient
+	calls []string
+}
+
+func (c *rollbackClient) SetRepositoryVariable(ctx context.Context, owner, repo, name, value string) (bool, error) {
+	c.calls = append(c.calls, fmt.Sprintf("set %s/%s %s=%s", owner, repo, name, value))
+	return c.MockClient.SetRepositoryVariable(ctx, owner, repo, name, value)
+}
+
+func (c *rollbackClient) SetEnvironmentVariable(ctx context.Context, owner, repo, environment, name, value string) (bool, error) {
+	c.calls = append(c.calls, fmt.Sprintf("set %s/%s/%s %s=%s", owner, repo, environment, name, value))
+	return c.MockClient.SetEnvironmentVariable(ctx, owner, repo, environment, name, value)
+}
+
+func (c *rollbackClient) DeleteRepositoryVariable(ctx context.Context, owner, repo, name string) error {
+	c.calls = append(c.calls, fmt.Sprintf("delete %s/%s %s", owner, repo, name))
+	return c.MockClient.DeleteRepositoryVariable(ctx, owner, repo, name)
+}
+
+func TestNewRollbackJournal(t *testing.T) {
+	assert.True(t, newRollbackJournal(true, false).enabled())
+	assert.False(t, newRollbackJournal(false, false).enabled())
+	assert.False(t, newRollbackJournal(true, true).enabled())
+
+	// A disabled journal records nothing
+	var journal *rollbackJournal
+	journal.record(state.ID{Name: "REGION"}, &github.VariableMetadata{Value: "eu"}, nil)
+	assert.Equal(t, 0, journal.len())
+}
+
+func TestRollbackJournal_Record(t *testing.T) {
+	id := state.ID{Owner: "my-org", Repo: "api", Kind: kindRepositoryVariable, Name: "REGION"}
+	unreadable := errors.New("forbidden")
+	tests := []struct {
+		name     string
+		existing *github.VariableMetadata
+		err      error
+		expected variableChange
+	}{
+		{
+			name:     "existing variable",
+			existing: &github.VariableMetadata{Name: "REGION", Value: "eu"},
+			expected: variableChange{ID: id, Previous: "eu", Existed: true},
+		},
+		{
+			name:     "missing variable",
+			err:      &github.RepositoryNotFoundError{Owner: "my-org", Repo: "api"},
+			expected: variableChange{ID: id},
+		},
+		{
+			name:     "unreadable variable",
+			err:      unreadable,
+			expected: variableChange{ID: id, Unknown: unreadable},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			journal := newRollbackJournal(true, false)
+			journal.record(id, tt.existing, tt.err)
+			require.Equal(t, 1, journal.len())
+			assert.Equal(t, tt.expected, journal.changes[0])
+		})
+	}
+}
+
+func TestRollbackJournal_Rollback(t *testing.T) {
+	client := &rollbackClient{MockClient: mocks.NewMockClient()}
+	cfg := &config.Config{GitHub: config.GitHubConfig{Owner: "my-org"}}
+	region := state.ID{Owner: "my-org", Repo: "api", Kind: kindRepositoryVariable, Name: "REGION"}
+	created := state.ID{Owner: "my-org", Repo: "api", Kind: kindRepositoryVariable, Name: "NEW"}
+	stage := state.ID{Owner: "my-org", Repo: "web", Environment: "production", Kind: kindEnvironmentVariable, Name: "STAGE"}
+	unreadable := state.ID{Owner: "other", Repo: "lib", Kind: kindRepositoryVariable, Name: "LOCKED"}
+
+	// REGION was changed twice; rolling back the latest change first restores
+	// the value it had before the run
+	journal := newRollbackJournal(true, false)
+	journal.record(region, &github.VariableMetadata{Value: "eu"}, nil)
+	journal.record(created, nil, &github.RepositoryNotFoundError{Owner: "my-org", Repo: "api"})
+	journal.record(stage, &github.VariableMetadata{Value: "prod"}, nil)
+	journal.record(region, &github.VariableMetadata{Value: "us"}, nil)
+	journal.record(unreadable, nil, errors.New("forbidden"))
+
+	errs := journal.rollback(context.Background(), logger.New(logger.Quiet), client, cfg, nil, nil)
+
+	assert.Equal(t, []string{
+		"set my-org/api REGION=us",
+		"set my-org/web/production STAGE=prod",
+		"delete my-org/api NEW",
+		"set my-org/api REGION=eu",
+	}, client.calls)
+	assert.Equal(t, "eu", client.Variables["my-org/api"]["REGION"].Value)
+
+	// Errors are reported by target repository
+	require.Len(t, errs, 1)
+	require.Len(t, errs["other/lib"], 1)
+	assert.ErrorContains(t, errs["other/lib"][0], "repo other/lib rollback repository_variable LOCKED: previous value could not be read: forbidden")
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/azolfagharj/gajin/internal/config"
+	"github.com/azolfagharj/gajin/internal/github"
+)
+
+// Categories of the errors of a run, in the order of the error summary.
+const (
+	categoryAuth       = "auth"
+	categoryNotFound   = "not-found"
+	categoryRateLimit  = "rate-limit"
+	categoryValidation = "validation"
+	categoryOther      = "other"
+)
+
+var errorCategories = []string{categoryAuth, categoryNotFound, categoryRateLimit, categoryValidation, categoryOther}
+
+// errorCategory classifies err. Rejected credentials and missing permissions
+// are both auth errors, and take precedence over the 404 responses GitHub
+// returns for resources the token cannot see.
+func errorCategory(err error) string {
+	var keyErr *config.KeyError
+	switch {
+	case github.IsRateLimitError(err):
+		return categoryRateLimit
+	case github.IsAuthenticationError(err), github.IsPermissionError(err):
+		return categoryAuth
+	case github.IsNotFoundError(err):
+		return categoryNotFound
+	case github.IsValidationError(err), errors.As(err, &keyErr):
+		return categoryValidation
+	}
+	return categoryOther
+}
+
+// runFailure is one error of a run.
+type runFailure struct {
+	// Repo is the repository the error belongs to (empty: the run itself,
+	// e.g. organization entries)
+	Repo     string
+	Category string
+	Err      error
+}
+
+// runReport collects the errors of a run by category and repository. It is
+// safe for concurrent use by the repository workers.
+type runReport struct {
+	mu       sync.Mutex
+	failures []runFailure
+}
+
+// add records the errors of repo (empty for errors of the run itself).
+func (r *runReport) add(repo string, errs ...error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, err := range errs {
+		r.failures = append(r.failures, runFailure{Repo: repo, Category: errorCategory(err), Err: err})
+	}
+}
+
+// len returns the number of errors.
+func (r *runReport) len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.failures)
+}
+
+// snapshot returns a copy of the failures.
+func (r *runReport) snapshot() []runFailure {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]runFailure(nil), r.failures...)
+}
+
+// err joins every error of the run, or returns nil without any.
+func (r *runReport) err() error {
+	var errs []error
+	for _, failure := range r.snapshot() {
+		errs = append(errs, failure.Err)
+	}
+	return errors.Join(errs...)
+}
+
+// unprocessedErrors returns the errors that belong to none of results, e.g.
+// of organization entries or of repositories dropped by preflight checks.
+func (r *runReport) unprocessedErrors(results []*repoResult) []error {
+	processed := make(map[string]bool, len(results))
+	for _, result := range results {
+		processed[result.Repo] = true
+	}
+	var errs []error
+	for _, failure := range r.snapshot() {
+		if !processed[failure.Repo] {
+			errs = append(errs, failure.Err)
+		}
+	}
+	return errs
+}
+
+// categoryCounts returns the number of errors of each category that occurred.
+func (r *runReport) categoryCounts() map[string]int {
+	counts := make(map[string]int)
+	for _, failure := range r.snapshot() {
+		counts[failure.Category]++
+	}
+	return counts
+}
+
+// exitCode classifies the run from its errors and the outcomes of the
+// organization and user entries (account, nil: none) and of the repositories:
+// authentication failures take precedence, since every other operation fails
+// with them as well. Otherwise the run failed when no secret or variable was
+// applied, and partially failed when some were, like the set command.
+func (r *runReport) exitCode(account *repoResult, results []*repoResult) int {
+	for _, failure := range r.snapshot() {
+		if github.IsAuthenticationError(failure.Err) {
+			return exitAuthError
+		}
+	}
+	if account != nil {
+		results = append([]*repoResult{account}, results...)
+	}
+	for _, result := range results {
+		for _, entry := range result.Entries {
+			if entry.Status != statusFailed {
+				return exitPartialFailure
+			}
+		}
+	}
+	return exitFailure
+}
+
+// printSummary writes the errors to w grouped by category, and within each
+// category by repository in alphabetical order; errors of the run itself
+// come first.
+func (r *runReport) printSummary(w io.Writer) error {
+	failures := r.snapshot()
+	sort.SliceStable(failures, func(i, j int) bool { return failures[i].Repo < failures[j].Repo })
+
+	fmt.Fprintf(w, "\n%d error(s):\n", len(failures))
+	for _, category := range errorCategories {
+		var lines []string
+		for _, failure := range failures {
+			if failure.Category != category {
+				continue
+			}
+			repo := failure.Repo
+			if repo == "" {
+				repo = "(run)"
+			}
+			lines = append(lines, fmt.Sprintf("    %s: %v", repo, failure.Err))
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "  %s (%d):\n", category, len(lines))
+		for _, line := range lines {
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
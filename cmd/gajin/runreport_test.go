@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	gogithub "github.com/google/go-github/v57/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunReport_ExitCode(t *testing.T) {
+	failure := errors.New("failure")
+	unauthorized := &gogithub.ErrorResponse{Response: &http.Response{StatusCode: http.StatusUnauthorized}}
+	applied := &repoResult{Repo: "api"}
+	applied.record(kindRepositorySecret, "", "TOKEN", true, nil)
+	applied.record(kindRepositoryVariable, "", "REGION", false, failure)
+	failed := &repoResult{Repo: "web"}
+	failed.record(kindRepositorySecret, "", "TOKEN", false, failure)
+	unchanged := &repoResult{Repo: "docs"}
+	unchanged.recordUnchanged(kindRepositoryVariable, "", "REGION")
+	account := &repoResult{}
+	account.recordSet(kindOrganizationSecret, "NPM_TOKEN", nil)
+
+	tests := []struct {
+		name     string
+		errs     []error
+		account  *repoResult
+		results  []*repoResult
+		expected int
+	}{
+		{
+			name:     "some entries applied",
+			errs:     []error{failure},
+			results:  []*repoResult{applied, failed},
+			expected: exitPartialFailure,
+		},
+		{
+			name:     "unchanged entries count as applied",
+			errs:     []error{failure},
+			results:  []*repoResult{failed, unchanged},
+			expected: exitPartialFailure,
+		},
+		{
+			name:     "organization entries applied",
+			errs:     []error{failure},
+			account:  account,
+			results:  []*repoResult{failed},
+			expected: exitPartialFailure,
+		},
+		{
+			name:     "every entry failed",
+			errs:     []error{failure},
+			results:  []*repoResult{failed},
+			expected: exitFailure,
+		},
+		{
+			name:     "nothing processed",
+			errs:     []error{failure},
+			expected: exitFailure,
+		},
+		{
+			name:     "authentication failure",
+			errs:     []error{failure, unauthorized},
+			results:  []*repoResult{applied},
+			expected: exitAuthError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := &runReport{}
+			report.add("", tt.errs...)
+			assert.Equal(t, tt.expected, report.exitCode(tt.account, tt.results))
+		})
+	}
+}
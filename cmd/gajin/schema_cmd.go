@@ -0,0 +1,36 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/azolfagharj/gajin/internal/config"
+)
+
+func newSchemaCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "schema",
+		Short: "Print the JSON Schema of the configuration file",
+		Long: `Print a JSON Schema describing the configuration file.
+
+Editors can use it to validate and complete configuration files, e.g. with the
+YAML language server:
+
+  gajin schema > gajin.schema.json
+  # yaml-language-server: $schema=./gajin.schema.json`,
+		Args:         cobra.NoArgs,
+		RunE:         runSchema,
+		SilenceUsage: true,
+	}
+}
+
+func runSchema(cmd *cobra.Command, args []string) error {
+	data, err := json.MarshalIndent(config.Schema(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode schema: %w", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/azolfagharj/gajin/pkg/sealedbox"
+)
+
+func newSelftestCmd() *cobra.Command {
+	selftestCmd := &cobra.Command{
+		Use:   "selftest",
+		Short: "Verify gajin works correctly on this platform",
+	}
+
+	selftestCmd.AddCommand(&cobra.Command{
+		Use:   "crypto",
+		Short: "Run known-answer tests for the sealed box encryption",
+		Long: `Run known-answer tests for the BLAKE2b-24 nonce derivation and the
+libsodium sealed box format, including decryption with a local test key pair.
+
+Run this before trusting a build on an unusual platform with production secrets.`,
+		RunE:         runSelftestCrypto,
+		SilenceUsage: true,
+	})
+
+	return selftestCmd
+}
+
+func runSelftestCrypto(cmd *cobra.Command, args []string) error {
+	failed := 0
+	for _, result := range sealedbox.SelfTest() {
+		if result.Err != nil {
+			failed++
+			fmt.Printf("FAIL  %s: %v\n", result.Name, result.Err)
+			continue
+		}
+		fmt.Printf("PASS  %s\n", result.Name)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d crypto self-test(s) failed", failed)
+	}
+	return nil
+}
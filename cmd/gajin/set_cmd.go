@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/azolfagharj/gajin/internal/cli"
+	"github.com/azolfagharj/gajin/internal/config"
+	"github.com/azolfagharj/gajin/internal/github"
+	"github.com/azolfagharj/gajin/internal/logger"
+)
+
+func newSetCmd() *cobra.Command {
+	setCmd := &cobra.Command{
+		Use:   "set NAME[=VALUE]",
+		Short: "Set one secret or variable without a configuration file",
+		Long: `Set one secret or variable in the repositories of --repo, without a
+configuration file, e.g. for an urgent rotation:
+
+  gajin set DEPLOY_KEY=s3cr3t --owner my-org --repo api,worker
+
+Without =VALUE, the value is prompted for without echo, or read from standard
+input, which keeps it out of the shell history:
+
+  vault read -field=key secret/deploy | gajin set DEPLOY_KEY --repo my-org/api
+
+The token is taken from --token, the keychain, GH_TOKEN_WITH_ACTIONS_WRITE,
+gajin login or the gh CLI. --owner defaults to the owner of the first
+repository given as owner/name.`,
+		Args:         cobra.ExactArgs(1),
+		RunE:         runSet,
+		SilenceUsage: true,
+	}
+	setCmd.Flags().Bool("variable", false, "Set a variable instead of a secret")
+	setCmd.Flags().String("environment", "", "Set the secret or variable of this environment instead of the repository")
+	setCmd.Flags().Bool("create-missing-environments", false, "Create the environment if it does not exist instead of failing")
+	setCmd.Flags().Bool("dry-run", false, "Show what would be done without making changes")
+	setCmd.Flags().String("api-url", "", "REST API URL of a GitHub Enterprise Server (default: api.github.com)")
+	return setCmd
+}
+
+func runSet(cmd *cobra.Command, args []string) error {
+	flags := readFlags(cmd)
+	log := newLogger(flags)
+	variable, _ := cmd.Flags().GetBool("variable")
+	envName, _ := cmd.Flags().GetString("environment")
+
+	name, value, hasValue, err := cli.ParseAssignment(args[0])
+	if err != nil {
+		log.Error("Invalid argument", "error", err)
+		return withExitCode(exitConfigError, err)
+	}
+	repos := cli.ParseRepos(flags.Repos)
+	if len(repos) == 0 {
+		err := fmt.Errorf("--repo is required")
+		log.Error("Invalid flag", "error", err)
+		return withExitCode(exitConfigError, err)
+	}
+	if !hasValue {
+		if value, err = readValue(name); err != nil {
+			log.Error("Failed to read the value", "error", err)
+			return withExitCode(exitConfigError, err)
+		}
+	}
+
+	// The entry is validated like one of a configuration file
+	cfg, err := config.NewConfig(loadOptions(flags))
+	if err != nil {
+		log.Error("Failed to load configuration", "error", err)
+		return withExitCode(exitConfigError, err)
+	}
+	owner := flags.Owner
+	if owner == "" {
+		owner, _, _ = strings.Cut(repos[0], "/")
+		if owner == repos[0] {
+			owner = ""
+		}
+	}
+	cfg.GitHub.APIURL, _ = cmd.Flags().GetString("api-url")
+	cfg.ApplyOverrides(flags.Token, owner, repos)
+	logReplacedToken(log, cfg, flags)
+	cfg.ApplyHTTPOverrides(flags.Proxy, flags.CAFile, flags.InsecureSkipVerify)
+	entries := map[string]string{name: value}
+	switch {
+	case variable && envName != "":
+		cfg.EnvironmentVariables = map[string]map[string]string{envName: entries}
+	case variable:
+		cfg.RepositoryVariables = entries
+	case envName != "":
+		cfg.EnvironmentSecrets = map[string]map[string]string{envName: entries}
+	default:
+		cfg.RepositorySecrets = entries
+	}
+	if cfg.GitHub.Owner == "" {
+		err := fmt.Errorf("--owner is required unless --repo names repositories as owner/name")
+		log.Error("Invalid flag", "error", err)
+		return withExitCode(exitConfigError, err)
+	}
+	// --exclude-repo applies to --repo as in runs of a configuration file
+	cfg.ApplyExcludeOverride(flags.ExcludeRepos)
+	cfg.GitHub.Repos = cfg.FilterExcluded(cfg.GitHub.Repos)
+	if len(cfg.GitHub.Repos) == 0 {
+		err := fmt.Errorf("no repositories left to process after --exclude-repo")
+		log.Error("Invalid flag", "error", err)
+		return withExitCode(exitConfigError, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Error("Configuration validation failed", "error", err)
+		return withExitCode(exitConfigError, err)
+	}
+
+	ghClient, err := newClient(cfg, log, nil, nil)
+	if err != nil {
+		log.Error("Failed to create GitHub client", "error", err)
+		return withExitCode(exitConfigError, err)
+	}
+
+	kind := "secret"
+	if variable {
+		kind = "variable"
+	}
+	display := maskSecret(value)
+	if variable {
+		display = value
+	}
+	ctx := context.Background()
+	failed := 0
+	for _, repo := range cfg.GitHub.Repos {
+		if flags.DryRun {
+			log.Info("Would set "+kind, "repo", repo, "environment", envName, "name", name, "value", display)
+			continue
+		}
+		if err := setEntry(ctx, log, ghClient, cfg, repo, envName, name, value, variable, flags.CreateMissingEnvironments); err != nil {
+			log.Error("Failed to set "+kind, "repo", repo, "environment", envName, "name", name, "error", err)
+			if github.IsAuthenticationError(err) {
+				return withExitCode(exitAuthError, err)
+			}
+			failed++
+			continue
+		}
+		log.Info("Successfully set "+kind, "repo", repo, "environment", envName, "name", name)
+	}
+
+	if failed > 0 {
+		err := fmt.Errorf("failed to set %s %s in %d of %d repositories", kind, name, failed, len(cfg.GitHub.Repos))
+		if failed < len(cfg.GitHub.Repos) {
+			return withExitCode(exitPartialFailure, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// setEntry sets the secret or variable name of a repository, or of its
+// environment envName.
+func setEntry(ctx context.Context, log *logger.Logger, ghClient github.Client, cfg *config.Config, repo, envName, name, value string, variable, createEnvironment bool) error {
+	owner, repoName := cfg.SplitRepo(repo)
+	switch {
+	case envName == "" && variable:
+		_, err := ghClient.SetRepositoryVariable(ctx, owner, repoName, name, value)
+		return err
+	case envName == "":
+		_, err := ghClient.SetRepositorySecret(ctx, owner, repoName, name, value)
+		return err
+	}
+	return setInEnvironment(ctx, log, ghClient, owner, repoName, envName, createEnvironment, func() (err error) {
+		if variable {
+			_, err = ghClient.SetEnvironmentVariable(ctx, owner, repoName, envName, name, value)
+		} else {
+			_, err = ghClient.SetEnvironmentSecret(ctx, owner, repoName, envName, name, value)
+		}
+		return err
+	})
+}
+
+// readValue prompts for the value of name without echo on a terminal, or
+// reads all of standard input, without its final line break.
+func readValue(name string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		fmt.Fprintf(os.Stderr, "Value of %s: ", name)
+		data, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("failed to read value: %w", err)
+		}
+		return string(data), nil
+	}
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", fmt.Errorf("failed to read value from standard input: %w", err)
+	}
+	value := strings.TrimSuffix(strings.TrimSuffix(string(data), "\n"), "\r")
+	if value == "" {
+		return "", fmt.Errorf("no value given for %s on standard input", name)
+	}
+	return value, nil
+}
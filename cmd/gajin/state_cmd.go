@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/azolfagharj/gajin/internal/cli"
+	"github.com/azolfagharj/gajin/internal/config"
+	"github.com/azolfagharj/gajin/internal/state"
+)
+
+func newStateCmd() *cobra.Command {
+	stateCmd := &cobra.Command{
+		Use:   "state",
+		Short: "Inspect the state file of applied secrets and variables",
+	}
+
+	stateCmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List the secrets and variables managed by gajin",
+		Long: `List the secrets and variables of the configured owner recorded in the state
+file, with when gajin first applied them and last applied a different value.
+Values are never shown; the state file only holds salted hashes.`,
+		Args:         cobra.NoArgs,
+		RunE:         runStateList,
+		SilenceUsage: true,
+	})
+
+	return stateCmd
+}
+
+func runStateList(cmd *cobra.Command, args []string) error {
+	flags := readFlags(cmd)
+	log := newLogger(flags)
+
+	cfg, err := config.ReadConfigFromPath(flags.ConfigPath, loadOptions(flags))
+	if err != nil {
+		log.Error("Failed to load configuration", "error", err)
+		return withExitCode(exitConfigError, err)
+	}
+	cfg.ApplyOverrides(flags.Token, flags.Owner, cli.ParseRepos(flags.Repos))
+	logReplacedToken(log, cfg, flags)
+
+	if !cfg.Settings.State.IsEnabled() {
+		return withExitCode(exitConfigError, fmt.Errorf("the state file is disabled (settings.state.enabled)"))
+	}
+	path, err := cfg.Settings.State.Path(cfg.Settings.Cache)
+	if err != nil {
+		return err
+	}
+	store, err := state.Load(path)
+	if err != nil {
+		return err
+	}
+
+	// Repositories listed as owner/name add their owners; without an owner,
+	// every resource is listed
+	owners := []string{cfg.GitHub.Owner}
+	for _, repo := range cfg.GitHub.Repos {
+		if owner, _ := cfg.SplitRepo(repo); cfg.GitHub.Owner != "" && !slices.Contains(owners, owner) {
+			owners = append(owners, owner)
+		}
+	}
+	var resources []state.Resource
+	for _, owner := range owners {
+		resources = append(resources, store.Resources(owner)...)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "REPO\tENVIRONMENT\tKIND\tNAME\tCREATED\tUPDATED")
+	for _, resource := range resources {
+		environment := resource.Environment
+		if environment == "" {
+			environment = "-"
+		}
+		fmt.Fprintf(w, "%s/%s\t%s\t%s\t%s\t%s\t%s\n", resource.Owner, resource.Repo, environment, resource.Kind, resource.Name,
+			resource.CreatedAt.Format(time.RFC3339), resource.UpdatedAt.Format(time.RFC3339))
+	}
+	return w.Flush()
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/azolfagharj/gajin/internal/cli"
+	"github.com/azolfagharj/gajin/internal/config"
+	"github.com/azolfagharj/gajin/internal/github"
+	"github.com/azolfagharj/gajin/internal/logger"
+)
+
+// resolveTargets expands dynamic repository selections (github.all_repos,
+// github.repos_by_topic and github.repos_by_team), adds the repositories of
+// targets and applies github.exclude_repos, leaving the final list of
+// repositories to process in cfg.GitHub.Repos.
+func resolveTargets(ctx context.Context, log *logger.Logger, ghClient github.Client, cfg *config.Config) error {
+	var repos []string
+	for _, repo := range cfg.GitHub.Repos {
+		repos = appendUnique(repos, cfg.NormalizeRepo(repo))
+	}
+
+	if cfg.GitHub.AllRepos || len(cfg.GitHub.ReposByTopic) > 0 {
+		ownerRepos, err := ghClient.ListOwnerRepositories(ctx, cfg.GitHub.Owner, nil)
+		if err != nil {
+			return err
+		}
+		log.Debug("Listed owner repositories", "owner", cfg.GitHub.Owner, "count", len(ownerRepos))
+
+		if cfg.GitHub.AllRepos {
+			repos = nil
+		}
+		for _, repo := range ownerRepos {
+			if cfg.GitHub.AllRepos || hasAnyTopic(repo.Topics, cfg.GitHub.ReposByTopic) {
+				repos = appendUnique(repos, repo.Name)
+			}
+		}
+	}
+
+	for _, team := range cfg.GitHub.ReposByTeam {
+		teamRepos, err := ghClient.ListTeamRepositories(ctx, cfg.GitHub.Owner, team)
+		if err != nil {
+			return err
+		}
+		log.Debug("Listed team repositories", "team", team, "count", len(teamRepos))
+		for _, repo := range teamRepos {
+			repos = appendUnique(repos, repo.Name)
+		}
+	}
+
+	// Repositories of targets are added to any selection of github.owner
+	for _, repo := range cfg.TargetRepos() {
+		repos = appendUnique(repos, cfg.NormalizeRepo(repo))
+	}
+
+	// Repositories are processed and reported in a stable order
+	cfg.GitHub.Repos = cfg.FilterExcluded(repos)
+	sort.Strings(cfg.GitHub.Repos)
+	// A configuration with only organization-level entries selects no repositories
+	selected := len(repos) > 0 || cfg.GitHub.HasDynamicRepos()
+	if len(cfg.GitHub.Repos) == 0 && selected {
+		return fmt.Errorf("no repositories left to process after resolving repository selections")
+	}
+
+	return nil
+}
+
+// preflightRepositories fetches the metadata of all target repositories with
+// a few GraphQL requests instead of one REST request each, priming the
+// repository ID cache. Archived repositories are dropped since they are
+// read-only. Repositories that do not exist or that the token cannot write to
+// are dropped as well and their errors added to report, before anything is
+// applied.
+// When the metadata cannot be fetched, repositories are processed as before.
+func preflightRepositories(ctx context.Context, log *logger.Logger, ghClient github.Client, cfg *config.Config, report *runReport) {
+	if len(cfg.GitHub.Repos) == 0 {
+		return
+	}
+
+	// Repositories are fetched in batches per owner
+	names := make(map[string][]string)
+	for _, repo := range cfg.GitHub.Repos {
+		owner, name := cfg.SplitRepo(repo)
+		names[owner] = append(names[owner], name)
+	}
+	metadata := make(map[string]map[string]github.RepositoryMetadata, len(names))
+	for owner := range names {
+		ownerMetadata, err := ghClient.GetRepositoriesMetadata(ctx, owner, names[owner])
+		if err != nil {
+			log.Debug("Failed to prefetch repository metadata", "owner", owner, "error", err)
+			return
+		}
+		metadata[owner] = ownerMetadata
+	}
+
+	repos := make([]string, 0, len(cfg.GitHub.Repos))
+	for _, repo := range cfg.GitHub.Repos {
+		owner, name := cfg.SplitRepo(repo)
+		repoMetadata, ok := metadata[owner][name]
+		var err error
+		switch {
+		case !ok:
+			err = &github.RepositoryNotFoundError{Owner: owner, Repo: name}
+		case repoMetadata.Archived:
+			log.Warn("Skipping archived repository", "repo", repo)
+		case !repoMetadata.CanWrite():
+			err = &github.WriteAccessError{Owner: owner, Repo: name, Permission: strings.ToLower(repoMetadata.Permission)}
+		default:
+			repos = append(repos, repo)
+		}
+		if err != nil {
+			log.Error("Preflight check failed", "repo", repo, "error", err)
+			report.add(repo, err)
+		}
+	}
+	cfg.GitHub.Repos = repos
+}
+
+// preflightEnvironments lists the environments of every repository before
+// anything is applied and reports all environments that hold secrets or
+// variables but do not exist at once, instead of one failing entry at a time.
+// Unless they are created (--create-missing-environments) or the run
+// continues on errors, they are added to report, which stops the run.
+func preflightEnvironments(ctx context.Context, log *logger.Logger, ghClient github.Client, cfg *config.Config, flags *cli.Flags, report *runReport) {
+	// Environments configured in the environments section are created with
+	// their protection rules, except in filtered runs
+	required := make(map[string][]string)
+	for _, repo := range cfg.GitHub.Repos {
+		filter := newEntryFilter(flags).forRepo(repo)
+		configured := cfg.EnvironmentsFor(repo)
+		if filter.active() {
+			configured = nil
+		}
+		for _, envName := range entryEnvironments(filter.resources(cfg.ResourcesFor(repo))) {
+			if !slices.Contains(configured, envName) {
+				required[repo] = append(required[repo], envName)
+			}
+		}
+	}
+	if len(required) == 0 {
+		return
+	}
+
+	var mu sync.Mutex
+	missing := make(map[string][]string)
+	var group errgroup.Group
+	group.SetLimit(cfg.Settings.Concurrency.MaxRepos())
+	for repo, envNames := range required {
+		group.Go(func() error {
+			owner, name := cfg.SplitRepo(repo)
+			existing, err := ghClient.ListEnvironments(ctx, owner, name)
+			if err != nil {
+				// The entries of the repository report missing environments instead
+				log.Debug("Could not list environments", "repo", repo, "error", err)
+				return nil
+			}
+			for _, envName := range envNames {
+				// Environment names are case-insensitive
+				if !slices.ContainsFunc(existing, func(name string) bool { return strings.EqualFold(name, envName) }) {
+					mu.Lock()
+					missing[repo] = append(missing[repo], envName)
+					mu.Unlock()
+				}
+			}
+			return nil
+		})
+	}
+	group.Wait()
+
+	for _, repo := range sortedKeys(missing) {
+		switch {
+		case flags.CreateMissingEnvironments:
+			log.Info("Missing environments will be created", "repo", repo, "environments", missing[repo])
+		case flags.ContinueOnError:
+			// Their entries fail and are reported; the other entries are applied
+			log.Error("Environments do not exist", "repo", repo, "environments", missing[repo])
+		default:
+			log.Error("Environments do not exist", "repo", repo, "environments", missing[repo])
+			owner, name := cfg.SplitRepo(repo)
+			for _, envName := range missing[repo] {
+				report.add(repo, &github.EnvironmentNotFoundError{Owner: owner, Repo: name, Environment: envName})
+			}
+		}
+	}
+	if len(missing) > 0 && !flags.CreateMissingEnvironments {
+		log.Info("Create the missing environments on GitHub or run with --create-missing-environments")
+	}
+}
+
+// warnUnmatchedTargets warns about the environments of --target that hold no
+// secret or variable of their repository, such as misspelled environments.
+func warnUnmatchedTargets(log *logger.Logger, cfg *config.Config, flags *cli.Flags) {
+	// Targets were checked by cli.ValidateTargets
+	targets, _ := cli.ParseTargets(flags.Targets)
+	for _, target := range targets {
+		if target.Environment == "" {
+			continue
+		}
+		if !slices.Contains(entryEnvironments(cfg.ResourcesFor(target.Repo)), target.Environment) &&
+			!slices.Contains(entryEnvironments(cfg.AbsentFor(target.Repo)), target.Environment) {
+			log.Warn("No configured secrets or variables in the target environment", "repo", target.Repo, "environment", target.Environment)
+		}
+	}
+}
+
+// hasAnyTopic reports whether topics contains at least one of wanted.
+func hasAnyTopic(topics, wanted []string) bool {
+	for _, topic := range topics {
+		for _, w := range wanted {
+			if topic == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// appendUnique appends repo unless it is already present.
+func appendUnique(repos []string, repo string) []string {
+	for _, existing := range repos {
+		if existing == repo {
+			return repos
+		}
+	}
+	return append(repos, repo)
+}
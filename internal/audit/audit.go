@@ -0,0 +1,116 @@
+// Package audit appends a record of every change gajin makes on GitHub to a
+// local JSON Lines file, e.g. for change management. Records never hold the
+// values of secrets or variables.
+package audit
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Results of a recorded change.
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+// Record is one line of the audit log.
+type Record struct {
+	Time time.Time `json:"time"`
+	// RunID is shared by the records of one run
+	RunID string `json:"run_id"`
+	// Actor is the GitHub identity that made the change
+	Actor string `json:"actor"`
+	// Operator is the local user who ran gajin
+	Operator    string `json:"operator,omitempty"`
+	Operation   string `json:"operation"`
+	Resource    string `json:"resource"`
+	Owner       string `json:"owner,omitempty"`
+	Repo        string `json:"repo,omitempty"`
+	Environment string `json:"environment,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Result      string `json:"result"`
+	Error       string `json:"error,omitempty"`
+}
+
+// Log appends records to a file, one JSON document per line. It is safe for
+// concurrent use.
+type Log struct {
+	mu       sync.Mutex
+	file     *os.File
+	runID    string
+	actor    string
+	operator string
+	// err is the first error writing a record
+	err error
+}
+
+// Open opens the audit log at path for appending, creating it and its
+// directory if needed. The records of the log are attributed to actor and
+// operator.
+func Open(path, actor, operator string) (*Log, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create directory for the audit log: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the audit log: %w", err)
+	}
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to generate the run ID: %w", err)
+	}
+	return &Log{file: file, runID: hex.EncodeToString(id), actor: actor, operator: operator}, nil
+}
+
+// RunID returns the ID shared by the records of this log.
+func (l *Log) RunID() string {
+	return l.runID
+}
+
+// Record appends record, stamped with the time, run ID and operator of the
+// log, and with its actor unless record names the actor. Every record is
+// written with a single write and synced, so that it survives a crash.
+// Errors are reported by Close.
+func (l *Log) Record(record Record) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	record.Time = time.Now().UTC()
+	record.RunID = l.runID
+	if record.Actor == "" {
+		record.Actor = l.actor
+	}
+	record.Operator = l.operator
+	line, err := json.Marshal(record)
+	if err == nil {
+		_, err = l.file.Write(append(line, '\n'))
+	}
+	if err == nil {
+		err = l.file.Sync()
+	}
+	if err != nil && l.err == nil {
+		l.err = fmt.Errorf("failed to write the audit log: %w", err)
+	}
+}
+
+// Close closes the file. It returns the first error writing a record, so
+// that a run whose changes were not all recorded fails.
+func (l *Log) Close() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.file.Close(); err != nil && l.err == nil {
+		l.err = fmt.Errorf("failed to close the audit log: %w", err)
+	}
+	return l.err
+}
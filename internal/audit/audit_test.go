@@ -0,0 +1,107 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readRecords returns the records of the audit log at path.
+func readRecords(t *testing.T, path string) []Record {
+	t.Helper()
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record Record
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		records = append(records, record)
+	}
+	require.NoError(t, scanner.Err())
+	return records
+}
+
+func TestLog_Record(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "gajin.jsonl")
+	log, err := Open(path, "octocat", "alice")
+	require.NoError(t, err)
+	log.Record(Record{Operation: "update", Resource: "environment_secret", Owner: "my-org", Repo: "api", Environment: "production", Name: "DB_PASSWORD", Result: ResultSuccess})
+	log.Record(Record{Operation: "delete", Resource: "repository_variable", Owner: "my-org", Repo: "api", Name: "REGION", Result: ResultFailure, Error: "forbidden"})
+	log.Record(Record{Actor: "other-bot", Operation: "create", Resource: "repository_secret", Owner: "other-org", Repo: "lib", Name: "TOKEN", Result: ResultSuccess})
+	require.NoError(t, log.Close())
+
+	records := readRecords(t, path)
+	require.Len(t, records, 3)
+	assert.False(t, records[0].Time.IsZero())
+	assert.Len(t, records[0].RunID, 16)
+	for i := range records {
+		records[i].Time = time.Time{}
+	}
+	assert.Equal(t, []Record{
+		{RunID: log.RunID(), Actor: "octocat", Operator: "alice", Operation: "update", Resource: "environment_secret", Owner: "my-org", Repo: "api", Environment: "production", Name: "DB_PASSWORD", Result: ResultSuccess},
+		{RunID: log.RunID(), Actor: "octocat", Operator: "alice", Operation: "delete", Resource: "repository_variable", Owner: "my-org", Repo: "api", Name: "REGION", Result: ResultFailure, Error: "forbidden"},
+		{RunID: log.RunID(), Actor: "other-bot", Operator: "alice", Operation: "create", Resource: "repository_secret", Owner: "other-org", Repo: "lib", Name: "TOKEN", Result: ResultSuccess},
+	}, records)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
+
+func TestLog_AppendsAcrossRuns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gajin.jsonl")
+	var runIDs []string
+	for i := 0; i < 2; i++ {
+		log, err := Open(path, "octocat", "")
+		require.NoError(t, err)
+		log.Record(Record{Operation: "create", Resource: "repository_secret", Owner: "o", Repo: "r", Name: "TOKEN", Result: ResultSuccess})
+		require.NoError(t, log.Close())
+		runIDs = append(runIDs, log.RunID())
+	}
+
+	records := readRecords(t, path)
+	require.Len(t, records, 2)
+	assert.Equal(t, runIDs[0], records[0].RunID)
+	assert.Equal(t, runIDs[1], records[1].RunID)
+	assert.NotEqual(t, runIDs[0], runIDs[1])
+}
+
+func TestLog_Concurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gajin.jsonl")
+	log, err := Open(path, "octocat", "")
+	require.NoError(t, err)
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			log.Record(Record{Operation: "set", Resource: "organization_secret", Owner: "o", Name: "TOKEN", Result: ResultSuccess})
+		}()
+	}
+	wg.Wait()
+	require.NoError(t, log.Close())
+	assert.Len(t, readRecords(t, path), 20)
+}
+
+func TestLog_ReportsWriteErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gajin.jsonl")
+	log, err := Open(path, "octocat", "")
+	require.NoError(t, err)
+	// Writing to a closed file fails
+	require.NoError(t, log.file.Close())
+	log.Record(Record{Operation: "set", Resource: "repository_secret", Result: ResultSuccess})
+
+	err = log.Close()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to write the audit log")
+}
@@ -0,0 +1,173 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zalando/go-keyring"
+	"golang.org/x/oauth2"
+)
+
+func TestCredentials_RoundTrip(t *testing.T) {
+	keyring.MockInitWithError(errors.New("no secret service"))
+	dir := t.TempDir()
+	t.Setenv(EnvConfigDir, dir)
+
+	creds, err := LoadCredentials()
+	require.NoError(t, err)
+	assert.Nil(t, creds)
+
+	require.NoError(t, SaveCredentials(Credentials{Token: "gho_abc", Scopes: []string{"repo"}}))
+
+	token, detail, err := StoredToken()
+	require.NoError(t, err)
+	assert.Equal(t, "gho_abc", token)
+	assert.Equal(t, filepath.Join(dir, "credentials.yaml"), detail)
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(detail)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	}
+}
+
+func TestStoredToken_Keychain(t *testing.T) {
+	keyring.MockInit()
+	dir := t.TempDir()
+	t.Setenv(EnvConfigDir, dir)
+
+	// The keychain entry of gajin login takes precedence over the file
+	require.NoError(t, SaveCredentials(Credentials{Token: "gho_file"}))
+	require.NoError(t, SetKeychainLoginToken("gho_keychain"))
+	token, detail, err := StoredToken()
+	require.NoError(t, err)
+	assert.Equal(t, "gho_keychain", token)
+	assert.Equal(t, "system keychain (gajin login)", detail)
+
+	// It is not the token of auth set-token
+	token, _, err = KeychainToken()
+	require.NoError(t, err)
+	assert.Empty(t, token)
+
+	require.NoError(t, RemoveCredentials())
+	require.NoError(t, RemoveCredentials())
+	creds, err := LoadCredentials()
+	require.NoError(t, err)
+	assert.Nil(t, creds)
+}
+
+func TestDeviceFlow_Login(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login/device/code", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "client-id", r.Form.Get("client_id"))
+		assert.Equal(t, "repo admin:org codespace", r.Form.Get("scope"))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"device_code": "dc", "user_code": "ABCD-1234", "verification_uri": "https://github.com/login/device", "expires_in": 900, "interval": 1}`)
+	})
+	mux.HandleFunc("/login/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "dc", r.Form.Get("device_code"))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token": "gho_token", "token_type": "bearer", "scope": "repo"}`)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	flow := DeviceFlow{
+		ClientID: "client-id",
+		Endpoint: oauth2.Endpoint{
+			DeviceAuthURL: server.URL + "/login/device/code",
+			TokenURL:      server.URL + "/login/oauth/access_token",
+		},
+	}
+	var userCode string
+	token, err := flow.Login(context.Background(), func(code *oauth2.DeviceAuthResponse) {
+		userCode = code.UserCode
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "ABCD-1234", userCode)
+	assert.Equal(t, "gho_token", token.AccessToken)
+}
+
+func TestDeviceFlow_RequiresClientID(t *testing.T) {
+	_, err := DeviceFlow{}.Login(context.Background(), func(*oauth2.DeviceAuthResponse) {})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "client ID is required")
+}
+
+func TestGHCLIToken_HostsFile(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("GH_CONFIG_DIR", dir)
+	hosts := "github.com:\n    user: octocat\n    oauth_token: gho_from_gh\n    git_protocol: https\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "hosts.yml"), []byte(hosts), 0o600))
+
+	token, detail, err := GHCLIToken()
+	require.NoError(t, err)
+	assert.Equal(t, "gho_from_gh", token)
+	assert.Equal(t, "gh CLI "+filepath.Join(dir, "hosts.yml"), detail)
+}
+
+func TestGHCLIToken_AuthTokenCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as gh")
+	}
+	dir := t.TempDir()
+	t.Setenv("GH_CONFIG_DIR", dir)
+
+	// Tokens in the keyring leave hosts.yml without oauth_token
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "hosts.yml"), []byte("github.com:\n    user: octocat\n"), 0o600))
+	gh := filepath.Join(dir, "gh")
+	require.NoError(t, os.WriteFile(gh, []byte("#!/bin/sh\necho gho_from_keyring\n"), 0o700))
+	original := ghCommand
+	ghCommand = gh
+	t.Cleanup(func() { ghCommand = original })
+
+	token, detail, err := GHCLIToken()
+	require.NoError(t, err)
+	assert.Equal(t, "gho_from_keyring", token)
+	assert.Equal(t, "gh auth token", detail)
+
+	// A gh that is not logged in yields no token
+	require.NoError(t, os.WriteFile(gh, []byte("#!/bin/sh\nexit 1\n"), 0o700))
+	token, _, err = GHCLIToken()
+	require.NoError(t, err)
+	assert.Empty(t, token)
+}
+
+func TestKeychainToken(t *testing.T) {
+	keyring.MockInit()
+
+	token, _, err := KeychainToken()
+	require.NoError(t, err)
+	assert.Empty(t, token)
+
+	require.NoError(t, SetKeychainToken("ghp_keychain"))
+	token, detail, err := KeychainToken()
+	require.NoError(t, err)
+	assert.Equal(t, "ghp_keychain", token)
+	assert.Equal(t, "system keychain", detail)
+
+	require.NoError(t, DeleteKeychainToken())
+	require.NoError(t, DeleteKeychainToken())
+	token, err = LookupKeychainToken()
+	require.NoError(t, err)
+	assert.Empty(t, token)
+
+	// An unavailable keychain is reported by the lookup but not by the provider
+	keyring.MockInitWithError(errors.New("no secret service"))
+	_, err = LookupKeychainToken()
+	require.Error(t, err)
+	token, _, err = KeychainToken()
+	require.NoError(t, err)
+	assert.Empty(t, token)
+}
@@ -0,0 +1,113 @@
+// Package auth obtains and stores the GitHub credentials gajin authenticates
+// with when no token is configured.
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/azolfagharj/gajin/internal/fsutil"
+)
+
+// EnvConfigDir overrides the directory the credentials are stored in.
+const EnvConfigDir = "GAJIN_CONFIG_DIR"
+
+// Credentials is the content of the credentials file written by gajin login.
+type Credentials struct {
+	Token  string   `yaml:"token"`
+	Scopes []string `yaml:"scopes,omitempty"`
+}
+
+// ConfigDir returns the directory of gajin's user-level files: $GAJIN_CONFIG_DIR,
+// or gajin in the user configuration directory (e.g. ~/.config/gajin).
+func ConfigDir() (string, error) {
+	if dir := os.Getenv(EnvConfigDir); dir != "" {
+		return dir, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate the user configuration directory: %w", err)
+	}
+	return filepath.Join(dir, "gajin"), nil
+}
+
+// CredentialsPath returns the path of the credentials file.
+func CredentialsPath() (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "credentials.yaml"), nil
+}
+
+// SaveCredentials writes creds to the credentials file, readable only by the
+// current user.
+func SaveCredentials(creds Credentials) error {
+	path, err := CredentialsPath()
+	if err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(creds)
+	if err != nil {
+		return fmt.Errorf("failed to encode credentials: %w", err)
+	}
+	if err := fsutil.WriteFileAtomic(path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to save credentials: %w", err)
+	}
+	return nil
+}
+
+// RemoveCredentials removes the credentials file, if any, e.g. once the
+// token of gajin login is stored in the system keychain instead.
+func RemoveCredentials() error {
+	path, err := CredentialsPath()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove the credentials file: %w", err)
+	}
+	return nil
+}
+
+// LoadCredentials reads the credentials file. It returns nil if gajin login
+// was never run.
+func LoadCredentials() (*Credentials, error) {
+	path, err := CredentialsPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credentials: %w", err)
+	}
+	var creds Credentials
+	if err := yaml.Unmarshal(data, &creds); err != nil {
+		return nil, fmt.Errorf("failed to parse credentials file %s: %w", path, err)
+	}
+	return &creds, nil
+}
+
+// StoredToken returns the token saved by gajin login, if any, along with
+// where it is stored: the system keychain or the path of the credentials file.
+func StoredToken() (token, detail string, err error) {
+	if token := keychainLoginToken(); token != "" {
+		return token, keychainLoginDetail, nil
+	}
+	creds, err := LoadCredentials()
+	if err != nil || creds == nil {
+		return "", "", err
+	}
+	path, err := CredentialsPath()
+	if err != nil {
+		return "", "", err
+	}
+	return creds.Token, path, nil
+}
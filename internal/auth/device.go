@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/endpoints"
+)
+
+// DefaultScopes are the OAuth scopes requested by gajin login: repository
+// secrets and variables, organization secrets and variables, and Codespaces
+// secrets.
+var DefaultScopes = []string{"repo", "admin:org", "codespace"}
+
+// DeviceFlow runs the OAuth device authorization flow of a GitHub OAuth app.
+type DeviceFlow struct {
+	// ClientID is the client ID of the OAuth app
+	ClientID string
+	// Scopes are the requested scopes (DefaultScopes if empty)
+	Scopes []string
+	// Endpoint is the OAuth endpoint (github.com if empty)
+	Endpoint oauth2.Endpoint
+}
+
+// Login requests a device code, calls prompt with the code the user must
+// enter at the verification URL and waits until the user authorizes the
+// app, the code expires or ctx is done.
+func (f DeviceFlow) Login(ctx context.Context, prompt func(*oauth2.DeviceAuthResponse)) (*oauth2.Token, error) {
+	if f.ClientID == "" {
+		return nil, fmt.Errorf("an OAuth app client ID is required for the device flow")
+	}
+	cfg := &oauth2.Config{
+		ClientID: f.ClientID,
+		Scopes:   f.Scopes,
+		Endpoint: f.Endpoint,
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = DefaultScopes
+	}
+	if cfg.Endpoint.DeviceAuthURL == "" {
+		cfg.Endpoint = endpoints.GitHub
+	}
+	// Public clients have no secret to send in an Authorization header
+	cfg.Endpoint.AuthStyle = oauth2.AuthStyleInParams
+
+	code, err := cfg.DeviceAuth(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request a device code: %w", err)
+	}
+	prompt(code)
+
+	token, err := cfg.DeviceAccessToken(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("device authorization failed: %w", err)
+	}
+	return token, nil
+}
@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ghHost is the host whose gh CLI credentials are reused.
+const ghHost = "github.com"
+
+// ghCommand is the gh CLI executable; replaced in tests.
+var ghCommand = "gh"
+
+// ghCommandTimeout bounds `gh auth token`, which may query the system keyring.
+const ghCommandTimeout = 10 * time.Second
+
+// GHCLIToken returns the token of the official gh CLI for github.com, read
+// from its hosts.yml or, when gh keeps it in the system keyring, from
+// `gh auth token`. It returns an empty token if gh is not installed or not
+// authenticated.
+func GHCLIToken() (token, detail string, err error) {
+	path := ghHostsPath()
+	token, err = readGHHostsToken(path)
+	if err != nil {
+		return "", "", err
+	}
+	if token != "" {
+		return token, "gh CLI " + path, nil
+	}
+
+	if _, err := exec.LookPath(ghCommand); err != nil {
+		return "", "", nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), ghCommandTimeout)
+	defer cancel()
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx, ghCommand, "auth", "token", "--hostname", ghHost)
+	cmd.Stdout = &stdout
+	// gh exits non-zero when it is not logged in, which is not an error here
+	if err := cmd.Run(); err != nil {
+		return "", "", nil
+	}
+	return strings.TrimSpace(stdout.String()), "gh auth token", nil
+}
+
+// ghHostsPath returns the path of the gh CLI hosts.yml, following gh's own
+// lookup of its configuration directory.
+func ghHostsPath() string {
+	if dir := os.Getenv("GH_CONFIG_DIR"); dir != "" {
+		return filepath.Join(dir, "hosts.yml")
+	}
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "gh", "hosts.yml")
+	}
+	if runtime.GOOS == "windows" {
+		if dir := os.Getenv("AppData"); dir != "" {
+			return filepath.Join(dir, "GitHub CLI", "hosts.yml")
+		}
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "gh", "hosts.yml")
+}
+
+// readGHHostsToken returns the oauth_token of github.com in a gh hosts.yml,
+// or an empty token if the file or the entry does not exist.
+func readGHHostsToken(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read gh CLI hosts file: %w", err)
+	}
+	var hosts map[string]struct {
+		OAuthToken string `yaml:"oauth_token"`
+	}
+	if err := yaml.Unmarshal(data, &hosts); err != nil {
+		return "", fmt.Errorf("failed to parse gh CLI hosts file %s: %w", path, err)
+	}
+	return hosts[ghHost].OAuthToken, nil
+}
@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/zalando/go-keyring"
+)
+
+// Keychain entry holding the GitHub token: macOS Keychain, Windows Credential
+// Manager or the Secret Service (e.g. GNOME Keyring) on Linux.
+const (
+	keychainService = "gajin"
+	keychainUser    = "github.com"
+	// keychainLoginUser holds the token of gajin login
+	keychainLoginUser = "github.com (gajin login)"
+)
+
+// keychainLoginDetail describes the keychain entry of gajin login.
+const keychainLoginDetail = "system keychain (gajin login)"
+
+// SetKeychainToken stores token in the system keychain.
+func SetKeychainToken(token string) error {
+	if err := keyring.Set(keychainService, keychainUser, token); err != nil {
+		return fmt.Errorf("failed to store the token in the system keychain: %w", err)
+	}
+	return nil
+}
+
+// DeleteKeychainToken removes the token from the system keychain. Removing a
+// token that is not stored is not an error.
+func DeleteKeychainToken() error {
+	err := keyring.Delete(keychainService, keychainUser)
+	if err != nil && !errors.Is(err, keyring.ErrNotFound) {
+		return fmt.Errorf("failed to remove the token from the system keychain: %w", err)
+	}
+	return nil
+}
+
+// LookupKeychainToken returns the token stored in the system keychain, or an
+// empty token if none is stored. Errors report an unavailable keychain.
+func LookupKeychainToken() (string, error) {
+	token, err := keyring.Get(keychainService, keychainUser)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read the system keychain: %w", err)
+	}
+	return token, nil
+}
+
+// KeychainToken returns the token stored in the system keychain. Machines
+// without a usable keychain, such as headless CI runners, have no token
+// rather than an error, so other token sources keep working there.
+func KeychainToken() (token, detail string, err error) {
+	token, err = LookupKeychainToken()
+	if err != nil || token == "" {
+		return "", "", nil
+	}
+	return token, "system keychain", nil
+}
+
+// SetKeychainLoginToken stores the token of gajin login in the system
+// keychain. Unlike a token of SetKeychainToken, it is used from the position
+// of the credentials file (see StoredToken).
+func SetKeychainLoginToken(token string) error {
+	if err := keyring.Set(keychainService, keychainLoginUser, token); err != nil {
+		return fmt.Errorf("failed to store the token in the system keychain: %w", err)
+	}
+	return nil
+}
+
+// keychainLoginToken returns the token of gajin login stored in the system
+// keychain, or an empty token if none is stored or the keychain is unusable.
+func keychainLoginToken() string {
+	token, err := keyring.Get(keychainService, keychainLoginUser)
+	if err != nil {
+		return ""
+	}
+	return token
+}
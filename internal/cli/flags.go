@@ -1,7 +1,29 @@
 package cli
 
 import (
+	"fmt"
+	"path"
+	"slices"
 	"strings"
+	"time"
+)
+
+// Output formats of the run results.
+const (
+	OutputText = "text"
+	OutputJSON = "json"
+)
+
+// Formats of the log entries on standard error.
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// Kinds of entries selected by the --only flag.
+const (
+	OnlySecrets   = "secrets"
+	OnlyVariables = "variables"
 )
 
 // Flags represents all CLI flags.
@@ -10,10 +32,139 @@ type Flags struct {
 	Token           string
 	Owner           string
 	Repos           string
+	ExcludeRepos    []string
 	DryRun          bool
+	FailOnDrift     bool
+	Yes             bool
 	ContinueOnError bool
 	Verbose         bool
+	Quiet           bool
 	ShowVersion     bool
+	Output          string
+	LogFormat       string
+	AllowCommands   bool
+	CommandTimeout  time.Duration
+	Profile         string
+	Format          string
+	Identity        string
+
+	CreateMissingEnvironments bool
+	Concurrency               int
+	MaxRetries                *int
+	Force                     bool
+	PruneState                bool
+	Only                      string
+	Environments              []string
+	Names                     []string
+	Targets                   []string
+	Resume                    bool
+	RollbackOnError           bool
+	MetricsFile               string
+
+	Proxy              string
+	CAFile             string
+	InsecureSkipVerify bool
+}
+
+// ValidateOutput checks the value of the --output flag.
+func ValidateOutput(output string) error {
+	switch output {
+	case OutputText, OutputJSON:
+		return nil
+	}
+	return fmt.Errorf("--output must be '%s' or '%s', got '%s'", OutputText, OutputJSON, output)
+}
+
+// ValidateLogFormat checks the value of the --log-format flag.
+func ValidateLogFormat(format string) error {
+	switch format {
+	case LogFormatText, LogFormatJSON:
+		return nil
+	}
+	return fmt.Errorf("--log-format must be '%s' or '%s', got '%s'", LogFormatText, LogFormatJSON, format)
+}
+
+// ValidateVerbosity checks that --quiet and --verbose are not combined.
+func ValidateVerbosity(verbose, quiet bool) error {
+	if verbose && quiet {
+		return fmt.Errorf("--quiet and --verbose cannot be combined")
+	}
+	return nil
+}
+
+// ValidateNames checks the glob patterns of the --name flag.
+func ValidateNames(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid --name pattern '%s': %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// ValidateOnly checks the value of the --only flag; empty selects every entry.
+func ValidateOnly(only string) error {
+	switch only {
+	case "", OnlySecrets, OnlyVariables:
+		return nil
+	}
+	return fmt.Errorf("--only must be '%s' or '%s', got '%s'", OnlySecrets, OnlyVariables, only)
+}
+
+// Target is a repository, or an environment of a repository, selected by
+// the --target flag.
+type Target struct {
+	Repo string
+	// Environment is empty when every entry of the repository is targeted
+	Environment string
+}
+
+// ParseTargets parses --target values of the form repo or repo@environment.
+func ParseTargets(values []string) ([]Target, error) {
+	targets := make([]Target, 0, len(values))
+	for _, value := range values {
+		repo, environment, scoped := strings.Cut(strings.TrimSpace(value), "@")
+		if repo == "" || (scoped && environment == "") {
+			return nil, fmt.Errorf("invalid --target '%s': expected repo or repo@environment", value)
+		}
+		targets = append(targets, Target{Repo: repo, Environment: environment})
+	}
+	return targets, nil
+}
+
+// ValidateTargets checks the values of the --target flag, which replaces the
+// repositories of --repo and cannot be combined with it.
+func ValidateTargets(targets []string, repos string) error {
+	if _, err := ParseTargets(targets); err != nil {
+		return err
+	}
+	if len(targets) > 0 && repos != "" {
+		return fmt.Errorf("--target and --repo cannot be combined")
+	}
+	return nil
+}
+
+// TargetRepos returns the repositories of targets, in order and without
+// duplicates.
+func TargetRepos(targets []Target) []string {
+	var repos []string
+	for _, target := range targets {
+		if !slices.Contains(repos, target.Repo) {
+			repos = append(repos, target.Repo)
+		}
+	}
+	return repos
+}
+
+// ParseAssignment parses the NAME=VALUE argument of gajin set. hasValue is
+// false for a bare NAME, whose value is read from standard input instead.
+func ParseAssignment(arg string) (name, value string, hasValue bool, err error) {
+	name, value, hasValue = strings.Cut(arg, "=")
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", "", false, fmt.Errorf("invalid argument '%s': expected NAME=VALUE or NAME", arg)
+	}
+	return name, value, hasValue, nil
 }
 
 // ParseRepos parses comma-separated repository names into a slice.
@@ -31,4 +182,3 @@ func ParseRepos(reposStr string) []string {
 	}
 	return result
 }
-
@@ -47,3 +47,66 @@ func TestParseRepos(t *testing.T) {
 	}
 }
 
+func TestValidateOutput(t *testing.T) {
+	assert.NoError(t, ValidateOutput(OutputText))
+	assert.NoError(t, ValidateOutput(OutputJSON))
+	assert.EqualError(t, ValidateOutput("yaml"), "--output must be 'text' or 'json', got 'yaml'")
+}
+
+func TestValidateLogFormat(t *testing.T) {
+	assert.NoError(t, ValidateLogFormat(LogFormatText))
+	assert.NoError(t, ValidateLogFormat(LogFormatJSON))
+	assert.EqualError(t, ValidateLogFormat("logfmt"), "--log-format must be 'text' or 'json', got 'logfmt'")
+}
+
+func TestValidateVerbosity(t *testing.T) {
+	assert.NoError(t, ValidateVerbosity(false, false))
+	assert.NoError(t, ValidateVerbosity(true, false))
+	assert.NoError(t, ValidateVerbosity(false, true))
+	assert.EqualError(t, ValidateVerbosity(true, true), "--quiet and --verbose cannot be combined")
+}
+
+func TestValidateOnly(t *testing.T) {
+	assert.NoError(t, ValidateOnly(""))
+	assert.NoError(t, ValidateOnly(OnlySecrets))
+	assert.NoError(t, ValidateOnly(OnlyVariables))
+	assert.EqualError(t, ValidateOnly("secret"), "--only must be 'secrets' or 'variables', got 'secret'")
+}
+
+func TestValidateNames(t *testing.T) {
+	assert.NoError(t, ValidateNames(nil))
+	assert.NoError(t, ValidateNames([]string{"DOCKER_PASSWORD", "AWS_*", "TOKEN_[0-9]"}))
+	assert.ErrorContains(t, ValidateNames([]string{"TOKEN", "AWS_[*"}), "invalid --name pattern 'AWS_[*'")
+}
+
+func TestParseTargets(t *testing.T) {
+	targets, err := ParseTargets([]string{"api@production", "worker", " api@staging "})
+	assert.NoError(t, err)
+	assert.Equal(t, []Target{{Repo: "api", Environment: "production"}, {Repo: "worker"}, {Repo: "api", Environment: "staging"}}, targets)
+	assert.Equal(t, []string{"api", "worker"}, TargetRepos(targets))
+
+	_, err = ParseTargets([]string{"@production"})
+	assert.EqualError(t, err, "invalid --target '@production': expected repo or repo@environment")
+	_, err = ParseTargets([]string{"api@"})
+	assert.EqualError(t, err, "invalid --target 'api@': expected repo or repo@environment")
+}
+
+func TestParseAssignment(t *testing.T) {
+	name, value, hasValue, err := ParseAssignment("DEPLOY_KEY=a=b")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"DEPLOY_KEY", "a=b", true}, []interface{}{name, value, hasValue})
+
+	name, value, hasValue, err = ParseAssignment("DEPLOY_KEY")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"DEPLOY_KEY", "", false}, []interface{}{name, value, hasValue})
+
+	_, _, _, err = ParseAssignment("=value")
+	assert.EqualError(t, err, "invalid argument '=value': expected NAME=VALUE or NAME")
+}
+
+func TestValidateTargets(t *testing.T) {
+	assert.NoError(t, ValidateTargets(nil, "api"))
+	assert.NoError(t, ValidateTargets([]string{"api@production"}, ""))
+	assert.EqualError(t, ValidateTargets([]string{"api@production"}, "api"), "--target and --repo cannot be combined")
+	assert.ErrorContains(t, ValidateTargets([]string{""}, ""), "invalid --target ''")
+}
@@ -0,0 +1,84 @@
+package config
+
+// SectionActionsPermissions declares the GitHub Actions policy of repositories.
+const SectionActionsPermissions = "actions_permissions"
+
+// Allowed actions policies of a repository.
+const (
+	AllowedActionsAll       = "all"
+	AllowedActionsLocalOnly = "local_only"
+	AllowedActionsSelected  = "selected"
+)
+
+// ActionsPermissions declares whether GitHub Actions is enabled for the
+// target repositories and which actions their workflows may use.
+type ActionsPermissions struct {
+	// Enabled turns GitHub Actions on or off (default: true)
+	Enabled *bool `yaml:"enabled"`
+	// AllowedActions is "all", "local_only" or "selected" (default: all)
+	AllowedActions string `yaml:"allowed_actions"`
+	// GitHubOwnedAllowed allows actions created by GitHub (selected only)
+	GitHubOwnedAllowed bool `yaml:"github_owned_allowed"`
+	// VerifiedAllowed allows actions by verified Marketplace creators (selected only)
+	VerifiedAllowed bool `yaml:"verified_allowed"`
+	// PatternsAllowed lists allowed actions and reusable workflows, e.g.
+	// my-org/* or docker/login-action@v3 (selected only)
+	PatternsAllowed []string `yaml:"patterns_allowed"`
+	// Repos restricts the policy to these repositories
+	Repos []string `yaml:"repos"`
+}
+
+// IsEnabled reports whether GitHub Actions is enabled by the policy.
+func (p *ActionsPermissions) IsEnabled() bool {
+	return p.Enabled == nil || *p.Enabled
+}
+
+// AllowedActionsOrDefault returns the allowed actions policy.
+func (p *ActionsPermissions) AllowedActionsOrDefault() string {
+	if p.AllowedActions == "" {
+		return AllowedActionsAll
+	}
+	return p.AllowedActions
+}
+
+// ActionsPermissionsFor returns the Actions policy to apply to a repository,
+// or nil if there is none.
+func (c *Config) ActionsPermissionsFor(repo string) *ActionsPermissions {
+	p := c.ActionsPermissions
+	if p == nil || (len(p.Repos) > 0 && !contains(p.Repos, repo)) {
+		return nil
+	}
+	return p
+}
+
+// validateActionsPermissions checks the actions_permissions section.
+func (c *Config) validateActionsPermissions() error {
+	p := c.ActionsPermissions
+	if p == nil {
+		return nil
+	}
+	key := SectionActionsPermissions
+
+	switch p.AllowedActions {
+	case "", AllowedActionsAll, AllowedActionsLocalOnly, AllowedActionsSelected:
+	default:
+		return keyError(key+".allowed_actions", "%s.allowed_actions must be 'all', 'local_only' or 'selected', got '%s'", key, p.AllowedActions)
+	}
+	if !p.IsEnabled() && p.AllowedActions != "" {
+		return keyError(key+".allowed_actions", "%s.allowed_actions cannot be set when Actions is disabled", key)
+	}
+	if p.AllowedActions != AllowedActionsSelected && (p.GitHubOwnedAllowed || p.VerifiedAllowed || len(p.PatternsAllowed) > 0) {
+		return keyError(key+".allowed_actions", "%s: github_owned_allowed, verified_allowed and patterns_allowed require allowed_actions: selected", key)
+	}
+	for _, pattern := range p.PatternsAllowed {
+		if pattern == "" {
+			return keyError(key+".patterns_allowed", "%s.patterns_allowed entries cannot be empty", key)
+		}
+	}
+	for _, repo := range p.Repos {
+		if repo == "" {
+			return keyError(key+".repos", "%s.repos entries cannot be empty", key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+)
+
+// decryptAge decrypts an age-encrypted value with the identities of
+// identityFile. The ciphertext is either ASCII-armored (-----BEGIN AGE
+// ENCRYPTED FILE-----) or the base64 encoding of the binary format.
+func decryptAge(ciphertext, identityFile string) (string, error) {
+	if identityFile == "" {
+		return "", fmt.Errorf("encrypted values require an age identity; pass --identity with the path to an age key file")
+	}
+
+	identities, err := readAgeIdentities(identityFile)
+	if err != nil {
+		return "", err
+	}
+
+	var src io.Reader
+	trimmed := strings.TrimSpace(ciphertext)
+	if strings.HasPrefix(trimmed, armor.Header) {
+		src = armor.NewReader(strings.NewReader(trimmed))
+	} else {
+		data, err := base64.StdEncoding.DecodeString(trimmed)
+		if err != nil {
+			return "", fmt.Errorf("encrypted value is neither ASCII-armored nor base64-encoded: %w", err)
+		}
+		src = bytes.NewReader(data)
+	}
+
+	r, err := age.Decrypt(src, identities...)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value: %w", err)
+	}
+	plaintext, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// readAgeIdentities parses an age identity file such as one created by age-keygen.
+func readAgeIdentities(path string) ([]age.Identity, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open age identity file: %w", err)
+	}
+	defer f.Close()
+
+	identities, err := age.ParseIdentities(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse age identity file %s: %w", path, err)
+	}
+	return identities, nil
+}
@@ -0,0 +1,43 @@
+package config
+
+import "path/filepath"
+
+// GitHubAppConfig authenticates as an installation of a GitHub App instead of
+// with a token. Installation tokens are minted from the private key and
+// refreshed automatically when they expire.
+type GitHubAppConfig struct {
+	AppID          int64 `yaml:"app_id"`
+	InstallationID int64 `yaml:"installation_id"`
+	// PrivateKeyPath is the PEM private key of the app, relative to the
+	// configuration file
+	PrivateKeyPath string `yaml:"private_key_path"`
+}
+
+// UsesApp reports whether the configuration authenticates as a GitHub App.
+func (c *Config) UsesApp() bool {
+	return c.GitHub.App != nil
+}
+
+// resolveAppKeyPath makes the private key path of the GitHub App relative to
+// the configuration file directory.
+func (c *Config) resolveAppKeyPath(baseDir string) {
+	if c.GitHub.App == nil || c.GitHub.App.PrivateKeyPath == "" || filepath.IsAbs(c.GitHub.App.PrivateKeyPath) {
+		return
+	}
+	c.GitHub.App.PrivateKeyPath = filepath.Join(baseDir, c.GitHub.App.PrivateKeyPath)
+}
+
+// validateApp checks the GitHub App credentials.
+func (c *Config) validateApp() error {
+	app := c.GitHub.App
+	if app.AppID <= 0 {
+		return keyError("github.app.app_id", "github.app.app_id is required")
+	}
+	if app.InstallationID <= 0 {
+		return keyError("github.app.installation_id", "github.app.installation_id is required")
+	}
+	if app.PrivateKeyPath == "" {
+		return keyError("github.app.private_key_path", "github.app.private_key_path is required")
+	}
+	return nil
+}
@@ -1,14 +1,68 @@
 package config
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
 
 // Config represents the application configuration.
 type Config struct {
-	GitHub               GitHubConfig                      `yaml:"github"`
-	RepositorySecrets    map[string]string                 `yaml:"repository_secrets"`
-	EnvironmentSecrets   map[string]map[string]string      `yaml:"environment_secrets"`
-	RepositoryVariables  map[string]string                 `yaml:"repository_variables"`
-	EnvironmentVariables map[string]map[string]string      `yaml:"environment_variables"`
+	GitHub               GitHubConfig                 `yaml:"github"`
+	RepositorySecrets    map[string]string            `yaml:"repository_secrets"`
+	EnvironmentSecrets   map[string]map[string]string `yaml:"environment_secrets"`
+	RepositoryVariables  map[string]string            `yaml:"repository_variables"`
+	EnvironmentVariables map[string]map[string]string `yaml:"environment_variables"`
+	// CodespacesSecrets are repository secrets available to Codespaces
+	CodespacesSecrets map[string]string `yaml:"codespaces_secrets"`
+	// OrganizationSecrets are set once on the owner organization
+	OrganizationSecrets map[string]string `yaml:"organization_secrets"`
+	// OrganizationVariables are set once on the owner organization
+	OrganizationVariables map[string]string `yaml:"organization_variables"`
+	// UserCodespacesSecrets are set once on the authenticated user
+	UserCodespacesSecrets map[string]string `yaml:"user_codespaces_secrets"`
+	// Environments declares the protection rules of repository environments
+	Environments map[string]EnvironmentSettings `yaml:"environments"`
+	// ActionsPermissions declares the GitHub Actions policy of repositories
+	ActionsPermissions *ActionsPermissions `yaml:"actions_permissions"`
+	// WorkflowPermissions declares the default GITHUB_TOKEN permissions of repositories
+	WorkflowPermissions *WorkflowPermissions `yaml:"workflow_permissions"`
+	// Hooks are commands run before and after the configuration is applied
+	Hooks Hooks `yaml:"hooks"`
+	// Notifications declares where the results of runs are posted
+	Notifications Notifications `yaml:"notifications"`
+	// Settings tunes how the configuration is applied
+	Settings Settings `yaml:"settings"`
+	// Doppler imports the secrets of Doppler configs into the global sections
+	Doppler []DopplerSource `yaml:"doppler"`
+	// Targets adds the repositories of other owners, each with its own token
+	Targets []Target `yaml:"targets"`
+	// Defaults holds entries inherited by every environment
+	Defaults Defaults `yaml:"defaults"`
+	// Groups names sets of repositories, e.g. backend: [api, worker]
+	Groups map[string][]string `yaml:"groups"`
+	// GroupOverrides adds or replaces entries for the repositories of a group
+	GroupOverrides map[string]Resources `yaml:"group_overrides"`
+	// RepoOverrides adds or replaces entries for individual repositories
+	RepoOverrides map[string]Resources `yaml:"repo_overrides"`
+	// Profiles are named variants of the configuration selected with --profile
+	Profiles map[string]*Config `yaml:"profiles"`
+
+	// Profile is the name of the applied profile, if any
+	Profile string `yaml:"-"`
+
+	// Specs holds structured entries (e.g. from_file), keyed like Resolve
+	Specs map[string]*ValueSpec `yaml:"-"`
+
+	origins   map[string]Origin
+	positions map[string]Position
+	baseDir   string          // directory of the configuration file
+	imported  map[string]bool // keys of entries imported from secret stores
+	// replacedToken is the origin of the token replaced by the keychain token
+	replacedToken *Origin
+	// valuesSkipped reports that the values are placeholders (SkipValues)
+	valuesSkipped bool
 }
 
 // GitHubConfig contains GitHub-specific configuration.
@@ -16,103 +70,295 @@ type GitHubConfig struct {
 	Token string   `yaml:"token"`
 	Owner string   `yaml:"owner"`
 	Repos []string `yaml:"repos"`
+	// AllRepos targets every non-archived repository of the owner
+	AllRepos bool `yaml:"all_repos"`
+	// ReposByTopic adds the owner's repositories tagged with any of these topics
+	ReposByTopic []string `yaml:"repos_by_topic"`
+	// ReposByTeam adds the repositories these organization teams (slugs) can access
+	ReposByTeam StringList `yaml:"repos_by_team"`
+	// ExcludeRepos is removed from the target repositories
+	ExcludeRepos []string `yaml:"exclude_repos"`
+	// App authenticates as a GitHub App installation; it takes precedence over Token
+	App *GitHubAppConfig `yaml:"app"`
+	// APIURL is the REST API URL of a GitHub Enterprise Server (default: api.github.com)
+	APIURL string `yaml:"api_url"`
+	// UploadURL is the upload URL of a GitHub Enterprise Server (default: APIURL)
+	UploadURL string `yaml:"upload_url"`
+	// APIVersion overrides the X-GitHub-Api-Version header sent with every request
+	APIVersion string `yaml:"api_version"`
+}
+
+// StringList is a list of strings that also accepts a single scalar in YAML.
+type StringList []string
+
+// UnmarshalYAML decodes either a scalar or a sequence of scalars.
+func (l *StringList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*l = StringList{node.Value}
+		return nil
+	}
+	var list []string
+	if err := node.Decode(&list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// HasDynamicRepos reports whether repositories are selected through the API.
+func (g *GitHubConfig) HasDynamicRepos() bool {
+	return g.AllRepos || len(g.ReposByTopic) > 0 || len(g.ReposByTeam) > 0
+}
+
+// hasRepositoryResources reports whether any repository-level entry, including
+// entries to delete, is configured.
+func (c *Config) hasRepositoryResources() bool {
+	// Doppler secrets are only imported once the values are resolved
+	if c.valuesSkipped && len(c.Doppler) > 0 {
+		return true
+	}
+	if !c.Global().IsEmpty() || len(c.Environments) > 0 || c.ActionsPermissions != nil || c.WorkflowPermissions != nil {
+		return true
+	}
+	for _, override := range c.RepoOverrides {
+		if !override.IsEmpty() {
+			return true
+		}
+	}
+	for _, override := range c.GroupOverrides {
+		if !override.IsEmpty() {
+			return true
+		}
+	}
+	for _, spec := range c.Specs {
+		if spec.State == StateAbsent && !isAccountSection(spec.Section) {
+			return true
+		}
+	}
+	return false
 }
 
-// Validate validates the configuration.
+// Validate validates the configuration. Errors about a specific key are
+// prefixed with its position in the configuration file when known.
 func (c *Config) Validate() error {
+	if err := c.validateFields(); err != nil {
+		return c.withPosition(err)
+	}
+	return nil
+}
+
+func (c *Config) validateFields() error {
 	if c.GitHub.Owner == "" {
 		return fmt.Errorf("github.owner is required")
 	}
 
-	if len(c.GitHub.Repos) == 0 {
-		return fmt.Errorf("at least one repository must be specified in github.repos")
+	// Repositories are optional when only organization-level entries are configured
+	orgOnly := c.hasOrganizationResources() && !c.hasRepositoryResources()
+	if len(c.GitHub.Repos) == 0 && len(c.TargetRepos()) == 0 && !c.GitHub.HasDynamicRepos() && !orgOnly {
+		return fmt.Errorf("at least one repository must be specified in github.repos (or set github.all_repos, github.repos_by_topic, github.repos_by_team or targets)")
 	}
 
-	if c.GitHub.Token == "" {
-		return fmt.Errorf("github.token is required (can be set via GH_TOKEN_WITH_ACTIONS_WRITE environment variable)")
+	if c.GitHub.AllRepos && (len(c.GitHub.Repos) > 0 || len(c.GitHub.ReposByTopic) > 0 || len(c.GitHub.ReposByTeam) > 0) {
+		return keyError("github.all_repos", "github.all_repos cannot be used together with github.repos, github.repos_by_topic or github.repos_by_team")
 	}
 
-	// Check if at least one section is specified
-	hasRepositorySecrets := len(c.RepositorySecrets) > 0
-	hasEnvironmentSecrets := len(c.EnvironmentSecrets) > 0
-	hasRepositoryVariables := len(c.RepositoryVariables) > 0
-	hasEnvironmentVariables := len(c.EnvironmentVariables) > 0
+	for _, topic := range c.GitHub.ReposByTopic {
+		if topic == "" {
+			return keyError("github.repos_by_topic", "github.repos_by_topic entries cannot be empty")
+		}
+	}
 
-	if !hasRepositorySecrets && !hasEnvironmentSecrets && !hasRepositoryVariables && !hasEnvironmentVariables {
-		return fmt.Errorf("at least one of repository_secrets, environment_secrets, repository_variables, or environment_variables must be specified")
+	for _, team := range c.GitHub.ReposByTeam {
+		if team == "" {
+			return keyError("github.repos_by_team", "github.repos_by_team entries cannot be empty")
+		}
+	}
+
+	if err := c.validateEnterprise(); err != nil {
+		return err
+	}
+
+	if err := c.validateTargets(); err != nil {
+		return err
+	}
+
+	if c.UsesApp() {
+		if err := c.validateApp(); err != nil {
+			return err
+		}
+	} else if c.GitHub.Token == "" {
+		return fmt.Errorf("github.token is required (can be set via GH_TOKEN_WITH_ACTIONS_WRITE environment variable, gajin login or the gh CLI, or use github.app)")
+	}
+
+	// Check if at least one section is specified
+	if !c.hasRepositoryResources() && !c.hasOrganizationResources() {
+		return fmt.Errorf("at least one of repository_secrets, environment_secrets, repository_variables, or environment_variables must be specified (or codespaces_secrets, environments, actions_permissions, workflow_permissions, organization_secrets, organization_variables or user_codespaces_secrets)")
 	}
 
 	for _, repo := range c.GitHub.Repos {
 		if repo == "" {
-			return fmt.Errorf("repository name cannot be empty")
+			return keyError("github.repos", "repository name cannot be empty")
+		}
+		if owner, name := c.SplitRepo(repo); owner == "" || name == "" || strings.Contains(name, "/") {
+			return keyError("github.repos", "repository '%s' must be a name or owner/name", repo)
 		}
 	}
 
-	// Validate repository secrets
-	for key, value := range c.RepositorySecrets {
-		if key == "" {
-			return fmt.Errorf("repository secret key cannot be empty")
+	for _, repo := range c.GitHub.ExcludeRepos {
+		if repo == "" {
+			return keyError("github.exclude_repos", "github.exclude_repos entries cannot be empty")
 		}
-		if value == "" {
-			return fmt.Errorf("repository secret value for '%s' cannot be empty", key)
+	}
+
+	if err := c.Global().validate(""); err != nil {
+		return err
+	}
+
+	if err := c.Settings.validate(); err != nil {
+		return err
+	}
+
+	if err := c.Defaults.validate(); err != nil {
+		return err
+	}
+
+	if err := c.validateOrganization(); err != nil {
+		return err
+	}
+	if err := c.validateUserCodespaces(); err != nil {
+		return err
+	}
+	if err := c.validateEnvironments(); err != nil {
+		return err
+	}
+
+	if err := c.validateActionsPermissions(); err != nil {
+		return err
+	}
+
+	if err := c.validateWorkflowPermissions(); err != nil {
+		return err
+	}
+
+	if err := c.Hooks.validate(); err != nil {
+		return err
+	}
+
+	if err := c.Notifications.validate(); err != nil {
+		return err
+	}
+
+	if err := c.validateTemplates(); err != nil {
+		return err
+	}
+
+	for _, spec := range c.Specs {
+		if err := spec.validateScope(); err != nil {
+			return err
 		}
 	}
 
-	// Validate environment secrets
-	for envName, secrets := range c.EnvironmentSecrets {
-		if envName == "" {
-			return fmt.Errorf("environment name cannot be empty")
+	// Validate groups and their resources
+	for group, members := range c.Groups {
+		if group == "" {
+			return keyError("groups.", "group name cannot be empty")
 		}
-		for key, value := range secrets {
-			if key == "" {
-				return fmt.Errorf("environment secret key cannot be empty for environment '%s'", envName)
-			}
-			if value == "" {
-				return fmt.Errorf("environment secret value for '%s' in environment '%s' cannot be empty", key, envName)
+		for _, member := range members {
+			if member == "" {
+				return keyError("groups."+group, "repository name in group '%s' cannot be empty", group)
 			}
 		}
 	}
-
-	// Validate repository variables
-	for key, value := range c.RepositoryVariables {
-		if key == "" {
-			return fmt.Errorf("repository variable key cannot be empty")
+	for group, override := range c.GroupOverrides {
+		if _, ok := c.Groups[group]; !ok {
+			return keyError(SectionGroupOverrides+"."+group, "group_overrides.%s: group '%s' is not defined in groups", group, group)
 		}
-		if value == "" {
-			return fmt.Errorf("repository variable value for '%s' cannot be empty", key)
+		if err := override.validate(scopePrefix(SectionGroupOverrides + "." + group)); err != nil {
+			return fmt.Errorf("group_overrides.%s: %w", group, err)
 		}
 	}
 
-	// Validate environment variables
-	for envName, variables := range c.EnvironmentVariables {
-		if envName == "" {
-			return fmt.Errorf("environment name cannot be empty")
+	// Validate per-repository overrides
+	for repo, override := range c.RepoOverrides {
+		if repo == "" {
+			return keyError(SectionRepoOverrides+".", "repo_overrides repository name cannot be empty")
 		}
-		for key, value := range variables {
-			if key == "" {
-				return fmt.Errorf("environment variable key cannot be empty for environment '%s'", envName)
-			}
-			if value == "" {
-				return fmt.Errorf("environment variable value for '%s' in environment '%s' cannot be empty", key, envName)
-			}
+		if err := override.validate(scopePrefix(SectionRepoOverrides + "." + repo)); err != nil {
+			return fmt.Errorf("repo_overrides.%s: %w", repo, err)
 		}
 	}
 
-	return nil
+	return c.validateLimits()
 }
 
 // ApplyOverrides applies CLI flag overrides to the configuration.
 func (c *Config) ApplyOverrides(token, owner string, repos []string) {
 	if token != "" {
 		c.GitHub.Token = token
+		c.SetOrigin("github.token", SourceFlag, "--token")
 	}
 
 	if owner != "" {
 		c.GitHub.Owner = owner
+		c.SetOrigin("github.owner", SourceFlag, "--owner")
 	}
 
 	if len(repos) > 0 {
+		// An explicit repository list replaces any dynamic selection and the
+		// repositories of targets, whose tokens are still used
 		c.GitHub.Repos = repos
+		c.GitHub.AllRepos = false
+		c.GitHub.ReposByTopic = nil
+		c.GitHub.ReposByTeam = nil
+		for i := range c.Targets {
+			c.Targets[i].Repos = nil
+		}
+		c.SetOrigin("github.repos", SourceFlag, "--repo")
 	}
 }
 
+// ApplyExcludeOverride adds the repositories of the --exclude-repo CLI flag
+// to github.exclude_repos.
+func (c *Config) ApplyExcludeOverride(repos []string) {
+	if len(repos) > 0 {
+		c.GitHub.ExcludeRepos = append(c.GitHub.ExcludeRepos, repos...)
+		c.SetOrigin("github.exclude_repos", SourceFlag, "--exclude-repo")
+	}
+}
+
+// SplitRepo returns the owner and name of a target repository, which is
+// either a name of github.owner or owner/name, e.g. for a repository of
+// another organization.
+func (c *Config) SplitRepo(repo string) (owner, name string) {
+	if owner, name, ok := strings.Cut(repo, "/"); ok {
+		return owner, name
+	}
+	return c.GitHub.Owner, repo
+}
+
+// NormalizeRepo returns the name of a repository of github.owner given as
+// owner/name, which is the same repository. Other repositories are returned
+// unchanged.
+func (c *Config) NormalizeRepo(repo string) string {
+	if owner, name := c.SplitRepo(repo); strings.EqualFold(owner, c.GitHub.Owner) {
+		return name
+	}
+	return repo
+}
+
+// FilterExcluded returns repos without the entries of github.exclude_repos.
+// Repositories are matched by name or owner/name (see NormalizeRepo).
+func (c *Config) FilterExcluded(repos []string) []string {
+	excluded := make(map[string]bool, len(c.GitHub.ExcludeRepos))
+	for _, repo := range c.GitHub.ExcludeRepos {
+		excluded[c.NormalizeRepo(repo)] = true
+	}
+
+	result := make([]string, 0, len(repos))
+	for _, repo := range repos {
+		if !excluded[c.NormalizeRepo(repo)] {
+			result = append(result, repo)
+		}
+	}
+	return result
+}
@@ -1,9 +1,24 @@
 package config
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 
+	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	smtypes "github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	"github.com/googleapis/gax-go/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -324,3 +339,2072 @@ repository_secrets:
 	assert.Equal(t, "env-token", cfg.GitHub.Token)
 }
 
+func TestReadConfig_Provenance(t *testing.T) {
+	os.Setenv(EnvTokenKey, "env-token")
+	defer os.Unsetenv(EnvTokenKey)
+
+	tmpFile, err := os.CreateTemp("", "test-config-*.yaml")
+	require.NoError(t, err)
+	defer os.Remove(tmpFile.Name())
+
+	configContent := `
+github:
+  token: file-token
+  owner: test-org
+  repos:
+    - repo1
+
+repository_secrets:
+  SECRET1: "value1"
+`
+
+	_, err = tmpFile.WriteString(configContent)
+	require.NoError(t, err)
+	tmpFile.Close()
+
+	cfg, err := ReadConfig(tmpFile.Name())
+	require.NoError(t, err)
+
+	// Environment variables take precedence over the config file
+	assert.Equal(t, "env-token", cfg.GitHub.Token)
+	assert.Equal(t, Origin{Source: SourceEnv, Detail: EnvTokenKey}, cfg.Origin("github.token"))
+	assert.Equal(t, Origin{Source: SourceFile, Detail: tmpFile.Name()}, cfg.Origin("github.owner"))
+	assert.Equal(t, Origin{Source: SourceFile, Detail: tmpFile.Name()}, cfg.Origin("repository_secrets.SECRET1"))
+
+	// CLI flags take precedence over everything else
+	cfg.ApplyOverrides("flag-token", "", []string{"repo2"})
+	assert.Equal(t, "flag-token", cfg.GitHub.Token)
+	assert.Equal(t, Origin{Source: SourceFlag, Detail: "--token"}, cfg.Origin("github.token"))
+	assert.Equal(t, Origin{Source: SourceFlag, Detail: "--repo"}, cfg.Origin("github.repos"))
+	assert.Equal(t, SourceFile, cfg.Origin("github.owner").Source)
+}
+
+func TestConfig_Resolve(t *testing.T) {
+	cfg := &Config{
+		GitHub: GitHubConfig{
+			Owner: "test-org",
+			Repos: []string{"repo1", "repo2"},
+		},
+		RepositorySecrets:   map[string]string{"SECRET1": "value1"},
+		RepositoryVariables: map[string]string{"VAR1": "value1"},
+		EnvironmentSecrets: map[string]map[string]string{
+			"production": {"SECRET2": "value2"},
+		},
+	}
+
+	keys := cfg.Resolve()
+	names := make([]string, 0, len(keys))
+	for _, key := range keys {
+		names = append(names, key.Key)
+		assert.Equal(t, SourceDefault, key.Origin.Source)
+	}
+
+	assert.Equal(t, []string{
+		"environment_secrets.production.SECRET2",
+		"github.owner",
+		"github.repos",
+		"github.token",
+		"repository_secrets.SECRET1",
+		"repository_variables.VAR1",
+	}, names)
+	assert.True(t, keys[0].Secret)
+	assert.Equal(t, "repo1,repo2", keys[2].Value)
+}
+
+func TestLoadConfig_ValueFromFile(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(dir+"/certs", 0o755))
+	require.NoError(t, os.WriteFile(dir+"/certs/key.pem", []byte("-----BEGIN KEY-----\n"), 0o600))
+	require.NoError(t, os.WriteFile(dir+"/region", []byte("us-east-1"), 0o600))
+
+	configContent := `
+github:
+  token: test-token
+  owner: test-org
+  repos:
+    - repo1
+
+repository_secrets:
+  SECRET1: { from_file: ./certs/key.pem }
+  SECRET2: "value2"
+
+environment_variables:
+  production:
+    REGION:
+      from_file: region
+`
+	configPath := dir + "/config.yaml"
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+
+	cfg, err := LoadConfig(configPath)
+	require.NoError(t, err)
+
+	assert.Equal(t, "-----BEGIN KEY-----\n", cfg.RepositorySecrets["SECRET1"])
+	assert.Equal(t, "value2", cfg.RepositorySecrets["SECRET2"])
+	assert.Equal(t, "us-east-1", cfg.EnvironmentVariables["production"]["REGION"])
+	assert.Equal(t, Origin{Source: SourceFile, Detail: "./certs/key.pem"}, cfg.Origin("repository_secrets.SECRET1"))
+}
+
+func TestLoadConfig_ValueFromFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(dir+"/large", make([]byte, MaxValueSize+1), 0o600))
+
+	tests := []struct {
+		name   string
+		entry  string
+		errMsg string
+	}{
+		{
+			name:   "missing file",
+			entry:  "{ from_file: ./missing }",
+			errMsg: "failed to read value file",
+		},
+		{
+			name:   "file too large",
+			entry:  "{ from_file: ./large }",
+			errMsg: "exceeding the GitHub limit",
+		},
+		{
+			name:   "value and from_file",
+			entry:  "{ value: x, from_file: ./large }",
+			errMsg: "only one value source can be set, got value and from_file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configContent := "github:\n  token: t\n  owner: o\n  repos: [r]\nrepository_secrets:\n  SECRET1: " + tt.entry + "\n"
+			configPath := dir + "/config.yaml"
+			require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+
+			_, err := LoadConfig(configPath)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "repository_secrets.SECRET1")
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
+
+func TestLoadConfig_ValueFromEnv(t *testing.T) {
+	os.Setenv("GAJIN_TEST_DEPLOY_TOKEN", "from-env-value")
+	defer os.Unsetenv("GAJIN_TEST_DEPLOY_TOKEN")
+
+	dir := t.TempDir()
+	configPath := dir + "/config.yaml"
+	configContent := "github:\n  token: t\n  owner: o\n  repos: [r]\nrepository_secrets:\n  SECRET1: { from_env: GAJIN_TEST_DEPLOY_TOKEN }\n"
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+
+	cfg, err := LoadConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, "from-env-value", cfg.RepositorySecrets["SECRET1"])
+	assert.Equal(t, Origin{Source: SourceEnv, Detail: "GAJIN_TEST_DEPLOY_TOKEN"}, cfg.Origin("repository_secrets.SECRET1"))
+
+	// Unset variables fail loudly instead of producing an empty value
+	configContent = "github:\n  token: t\n  owner: o\n  repos: [r]\nrepository_secrets:\n  SECRET1: { from_env: GAJIN_TEST_UNSET_VARIABLE }\n"
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+
+	_, err = LoadConfig(configPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "environment variable GAJIN_TEST_UNSET_VARIABLE is not set")
+}
+
+func TestLoadConfig_ValueFromCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX shell commands")
+	}
+
+	dir := t.TempDir()
+	configPath := dir + "/config.yaml"
+	configContent := "github:\n  token: t\n  owner: o\n  repos: [r]\nrepository_secrets:\n  SECRET1: { from_command: \"echo from-command\" }\n"
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+
+	// Commands are disabled unless explicitly allowed
+	_, err := LoadConfig(configPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--allow-commands")
+
+	cfg, err := LoadConfigWithOptions(configPath, LoadOptions{AllowCommands: true})
+	require.NoError(t, err)
+	assert.Equal(t, "from-command", cfg.RepositorySecrets["SECRET1"])
+	assert.Equal(t, Origin{Source: SourceCommand, Detail: "echo"}, cfg.Origin("repository_secrets.SECRET1"))
+
+	// Only the executable is recorded, not its arguments or variables
+	configContent = "github:\n  token: t\n  owner: o\n  repos: [r]\nrepository_secrets:\n  SECRET1: { from_command: \"TOKEN=hunter2 /bin/echo ok\" }\n"
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+
+	cfg, err = LoadConfigWithOptions(configPath, LoadOptions{AllowCommands: true})
+	require.NoError(t, err)
+	assert.Equal(t, Origin{Source: SourceCommand, Detail: "echo"}, cfg.Origin("repository_secrets.SECRET1"))
+
+	// Only the first line of the error output is quoted, truncated
+	configContent = "github:\n  token: t\n  owner: o\n  repos: [r]\nrepository_secrets:\n  SECRET1: { from_command: \"echo oops >&2; echo hunter2 >&2; exit 3\" }\n"
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+
+	_, err = LoadConfigWithOptions(configPath, LoadOptions{AllowCommands: true})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "oops")
+	assert.NotContains(t, err.Error(), "hunter2")
+	assert.Equal(t, strings.Repeat("x", maxErrorOutput)+"...", errorOutput(strings.Repeat("x", 1000)))
+
+	configContent = "github:\n  token: t\n  owner: o\n  repos: [r]\nrepository_secrets:\n  SECRET1: { from_command: \"sleep 5\" }\n"
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+
+	_, err = LoadConfigWithOptions(configPath, LoadOptions{AllowCommands: true, CommandTimeout: 50 * time.Millisecond})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "timed out")
+}
+
+func TestLoadConfig_EncryptedValue(t *testing.T) {
+	dir := t.TempDir()
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	identityPath := dir + "/key.txt"
+	require.NoError(t, os.WriteFile(identityPath, []byte(identity.String()+"\n"), 0o600))
+
+	var armored bytes.Buffer
+	armorWriter := armor.NewWriter(&armored)
+	w, err := age.Encrypt(armorWriter, identity.Recipient())
+	require.NoError(t, err)
+	_, err = w.Write([]byte("{{ not a template }}"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	require.NoError(t, armorWriter.Close())
+
+	configPath := dir + "/config.yaml"
+	configContent := "github:\n  token: t\n  owner: o\n  repos: [r]\nrepository_secrets:\n  SECRET1:\n    encrypted: |\n" +
+		indent(armored.String(), "      ")
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+
+	// An identity is required to decrypt values
+	_, err = LoadConfig(configPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--identity")
+
+	cfg, err := LoadConfigWithOptions(configPath, LoadOptions{Identity: identityPath})
+	require.NoError(t, err)
+	assert.Equal(t, "{{ not a template }}", cfg.RepositorySecrets["SECRET1"])
+
+	// Decrypted values are never rendered as templates
+	rendered, err := cfg.RenderedResourcesFor("r")
+	require.NoError(t, err)
+	assert.Equal(t, "{{ not a template }}", rendered.RepositorySecrets["SECRET1"])
+
+	other, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(identityPath, []byte(other.String()+"\n"), 0o600))
+	_, err = LoadConfigWithOptions(configPath, LoadOptions{Identity: identityPath})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to decrypt value")
+}
+
+// indent prefixes every line of s with prefix.
+func indent(s, prefix string) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = prefix + line
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func TestLoadConfig_Sops(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell script as sops")
+	}
+
+	dir := t.TempDir()
+	configPath := dir + "/config.yaml"
+	configContent := `github:
+  token: ENC[AES256_GCM,data:abc,type:str]
+  owner: o
+  repos: [r]
+repository_secrets:
+  SECRET1: ENC[AES256_GCM,data:def,type:str]
+sops:
+  age:
+    - recipient: age1example
+  version: 3.8.1
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+
+	// Without sops in PATH the file cannot be read
+	t.Setenv("PATH", dir)
+	_, err := LoadConfig(configPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "sops executable was not found")
+
+	// A fake sops prints the decrypted document after checking its arguments
+	bin := t.TempDir()
+	script := "#!/bin/sh\n" +
+		"[ \"$1 $2 $3 $4 $5 $6\" = \"--decrypt --input-type yaml --output-type yaml " + configPath + "\" ] || exit 2\n" +
+		"printf 'github:\\n  token: t\\n  owner: o\\n  repos: [r]\\nrepository_secrets:\\n  SECRET1: decrypted\\n'\n"
+	require.NoError(t, os.WriteFile(bin+"/sops", []byte(script), 0o755))
+	t.Setenv("PATH", bin+":/usr/bin:/bin")
+
+	cfg, err := LoadConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, "t", cfg.GitHub.Token)
+	assert.Equal(t, "decrypted", cfg.RepositorySecrets["SECRET1"])
+
+	// Only the first line of the error output is quoted
+	script = "#!/bin/sh\necho 'Failed to get the data key' >&2\necho 'SECRET1: decrypted' >&2\nexit 128\n"
+	require.NoError(t, os.WriteFile(bin+"/sops", []byte(script), 0o755))
+	_, err = LoadConfig(configPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Failed to get the data key")
+	assert.NotContains(t, err.Error(), "decrypted")
+}
+
+// fakeTool installs an executable shell script named name in a directory
+// that replaces PATH for the rest of the test.
+func fakeTool(t *testing.T, name, script string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell script as " + name)
+	}
+	bin := t.TempDir()
+	require.NoError(t, os.WriteFile(bin+"/"+name, []byte("#!/bin/sh\n"+script), 0o755))
+	t.Setenv("PATH", bin+":/usr/bin:/bin")
+}
+
+// fakeAWSSecretsManager serves secrets by name; nil entries are binary.
+type fakeAWSSecretsManager map[string]*string
+
+func (f fakeAWSSecretsManager) GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
+	secret, ok := f[*params.SecretId]
+	if !ok {
+		return nil, &smtypes.ResourceNotFoundException{Message: params.SecretId}
+	}
+	if secret == nil {
+		return &secretsmanager.GetSecretValueOutput{Name: params.SecretId, SecretBinary: []byte{0, 1}}, nil
+	}
+	return &secretsmanager.GetSecretValueOutput{Name: params.SecretId, SecretString: secret}, nil
+}
+
+func TestLoadConfig_AWSSecretsManager(t *testing.T) {
+	original := newAWSSecretsManager
+	t.Cleanup(func() { newAWSSecretsManager = original })
+	newAWSSecretsManager = func(ctx context.Context) (awsSecretsManager, error) {
+		document, token := `{"password": "pw", "port": 5432}`, "tok"
+		return fakeAWSSecretsManager{"prod/db": &document, "prod/token": &token, "prod/cert": nil}, nil
+	}
+
+	dir := t.TempDir()
+	configPath := dir + "/config.yaml"
+	configContent := `github:
+  token: t
+  owner: o
+  repos: [r]
+repository_secrets:
+  DB_PASSWORD: { aws_secretsmanager: "prod/db#password" }
+  DB_PORT: { aws_secretsmanager: "prod/db#port" }
+  TOKEN: { aws_secretsmanager: prod/token }
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+
+	cfg, err := LoadConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"DB_PASSWORD": "pw", "DB_PORT": "5432", "TOKEN": "tok"}, cfg.RepositorySecrets)
+	assert.Equal(t, Origin{Source: SourceStore, Detail: "aws_secretsmanager:prod/token"}, cfg.Origin("repository_secrets.TOKEN"))
+
+	for ref, errMsg := range map[string]string{
+		"prod/db#user":   "secret has no key 'user'",
+		"prod/token#key": "secret is not a JSON object",
+		"prod/cert":      "binary secrets are not supported",
+		"prod/missing":   "ResourceNotFoundException",
+	} {
+		configContent := "github:\n  token: t\n  owner: o\n  repos: [r]\nrepository_secrets:\n  S: { aws_secretsmanager: \"" + ref + "\" }\n"
+		require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+		_, err := LoadConfig(configPath)
+		require.Error(t, err, ref)
+		assert.Contains(t, err.Error(), errMsg)
+	}
+}
+
+// fakeGCPSecretManager serves every version of projects/my-project/secrets/api-key.
+type fakeGCPSecretManager struct{}
+
+func (fakeGCPSecretManager) AccessSecretVersion(ctx context.Context, req *secretmanagerpb.AccessSecretVersionRequest, opts ...gax.CallOption) (*secretmanagerpb.AccessSecretVersionResponse, error) {
+	version, ok := strings.CutPrefix(req.Name, "projects/my-project/secrets/api-key/versions/")
+	if !ok {
+		return nil, fmt.Errorf("rpc error: code = NotFound desc = Secret [%s] not found", req.Name)
+	}
+	return &secretmanagerpb.AccessSecretVersionResponse{Name: req.Name, Payload: &secretmanagerpb.SecretPayload{Data: []byte("key-" + version)}}, nil
+}
+
+func (fakeGCPSecretManager) Close() error {
+	return nil
+}
+
+func TestLoadConfig_GCPSecret(t *testing.T) {
+	original := newGCPSecretManager
+	t.Cleanup(func() { newGCPSecretManager = original })
+	newGCPSecretManager = func(ctx context.Context) (gcpSecretManager, error) {
+		return fakeGCPSecretManager{}, nil
+	}
+
+	dir := t.TempDir()
+	configPath := dir + "/config.yaml"
+	configContent := `github:
+  token: t
+  owner: o
+  repos: [r]
+repository_secrets:
+  LATEST: { gcp_secret: "projects/my-project/secrets/api-key" }
+  PINNED: { gcp_secret: "projects/my-project/secrets/api-key/versions/3" }
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+
+	cfg, err := LoadConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"LATEST": "key-latest", "PINNED": "key-3"}, cfg.RepositorySecrets)
+
+	configContent = "github:\n  token: t\n  owner: o\n  repos: [r]\nrepository_secrets:\n  S: { gcp_secret: \"my-project/api-key\" }\n"
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+	_, err = LoadConfig(configPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid secret reference 'my-project/api-key'")
+
+	configContent = "github:\n  token: t\n  owner: o\n  repos: [r]\nrepository_secrets:\n  S: { gcp_secret: \"projects/my-project/secrets/missing\" }\n"
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+	_, err = LoadConfig(configPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "NotFound")
+}
+
+// fakeAzureKeyVault serves the secrets of a vault by name and version.
+type fakeAzureKeyVault map[string]string
+
+func (f fakeAzureKeyVault) GetSecret(ctx context.Context, name, version string, options *azsecrets.GetSecretOptions) (azsecrets.GetSecretResponse, error) {
+	value, ok := f[name+"/"+version]
+	if !ok {
+		return azsecrets.GetSecretResponse{}, fmt.Errorf("SecretNotFound: A secret with (name/id) %s was not found in this key vault", name)
+	}
+	return azsecrets.GetSecretResponse{Secret: azsecrets.Secret{Value: &value}}, nil
+}
+
+func TestLoadConfig_AzureKeyVault(t *testing.T) {
+	original := newAzureKeyVault
+	t.Cleanup(func() { newAzureKeyVault = original })
+	var vaults []string
+	newAzureKeyVault = func(vaultURL string) (azureKeyVault, error) {
+		vaults = append(vaults, vaultURL)
+		return fakeAzureKeyVault{"api-key/": "from-keyvault", "api-key/abc": "pinned"}, nil
+	}
+
+	dir := t.TempDir()
+	configPath := dir + "/config.yaml"
+	configContent := "github:\n  token: t\n  owner: o\n  repos: [r]\nrepository_secrets:\n  API_KEY: { azure_keyvault: \"https://myvault.vault.azure.net/secrets/api-key\" }\n  PINNED: { azure_keyvault: \"https://myvault.vault.azure.net/secrets/api-key/abc\" }\n"
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+
+	cfg, err := LoadConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"API_KEY": "from-keyvault", "PINNED": "pinned"}, cfg.RepositorySecrets)
+	assert.Equal(t, []string{"https://myvault.vault.azure.net", "https://myvault.vault.azure.net"}, vaults)
+
+	configContent = "github:\n  token: t\n  owner: o\n  repos: [r]\nrepository_secrets:\n  API_KEY: { azure_keyvault: \"https://myvault.vault.azure.net/keys/api-key\" }\n"
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+	_, err = LoadConfig(configPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid secret identifier")
+}
+
+func TestLoadConfig_OnePassword(t *testing.T) {
+	fakeTool(t, "op", `[ "$1 $2" = "read --no-newline" ] || exit 2
+case "$3" in
+  op://ci/npm/token) printf 'npm-token' ;;
+  op://ci/db/prod/password) printf 'db-password' ;;
+  *) echo "[ERROR] could not read secret '$3': item not found" >&2; exit 1 ;;
+esac
+`)
+
+	dir := t.TempDir()
+	configPath := dir + "/config.yaml"
+	configContent := `github:
+  token: t
+  owner: o
+  repos: [r]
+repository_secrets:
+  NPM_TOKEN: { onepassword: "op://ci/npm/token" }
+  DB_PASSWORD: { onepassword: "op://ci/db/prod/password" }
+  LITERAL: op://not/a/reference
+environment_secrets:
+  production:
+    NPM_TOKEN: { onepassword: "op://ci/npm/token" }
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+
+	cfg, err := LoadConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"NPM_TOKEN":   "npm-token",
+		"DB_PASSWORD": "db-password",
+		"LITERAL":     "op://not/a/reference",
+	}, cfg.RepositorySecrets)
+	assert.Equal(t, "npm-token", cfg.EnvironmentSecrets["production"]["NPM_TOKEN"])
+	assert.Equal(t, Origin{Source: SourceStore, Detail: "onepassword:op://ci/npm/token"}, cfg.Origin("repository_secrets.NPM_TOKEN"))
+
+	configContent = "github:\n  token: t\n  owner: o\n  repos: [r]\nrepository_secrets:\n  S: { onepassword: \"op://ci/missing/token\" }\n"
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+	_, err = LoadConfig(configPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "item not found")
+}
+
+func TestLoadConfig_Doppler(t *testing.T) {
+	fakeTool(t, "doppler", `[ "$1 $2 $3 $4 $5" = "secrets download --no-file --format json" ] || exit 2
+case "$7/$9" in
+  backend/prd) echo '{"DOPPLER_PROJECT": "backend", "DB_URL": "postgres://db", "DB_DEBUG": "1", "API_KEY": "{{ raw }}", "APP_PORT": "8080"}' ;;
+  *) echo 'Doppler Error: Could not find requested config' >&2; exit 1 ;;
+esac
+`)
+
+	dir := t.TempDir()
+	configPath := dir + "/config.yaml"
+	configContent := `github:
+  token: t
+  owner: o
+  repos: [r]
+repository_secrets:
+  API_KEY: from-file
+doppler:
+  - project: backend
+    config: prd
+    exclude: ["*_DEBUG", "APP_*"]
+    rename: { DB_URL: DATABASE_URL }
+  - project: backend
+    config: prd
+    section: environment_variables
+    environment: production
+    include: ["APP_*"]
+    strip_prefix: APP_
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+
+	cfg, err := LoadConfig(configPath)
+	require.NoError(t, err)
+	// Entries of the configuration file take precedence over imported ones
+	assert.Equal(t, map[string]string{"API_KEY": "from-file", "DATABASE_URL": "postgres://db"}, cfg.RepositorySecrets)
+	assert.Equal(t, map[string]map[string]string{"production": {"PORT": "8080"}}, cfg.EnvironmentVariables)
+	assert.Equal(t, Origin{Source: SourceStore, Detail: "doppler:backend/prd/DB_URL"}, cfg.Origin("repository_secrets.DATABASE_URL"))
+
+	for content, errMsg := range map[string]string{
+		"doppler:\n  - { project: backend, config: dev }\n":                               "Could not find requested config",
+		"doppler:\n  - { project: backend, config: prd, section: environment_secrets }\n": "doppler[0]: environment is required with section environment_secrets",
+		"doppler:\n  - { project: backend, config: prd, includes: [A] }\n":                "unknown key 'doppler[0].includes' (did you mean 'include'?)",
+	} {
+		configContent := "github:\n  token: t\n  owner: o\n  repos: [r]\n" + content
+		require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+		_, err := LoadConfig(configPath)
+		require.Error(t, err, content)
+		assert.Contains(t, err.Error(), errMsg)
+	}
+}
+
+func TestConfig_ResourcesFor(t *testing.T) {
+	cfg := &Config{
+		RepositorySecrets: map[string]string{"SECRET1": "global1", "SECRET2": "global2"},
+		EnvironmentVariables: map[string]map[string]string{
+			"production": {"REGION": "us-east-1"},
+		},
+		RepoOverrides: map[string]Resources{
+			"api": {
+				RepositorySecrets: map[string]string{"SECRET2": "api2", "SECRET3": "api3"},
+				EnvironmentVariables: map[string]map[string]string{
+					"production": {"REPLICAS": "3"},
+				},
+			},
+		},
+	}
+
+	api := cfg.ResourcesFor("api")
+	assert.Equal(t, map[string]string{"SECRET1": "global1", "SECRET2": "api2", "SECRET3": "api3"}, api.RepositorySecrets)
+	assert.Equal(t, map[string]string{"REGION": "us-east-1", "REPLICAS": "3"}, api.EnvironmentVariables["production"])
+
+	web := cfg.ResourcesFor("web")
+	assert.Equal(t, map[string]string{"SECRET1": "global1", "SECRET2": "global2"}, web.RepositorySecrets)
+	assert.Equal(t, 5, api.Len())
+	assert.Equal(t, 3, web.Len())
+
+	// The merged maps are copies
+	api.RepositorySecrets["SECRET1"] = "changed"
+	assert.Equal(t, "global1", cfg.RepositorySecrets["SECRET1"])
+}
+
+func TestConfig_Validate_RepoOverrides(t *testing.T) {
+	cfg := &Config{
+		GitHub: GitHubConfig{Token: "t", Owner: "o", Repos: []string{"api"}},
+		RepoOverrides: map[string]Resources{
+			"api": {RepositoryVariables: map[string]string{"VAR1": "value1"}},
+		},
+	}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.RepoOverrides["api"] = Resources{RepositoryVariables: map[string]string{"VAR1": ""}}
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "repo_overrides.api: repository variable value for 'VAR1' cannot be empty")
+}
+
+func TestLoadConfig_RepoOverrides(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(dir+"/api.pem", []byte("api-key"), 0o600))
+
+	configContent := `
+github:
+  token: test-token
+  owner: test-org
+  repos: [api, web]
+
+repository_secrets:
+  SECRET1: "global"
+
+repo_overrides:
+  api:
+    repository_secrets:
+      SECRET1: { from_file: ./api.pem }
+`
+	configPath := dir + "/config.yaml"
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+
+	cfg, err := LoadConfig(configPath)
+	require.NoError(t, err)
+
+	assert.Equal(t, "api-key", cfg.ResourcesFor("api").RepositorySecrets["SECRET1"])
+	assert.Equal(t, "global", cfg.ResourcesFor("web").RepositorySecrets["SECRET1"])
+	assert.Equal(t, Origin{Source: SourceFile, Detail: "./api.pem"}, cfg.Origin("repo_overrides.api.repository_secrets.SECRET1"))
+}
+
+func TestLoadConfig_Groups(t *testing.T) {
+	dir := t.TempDir()
+	configContent := `
+github:
+  token: test-token
+  owner: test-org
+  repos: [api, worker, web]
+
+groups:
+  backend: [api, worker]
+  frontend: [web]
+
+group_overrides:
+  backend:
+    repository_secrets:
+      DATABASE_URL: "postgres://db"
+  frontend:
+    repository_variables:
+      CDN_URL: "https://cdn.example.com"
+
+repo_overrides:
+  worker:
+    repository_secrets:
+      DATABASE_URL: "postgres://worker-db"
+`
+	configPath := dir + "/config.yaml"
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+
+	cfg, err := LoadConfig(configPath)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"backend"}, cfg.GroupsOf("api"))
+	assert.Equal(t, map[string]string{"DATABASE_URL": "postgres://db"}, cfg.ResourcesFor("api").RepositorySecrets)
+	assert.Empty(t, cfg.ResourcesFor("api").RepositoryVariables)
+
+	// Repository overrides take precedence over groups
+	assert.Equal(t, "postgres://worker-db", cfg.ResourcesFor("worker").RepositorySecrets["DATABASE_URL"])
+
+	assert.Empty(t, cfg.ResourcesFor("web").RepositorySecrets)
+	assert.Equal(t, "https://cdn.example.com", cfg.ResourcesFor("web").RepositoryVariables["CDN_URL"])
+}
+
+func TestConfig_Validate_Groups(t *testing.T) {
+	cfg := &Config{
+		GitHub: GitHubConfig{Token: "t", Owner: "o", Repos: []string{"api"}},
+		Groups: map[string][]string{"backend": {"api"}},
+		GroupOverrides: map[string]Resources{
+			"backnd": {RepositoryVariables: map[string]string{"VAR1": "value1"}},
+		},
+	}
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "group 'backnd' is not defined in groups")
+}
+
+func TestConfig_AllRepos(t *testing.T) {
+	cfg := &Config{
+		GitHub: GitHubConfig{
+			Token:        "t",
+			Owner:        "o",
+			AllRepos:     true,
+			ExcludeRepos: []string{"legacy"},
+		},
+		RepositorySecrets: map[string]string{"SECRET1": "value1"},
+	}
+	require.NoError(t, cfg.Validate())
+	assert.Equal(t, []string{"api", "web"}, cfg.FilterExcluded([]string{"api", "legacy", "web"}))
+
+	cfg.GitHub.Repos = []string{"api"}
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "github.all_repos cannot be used together with github.repos")
+
+	// --repo replaces the dynamic selection
+	cfg.ApplyOverrides("", "", []string{"web"})
+	assert.False(t, cfg.GitHub.AllRepos)
+	assert.NoError(t, cfg.Validate())
+
+	// --exclude-repo adds to the configured exclusions
+	cfg.ApplyExcludeOverride(nil)
+	assert.Equal(t, []string{"legacy"}, cfg.GitHub.ExcludeRepos)
+	cfg.ApplyExcludeOverride([]string{"web"})
+	assert.Equal(t, []string{"api"}, cfg.FilterExcluded([]string{"api", "legacy", "web"}))
+	assert.Equal(t, []string{"o/api", "other/web"}, cfg.FilterExcluded([]string{"o/api", "o/web", "other/web"}))
+	assert.Equal(t, Origin{Source: SourceFlag, Detail: "--exclude-repo"}, cfg.Origin("github.exclude_repos"))
+}
+
+func TestConfig_Validate_ReposByTopic(t *testing.T) {
+	cfg := &Config{
+		GitHub: GitHubConfig{
+			Token:        "t",
+			Owner:        "o",
+			ReposByTopic: []string{"deploys-with-gajin"},
+		},
+		RepositorySecrets: map[string]string{"SECRET1": "value1"},
+	}
+	require.NoError(t, cfg.Validate())
+
+	cfg.GitHub.AllRepos = true
+	assert.Error(t, cfg.Validate())
+
+	cfg.GitHub.AllRepos = false
+	cfg.GitHub.ReposByTopic = []string{""}
+	assert.Error(t, cfg.Validate())
+}
+
+func TestConfig_Validate_Names(t *testing.T) {
+	base := func() *Config {
+		return &Config{
+			GitHub: GitHubConfig{Token: "t", Owner: "o", Repos: []string{"api"}},
+		}
+	}
+
+	valid := base()
+	valid.RepositorySecrets = map[string]string{"API_KEY": "a", "_private": "b", "key2": "c"}
+	valid.EnvironmentVariables = map[string]map[string]string{"production": {"REGION": "eu"}}
+	require.NoError(t, valid.Validate())
+
+	tests := []struct {
+		name    string
+		modify  func(*Config)
+		wantErr string
+	}{
+		{
+			name:    "leading digit",
+			modify:  func(c *Config) { c.RepositorySecrets = map[string]string{"1PASSWORD": "x"} },
+			wantErr: "repository secret name '1PASSWORD' cannot start with a number",
+		},
+		{
+			name:    "invalid character",
+			modify:  func(c *Config) { c.RepositoryVariables = map[string]string{"LOG-LEVEL": "x"} },
+			wantErr: "repository variable name 'LOG-LEVEL' can only contain letters, numbers and underscores",
+		},
+		{
+			name:    "reserved prefix",
+			modify:  func(c *Config) { c.EnvironmentSecrets = map[string]map[string]string{"prod": {"github_token": "x"}} },
+			wantErr: "environment secret name 'github_token' cannot start with the reserved GITHUB_ prefix",
+		},
+		{
+			name:    "too long",
+			modify:  func(c *Config) { c.CodespacesSecrets = map[string]string{strings.Repeat("A", MaxNameLength+1): "x"} },
+			wantErr: "is too long",
+		},
+		{
+			name:    "case conflict",
+			modify:  func(c *Config) { c.RepositorySecrets = map[string]string{"API_KEY": "a", "api_key": "b"} },
+			wantErr: "repository secret names are case-insensitive: 'API_KEY' and 'api_key' refer to the same repository secret",
+		},
+		{
+			name: "override",
+			modify: func(c *Config) {
+				c.RepositorySecrets = map[string]string{"A": "a"}
+				c.RepoOverrides = map[string]Resources{"api": {RepositoryVariables: map[string]string{"BAD NAME": "x"}}}
+			},
+			wantErr: "repo_overrides.api: repository variable name 'BAD NAME'",
+		},
+		{
+			name:    "organization variable",
+			modify:  func(c *Config) { c.OrganizationVariables = map[string]string{"GITHUB_REGION": "x"} },
+			wantErr: "organization variable name 'GITHUB_REGION' cannot start with the reserved GITHUB_ prefix",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := base()
+			tt.modify(cfg)
+			err := cfg.Validate()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestLoadConfig_ReposByTeam(t *testing.T) {
+	dir := t.TempDir()
+	configPath := dir + "/config.yaml"
+
+	for content, want := range map[string][]string{
+		"repos_by_team: platform-team":             {"platform-team"},
+		"repos_by_team: [platform-team, sre-team]": {"platform-team", "sre-team"},
+	} {
+		configContent := "github:\n  token: t\n  owner: o\n  " + content + "\nrepository_secrets:\n  SECRET1: value1\n"
+		require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+
+		cfg, err := LoadConfig(configPath)
+		require.NoError(t, err)
+		assert.Equal(t, StringList(want), cfg.GitHub.ReposByTeam)
+	}
+}
+
+func TestLoadConfig_Profiles(t *testing.T) {
+	dir := t.TempDir()
+	configPath := dir + "/config.yaml"
+	require.NoError(t, os.WriteFile(dir+"/prod.key", []byte("prod-key"), 0o600))
+
+	configContent := `github:
+  token: base-token
+  owner: base-owner
+  repos_by_topic: [deploy]
+repository_secrets:
+  API_KEY: base-key
+  SHARED: shared
+repository_variables:
+  STAGE: base
+profiles:
+  staging:
+    repository_variables:
+      STAGE: staging
+  production:
+    github:
+      owner: prod-owner
+      repos: [api, web]
+    repository_secrets:
+      API_KEY:
+        from_file: prod.key
+    repository_variables:
+      STAGE: production
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+
+	base, err := LoadConfig(configPath)
+	require.NoError(t, err)
+	assert.Empty(t, base.Profile)
+	assert.Equal(t, "base", base.RepositoryVariables["STAGE"])
+
+	staging, err := LoadConfigWithOptions(configPath, LoadOptions{Profile: "staging"})
+	require.NoError(t, err)
+	assert.Equal(t, "staging", staging.Profile)
+	assert.Equal(t, "base-owner", staging.GitHub.Owner)
+	assert.Equal(t, []string{"deploy"}, staging.GitHub.ReposByTopic)
+	assert.Equal(t, "staging", staging.RepositoryVariables["STAGE"])
+	assert.Equal(t, "base-key", staging.RepositorySecrets["API_KEY"])
+
+	prod, err := LoadConfigWithOptions(configPath, LoadOptions{Profile: "production"})
+	require.NoError(t, err)
+	assert.Equal(t, "prod-owner", prod.GitHub.Owner)
+	assert.Equal(t, "base-token", prod.GitHub.Token)
+	assert.Equal(t, []string{"api", "web"}, prod.GitHub.Repos)
+	assert.Empty(t, prod.GitHub.ReposByTopic)
+	assert.Equal(t, "prod-key", prod.RepositorySecrets["API_KEY"])
+	assert.Equal(t, "shared", prod.RepositorySecrets["SHARED"])
+	assert.Equal(t, Origin{Source: SourceProfile, Detail: "production"}, prod.Origin("github.owner"))
+	assert.Equal(t, Origin{Source: SourceFile, Detail: "prod.key"}, prod.Origin("repository_secrets.API_KEY"))
+	assert.Equal(t, SourceFile, prod.Origin("repository_secrets.SHARED").Source)
+
+	_, err = LoadConfigWithOptions(configPath, LoadOptions{Profile: "qa"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "profile 'qa' is not defined (available: production, staging)")
+}
+
+func TestLoadConfig_JSONAndTOML(t *testing.T) {
+	t.Setenv("GAJIN_TEST_FORMAT_SECRET", "from-env")
+	dir := t.TempDir()
+
+	jsonContent := `{
+	"github": {"token": "t", "owner": "o", "repos": ["repo1"]},
+	"repository_secrets": {"SECRET1": "value1", "SECRET2": {"from_env": "GAJIN_TEST_FORMAT_SECRET"}},
+	"environment_variables": {"production": {"PORT": 8080}}
+}`
+	tomlContent := `[github]
+token = "t"
+owner = "o"
+repos = ["repo1"]
+
+[repository_secrets]
+SECRET1 = "value1"
+SECRET2 = { from_env = "GAJIN_TEST_FORMAT_SECRET" }
+
+[environment_variables.production]
+PORT = 8080
+`
+
+	for name, content := range map[string]string{"config.json": jsonContent, "config.toml": tomlContent} {
+		t.Run(name, func(t *testing.T) {
+			configPath := dir + "/" + name
+			require.NoError(t, os.WriteFile(configPath, []byte(content), 0o600))
+
+			cfg, err := LoadConfig(configPath)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"repo1"}, cfg.GitHub.Repos)
+			assert.Equal(t, "value1", cfg.RepositorySecrets["SECRET1"])
+			assert.Equal(t, "from-env", cfg.RepositorySecrets["SECRET2"])
+			assert.Equal(t, "8080", cfg.EnvironmentVariables["production"]["PORT"])
+		})
+	}
+
+	// An explicit format overrides the file extension
+	configPath := dir + "/config.conf"
+	require.NoError(t, os.WriteFile(configPath, []byte(tomlContent), 0o600))
+	_, err := LoadConfig(configPath)
+	require.Error(t, err)
+
+	cfg, err := LoadConfigWithOptions(configPath, LoadOptions{Format: "toml"})
+	require.NoError(t, err)
+	assert.Equal(t, "o", cfg.GitHub.Owner)
+
+	_, err = LoadConfigWithOptions(configPath, LoadOptions{Format: "ini"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported config format 'ini'")
+}
+
+func TestLoadConfig_UnknownKeys(t *testing.T) {
+	dir := t.TempDir()
+	configPath := dir + "/config.yaml"
+
+	configContent := `github:
+  token: t
+  owner: o
+  repo: [repo1]
+repository_secret:
+  SECRET1: value1
+repo_overrides:
+  api:
+    repository_variables:
+      VAR1: { from_fle: ./x }
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+
+	_, err := LoadConfig(configPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "line 4, column 3: unknown key 'github.repo' (did you mean 'repos'?)")
+	assert.Contains(t, err.Error(), "line 5, column 1: unknown key 'repository_secret' (did you mean 'repository_secrets'?)")
+}
+
+func TestValidate_Position(t *testing.T) {
+	dir := t.TempDir()
+	configPath := dir + "/config.yaml"
+
+	configContent := `github:
+  token: t
+  owner: o
+  repos: [repo1]
+repository_secrets:
+  SECRET1: value1
+repo_overrides:
+  api:
+    environment_variables:
+      production:
+        VAR1: ""
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+
+	_, err := LoadConfig(configPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "line 11, column 9: repo_overrides.api: environment variable value for 'VAR1' in environment 'production' cannot be empty")
+
+	configContent = `github:
+  token: t
+  owner: o
+  repos: [repo1]
+repository_secrets:
+  SECRET1: { value: x, from_fle: ./x }
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+
+	_, err = LoadConfig(configPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown key 'from_fle' (did you mean 'from_file'?)")
+}
+
+func TestSchema(t *testing.T) {
+	schema := Schema()
+	properties, ok := schema["properties"].(map[string]interface{})
+	require.True(t, ok)
+
+	// Every top-level configuration key is described by the schema
+	for _, key := range configKeys {
+		assert.Contains(t, properties, key)
+	}
+	defs := schema["$defs"].(map[string]interface{})
+	githubProperties := defs["github"].(map[string]interface{})["properties"].(map[string]interface{})
+	for _, key := range githubKeys {
+		assert.Contains(t, githubProperties, key)
+	}
+	specProperties := defs["valueSpec"].(map[string]interface{})["properties"].(map[string]interface{})
+	for _, key := range valueSpecKeys {
+		assert.Contains(t, specProperties, key)
+	}
+}
+
+func TestRenderedResourcesFor(t *testing.T) {
+	t.Setenv("GAJIN_TEST_TEMPLATE_REGION", "eu-west-1")
+	t.Setenv("GAJIN_TEST_TEMPLATE_RAW", "{{ .Repo }}")
+	dir := t.TempDir()
+	configPath := dir + "/config.yaml"
+
+	configContent := `github:
+  token: t
+  owner: my-org
+  repos: [api, web]
+repository_secrets:
+  BASIC_AUTH: '{{ printf "%s:%s" .Owner .Repo | b64enc }}'
+  RAW: { from_env: GAJIN_TEST_TEMPLATE_RAW }
+repository_variables:
+  API_URL: "https://{{ .Repo }}.example.com"
+  REGION: '{{ env "GAJIN_TEST_TEMPLATE_REGION" }}'
+environment_variables:
+  production:
+    DEPLOY_TARGET: "{{ .Environment }}/{{ .Name | lower }}"
+    BUCKET: "{{ .Owner }}-{{ .Repo }}-{{ .Environment }}"
+  staging:
+    BUCKET: "{{ .Owner }}-{{ .Repo }}-{{ .Environment }}"
+repo_overrides:
+  web:
+    repository_variables:
+      API_URL: "https://www.example.com/{{ .Repo }}"
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+
+	cfg, err := LoadConfig(configPath)
+	require.NoError(t, err)
+
+	api, err := cfg.RenderedResourcesFor("api")
+	require.NoError(t, err)
+	assert.Equal(t, "https://api.example.com", api.RepositoryVariables["API_URL"])
+	assert.Equal(t, "eu-west-1", api.RepositoryVariables["REGION"])
+	assert.Equal(t, "bXktb3JnOmFwaQ==", api.RepositorySecrets["BASIC_AUTH"])
+	assert.Equal(t, "{{ .Repo }}", api.RepositorySecrets["RAW"], "values loaded from sources are not rendered")
+	assert.Equal(t, "production/deploy_target", api.EnvironmentVariables["production"]["DEPLOY_TARGET"])
+
+	web, err := cfg.RenderedResourcesFor("web")
+	require.NoError(t, err)
+	assert.Equal(t, "https://www.example.com/web", web.RepositoryVariables["API_URL"])
+
+	// The same entry is rendered independently for every repository and environment
+	assert.Equal(t, "my-org-api-production", api.EnvironmentVariables["production"]["BUCKET"])
+	assert.Equal(t, "my-org-api-staging", api.EnvironmentVariables["staging"]["BUCKET"])
+	assert.Equal(t, "my-org-web-production", web.EnvironmentVariables["production"]["BUCKET"])
+	assert.Equal(t, "my-org-web-staging", web.EnvironmentVariables["staging"]["BUCKET"])
+	assert.Equal(t, "bXktb3JnOndlYg==", web.RepositorySecrets["BASIC_AUTH"])
+
+	// Unrendered values are still available through ResourcesFor
+	assert.Equal(t, "https://{{ .Repo }}.example.com", cfg.ResourcesFor("api").RepositoryVariables["API_URL"])
+
+	// Repositories of other owners are rendered with their owner and name
+	shared, err := cfg.RenderedResourcesFor("other-org/shared-lib")
+	require.NoError(t, err)
+	assert.Equal(t, "other-org-shared-lib-staging", shared.EnvironmentVariables["staging"]["BUCKET"])
+	assert.Equal(t, "https://shared-lib.example.com", shared.RepositoryVariables["API_URL"])
+}
+
+func TestConfig_FullRepoNames(t *testing.T) {
+	cfg := &Config{
+		GitHub:            GitHubConfig{Token: "t", Owner: "my-org", Repos: []string{"api", "other-org/shared-lib"}},
+		RepositorySecrets: map[string]string{"SECRET1": "value1"},
+		RepoOverrides: map[string]Resources{
+			"other-org/shared-lib": {RepositoryVariables: map[string]string{"REGION": "us"}},
+		},
+	}
+	require.NoError(t, cfg.Validate())
+
+	owner, name := cfg.SplitRepo("api")
+	assert.Equal(t, []string{"my-org", "api"}, []string{owner, name})
+	owner, name = cfg.SplitRepo("other-org/shared-lib")
+	assert.Equal(t, []string{"other-org", "shared-lib"}, []string{owner, name})
+
+	assert.Equal(t, "api", cfg.NormalizeRepo("My-Org/api"))
+	assert.Equal(t, "other-org/shared-lib", cfg.NormalizeRepo("other-org/shared-lib"))
+	cfg.GitHub.ExcludeRepos = []string{"my-org/api"}
+	assert.Equal(t, []string{"other-org/shared-lib"}, cfg.FilterExcluded([]string{"api", "other-org/shared-lib"}))
+
+	// Overrides are keyed by the repository as listed
+	assert.Equal(t, "us", cfg.ResourcesFor("other-org/shared-lib").RepositoryVariables["REGION"])
+	assert.Empty(t, cfg.ResourcesFor("shared-lib").RepositoryVariables)
+
+	for _, repo := range []string{"/shared-lib", "other-org/", "other-org/shared-lib/main"} {
+		cfg.GitHub.Repos = []string{repo}
+		err := cfg.Validate()
+		require.Error(t, err, repo)
+		assert.Contains(t, err.Error(), "repository '"+repo+"' must be a name or owner/name")
+	}
+}
+
+func TestLoadConfig_Targets(t *testing.T) {
+	t.Setenv("GAJIN_TEST_OTHER_ORG_TOKEN", "other-token")
+
+	dir := t.TempDir()
+	configPath := dir + "/config.yaml"
+	configContent := `github:
+  token: t
+  owner: my-org
+targets:
+  - owner: other-org
+    token_env: GAJIN_TEST_OTHER_ORG_TOKEN
+    repos: [shared-lib, docs]
+  - owner: partner
+    repos: [sdk]
+repository_secrets:
+  SECRET1: value1
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+
+	cfg, err := LoadConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"other-org/shared-lib", "other-org/docs", "partner/sdk"}, cfg.TargetRepos())
+	assert.Equal(t, map[string]string{"other-org": "other-token"}, cfg.OwnerTokens())
+
+	// An explicit repository list replaces the repositories of targets
+	cfg.ApplyOverrides("", "", []string{"other-org/docs"})
+	assert.Empty(t, cfg.TargetRepos())
+	assert.Equal(t, map[string]string{"other-org": "other-token"}, cfg.OwnerTokens())
+
+	tests := []struct {
+		name    string
+		targets []Target
+		wantErr string
+	}{
+		{"missing owner", []Target{{Repos: []string{"r"}}}, "targets[0]: owner is required"},
+		{"github owner", []Target{{Owner: "My-Org", Repos: []string{"r"}}}, "owner 'My-Org' is github.owner"},
+		{"duplicate owner", []Target{{Owner: "a", Repos: []string{"r"}}, {Owner: "A"}}, "targets[1]: owner 'A' is listed more than once"},
+		{"unset token", []Target{{Owner: "a", TokenEnv: "GAJIN_TEST_UNSET_VARIABLE", Repos: []string{"r"}}}, "environment variable GAJIN_TEST_UNSET_VARIABLE of token_env is not set"},
+		{"full name", []Target{{Owner: "a", Repos: []string{"a/r"}}}, "repository 'a/r' must be a name of a repository of a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				GitHub:            GitHubConfig{Token: "t", Owner: "my-org"},
+				RepositorySecrets: map[string]string{"SECRET1": "value1"},
+				Targets:           tt.targets,
+			}
+			err := cfg.Validate()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestValidate_InvalidTemplate(t *testing.T) {
+	cfg := &Config{
+		GitHub:              GitHubConfig{Token: "t", Owner: "o", Repos: []string{"repo1"}},
+		RepositoryVariables: map[string]string{"API_URL": "https://{{ .Repo }.example.com"},
+	}
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid template in repository_variables.API_URL")
+}
+
+func TestValidate_Limits(t *testing.T) {
+	entries := func(n int) map[string]string {
+		values := make(map[string]string, n)
+		for i := 0; i < n; i++ {
+			values[fmt.Sprintf("NAME_%d", i)] = "v"
+		}
+		return values
+	}
+	large := strings.Repeat("x", MaxValueSize+1)
+	github := GitHubConfig{Token: "t", Owner: "o", Repos: []string{"repo1"}}
+
+	tests := []struct {
+		name    string
+		config  *Config
+		wantErr string
+	}{
+		{
+			name:   "values at the limit",
+			config: &Config{GitHub: github, RepositorySecrets: map[string]string{"CERT": strings.Repeat("x", MaxValueSize)}, RepositoryVariables: entries(MaxRepositoryVariables)},
+		},
+		{
+			name:    "secret value too large",
+			config:  &Config{GitHub: github, RepositorySecrets: map[string]string{"CERT": large}},
+			wantErr: "repository secret value for 'CERT' is 49153 bytes, exceeding the GitHub limit of 49152 bytes",
+		},
+		{
+			name: "override variable value too large",
+			config: &Config{GitHub: github, RepositoryVariables: map[string]string{"REGION": "eu"}, RepoOverrides: map[string]Resources{
+				"api": {EnvironmentVariables: map[string]map[string]string{"production": {"CONFIG": large}}},
+			}},
+			wantErr: "environment variable value for 'CONFIG' is 49153 bytes",
+		},
+		{
+			name:    "organization variable value too large",
+			config:  &Config{GitHub: GitHubConfig{Token: "t", Owner: "o"}, OrganizationVariables: map[string]string{"CONFIG": large}},
+			wantErr: "organization variable value for 'CONFIG' is 49153 bytes",
+		},
+		{
+			name:    "too many repository secrets",
+			config:  &Config{GitHub: github, RepositorySecrets: entries(MaxRepositorySecrets + 1)},
+			wantErr: "repository 'repo1': 101 repository secrets are configured, exceeding the GitHub limit of 100 per repository",
+		},
+		{
+			name:    "too many repository variables",
+			config:  &Config{GitHub: github, RepositoryVariables: entries(MaxRepositoryVariables + 1)},
+			wantErr: "501 repository variables are configured",
+		},
+		{
+			name: "too many environment secrets with an override",
+			config: &Config{GitHub: github, EnvironmentSecrets: map[string]map[string]string{"production": entries(MaxEnvironmentSecrets)}, RepoOverrides: map[string]Resources{
+				"api": {EnvironmentSecrets: map[string]map[string]string{"production": {"EXTRA": "v"}}},
+			}},
+			wantErr: "repository 'api': 101 environment secrets are configured for environment 'production', exceeding the GitHub limit of 100 per environment",
+		},
+		{
+			name:    "too many environment variables",
+			config:  &Config{GitHub: github, EnvironmentVariables: map[string]map[string]string{"staging": entries(MaxEnvironmentVariables + 1)}},
+			wantErr: "101 environment variables are configured for environment 'staging'",
+		},
+		{
+			name: "repository variables too large combined",
+			config: &Config{GitHub: github, RepositoryVariables: map[string]string{
+				"A": strings.Repeat("x", MaxValueSize), "B": strings.Repeat("x", MaxValueSize), "C": strings.Repeat("x", MaxValueSize),
+				"D": strings.Repeat("x", MaxValueSize), "E": strings.Repeat("x", MaxValueSize),
+			}, RepoOverrides: map[string]Resources{
+				"api": {RepositoryVariables: map[string]string{"F": strings.Repeat("x", 16*1024+1)}},
+			}},
+			wantErr: "repository 'api': repository variables are 262145 bytes combined, exceeding the GitHub limit of 262144 bytes per repository",
+		},
+		{
+			name:    "too many organization secrets",
+			config:  &Config{GitHub: GitHubConfig{Token: "t", Owner: "o"}, OrganizationSecrets: entries(MaxOrganizationSecrets + 1)},
+			wantErr: "1001 organization secrets are configured, exceeding the GitHub limit of 1000 per organization",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestRenderedResourcesFor_ValueTooLarge(t *testing.T) {
+	cfg := &Config{
+		GitHub:              GitHubConfig{Token: "t", Owner: "o", Repos: []string{"repo1"}},
+		RepositoryVariables: map[string]string{"PADDED": `{{ printf "%50000s" .Repo }}`},
+	}
+	require.NoError(t, cfg.Validate())
+
+	_, err := cfg.RenderedResourcesFor("repo1")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "repository_variables.PADDED rendered for repo1 is 50000 bytes, exceeding the GitHub limit of 49152 bytes")
+}
+
+func TestLoadConfig_Defaults(t *testing.T) {
+	t.Setenv("GAJIN_TEST_DEFAULT_TOKEN", "default-token")
+	dir := t.TempDir()
+	configPath := dir + "/config.yaml"
+
+	configContent := `github:
+  token: t
+  owner: o
+  repos: [api]
+defaults:
+  environment_secrets:
+    DB_USER: app
+    DEPLOY_TOKEN: { from_env: GAJIN_TEST_DEFAULT_TOKEN }
+  environment_variables:
+    STAGE: "{{ .Environment }}"
+environment_secrets:
+  staging:
+    DB_PASSWORD: staging-password
+  production:
+    DB_PASSWORD: production-password
+    DB_USER: prod_app
+environment_variables:
+  preview:
+    PREVIEW: "true"
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+
+	cfg, err := LoadConfig(configPath)
+	require.NoError(t, err)
+
+	res, err := cfg.RenderedResourcesFor("api")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"DB_USER": "app", "DB_PASSWORD": "staging-password", "DEPLOY_TOKEN": "default-token"}, res.EnvironmentSecrets["staging"])
+	assert.Equal(t, "prod_app", res.EnvironmentSecrets["production"]["DB_USER"])
+	assert.Equal(t, "app", res.EnvironmentSecrets["preview"]["DB_USER"])
+	assert.Equal(t, "production", res.EnvironmentVariables["production"]["STAGE"])
+	assert.Equal(t, "preview", res.EnvironmentVariables["preview"]["STAGE"])
+	assert.Equal(t, Origin{Source: SourceEnv, Detail: "GAJIN_TEST_DEFAULT_TOKEN"}, cfg.Origin("defaults.environment_secrets.DEPLOY_TOKEN"))
+
+	assert.Equal(t, "{{ .Environment }}", cfg.ResourcesFor("api").EnvironmentVariables["staging"]["STAGE"])
+}
+
+func TestLoadConfig_EntryScoping(t *testing.T) {
+	dir := t.TempDir()
+	configPath := dir + "/config.yaml"
+
+	configContent := `github:
+  token: t
+  owner: o
+  repos: [api, worker, web]
+defaults:
+  environment_variables:
+    DEBUG: { value: "true", environments: [staging] }
+repository_secrets:
+  COMMON: common
+  QUEUE_URL: { value: "amqp://queue", repos: [api, worker] }
+  DEPLOY_KEY: { value: key, repos: [api], environments: [production] }
+environment_variables:
+  staging:
+    STAGE: staging
+  production:
+    STAGE: production
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+
+	cfg, err := LoadConfig(configPath)
+	require.NoError(t, err)
+
+	api := cfg.ResourcesFor("api")
+	assert.Equal(t, map[string]string{"COMMON": "common", "QUEUE_URL": "amqp://queue"}, api.RepositorySecrets)
+	assert.Equal(t, map[string]map[string]string{"production": {"DEPLOY_KEY": "key"}}, api.EnvironmentSecrets)
+	assert.Equal(t, "true", api.EnvironmentVariables["staging"]["DEBUG"])
+	assert.NotContains(t, api.EnvironmentVariables["production"], "DEBUG")
+
+	web, err := cfg.RenderedResourcesFor("web")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"COMMON": "common"}, web.RepositorySecrets)
+	assert.Empty(t, web.EnvironmentSecrets)
+}
+
+func TestValidate_EntryScoping(t *testing.T) {
+	cfg := &Config{
+		GitHub:             GitHubConfig{Token: "t", Owner: "o", Repos: []string{"repo1"}},
+		EnvironmentSecrets: map[string]map[string]string{"production": {"SECRET1": "x"}},
+		Specs: map[string]*ValueSpec{
+			"environment_secrets.production.SECRET1": {
+				Value: "x", Environments: []string{"staging"},
+				Section: SectionEnvironmentSecrets, Environment: "production", Name: "SECRET1",
+			},
+		},
+	}
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "environments cannot be set on an entry of a specific environment")
+}
+
+func TestLoadConfig_StateAbsent(t *testing.T) {
+	dir := t.TempDir()
+	configPath := dir + "/config.yaml"
+
+	configContent := `github:
+  token: t
+  owner: o
+  repos: [api, web]
+repository_secrets:
+  OLD_TOKEN: { state: absent }
+  LEGACY_KEY: legacy
+environment_variables:
+  production:
+    OLD_VAR: { state: absent, repos: [web] }
+repo_overrides:
+  api:
+    repository_secrets:
+      OLD_TOKEN: still-used
+      LEGACY_KEY: { state: absent }
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+
+	cfg, err := LoadConfig(configPath)
+	require.NoError(t, err)
+
+	api := cfg.ResourcesFor("api")
+	assert.Equal(t, map[string]string{"OLD_TOKEN": "still-used"}, api.RepositorySecrets)
+	assert.Equal(t, Resources{RepositorySecrets: map[string]string{"LEGACY_KEY": ""}}, cfg.AbsentFor("api"))
+
+	web := cfg.ResourcesFor("web")
+	assert.Equal(t, map[string]string{"LEGACY_KEY": "legacy"}, web.RepositorySecrets)
+	assert.Equal(t, Resources{
+		RepositorySecrets:    map[string]string{"OLD_TOKEN": ""},
+		EnvironmentVariables: map[string]map[string]string{"production": {"OLD_VAR": ""}},
+	}, cfg.AbsentFor("web"))
+
+	// A configuration that only deletes entries is valid
+	configContent = "github:\n  token: t\n  owner: o\n  repos: [api]\nrepository_secrets:\n  OLD_TOKEN: { state: absent }\n"
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+	_, err = LoadConfig(configPath)
+	require.NoError(t, err)
+
+	configContent = "github:\n  token: t\n  owner: o\n  repos: [api]\nrepository_secrets:\n  OLD_TOKEN: { state: absent, value: x }\n"
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+	_, err = LoadConfig(configPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "an entry with state absent cannot have a value")
+}
+
+func TestLoadConfig_OrganizationSecrets(t *testing.T) {
+	dir := t.TempDir()
+	configPath := dir + "/config.yaml"
+
+	configContent := `github:
+  token: t
+  owner: my-org
+organization_secrets:
+  SONAR_TOKEN: sonar
+  NPM_TOKEN:
+    value: npm
+    visibility: selected
+    selected_repos: [api, web]
+  OLD_TOKEN: { state: absent }
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+
+	// Repositories are optional with only organization-level entries
+	cfg, err := LoadConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, []OrganizationEntry{
+		{Name: "NPM_TOKEN", Value: "npm", Visibility: VisibilitySelected, SelectedRepos: []string{"api", "web"}},
+		{Name: "SONAR_TOKEN", Value: "sonar", Visibility: DefaultOrganizationVisibility},
+	}, cfg.OrganizationSecretsList())
+	assert.Equal(t, []string{"OLD_TOKEN"}, cfg.AbsentOrganizationSecrets())
+	assert.Empty(t, cfg.AbsentFor("api"))
+
+	for content, errMsg := range map[string]string{
+		"organization_secrets:\n  A: { value: x, visibility: selected }\n":          "visibility selected requires at least one repository in selected_repos",
+		"organization_secrets:\n  A: { value: x, visibility: public }\n":            "invalid visibility 'public'",
+		"organization_secrets:\n  A: { value: x, selected_repos: [api] }\n":         "selected_repos requires visibility: selected",
+		"  repos: [api]\nrepository_secrets:\n  A: { value: x, visibility: all }\n": "visibility and selected_repos can only be used in organization_secrets",
+	} {
+		configContent := "github:\n  token: t\n  owner: my-org\n" + content
+		require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+		_, err := LoadConfig(configPath)
+		require.Error(t, err, content)
+		assert.Contains(t, err.Error(), errMsg)
+	}
+}
+
+func TestLoadConfig_OrganizationVariables(t *testing.T) {
+	dir := t.TempDir()
+	configPath := dir + "/config.yaml"
+
+	configContent := `github:
+  token: t
+  owner: my-org
+organization_variables:
+  REGION: eu-west-1
+  DEPLOY_TARGET:
+    value: k8s
+    visibility: selected
+    selected_repos: [api]
+  OLD_VAR: { state: absent }
+organization_secrets:
+  REGION: secret-region
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+
+	cfg, err := LoadConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, []OrganizationEntry{
+		{Name: "DEPLOY_TARGET", Value: "k8s", Visibility: VisibilitySelected, SelectedRepos: []string{"api"}},
+		{Name: "REGION", Value: "eu-west-1", Visibility: DefaultOrganizationVisibility},
+	}, cfg.OrganizationVariablesList())
+	assert.Equal(t, []OrganizationEntry{
+		{Name: "REGION", Value: "secret-region", Visibility: DefaultOrganizationVisibility},
+	}, cfg.OrganizationSecretsList())
+	assert.Equal(t, []string{"OLD_VAR"}, cfg.AbsentOrganizationVariables())
+	assert.Empty(t, cfg.AbsentOrganizationSecrets())
+
+	configContent = "github:\n  token: t\n  owner: my-org\norganization_variables:\n  A: { value: x, repos: [api] }\n"
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+	_, err = LoadConfig(configPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "repos and environments cannot be used in organization_variables")
+}
+
+func TestLoadConfig_UserCodespacesSecrets(t *testing.T) {
+	dir := t.TempDir()
+	configPath := dir + "/config.yaml"
+
+	configContent := `github:
+  token: t
+  owner: me
+user_codespaces_secrets:
+  DOTFILES_TOKEN: dotfiles
+  NPM_TOKEN:
+    value: npm
+    selected_repos: [api, other-owner/tools]
+  OLD_TOKEN: { state: absent }
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+
+	// Repositories are optional with only user-level entries
+	cfg, err := LoadConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, []UserSecretEntry{
+		{Name: "DOTFILES_TOKEN", Value: "dotfiles"},
+		{Name: "NPM_TOKEN", Value: "npm", SelectedRepos: []string{"api", "other-owner/tools"}},
+	}, cfg.UserCodespacesSecretsList())
+	assert.Equal(t, []string{"OLD_TOKEN"}, cfg.AbsentUserCodespacesSecrets())
+	assert.Empty(t, cfg.AbsentFor("api"))
+
+	for content, errMsg := range map[string]string{
+		"user_codespaces_secrets:\n  A: { value: x, visibility: all }\n": "visibility cannot be used in user_codespaces_secrets",
+		"user_codespaces_secrets:\n  A: { value: x, repos: [api] }\n":    "repos and environments cannot be used in user_codespaces_secrets",
+		"user_codespaces_secrets:\n  GITHUB_A: x\n":                      "GITHUB_",
+	} {
+		configContent := "github:\n  token: t\n  owner: me\n" + content
+		require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+		_, err := LoadConfig(configPath)
+		require.Error(t, err, content)
+		assert.Contains(t, err.Error(), errMsg)
+	}
+}
+
+func TestLoadConfig_Environments(t *testing.T) {
+	dir := t.TempDir()
+	configPath := dir + "/config.yaml"
+
+	configContent := `github:
+  token: t
+  owner: my-org
+  repos: [api, web]
+environments:
+  production:
+    wait_timer: 30
+    reviewers:
+      users: [alice]
+      teams: [platform]
+    prevent_self_review: true
+  staging:
+    repos: [web]
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+
+	// The environments section alone is enough to process repositories
+	cfg, err := LoadConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, EnvironmentSettings{
+		WaitTimer:         30,
+		Reviewers:         EnvironmentReviewers{Users: []string{"alice"}, Teams: []string{"platform"}},
+		PreventSelfReview: true,
+	}, cfg.Environments["production"])
+	assert.Equal(t, []string{"production"}, cfg.EnvironmentsFor("api"))
+	assert.Equal(t, []string{"production", "staging"}, cfg.EnvironmentsFor("web"))
+
+	for content, errMsg := range map[string]string{
+		"environments:\n  production:\n    wait_timer: 50000\n":                                    "wait_timer must be between 0 and 43200",
+		"environments:\n  production:\n    prevent_self_review: true\n":                            "prevent_self_review requires at least one reviewer",
+		"environments:\n  production:\n    reviewers: { users: [a, b, c, d], teams: [e, f, g] }\n": "at most 6 reviewers",
+		"environments:\n  production:\n    wait_timers: 5\n":                                       "unknown key 'environments.production.wait_timers' (did you mean 'wait_timer'?)",
+		"environments:\n  production:\n    reviewers: { user: [alice] }\n":                         "unknown key 'environments.production.reviewers.user' (did you mean 'users'?)",
+	} {
+		configContent := "github:\n  token: t\n  owner: my-org\n  repos: [api]\n" + content
+		require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+		_, err := LoadConfig(configPath)
+		require.Error(t, err, content)
+		assert.Contains(t, err.Error(), errMsg)
+	}
+}
+
+func TestLoadConfig_ActionsPermissions(t *testing.T) {
+	dir := t.TempDir()
+	configPath := dir + "/config.yaml"
+
+	configContent := `github:
+  token: t
+  owner: my-org
+  repos: [api, web]
+actions_permissions:
+  allowed_actions: selected
+  github_owned_allowed: true
+  patterns_allowed: [my-org/*, docker/login-action@v3]
+  repos: [api]
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+
+	// The actions_permissions section alone is enough to process repositories
+	cfg, err := LoadConfig(configPath)
+	require.NoError(t, err)
+	policy := cfg.ActionsPermissionsFor("api")
+	require.NotNil(t, policy)
+	assert.True(t, policy.IsEnabled())
+	assert.Equal(t, AllowedActionsSelected, policy.AllowedActionsOrDefault())
+	assert.Equal(t, []string{"my-org/*", "docker/login-action@v3"}, policy.PatternsAllowed)
+	assert.Nil(t, cfg.ActionsPermissionsFor("web"))
+	assert.Equal(t, Origin{Source: SourceFile, Detail: configPath}, cfg.Origin("actions_permissions.allowed_actions"))
+
+	for content, errMsg := range map[string]string{
+		"actions_permissions:\n  allowed_actions: some\n":                  "actions_permissions.allowed_actions must be 'all', 'local_only' or 'selected', got 'some'",
+		"actions_permissions:\n  enabled: false\n  allowed_actions: all\n": "actions_permissions.allowed_actions cannot be set when Actions is disabled",
+		"actions_permissions:\n  verified_allowed: true\n":                 "require allowed_actions: selected",
+		"actions_permissions:\n  allowed_action: all\n":                    "unknown key 'actions_permissions.allowed_action' (did you mean 'allowed_actions'?)",
+	} {
+		configContent := "github:\n  token: t\n  owner: my-org\n  repos: [api]\n" + content
+		require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+		_, err := LoadConfig(configPath)
+		require.Error(t, err, content)
+		assert.Contains(t, err.Error(), errMsg)
+	}
+}
+
+func TestLoadConfig_WorkflowPermissions(t *testing.T) {
+	dir := t.TempDir()
+	configPath := dir + "/config.yaml"
+
+	configContent := `github:
+  token: t
+  owner: my-org
+  repos: [api, web]
+workflow_permissions:
+  default: read
+  can_approve_pull_requests: true
+  repos: [web]
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+
+	cfg, err := LoadConfig(configPath)
+	require.NoError(t, err)
+	assert.Nil(t, cfg.WorkflowPermissionsFor("api"))
+	assert.Equal(t, &WorkflowPermissions{Default: WorkflowPermissionsRead, CanApprovePullRequests: true, Repos: []string{"web"}}, cfg.WorkflowPermissionsFor("web"))
+
+	for content, errMsg := range map[string]string{
+		"workflow_permissions:\n  default: admin\n":                  "workflow_permissions.default must be 'read' or 'write', got 'admin'",
+		"workflow_permissions:\n  can_approve_pull_requests: true\n": "workflow_permissions.default must be 'read' or 'write', got ''",
+		"workflow_permissions:\n  defaults: read\n":                  "unknown key 'workflow_permissions.defaults' (did you mean 'default'?)",
+	} {
+		configContent := "github:\n  token: t\n  owner: my-org\n  repos: [api]\n" + content
+		require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+		_, err := LoadConfig(configPath)
+		require.Error(t, err, content)
+		assert.Contains(t, err.Error(), errMsg)
+	}
+}
+
+func TestLoadConfig_Hooks(t *testing.T) {
+	dir := t.TempDir()
+	configPath := dir + "/config.yaml"
+
+	configContent := `github:
+  token: t
+  owner: my-org
+  repos: [api]
+repository_variables:
+  REGION: us
+hooks:
+  pre_apply:
+    - run: ./check.sh
+  post_apply:
+    - run: ./redeploy.sh
+      on_failure: report
+      timeout: 2m
+      dir: scripts
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+
+	cfg, err := LoadConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, []Hook{{Run: "./check.sh", Dir: dir}}, cfg.Hooks.PreApply)
+	assert.Equal(t, []Hook{{Run: "./redeploy.sh", OnFailure: HookReport, Timeout: 2 * time.Minute, Dir: filepath.Join(dir, "scripts")}}, cfg.Hooks.PostApply)
+	assert.Equal(t, HookAbort, cfg.Hooks.PreApply[0].OnFailureOrDefault())
+
+	for content, errMsg := range map[string]string{
+		"hooks:\n  pre_apply:\n    - on_failure: abort\n":               "hooks.pre_apply[0].run is required",
+		"hooks:\n  post_apply:\n    - run: x\n      on_failure: skip\n": "hooks.post_apply[0].on_failure must be 'abort' or 'report', got 'skip'",
+		"hooks:\n  post_apply:\n    - run: x\n      timeout: -1s\n":     "hooks.post_apply[0].timeout cannot be negative",
+		"hooks:\n  pre_aply: []\n":                                      "unknown key 'hooks.pre_aply' (did you mean 'pre_apply'?)",
+		"hooks:\n  pre_apply:\n    - command: x\n":                      "unknown key 'hooks.pre_apply[0].command'",
+	} {
+		configContent := "github:\n  token: t\n  owner: my-org\n  repos: [api]\nrepository_variables:\n  REGION: us\n" + content
+		require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+		_, err := LoadConfig(configPath)
+		require.Error(t, err, content)
+		assert.Contains(t, err.Error(), errMsg)
+	}
+
+	// The configuration is validated before the values hooks may prepare exist
+	configContent = "github:\n  token: t\n  owner: my-org\n  repos: [api]\nrepository_secrets:\n  KEY: { from_file: key.txt }\n  PLAIN: { value: p }\nhooks:\n  pre_apply:\n    - run: ./fetch-key.sh\n"
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+	_, err = LoadConfig(configPath)
+	require.Error(t, err)
+	cfg, err = LoadConfigWithOptions(configPath, LoadOptions{SkipValues: true})
+	require.NoError(t, err)
+	assert.Equal(t, unresolvedValue, cfg.RepositorySecrets["KEY"])
+	assert.Equal(t, "p", cfg.RepositorySecrets["PLAIN"])
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "key.txt"), []byte("s3cr3t"), 0o600))
+	require.NoError(t, cfg.ResolveValues(LoadOptions{}))
+	assert.Equal(t, "s3cr3t", cfg.RepositorySecrets["KEY"])
+	require.NoError(t, cfg.ResolveValues(LoadOptions{}))
+}
+
+func TestLoadConfig_Notifications(t *testing.T) {
+	dir := t.TempDir()
+	configPath := dir + "/config.yaml"
+	base := "github:\n  token: t\n  owner: my-org\n  repos: [api]\nrepository_variables:\n  REGION: us\n"
+
+	require.NoError(t, os.WriteFile(configPath, []byte(base+"notifications:\n  slack:\n    webhook_url: https://hooks.slack.com/services/T/B/x\n    on: change\n"), 0o600))
+	cfg, err := LoadConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, &ChatNotification{WebhookURL: "https://hooks.slack.com/services/T/B/x", On: NotifyChange}, cfg.Notifications.Slack)
+
+	// The environment variable enables Slack notifications by itself
+	t.Setenv(EnvSlackWebhookKey, "https://hooks.slack.com/services/T/B/env")
+	require.NoError(t, os.WriteFile(configPath, []byte(base), 0o600))
+	cfg, err = LoadConfig(configPath)
+	require.NoError(t, err)
+	require.NotNil(t, cfg.Notifications.Slack)
+	assert.Equal(t, "https://hooks.slack.com/services/T/B/env", cfg.Notifications.Slack.WebhookURL)
+	assert.Equal(t, NotifyAlways, cfg.Notifications.Slack.OnOrDefault())
+	t.Setenv(EnvSlackWebhookKey, "")
+
+	// Profiles replace the notifications they configure
+	content := base + `notifications:
+  slack:
+    webhook_url: https://hooks.slack.com/services/T/B/base
+  webhooks:
+    - url: https://example.com/base
+profiles:
+  prod:
+    notifications:
+      teams:
+        webhook_url: https://example.webhook.office.com/prod
+        on: failure
+      webhooks:
+        - url: https://example.com/prod
+          headers: { Authorization: Bearer t }
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(content), 0o600))
+	cfg, err = LoadConfigWithOptions(configPath, LoadOptions{Profile: "prod"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://hooks.slack.com/services/T/B/base", cfg.Notifications.Slack.WebhookURL)
+	assert.Equal(t, &ChatNotification{WebhookURL: "https://example.webhook.office.com/prod", On: NotifyFailure}, cfg.Notifications.Teams)
+	assert.Equal(t, []WebhookNotification{{URL: "https://example.com/prod", Headers: map[string]string{"Authorization": "Bearer t"}}}, cfg.Notifications.Webhooks)
+
+	for content, errMsg := range map[string]string{
+		"notifications:\n  slack:\n    on: failure\n":                             "notifications.slack.webhook_url is required (can be set via the GAJIN_SLACK_WEBHOOK_URL environment variable)",
+		"notifications:\n  slack:\n    webhook_url: hooks.slack.com/x\n":          "notifications.slack.webhook_url must be an http or https URL",
+		"notifications:\n  slack:\n    webhook_url: https://h/x\n    on: never\n": "notifications.slack.on must be 'always', 'failure' or 'change', got 'never'",
+		"notifications:\n  slak: {}\n":                                            "unknown key 'notifications.slak' (did you mean 'slack'?)",
+		"notifications:\n  slack:\n    webhook: https://h/x\n":                    "unknown key 'notifications.slack.webhook'",
+		"notifications:\n  teams: {}\n":                                           "notifications.teams.webhook_url is required (can be set via the GAJIN_TEAMS_WEBHOOK_URL environment variable)",
+		"notifications:\n  webhooks:\n    - on: always\n":                         "notifications.webhooks[0].url is required",
+		"notifications:\n  webhooks:\n    - url: ftp://h/x\n":                     "notifications.webhooks[0].url must be an http or https URL",
+		"notifications:\n  webhooks:\n    - url: https://h/x\n      header: {}\n": "unknown key 'notifications.webhooks[0].header' (did you mean 'headers'?)",
+	} {
+		require.NoError(t, os.WriteFile(configPath, []byte(base+content), 0o600))
+		_, err := LoadConfig(configPath)
+		require.Error(t, err, content)
+		assert.Contains(t, err.Error(), errMsg)
+	}
+}
+
+func TestLoadConfig_GitHubApp(t *testing.T) {
+	dir := t.TempDir()
+	configPath := dir + "/config.yaml"
+
+	configContent := `github:
+  owner: my-org
+  repos: [api]
+  app:
+    app_id: 12
+    installation_id: 34
+    private_key_path: keys/app.pem
+repository_secrets:
+  KEY: value
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+
+	// No token is required when authenticating as an app
+	cfg, err := LoadConfig(configPath)
+	require.NoError(t, err)
+	require.True(t, cfg.UsesApp())
+	assert.Equal(t, &GitHubAppConfig{AppID: 12, InstallationID: 34, PrivateKeyPath: dir + "/keys/app.pem"}, cfg.GitHub.App)
+
+	for content, errMsg := range map[string]string{
+		"  app:\n    installation_id: 34\n    private_key_path: app.pem\n": "github.app.app_id is required",
+		"  app:\n    app_id: 12\n    private_key_path: app.pem\n":          "github.app.installation_id is required",
+		"  app:\n    app_id: 12\n    installation_id: 34\n":                "github.app.private_key_path is required",
+		"  app:\n    app_id: 12\n    installation: 34\n":                   "unknown key 'github.app.installation'",
+	} {
+		configContent := "github:\n  owner: my-org\n  repos: [api]\n" + content + "repository_secrets:\n  KEY: value\n"
+		require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+		_, err := LoadConfig(configPath)
+		require.Error(t, err, content)
+		assert.Contains(t, err.Error(), errMsg)
+	}
+}
+
+func TestLoadConfig_StoredToken(t *testing.T) {
+	dir := t.TempDir()
+	configPath := dir + "/config.yaml"
+	require.NoError(t, os.WriteFile(configPath, []byte("github:\n  owner: my-org\n  repos: [api]\nrepository_secrets:\n  KEY: value\n"), 0o600))
+	t.Setenv(EnvTokenKey, "")
+
+	var calls []string
+	providers := []TokenProvider{
+		func() (string, string, error) { calls = append(calls, "empty"); return "", "", nil },
+		func() (string, string, error) {
+			calls = append(calls, "stored")
+			return "stored-token", "/home/me/credentials.yaml", nil
+		},
+	}
+	cfg, err := LoadConfigWithOptions(configPath, LoadOptions{TokenProviders: providers})
+	require.NoError(t, err)
+	assert.Equal(t, "stored-token", cfg.GitHub.Token)
+	assert.Equal(t, Origin{Source: SourceCredentials, Detail: "/home/me/credentials.yaml"}, cfg.Origin("github.token"))
+	assert.Equal(t, []string{"empty", "stored"}, calls)
+
+	// A configured token is never replaced
+	t.Setenv(EnvTokenKey, "env-token")
+	calls = nil
+	cfg, err = LoadConfigWithOptions(configPath, LoadOptions{TokenProviders: providers})
+	require.NoError(t, err)
+	assert.Equal(t, "env-token", cfg.GitHub.Token)
+	assert.Empty(t, calls)
+
+	t.Setenv(EnvTokenKey, "")
+	_, err = LoadConfigWithOptions(configPath, LoadOptions{TokenProviders: []TokenProvider{
+		func() (string, string, error) { return "", "", errors.New("corrupt") },
+	}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read stored GitHub token: corrupt")
+}
+
+func TestLoadConfig_KeychainToken(t *testing.T) {
+	dir := t.TempDir()
+	configPath := dir + "/config.yaml"
+	require.NoError(t, os.WriteFile(configPath, []byte("github:\n  token: file-token\n  owner: my-org\n  repos: [api]\nrepository_secrets:\n  KEY: value\n"), 0o600))
+	t.Setenv(EnvTokenKey, "env-token")
+
+	// The keychain is checked before the environment and the file
+	keychain := func() (string, string, error) { return "keychain-token", "system keychain", nil }
+	cfg, err := LoadConfigWithOptions(configPath, LoadOptions{KeychainToken: keychain})
+	require.NoError(t, err)
+	assert.Equal(t, "keychain-token", cfg.GitHub.Token)
+	assert.Equal(t, Origin{Source: SourceKeychain, Detail: "system keychain"}, cfg.Origin("github.token"))
+	require.NotNil(t, cfg.ReplacedToken())
+	assert.Equal(t, SourceEnv, cfg.ReplacedToken().Source)
+
+	cfg.ApplyOverrides("flag-token", "", nil)
+	assert.Equal(t, "flag-token", cfg.GitHub.Token)
+
+	// An empty keychain leaves the environment token in place
+	empty := func() (string, string, error) { return "", "", nil }
+	cfg, err = LoadConfigWithOptions(configPath, LoadOptions{KeychainToken: empty})
+	require.NoError(t, err)
+	assert.Equal(t, "env-token", cfg.GitHub.Token)
+	assert.Nil(t, cfg.ReplacedToken())
+}
+
+func TestLoadConfig_Enterprise(t *testing.T) {
+	dir := t.TempDir()
+	configPath := dir + "/config.yaml"
+
+	configContent := `github:
+  token: t
+  owner: my-org
+  repos: [api]
+  api_url: https://github.example.com/api/v3/
+  upload_url: https://uploads.github.example.com/
+  api_version: "2022-11-28"
+repository_secrets:
+  KEY: value
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+
+	cfg, err := LoadConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, "https://github.example.com/api/v3/", cfg.GitHub.APIURL)
+	assert.Equal(t, "https://uploads.github.example.com/", cfg.GitHub.UploadURL)
+	assert.Equal(t, "2022-11-28", cfg.GitHub.APIVersion)
+
+	for content, errMsg := range map[string]string{
+		"  api_url: github.example.com\n":                    "github.api_url must be an absolute http(s) URL",
+		"  upload_url: https://uploads.github.example.com\n": "github.upload_url requires github.api_url",
+		"  api_version: latest\n":                            "github.api_version must be a date like 2022-11-28",
+	} {
+		configContent := "github:\n  token: t\n  owner: my-org\n  repos: [api]\n" + content + "repository_secrets:\n  KEY: value\n"
+		require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+		_, err := LoadConfig(configPath)
+		require.Error(t, err, content)
+		assert.Contains(t, err.Error(), errMsg)
+	}
+}
+
+func TestLoadConfig_Settings(t *testing.T) {
+	dir := t.TempDir()
+	configPath := dir + "/config.yaml"
+
+	configContent := `github:
+  token: t
+  owner: o
+  repos: [api]
+settings:
+  concurrency:
+    repos: 4
+  rate_limit:
+    requests_per_second: 2.5
+  retry:
+    max_attempts: 5
+    max_backoff: 1m
+    max_wait: 5m
+    operation_retries: 4
+  cache:
+    dir: .cache
+  state:
+    file: gajin-state.json
+  audit:
+    file: audit/gajin.jsonl
+  http:
+    proxy: http://proxy.example.com:3128
+    ca_file: certs/corp.pem
+  request_timeout: 10s
+  operation_timeout: 2m
+  ensure_actions_enabled: true
+  confirm_threshold: 10
+repository_variables:
+  A: a
+profiles:
+  ci:
+    settings:
+      concurrency:
+        operations: 3
+      retry:
+        jitter: 0
+      cache:
+        etags: false
+      state:
+        enabled: false
+        skip_unchanged: false
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+
+	cfg, err := LoadConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, 4, cfg.Settings.Concurrency.Repos)
+	assert.Equal(t, DefaultConcurrentOperations, cfg.Settings.Concurrency.MaxOperations())
+	assert.Equal(t, RateLimitSettings{RequestsPerSecond: 2.5}, cfg.Settings.RateLimit)
+	assert.Equal(t, DefaultRateLimitPauseBelow, cfg.Settings.RateLimit.PauseThreshold())
+	retry := cfg.Settings.Retry.WithDefaults()
+	assert.Equal(t, 5, retry.MaxAttempts)
+	assert.Equal(t, DefaultRetryInitialBackoff, retry.InitialBackoff)
+	assert.Equal(t, time.Minute, retry.MaxBackoff)
+	assert.Equal(t, DefaultRetryJitter, *retry.Jitter)
+	assert.Equal(t, 5*time.Minute, retry.MaxWait)
+	assert.Equal(t, 4, *retry.OperationRetries)
+	assert.Equal(t, DefaultOperationRetries, *RetrySettings{}.WithDefaults().OperationRetries)
+	assert.True(t, cfg.Settings.Cache.ETagsEnabled())
+	cacheDir, err := cfg.Settings.Cache.Directory()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, ".cache"), cacheDir)
+	assert.True(t, cfg.Settings.State.IsEnabled())
+	assert.True(t, cfg.Settings.State.SkipUnchangedEnabled())
+	statePath, err := cfg.Settings.State.Path(cfg.Settings.Cache)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "gajin-state.json"), statePath)
+	statePath, err = StateSettings{}.Path(cfg.Settings.Cache)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, ".cache", "state.json"), statePath)
+	assert.Equal(t, HTTPSettings{Proxy: "http://proxy.example.com:3128", CAFile: filepath.Join(dir, "certs/corp.pem")}, cfg.Settings.HTTP)
+	assert.True(t, cfg.Settings.Audit.IsEnabled())
+	assert.Equal(t, filepath.Join(dir, "audit/gajin.jsonl"), cfg.Settings.Audit.File)
+	assert.False(t, AuditSettings{}.IsEnabled())
+
+	cfg.ApplyHTTPOverrides("socks5://localhost:1080", "", true)
+	assert.Equal(t, "socks5://localhost:1080", cfg.Settings.HTTP.Proxy)
+	assert.True(t, cfg.Settings.HTTP.InsecureSkipVerify)
+	assert.Equal(t, Origin{Source: SourceFlag, Detail: "--proxy"}, cfg.Origin("settings.http.proxy"))
+
+	cfg.ApplyConcurrencyOverride(0)
+	assert.Equal(t, 4, cfg.Settings.Concurrency.MaxRepos())
+	cfg.ApplyConcurrencyOverride(8)
+	assert.Equal(t, 8, cfg.Settings.Concurrency.MaxRepos())
+	assert.Equal(t, Origin{Source: SourceFlag, Detail: "--concurrency"}, cfg.Origin("settings.concurrency.repos"))
+	assert.Equal(t, DefaultConcurrentRepos, ConcurrencySettings{}.MaxRepos())
+
+	cfg.ApplyOperationRetriesOverride(nil)
+	assert.Equal(t, 4, *cfg.Settings.Retry.OperationRetries)
+	noRetries := 0
+	cfg.ApplyOperationRetriesOverride(&noRetries)
+	assert.Equal(t, 0, *cfg.Settings.Retry.WithDefaults().OperationRetries)
+	assert.Equal(t, Origin{Source: SourceFlag, Detail: "--max-retries"}, cfg.Origin("settings.retry.operation_retries"))
+
+	cfg, err = LoadConfigWithOptions(configPath, LoadOptions{Profile: "ci"})
+	require.NoError(t, err)
+	assert.Equal(t, ConcurrencySettings{Repos: 4, Operations: 3}, cfg.Settings.Concurrency)
+	assert.Equal(t, 0.0, *cfg.Settings.Retry.WithDefaults().Jitter)
+	assert.False(t, cfg.Settings.Cache.ETagsEnabled())
+	assert.False(t, cfg.Settings.State.IsEnabled())
+	assert.False(t, cfg.Settings.State.SkipUnchangedEnabled())
+	assert.Equal(t, 10*time.Second, cfg.Settings.RequestTimeoutOrDefault())
+	assert.Equal(t, 2*time.Minute, cfg.Settings.OperationTimeoutOrDefault())
+	assert.True(t, cfg.Settings.EnsureActionsEnabled)
+	assert.Equal(t, 10, cfg.Settings.ConfirmThresholdOrDefault())
+	assert.Equal(t, DefaultConfirmThreshold, Settings{}.ConfirmThresholdOrDefault())
+
+	for content, errMsg := range map[string]string{
+		"settings:\n  concurrency:\n    repos: -1\n":          "settings.concurrency.repos cannot be negative",
+		"settings:\n  concurrency:\n    repo: 2\n":            "unknown key 'settings.concurrency.repo' (did you mean 'repos'?)",
+		"settings:\n  rate_limit:\n    on_exhausted: retry\n": "settings.rate_limit.on_exhausted must be 'wait' or 'fail', got 'retry'",
+		"settings:\n  concurency:\n    repos: 2\n":            "unknown key 'settings.concurency'",
+		"settings:\n  rate_limit:\n    pause_below: -5\n":     "settings.rate_limit.pause_below cannot be negative",
+		"settings:\n  http:\n    proxy: ftp://proxy:21\n":     "settings.http.proxy scheme must be http, https or socks5, got 'ftp'",
+		"settings:\n  http:\n    proxy: proxy\n":              "settings.http.proxy must be a URL",
+		"settings:\n  retry:\n    jitter: 2\n":                "settings.retry.jitter must be between 0 and 1, got 2",
+		"settings:\n  retry:\n    max_attempts: -1\n":         "settings.retry.max_attempts cannot be negative",
+		"settings:\n  retry:\n    operation_retries: -1\n":    "settings.retry.operation_retries cannot be negative",
+		"settings:\n  request_timeout: -1s\n":                 "settings.request_timeout cannot be negative",
+		"settings:\n  operation_timeout: -1s\n":               "settings.operation_timeout cannot be negative",
+		"settings:\n  confirm_threshold: -1\n":                "settings.confirm_threshold cannot be negative",
+	} {
+		configContent := "github:\n  token: t\n  owner: o\n  repos: [api]\nrepository_variables:\n  A: a\n" + content
+		require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+		_, err := LoadConfig(configPath)
+		require.Error(t, err, content)
+		assert.Contains(t, err.Error(), errMsg)
+	}
+}
+
+func TestLoadConfig_CodespacesSecrets(t *testing.T) {
+	dir := t.TempDir()
+	configPath := dir + "/config.yaml"
+
+	configContent := `github:
+  token: t
+  owner: o
+  repos: [api, web]
+codespaces_secrets:
+  DEV_TOKEN: dev
+repo_overrides:
+  web:
+    codespaces_secrets:
+      DEV_TOKEN: web-dev
+      OLD_DEV_TOKEN: { state: absent }
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+
+	cfg, err := LoadConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"DEV_TOKEN": "dev"}, cfg.ResourcesFor("api").CodespacesSecrets)
+	assert.Equal(t, map[string]string{"DEV_TOKEN": "web-dev"}, cfg.ResourcesFor("web").CodespacesSecrets)
+	assert.Equal(t, map[string]string{"OLD_DEV_TOKEN": ""}, cfg.AbsentFor("web").CodespacesSecrets)
+}
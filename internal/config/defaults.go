@@ -0,0 +1,126 @@
+package config
+
+import "sort"
+
+// SectionDefaults holds entries inherited by every environment.
+const SectionDefaults = "defaults"
+
+// Defaults holds environment entries inherited by every environment of a
+// repository. Entries set for a specific environment take precedence.
+type Defaults struct {
+	EnvironmentSecrets   map[string]string `yaml:"environment_secrets"`
+	EnvironmentVariables map[string]string `yaml:"environment_variables"`
+}
+
+// IsEmpty reports whether no default is defined.
+func (d Defaults) IsEmpty() bool {
+	return len(d.EnvironmentSecrets) == 0 && len(d.EnvironmentVariables) == 0
+}
+
+// set stores a default value in the section map it belongs to.
+func (d *Defaults) set(section, name, value string) {
+	switch section {
+	case SectionEnvironmentSecrets:
+		if d.EnvironmentSecrets == nil {
+			d.EnvironmentSecrets = make(map[string]string)
+		}
+		d.EnvironmentSecrets[name] = value
+	case SectionEnvironmentVariables:
+		if d.EnvironmentVariables == nil {
+			d.EnvironmentVariables = make(map[string]string)
+		}
+		d.EnvironmentVariables[name] = value
+	}
+}
+
+// merge copies all entries of other into d, replacing existing entries.
+func (d *Defaults) merge(other Defaults) {
+	for name, value := range other.EnvironmentSecrets {
+		d.set(SectionEnvironmentSecrets, name, value)
+	}
+	for name, value := range other.EnvironmentVariables {
+		d.set(SectionEnvironmentVariables, name, value)
+	}
+}
+
+// validate checks that no name or value is empty and that names are valid.
+func (d Defaults) validate() error {
+	for _, section := range []struct {
+		name   string
+		kind   string
+		values map[string]string
+	}{
+		{SectionEnvironmentSecrets, "secret", d.EnvironmentSecrets},
+		{SectionEnvironmentVariables, "variable", d.EnvironmentVariables},
+	} {
+		for name, value := range section.values {
+			key := scopePrefix(SectionDefaults) + entryKey(section.name, "", name)
+			if name == "" {
+				return keyError(key, "default environment %s key cannot be empty", section.kind)
+			}
+			if value == "" {
+				return keyError(key, "default environment %s value for '%s' cannot be empty", section.kind, name)
+			}
+		}
+		if err := validateNames(func(name string) string {
+			return scopePrefix(SectionDefaults) + entryKey(section.name, "", name)
+		}, "default environment "+section.kind, section.values); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Environments returns the sorted names of the environments with at least one
+// secret or variable.
+func (r Resources) Environments() []string {
+	seen := make(map[string]bool)
+	for envName := range r.EnvironmentSecrets {
+		seen[envName] = true
+	}
+	for envName := range r.EnvironmentVariables {
+		seen[envName] = true
+	}
+
+	envs := make([]string, 0, len(seen))
+	for envName := range seen {
+		envs = append(envs, envName)
+	}
+	sort.Strings(envs)
+	return envs
+}
+
+// applyDefaults adds the defaults to every environment of r that does not set
+// the entry itself, honoring the repos and environments restrictions of each
+// default. value returns the value to use for an environment.
+func (c *Config) applyDefaults(r *Resources, repo string, value func(section, environment, name, value string) (string, error)) error {
+	d := c.Defaults
+	applies := func(section, envName, name string) bool {
+		spec := c.Specs[scopePrefix(SectionDefaults)+entryKey(section, "", name)]
+		return spec == nil || (spec.appliesToRepo(repo) && spec.appliesToEnvironment(envName))
+	}
+
+	for _, envName := range r.Environments() {
+		for name, defaultValue := range d.EnvironmentSecrets {
+			if _, ok := r.EnvironmentSecrets[envName][name]; ok || !applies(SectionEnvironmentSecrets, envName, name) {
+				continue
+			}
+			v, err := value(SectionEnvironmentSecrets, envName, name, defaultValue)
+			if err != nil {
+				return err
+			}
+			r.set(SectionEnvironmentSecrets, envName, name, v)
+		}
+		for name, defaultValue := range d.EnvironmentVariables {
+			if _, ok := r.EnvironmentVariables[envName][name]; ok || !applies(SectionEnvironmentVariables, envName, name) {
+				continue
+			}
+			v, err := value(SectionEnvironmentVariables, envName, name, defaultValue)
+			if err != nil {
+				return err
+			}
+			r.set(SectionEnvironmentVariables, envName, name, v)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// SectionDoppler lists Doppler configs whose secrets are imported.
+const SectionDoppler = "doppler"
+
+// dopplerMetadataPrefix prefixes the read-only metadata entries (e.g.
+// DOPPLER_PROJECT) included in every Doppler config; they are never imported.
+const dopplerMetadataPrefix = "DOPPLER_"
+
+// DopplerSource imports every secret of a Doppler config into a section, e.g.
+//
+//	doppler:
+//	  - project: backend
+//	    config: prd
+//	    section: environment_secrets
+//	    environment: production
+//	    include: ["DB_*"]
+//	    rename: { DB_URL: DATABASE_URL }
+//
+// Entries set explicitly in the configuration take precedence over imported ones.
+type DopplerSource struct {
+	// Project and Config select the Doppler config; both can be omitted when
+	// the Doppler CLI is scoped by a service token or doppler setup
+	Project string `yaml:"project"`
+	Config  string `yaml:"config"`
+	// Section receives the secrets: repository_secrets (default),
+	// environment_secrets, repository_variables, environment_variables or codespaces_secrets
+	Section string `yaml:"section"`
+	// Environment is the target environment of environment sections
+	Environment string `yaml:"environment"`
+	// Include and Exclude filter secret names with glob patterns such as DB_*
+	Include []string `yaml:"include"`
+	Exclude []string `yaml:"exclude"`
+	// Rename maps Doppler names to GitHub names; other names get StripPrefix
+	// removed and AddPrefix added
+	Rename      map[string]string `yaml:"rename"`
+	StripPrefix string            `yaml:"strip_prefix"`
+	AddPrefix   string            `yaml:"add_prefix"`
+}
+
+// name returns a display name of the Doppler config.
+func (d DopplerSource) name() string {
+	if d.Project == "" && d.Config == "" {
+		return "doppler"
+	}
+	return d.Project + "/" + d.Config
+}
+
+// targetSection returns the section receiving the imported secrets.
+func (d DopplerSource) targetSection() string {
+	if d.Section == "" {
+		return SectionRepositorySecrets
+	}
+	return d.Section
+}
+
+// validate checks the import options of the source at index i.
+func (d DopplerSource) validate(i int) error {
+	key := fmt.Sprintf("%s[%d]", SectionDoppler, i)
+	switch d.targetSection() {
+	case SectionEnvironmentSecrets, SectionEnvironmentVariables:
+		if d.Environment == "" {
+			return fmt.Errorf("%s: environment is required with section %s", key, d.targetSection())
+		}
+	case SectionRepositorySecrets, SectionRepositoryVariables, SectionCodespacesSecrets:
+		if d.Environment != "" {
+			return fmt.Errorf("%s: environment can only be used with environment_secrets or environment_variables", key)
+		}
+	default:
+		return fmt.Errorf("%s: invalid section '%s'", key, d.Section)
+	}
+	for _, pattern := range append(append([]string(nil), d.Include...), d.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("%s: invalid pattern '%s': %w", key, pattern, err)
+		}
+	}
+	return nil
+}
+
+// targetName returns the GitHub name of a Doppler secret, or an empty string
+// if the secret is filtered out.
+func (d DopplerSource) targetName(name string) string {
+	if strings.HasPrefix(name, dopplerMetadataPrefix) {
+		return ""
+	}
+	if len(d.Include) > 0 && !matchAny(d.Include, name) {
+		return ""
+	}
+	if matchAny(d.Exclude, name) {
+		return ""
+	}
+	if renamed, ok := d.Rename[name]; ok {
+		return renamed
+	}
+	return d.AddPrefix + strings.TrimPrefix(name, d.StripPrefix)
+}
+
+// matchAny reports whether name matches one of the glob patterns.
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// importDoppler adds the secrets of the doppler sources to the global sections.
+func (c *Config) importDoppler(opts LoadOptions) error {
+	for i, source := range c.Doppler {
+		if err := source.validate(i); err != nil {
+			return err
+		}
+
+		secrets, err := downloadDopplerSecrets(source, opts)
+		if err != nil {
+			return fmt.Errorf("%s[%d]: %w", SectionDoppler, i, err)
+		}
+
+		names := make([]string, 0, len(secrets))
+		for name := range secrets {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		global := c.Global()
+		section := source.targetSection()
+		for _, name := range names {
+			target := source.targetName(name)
+			if target == "" {
+				continue
+			}
+			key := entryKey(section, source.Environment, target)
+			if _, ok := c.Specs[key]; ok || global.has(section, source.Environment, target) {
+				// Entries of the configuration file take precedence
+				continue
+			}
+			global.set(section, source.Environment, target, secrets[name])
+			c.SetOrigin(key, SourceStore, SectionDoppler+":"+source.name()+"/"+name)
+			if c.imported == nil {
+				c.imported = make(map[string]bool)
+			}
+			c.imported[key] = true
+		}
+		c.setGlobal(global)
+	}
+	return nil
+}
+
+// downloadDopplerSecrets returns every secret of a Doppler config using the
+// Doppler CLI.
+func downloadDopplerSecrets(source DopplerSource, opts LoadOptions) (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout(opts))
+	defer cancel()
+
+	args := []string{"secrets", "download", "--no-file", "--format", "json"}
+	if source.Project != "" {
+		args = append(args, "--project", source.Project)
+	}
+	if source.Config != "" {
+		args = append(args, "--config", source.Config)
+	}
+	out, err := runTool(ctx, "doppler", args...)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("doppler download timed out after %s", commandTimeout(opts))
+		}
+		return nil, err
+	}
+
+	var secrets map[string]string
+	if err := json.Unmarshal(out, &secrets); err != nil {
+		return nil, fmt.Errorf("failed to parse doppler output: %w", err)
+	}
+	return secrets, nil
+}
@@ -0,0 +1,36 @@
+package config
+
+import (
+	"net/url"
+	"time"
+)
+
+// apiVersionLayout is the date format of GitHub REST API versions.
+const apiVersionLayout = "2006-01-02"
+
+// validateEnterprise checks the API URLs and version of the GitHub
+// configuration.
+func (c *Config) validateEnterprise() error {
+	for _, entry := range []struct{ key, value string }{
+		{"github.api_url", c.GitHub.APIURL},
+		{"github.upload_url", c.GitHub.UploadURL},
+	} {
+		key, value := entry.key, entry.value
+		if value == "" {
+			continue
+		}
+		u, err := url.Parse(value)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return keyError(key, "%s must be an absolute http(s) URL, got '%s'", key, value)
+		}
+	}
+	if c.GitHub.UploadURL != "" && c.GitHub.APIURL == "" {
+		return keyError("github.upload_url", "github.upload_url requires github.api_url")
+	}
+	if c.GitHub.APIVersion != "" {
+		if _, err := time.Parse(apiVersionLayout, c.GitHub.APIVersion); err != nil {
+			return keyError("github.api_version", "github.api_version must be a date like 2022-11-28, got '%s'", c.GitHub.APIVersion)
+		}
+	}
+	return nil
+}
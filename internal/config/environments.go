@@ -0,0 +1,87 @@
+package config
+
+import "sort"
+
+// SectionEnvironments declares the protection rules of repository environments.
+const SectionEnvironments = "environments"
+
+const (
+	// MaxEnvironmentReviewers is the number of required reviewers GitHub
+	// accepts per environment.
+	MaxEnvironmentReviewers = 6
+	// MaxWaitTimer is the longest wait timer GitHub accepts, in minutes (30 days).
+	MaxWaitTimer = 43200
+)
+
+// EnvironmentSettings declares the protection rules of an environment. They
+// replace the environment's current wait timer, reviewers and self-review
+// setting; other settings such as deployment branch policies are kept.
+type EnvironmentSettings struct {
+	// WaitTimer delays jobs that reference the environment, in minutes
+	WaitTimer int `yaml:"wait_timer"`
+	// Reviewers must approve jobs that reference the environment
+	Reviewers EnvironmentReviewers `yaml:"reviewers"`
+	// PreventSelfReview stops users from approving runs they triggered
+	PreventSelfReview bool `yaml:"prevent_self_review"`
+	// Repos restricts the settings to these repositories
+	Repos []string `yaml:"repos"`
+}
+
+// EnvironmentReviewers lists the required reviewers of an environment.
+type EnvironmentReviewers struct {
+	// Users are user logins
+	Users []string `yaml:"users"`
+	// Teams are team slugs of the owner organization
+	Teams []string `yaml:"teams"`
+}
+
+// EnvironmentsFor returns the environments to configure for a repository,
+// sorted by name.
+func (c *Config) EnvironmentsFor(repo string) []string {
+	var names []string
+	for name, env := range c.Environments {
+		if len(env.Repos) == 0 || contains(env.Repos, repo) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validateEnvironments checks the protection rules of the environments section.
+func (c *Config) validateEnvironments() error {
+	for name, env := range c.Environments {
+		key := SectionEnvironments + "." + name
+		if name == "" {
+			return keyError(key, "environment name cannot be empty")
+		}
+		if env.WaitTimer < 0 || env.WaitTimer > MaxWaitTimer {
+			return keyError(key+".wait_timer", "%s.wait_timer must be between 0 and %d minutes, got %d", key, MaxWaitTimer, env.WaitTimer)
+		}
+		if n := len(env.Reviewers.Users) + len(env.Reviewers.Teams); n > MaxEnvironmentReviewers {
+			return keyError(key+".reviewers", "%s.reviewers: at most %d reviewers are allowed, got %d", key, MaxEnvironmentReviewers, n)
+		}
+		for _, list := range []struct {
+			kind  string
+			names []string
+		}{
+			{"users", env.Reviewers.Users},
+			{"teams", env.Reviewers.Teams},
+		} {
+			for _, reviewer := range list.names {
+				if reviewer == "" {
+					return keyError(key+".reviewers", "%s.reviewers.%s entries cannot be empty", key, list.kind)
+				}
+			}
+		}
+		if env.PreventSelfReview && len(env.Reviewers.Users)+len(env.Reviewers.Teams) == 0 {
+			return keyError(key+".prevent_self_review", "%s.prevent_self_review requires at least one reviewer", key)
+		}
+		for _, repo := range env.Repos {
+			if repo == "" {
+				return keyError(key+".repos", "%s.repos entries cannot be empty", key)
+			}
+		}
+	}
+	return nil
+}
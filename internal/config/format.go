@@ -0,0 +1,86 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Supported configuration file formats.
+const (
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+	FormatTOML = "toml"
+)
+
+// DetectFormat returns the configuration format of a file. An explicit format
+// takes precedence over the file extension; unknown extensions are read as YAML.
+func DetectFormat(path, format string) (string, error) {
+	switch strings.ToLower(format) {
+	case FormatYAML, "yml":
+		return FormatYAML, nil
+	case FormatJSON:
+		return FormatJSON, nil
+	case FormatTOML:
+		return FormatTOML, nil
+	case "":
+	default:
+		return "", fmt.Errorf("unsupported config format '%s' (supported: yaml, json, toml)", format)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON, nil
+	case ".toml":
+		return FormatTOML, nil
+	default:
+		return FormatYAML, nil
+	}
+}
+
+// parseConfig decodes configuration data in the given format.
+//
+// JSON and TOML documents are converted to a YAML node first so that every
+// format goes through the same decoding, including structured value entries.
+func parseConfig(data []byte, format string) (*Config, error) {
+	var node yaml.Node
+	switch format {
+	case FormatJSON:
+		var doc map[string]interface{}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		if err := decoder.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("failed to parse JSON: %w", err)
+		}
+		if err := node.Encode(doc); err != nil {
+			return nil, fmt.Errorf("failed to parse JSON: %w", err)
+		}
+	case FormatTOML:
+		var doc map[string]interface{}
+		if err := toml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("failed to parse TOML: %w", err)
+		}
+		if err := node.Encode(doc); err != nil {
+			return nil, fmt.Errorf("failed to parse TOML: %w", err)
+		}
+	default:
+		if err := yaml.Unmarshal(data, &node); err != nil {
+			return nil, fmt.Errorf("failed to parse YAML: %w", err)
+		}
+	}
+
+	var cfg Config
+	if node.Kind == 0 {
+		// Empty document
+		return &cfg, nil
+	}
+	if err := node.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", strings.ToUpper(format), err)
+	}
+	return &cfg, nil
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+)
+
+// SectionHooks declares commands run before and after the configuration is applied.
+const SectionHooks = "hooks"
+
+// Failure policies of hooks.
+const (
+	// HookAbort fails the run: a failing pre_apply hook stops it before
+	// anything is applied, a failing post_apply hook skips the hooks after it
+	HookAbort = "abort"
+	// HookReport reports the failure as an error of the run and continues
+	HookReport = "report"
+)
+
+// Hooks declares shell commands run around applying the configuration, e.g.
+//
+//	hooks:
+//	  pre_apply:
+//	    - run: ./scripts/check-change-freeze.sh
+//	  post_apply:
+//	    - run: ./scripts/redeploy.sh
+//	      on_failure: report
+//
+// Hooks execute arbitrary commands and require --allow-commands.
+type Hooks struct {
+	// PreApply runs in order before anything is applied
+	PreApply []Hook `yaml:"pre_apply"`
+	// PostApply runs in order after the repositories were processed, also
+	// when the run failed
+	PostApply []Hook `yaml:"post_apply"`
+}
+
+// Hook is a command run by the system shell.
+type Hook struct {
+	// Run is the command
+	Run string `yaml:"run"`
+	// OnFailure is "abort" or "report" (default: abort)
+	OnFailure string `yaml:"on_failure"`
+	// Timeout bounds the command (default: --command-timeout)
+	Timeout time.Duration `yaml:"timeout"`
+	// Dir is the working directory, relative to the configuration file
+	// (default: the directory of the configuration file)
+	Dir string `yaml:"dir"`
+}
+
+// OnFailureOrDefault returns the failure policy of the hook.
+func (h Hook) OnFailureOrDefault() string {
+	if h.OnFailure == "" {
+		return HookAbort
+	}
+	return h.OnFailure
+}
+
+// IsEmpty reports whether no hook is configured.
+func (h Hooks) IsEmpty() bool {
+	return len(h.PreApply) == 0 && len(h.PostApply) == 0
+}
+
+// validate checks the hooks of every stage.
+func (h Hooks) validate() error {
+	for _, stage := range []struct {
+		name  string
+		hooks []Hook
+	}{{"pre_apply", h.PreApply}, {"post_apply", h.PostApply}} {
+		for i, hook := range stage.hooks {
+			key := fmt.Sprintf("%s.%s[%d]", SectionHooks, stage.name, i)
+			if hook.Run == "" {
+				return keyError(key+".run", "%s.run is required", key)
+			}
+			if policy := hook.OnFailureOrDefault(); policy != HookAbort && policy != HookReport {
+				return keyError(key+".on_failure", "%s.on_failure must be 'abort' or 'report', got '%s'", key, hook.OnFailure)
+			}
+			if hook.Timeout < 0 {
+				return keyError(key+".timeout", "%s.timeout cannot be negative", key)
+			}
+		}
+	}
+	return nil
+}
+
+// resolveHookDirs makes the working directories of hooks relative to the
+// configuration file directory.
+func (c *Config) resolveHookDirs(baseDir string) {
+	for _, hooks := range [][]Hook{c.Hooks.PreApply, c.Hooks.PostApply} {
+		for i := range hooks {
+			if !filepath.IsAbs(hooks[i].Dir) {
+				hooks[i].Dir = filepath.Join(baseDir, hooks[i].Dir)
+			}
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Numbers of secrets and variables GitHub stores at most per repository,
+// environment and organization.
+const (
+	MaxRepositorySecrets     = 100
+	MaxEnvironmentSecrets    = 100
+	MaxOrganizationSecrets   = 1000
+	MaxRepositoryVariables   = 500
+	MaxEnvironmentVariables  = 100
+	MaxOrganizationVariables = 1000
+)
+
+// MaxRepositoryVariablesSize is the combined size of the repository variables
+// GitHub passes to the workflows of a repository (256 KB).
+const MaxRepositoryVariablesSize = 256 * 1024
+
+// checkValueSize returns an error if the value of an entry exceeds
+// MaxValueSize, which GitHub would reject only after it was encrypted and
+// sent.
+func checkValueSize(key, kind, name, value string) error {
+	if len(value) > MaxValueSize {
+		return keyError(key, "%s value for '%s' is %d bytes, exceeding the GitHub limit of %d bytes", kind, name, len(value), MaxValueSize)
+	}
+	return nil
+}
+
+// validateLimits checks the configured values and the number of entries of
+// every repository, environment and the organization against the GitHub
+// limits. Entries that already exist on GitHub but are not configured are not
+// counted.
+func (c *Config) validateLimits() error {
+	if err := c.validateValueSizes(); err != nil {
+		return err
+	}
+
+	for _, section := range []struct {
+		name   string
+		values map[string]string
+		max    int
+	}{
+		{SectionOrganizationSecrets, c.OrganizationSecrets, MaxOrganizationSecrets},
+		{SectionOrganizationVariables, c.OrganizationVariables, MaxOrganizationVariables},
+	} {
+		if len(section.values) > section.max {
+			return keyError(section.name, "%d %ss are configured, exceeding the GitHub limit of %d per organization", len(section.values), sectionKinds[section.name], section.max)
+		}
+	}
+
+	for _, repo := range c.configuredRepos() {
+		if err := c.ResourcesFor(repo).checkCounts(); err != nil {
+			if repo == "" {
+				return err
+			}
+			return fmt.Errorf("repository '%s': %w", repo, err)
+		}
+	}
+	return nil
+}
+
+// validateValueSizes checks the size of every configured value. Values
+// rendered from templates are checked when they are rendered.
+func (c *Config) validateValueSizes() error {
+	scopes := []resourceLayer{{scope: "", resources: c.Global()}}
+	for group, override := range c.GroupOverrides {
+		scopes = append(scopes, resourceLayer{scope: SectionGroupOverrides + "." + group, resources: override})
+	}
+	for repo, override := range c.RepoOverrides {
+		scopes = append(scopes, resourceLayer{scope: SectionRepoOverrides + "." + repo, resources: override})
+	}
+	for _, layer := range scopes {
+		err := layer.resources.each(func(section, environment, name, value string) error {
+			return checkValueSize(scopePrefix(layer.scope)+entryKey(section, environment, name), sectionKinds[section], name, value)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, section := range []struct {
+		name   string
+		scope  string
+		kind   string
+		values map[string]string
+	}{
+		{SectionEnvironmentSecrets, SectionDefaults, "default environment secret", c.Defaults.EnvironmentSecrets},
+		{SectionEnvironmentVariables, SectionDefaults, "default environment variable", c.Defaults.EnvironmentVariables},
+		{SectionOrganizationSecrets, "", sectionKinds[SectionOrganizationSecrets], c.OrganizationSecrets},
+		{SectionOrganizationVariables, "", sectionKinds[SectionOrganizationVariables], c.OrganizationVariables},
+		{SectionUserCodespacesSecrets, "", sectionKinds[SectionUserCodespacesSecrets], c.UserCodespacesSecrets},
+	} {
+		for name, value := range section.values {
+			if err := checkValueSize(scopePrefix(section.scope)+entryKey(section.name, "", name), section.kind, name, value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// configuredRepos returns the sorted repositories named in the configuration:
+// github.repos, targets, repo_overrides and the members of groups. With
+// repositories selected through the API, "" stands for those without
+// overrides.
+func (c *Config) configuredRepos() []string {
+	seen := make(map[string]bool)
+	for _, repo := range append(c.GitHub.Repos, c.TargetRepos()...) {
+		seen[repo] = true
+	}
+	for repo := range c.RepoOverrides {
+		seen[repo] = true
+	}
+	for _, members := range c.Groups {
+		for _, repo := range members {
+			seen[repo] = true
+		}
+	}
+	if c.GitHub.HasDynamicRepos() {
+		seen[""] = true
+	}
+
+	repos := make([]string, 0, len(seen))
+	for repo := range seen {
+		repos = append(repos, repo)
+	}
+	sort.Strings(repos)
+	return repos
+}
+
+// checkCounts checks the number of entries of a repository and of each of its
+// environments, and the combined size of its variables, against the GitHub
+// limits.
+func (r Resources) checkCounts() error {
+	if len(r.RepositorySecrets) > MaxRepositorySecrets {
+		return fmt.Errorf("%d repository secrets are configured, exceeding the GitHub limit of %d per repository", len(r.RepositorySecrets), MaxRepositorySecrets)
+	}
+	if len(r.RepositoryVariables) > MaxRepositoryVariables {
+		return fmt.Errorf("%d repository variables are configured, exceeding the GitHub limit of %d per repository", len(r.RepositoryVariables), MaxRepositoryVariables)
+	}
+	size := 0
+	for _, value := range r.RepositoryVariables {
+		size += len(value)
+	}
+	if size > MaxRepositoryVariablesSize {
+		return fmt.Errorf("repository variables are %d bytes combined, exceeding the GitHub limit of %d bytes per repository", size, MaxRepositoryVariablesSize)
+	}
+	for _, envName := range r.Environments() {
+		if n := len(r.EnvironmentSecrets[envName]); n > MaxEnvironmentSecrets {
+			return fmt.Errorf("%d environment secrets are configured for environment '%s', exceeding the GitHub limit of %d per environment", n, envName, MaxEnvironmentSecrets)
+		}
+		if n := len(r.EnvironmentVariables[envName]); n > MaxEnvironmentVariables {
+			return fmt.Errorf("%d environment variables are configured for environment '%s', exceeding the GitHub limit of %d per environment", n, envName, MaxEnvironmentVariables)
+		}
+	}
+	return nil
+}
@@ -4,47 +4,237 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-
-	"gopkg.in/yaml.v3"
+	"time"
 )
 
 const (
 	// EnvTokenKey is the environment variable key for GitHub token.
 	EnvTokenKey = "GH_TOKEN_WITH_ACTIONS_WRITE"
+
+	// DefaultCommandTimeout bounds each from_command execution.
+	DefaultCommandTimeout = 30 * time.Second
 )
 
-// LoadConfig loads configuration from a YAML file.
+// LoadOptions controls how structured values are resolved while loading.
+type LoadOptions struct {
+	// AllowCommands enables from_command values, which execute arbitrary commands.
+	AllowCommands bool
+	// CommandTimeout bounds each from_command execution (DefaultCommandTimeout if zero).
+	CommandTimeout time.Duration
+	// Profile selects an entry of the profiles section to overlay on the base configuration.
+	Profile string
+	// Format is the configuration file format (yaml, json or toml); detected from
+	// the file extension if empty.
+	Format string
+	// Identity is the path of the age identity file used to decrypt encrypted values.
+	Identity string
+	// KeychainToken supplies the token stored in the system keychain, which
+	// takes precedence over environment variables and the configuration file.
+	KeychainToken TokenProvider
+	// TokenProviders are tried in order for a token when neither the
+	// configuration file nor the environment sets one.
+	TokenProviders []TokenProvider
+	// SkipValues stands in a placeholder for the values of files, environment
+	// variables, commands, encryption and secret stores, and defers the
+	// Doppler import, until ResolveValues is called. The configuration can be
+	// validated before the pre_apply hooks that prepare these values run.
+	SkipValues bool
+}
+
+// TokenProvider returns a stored GitHub token, or an empty token if it has
+// none. detail describes where the token was found.
+type TokenProvider func() (token, detail string, err error)
+
+// LoadConfig loads configuration from a YAML, JSON or TOML file and validates it.
 func LoadConfig(configPath string) (*Config, error) {
+	return LoadConfigWithOptions(configPath, LoadOptions{})
+}
+
+// LoadConfigWithOptions loads configuration from a YAML file and validates it.
+func LoadConfigWithOptions(configPath string, opts LoadOptions) (*Config, error) {
+	cfg, err := ReadConfigWithOptions(configPath, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate configuration
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("config validation failed: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// ReadConfig loads configuration from a YAML file and applies environment
+// variables without validating the result, so callers can inspect a partially
+// configured file.
+func ReadConfig(configPath string) (*Config, error) {
+	return ReadConfigWithOptions(configPath, LoadOptions{})
+}
+
+// ReadConfigWithOptions is like ReadConfig but resolves structured values
+// according to opts.
+func ReadConfigWithOptions(configPath string, opts LoadOptions) (*Config, error) {
 	data, err := os.ReadFile(configPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	var cfg Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
-		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	format, err := DetectFormat(configPath, opts.Format)
+	if err != nil {
+		return nil, err
 	}
+	if isSopsEncrypted(data, format) {
+		if data, err = decryptSops(configPath, format); err != nil {
+			return nil, err
+		}
+	}
+	cfg, err := parseConfig(data, format)
+	if err != nil {
+		return nil, err
+	}
+	cfg.recordFileOrigins(configPath)
 
-	// Load token from environment variable if not set in config
-	if cfg.GitHub.Token == "" {
-		cfg.GitHub.Token = os.Getenv(EnvTokenKey)
+	if err := cfg.applyProfile(opts.Profile); err != nil {
+		return nil, err
 	}
 
-	// Validate configuration
-	if err := cfg.Validate(); err != nil {
-		return nil, fmt.Errorf("config validation failed: %w", err)
+	// Load structured values such as from_file relative to the config file
+	cfg.baseDir = filepath.Dir(configPath)
+	cfg.resolveAppKeyPath(cfg.baseDir)
+	cfg.resolveSettingsPaths(cfg.baseDir)
+	cfg.resolveHookDirs(cfg.baseDir)
+	if err := cfg.resolveValues(cfg.baseDir, opts); err != nil {
+		return nil, fmt.Errorf("failed to resolve values: %w", err)
+	}
+	if opts.SkipValues {
+		cfg.valuesSkipped = true
+	} else if err := cfg.importDoppler(opts); err != nil {
+		return nil, fmt.Errorf("failed to import Doppler secrets: %w", err)
+	}
+
+	cfg.applyWebhookEnv()
+	cfg.resolveTargetTokens()
+
+	if err := cfg.applyTokens(opts); err != nil {
+		return nil, err
 	}
 
-	return &cfg, nil
+	return cfg, nil
+}
+
+// ResolveValues resolves the values of a configuration read with SkipValues
+// and validates it again. It does nothing for other configurations.
+func (c *Config) ResolveValues(opts LoadOptions) error {
+	if !c.valuesSkipped {
+		return nil
+	}
+	opts.SkipValues = false
+	if err := c.resolveValues(c.baseDir, opts); err != nil {
+		return fmt.Errorf("failed to resolve values: %w", err)
+	}
+	if err := c.importDoppler(opts); err != nil {
+		return fmt.Errorf("failed to import Doppler secrets: %w", err)
+	}
+	c.valuesSkipped = false
+	if err := c.Validate(); err != nil {
+		return fmt.Errorf("config validation failed: %w", err)
+	}
+	return nil
+}
+
+// NewConfig returns a configuration without entries for commands that run
+// without a configuration file, such as gajin set. Only the token is
+// resolved, from the same sources as for a configuration file.
+func NewConfig(opts LoadOptions) (*Config, error) {
+	cfg := &Config{}
+	if err := cfg.applyTokens(opts); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// applyTokens applies the GitHub tokens of sources outside the configuration
+// file. Each source replaces the token of the previous one: github.token,
+// GH_TOKEN_WITH_ACTIONS_WRITE, then the system keychain (--token replaces all
+// of them in ApplyOverrides). Only when none of them sets a token are the
+// stored credentials tried.
+func (c *Config) applyTokens(opts LoadOptions) error {
+	if token := os.Getenv(EnvTokenKey); token != "" {
+		c.GitHub.Token = token
+		c.SetOrigin("github.token", SourceEnv, EnvTokenKey)
+	}
+
+	if err := c.applyKeychainToken(opts.KeychainToken); err != nil {
+		return err
+	}
+
+	return c.applyStoredToken(opts.TokenProviders)
 }
 
 // LoadConfigFromPath loads configuration from a path, expanding it if needed.
-func LoadConfigFromPath(path string) (*Config, error) {
+func LoadConfigFromPath(path string, opts LoadOptions) (*Config, error) {
 	expandedPath, err := filepath.Abs(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to expand config path: %w", err)
 	}
 
-	return LoadConfig(expandedPath)
+	return LoadConfigWithOptions(expandedPath, opts)
 }
 
+// ReadConfigFromPath reads configuration from a path without validating it.
+func ReadConfigFromPath(path string, opts LoadOptions) (*Config, error) {
+	expandedPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand config path: %w", err)
+	}
+
+	return ReadConfigWithOptions(expandedPath, opts)
+}
+
+// applyKeychainToken replaces the configured token with the one of the
+// system keychain, if any, remembering the origin of the replaced token.
+func (c *Config) applyKeychainToken(provider TokenProvider) error {
+	if provider == nil {
+		return nil
+	}
+	token, detail, err := provider()
+	if err != nil {
+		return fmt.Errorf("failed to read GitHub token from the keychain: %w", err)
+	}
+	if token != "" {
+		if c.GitHub.Token != "" {
+			origin := c.Origin("github.token")
+			c.replacedToken = &origin
+		}
+		c.GitHub.Token = token
+		c.SetOrigin("github.token", SourceKeychain, detail)
+	}
+	return nil
+}
+
+// ReplacedToken returns the origin of the token that the token of the system
+// keychain replaced, or nil if it replaced none.
+func (c *Config) ReplacedToken() *Origin {
+	return c.replacedToken
+}
+
+// applyStoredToken falls back to the first token of providers when no token
+// and no GitHub App are configured.
+func (c *Config) applyStoredToken(providers []TokenProvider) error {
+	if c.GitHub.Token != "" || c.UsesApp() {
+		return nil
+	}
+	for _, provider := range providers {
+		token, detail, err := provider()
+		if err != nil {
+			return fmt.Errorf("failed to read stored GitHub token: %w", err)
+		}
+		if token != "" {
+			c.GitHub.Token = token
+			c.SetOrigin("github.token", SourceCredentials, detail)
+			return nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// MaxNameLength is the maximum length of a secret or variable name.
+const MaxNameLength = 255
+
+// reservedNamePrefix is reserved by GitHub for its own secrets and variables.
+const reservedNamePrefix = "GITHUB_"
+
+var namePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// sectionKinds describes the entries of each section in error messages.
+var sectionKinds = map[string]string{
+	SectionRepositorySecrets:     "repository secret",
+	SectionEnvironmentSecrets:    "environment secret",
+	SectionRepositoryVariables:   "repository variable",
+	SectionEnvironmentVariables:  "environment variable",
+	SectionCodespacesSecrets:     "codespaces secret",
+	SectionOrganizationSecrets:   "organization secret",
+	SectionOrganizationVariables: "organization variable",
+	SectionUserCodespacesSecrets: "user codespaces secret",
+}
+
+// validateName checks a secret or variable name against the GitHub naming
+// rules: letters, digits and underscores only, not starting with a digit or
+// the reserved GITHUB_ prefix.
+func validateName(key, kind, name string) error {
+	switch {
+	case name == "":
+		return keyError(key, "%s name cannot be empty", kind)
+	case len(name) > MaxNameLength:
+		return keyError(key, "%s name '%s' is too long (%d characters, maximum %d)", kind, name, len(name), MaxNameLength)
+	case name[0] >= '0' && name[0] <= '9':
+		return keyError(key, "%s name '%s' cannot start with a number", kind, name)
+	case !namePattern.MatchString(name):
+		return keyError(key, "%s name '%s' can only contain letters, numbers and underscores", kind, name)
+	case strings.HasPrefix(strings.ToUpper(name), reservedNamePrefix):
+		return keyError(key, "%s name '%s' cannot start with the reserved %s prefix", kind, name, reservedNamePrefix)
+	}
+	return nil
+}
+
+// validateNames checks the names of a section. GitHub names are
+// case-insensitive, so names that only differ in case are rejected too.
+func validateNames(keyFor func(name string) string, kind string, values map[string]string) error {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	seen := make(map[string]string, len(names))
+	for _, name := range names {
+		if err := validateName(keyFor(name), kind, name); err != nil {
+			return err
+		}
+		upper := strings.ToUpper(name)
+		if other, ok := seen[upper]; ok {
+			return keyError(keyFor(name), "%s names are case-insensitive: '%s' and '%s' refer to the same %s", kind, other, name, kind)
+		}
+		seen[upper] = name
+	}
+	return nil
+}
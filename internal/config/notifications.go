@@ -0,0 +1,195 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+)
+
+// SectionNotifications declares where the results of runs are posted.
+const SectionNotifications = "notifications"
+
+// Environment variables holding webhook URLs, which take precedence over
+// the webhook_url of their notification.
+const (
+	EnvSlackWebhookKey = "GAJIN_SLACK_WEBHOOK_URL"
+	EnvTeamsWebhookKey = "GAJIN_TEAMS_WEBHOOK_URL"
+)
+
+// When notifications are sent.
+const (
+	// NotifyAlways notifies after every run
+	NotifyAlways = "always"
+	// NotifyFailure notifies after runs that ended with errors
+	NotifyFailure = "failure"
+	// NotifyChange notifies after runs that failed, changed anything or,
+	// for dry runs, found drift
+	NotifyChange = "change"
+)
+
+// Notifications declares where the summary of every run is posted, e.g.
+//
+//	notifications:
+//	  slack:
+//	    webhook_url: https://hooks.slack.com/services/...
+//	    on: change
+//	  teams:
+//	    on: failure
+//	  webhooks:
+//	    - url: https://example.com/gajin
+//	      headers: { Authorization: Bearer ... }
+//
+// Profiles replace the notifications they configure, so that every
+// environment reports to its own channel.
+type Notifications struct {
+	Slack *ChatNotification `yaml:"slack"`
+	// Teams posts Adaptive Cards to a Microsoft Teams incoming webhook or workflow
+	Teams *ChatNotification `yaml:"teams"`
+	// Webhooks receive the summary as JSON
+	Webhooks []WebhookNotification `yaml:"webhooks"`
+}
+
+// ChatNotification posts run summaries to the incoming webhook of a chat service.
+type ChatNotification struct {
+	// WebhookURL is the incoming webhook URL; the environment variable of
+	// the service takes precedence, so that it need not be stored in the
+	// configuration file
+	WebhookURL string `yaml:"webhook_url"`
+	// On is "always", "failure" or "change" (default: always)
+	On string `yaml:"on"`
+}
+
+// WebhookNotification posts run summaries as JSON to a URL.
+type WebhookNotification struct {
+	URL string `yaml:"url"`
+	// Headers are added to the request, e.g. Authorization
+	Headers map[string]string `yaml:"headers"`
+	// On is "always", "failure" or "change" (default: always)
+	On string `yaml:"on"`
+}
+
+// OnOrDefault returns when the notification is sent.
+func (n *ChatNotification) OnOrDefault() string {
+	return notifyOnOrDefault(n.On)
+}
+
+// OnOrDefault returns when the notification is sent.
+func (n WebhookNotification) OnOrDefault() string {
+	return notifyOnOrDefault(n.On)
+}
+
+// notifyOnOrDefault returns on, or NotifyAlways if it is empty.
+func notifyOnOrDefault(on string) string {
+	if on == "" {
+		return NotifyAlways
+	}
+	return on
+}
+
+// IsEmpty reports whether no notification is configured.
+func (n Notifications) IsEmpty() bool {
+	return n.Slack == nil && n.Teams == nil && len(n.Webhooks) == 0
+}
+
+// merge applies the notifications of a profile, which replace the base ones.
+func (n *Notifications) merge(profile Notifications) {
+	if profile.Slack != nil {
+		n.Slack = profile.Slack
+	}
+	if profile.Teams != nil {
+		n.Teams = profile.Teams
+	}
+	if len(profile.Webhooks) > 0 {
+		n.Webhooks = profile.Webhooks
+	}
+}
+
+// chatTarget is a chat notification with the environment variable that
+// holds its webhook URL.
+type chatTarget struct {
+	name         string
+	env          string
+	notification **ChatNotification
+}
+
+// chatTargets returns the chat notifications of n, in a fixed order.
+func (n *Notifications) chatTargets() []chatTarget {
+	return []chatTarget{
+		{"slack", EnvSlackWebhookKey, &n.Slack},
+		{"teams", EnvTeamsWebhookKey, &n.Teams},
+	}
+}
+
+// applyWebhookEnv applies GAJIN_SLACK_WEBHOOK_URL and
+// GAJIN_TEAMS_WEBHOOK_URL, which enable their notification without a
+// section in the configuration file.
+func (c *Config) applyWebhookEnv() {
+	for _, target := range c.Notifications.chatTargets() {
+		webhookURL := os.Getenv(target.env)
+		if webhookURL == "" {
+			continue
+		}
+		if *target.notification == nil {
+			*target.notification = &ChatNotification{}
+		}
+		(*target.notification).WebhookURL = webhookURL
+		c.SetOrigin(SectionNotifications+"."+target.name+".webhook_url", SourceEnv, target.env)
+	}
+}
+
+// validate checks the notification targets.
+func (n *Notifications) validate() error {
+	for _, target := range n.chatTargets() {
+		chat := *target.notification
+		if chat == nil {
+			continue
+		}
+		key := SectionNotifications + "." + target.name
+		if chat.WebhookURL == "" {
+			return keyError(key+".webhook_url", "%s.webhook_url is required (can be set via the %s environment variable)", key, target.env)
+		}
+		if err := validateWebhookURL(key+".webhook_url", chat.WebhookURL); err != nil {
+			return err
+		}
+		if err := validateNotifyOn(key+".on", chat.OnOrDefault()); err != nil {
+			return err
+		}
+	}
+	for i, webhook := range n.Webhooks {
+		key := fmt.Sprintf("%s.webhooks[%d]", SectionNotifications, i)
+		if webhook.URL == "" {
+			return keyError(key+".url", "%s.url is required", key)
+		}
+		if err := validateWebhookURL(key+".url", webhook.URL); err != nil {
+			return err
+		}
+		for name := range webhook.Headers {
+			if name == "" {
+				return keyError(key+".headers", "%s.headers names cannot be empty", key)
+			}
+		}
+		if err := validateNotifyOn(key+".on", webhook.OnOrDefault()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateWebhookURL checks the webhook URL at key. The URL is not included
+// in errors, since it usually holds a secret token.
+func validateWebhookURL(key, webhookURL string) error {
+	u, err := url.Parse(webhookURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return keyError(key, "%s must be an http or https URL", key)
+	}
+	return nil
+}
+
+// validateNotifyOn checks when a notification at key is sent.
+func validateNotifyOn(key, on string) error {
+	switch on {
+	case NotifyAlways, NotifyFailure, NotifyChange:
+		return nil
+	}
+	return keyError(key, "%s must be 'always', 'failure' or 'change', got '%s'", key, on)
+}
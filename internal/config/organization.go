@@ -0,0 +1,161 @@
+package config
+
+import "sort"
+
+// Sections holding entries of the owner organization.
+const (
+	SectionOrganizationSecrets   = "organization_secrets"
+	SectionOrganizationVariables = "organization_variables"
+)
+
+// isOrganizationSection reports whether section holds organization entries.
+func isOrganizationSection(section string) bool {
+	return section == SectionOrganizationSecrets || section == SectionOrganizationVariables
+}
+
+// Organization entry visibilities.
+const (
+	VisibilityAll      = "all"
+	VisibilityPrivate  = "private"
+	VisibilitySelected = "selected"
+)
+
+// DefaultOrganizationVisibility is used for organization entries that do not
+// set a visibility: private and internal repositories can access them.
+const DefaultOrganizationVisibility = VisibilityPrivate
+
+// OrganizationEntry is an organization secret or variable with its access policy.
+type OrganizationEntry struct {
+	Name       string
+	Value      string
+	Visibility string
+	// SelectedRepos lists the repositories that can access the entry when
+	// Visibility is VisibilitySelected
+	SelectedRepos []string
+}
+
+// OrganizationSecretsList returns the organization secrets to set, sorted by name.
+func (c *Config) OrganizationSecretsList() []OrganizationEntry {
+	return c.organizationEntries(SectionOrganizationSecrets, c.OrganizationSecrets)
+}
+
+// OrganizationVariablesList returns the organization variables to set, sorted by name.
+func (c *Config) OrganizationVariablesList() []OrganizationEntry {
+	return c.organizationEntries(SectionOrganizationVariables, c.OrganizationVariables)
+}
+
+func (c *Config) organizationEntries(section string, values map[string]string) []OrganizationEntry {
+	entries := make([]OrganizationEntry, 0, len(values))
+	for name, value := range values {
+		entry := OrganizationEntry{Name: name, Value: value, Visibility: DefaultOrganizationVisibility}
+		if spec, ok := c.Specs[entryKey(section, "", name)]; ok {
+			if spec.Visibility != "" {
+				entry.Visibility = spec.Visibility
+			}
+			entry.SelectedRepos = spec.SelectedRepos
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+	return entries
+}
+
+// AbsentOrganizationSecrets returns the sorted names of the organization
+// secrets marked with state: absent.
+func (c *Config) AbsentOrganizationSecrets() []string {
+	return c.absentOrganizationEntries(SectionOrganizationSecrets)
+}
+
+// AbsentOrganizationVariables returns the sorted names of the organization
+// variables marked with state: absent.
+func (c *Config) AbsentOrganizationVariables() []string {
+	return c.absentOrganizationEntries(SectionOrganizationVariables)
+}
+
+func (c *Config) absentOrganizationEntries(section string) []string {
+	var names []string
+	for _, spec := range c.Specs {
+		if spec.Section == section && spec.State == StateAbsent {
+			names = append(names, spec.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// hasOrganizationResources reports whether organization-level or user-level
+// entries are configured.
+func (c *Config) hasOrganizationResources() bool {
+	if len(c.OrganizationSecrets) > 0 || len(c.OrganizationVariables) > 0 || len(c.UserCodespacesSecrets) > 0 {
+		return true
+	}
+	for _, spec := range c.Specs {
+		if isAccountSection(spec.Section) && spec.State == StateAbsent {
+			return true
+		}
+	}
+	return false
+}
+
+// validateOrganization checks the organization entries and their access policy.
+func (c *Config) validateOrganization() error {
+	for _, section := range []struct {
+		name   string
+		kind   string
+		values map[string]string
+	}{
+		{SectionOrganizationSecrets, "secret", c.OrganizationSecrets},
+		{SectionOrganizationVariables, "variable", c.OrganizationVariables},
+	} {
+		for name, value := range section.values {
+			key := entryKey(section.name, "", name)
+			if name == "" {
+				return keyError(key, "organization %s key cannot be empty", section.kind)
+			}
+			if value == "" {
+				return keyError(key, "organization %s value for '%s' cannot be empty", section.kind, name)
+			}
+		}
+		if err := validateNames(func(name string) string {
+			return entryKey(section.name, "", name)
+		}, sectionKinds[section.name], section.values); err != nil {
+			return err
+		}
+	}
+
+	for key, spec := range c.Specs {
+		if spec.Section == SectionUserCodespacesSecrets {
+			continue
+		}
+		if !isOrganizationSection(spec.Section) {
+			if spec.Visibility != "" || len(spec.SelectedRepos) > 0 {
+				return keyError(key, "%s: visibility and selected_repos can only be used in organization_secrets and organization_variables (selected_repos also in user_codespaces_secrets)", key)
+			}
+			continue
+		}
+
+		switch spec.Visibility {
+		case "", VisibilityAll, VisibilityPrivate:
+			if len(spec.SelectedRepos) > 0 {
+				return keyError(key, "%s: selected_repos requires visibility: selected", key)
+			}
+		case VisibilitySelected:
+			if len(spec.SelectedRepos) == 0 && spec.State != StateAbsent {
+				return keyError(key, "%s: visibility selected requires at least one repository in selected_repos", key)
+			}
+			for _, repo := range spec.SelectedRepos {
+				if repo == "" {
+					return keyError(key, "%s: selected_repos entries cannot be empty", key)
+				}
+			}
+		default:
+			return keyError(key, "%s: invalid visibility '%s' (expected all, private or selected)", key, spec.Visibility)
+		}
+		if len(spec.Repos) > 0 || len(spec.Environments) > 0 {
+			return keyError(key, "%s: repos and environments cannot be used in %s; use visibility and selected_repos", key, spec.Section)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ProfileNames returns the sorted names of the profiles defined in the configuration.
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// applyProfile overlays the named profile onto the base configuration.
+//
+// Profile entries replace base entries with the same key. A profile that
+// selects repositories (repos, all_repos, repos_by_topic or repos_by_team)
+// replaces the base selection entirely, and one with targets the base
+// targets.
+func (c *Config) applyProfile(name string) error {
+	if name == "" {
+		return nil
+	}
+
+	profile, ok := c.Profiles[name]
+	if !ok || profile == nil {
+		if len(c.Profiles) == 0 {
+			return fmt.Errorf("profile '%s' is not defined: the configuration has no profiles", name)
+		}
+		return fmt.Errorf("profile '%s' is not defined (available: %s)", name, strings.Join(c.ProfileNames(), ", "))
+	}
+	if len(profile.Profiles) > 0 {
+		return fmt.Errorf("profiles.%s: profiles cannot be nested", name)
+	}
+
+	// Profile keys take precedence over the base file
+	profileKeys := profile.Resolve()
+	for _, key := range profileKeys {
+		if !profile.hasKey(key.Key) {
+			continue
+		}
+		delete(c.Specs, key.Key)
+		c.SetOrigin(key.Key, SourceProfile, name)
+	}
+	for key, spec := range profile.Specs {
+		if c.Specs == nil {
+			c.Specs = make(map[string]*ValueSpec)
+		}
+		c.Specs[key] = spec
+	}
+
+	c.mergeGitHub(profile.GitHub)
+	c.Settings.merge(profile.Settings)
+	c.Doppler = append(c.Doppler, profile.Doppler...)
+	if len(profile.Targets) > 0 {
+		c.Targets = profile.Targets
+	}
+	c.Hooks.PreApply = append(c.Hooks.PreApply, profile.Hooks.PreApply...)
+	c.Hooks.PostApply = append(c.Hooks.PostApply, profile.Hooks.PostApply...)
+	c.Notifications.merge(profile.Notifications)
+	if profile.ActionsPermissions != nil {
+		c.ActionsPermissions = profile.ActionsPermissions
+	}
+	if profile.WorkflowPermissions != nil {
+		c.WorkflowPermissions = profile.WorkflowPermissions
+	}
+
+	global := c.Global()
+	global.merge(profile.Global())
+	c.setGlobal(global)
+	c.Defaults.merge(profile.Defaults)
+	c.OrganizationSecrets = mergeValues(c.OrganizationSecrets, profile.OrganizationSecrets)
+	c.OrganizationVariables = mergeValues(c.OrganizationVariables, profile.OrganizationVariables)
+	c.UserCodespacesSecrets = mergeValues(c.UserCodespacesSecrets, profile.UserCodespacesSecrets)
+	for name, env := range profile.Environments {
+		if c.Environments == nil {
+			c.Environments = make(map[string]EnvironmentSettings)
+		}
+		c.Environments[name] = env
+	}
+
+	for group, members := range profile.Groups {
+		if c.Groups == nil {
+			c.Groups = make(map[string][]string)
+		}
+		c.Groups[group] = members
+	}
+	c.GroupOverrides = mergeScoped(c.GroupOverrides, profile.GroupOverrides)
+	c.RepoOverrides = mergeScoped(c.RepoOverrides, profile.RepoOverrides)
+
+	for key, pos := range profile.positions {
+		if c.positions == nil {
+			c.positions = make(map[string]Position)
+		}
+		c.positions[key] = pos
+	}
+
+	c.Profile = name
+	return nil
+}
+
+// hasKey reports whether a key returned by Resolve was set explicitly.
+func (c *Config) hasKey(key string) bool {
+	switch key {
+	case "github.token":
+		return c.GitHub.Token != ""
+	case "github.owner":
+		return c.GitHub.Owner != ""
+	case "github.repos":
+		return len(c.GitHub.Repos) > 0
+	}
+	return true
+}
+
+// mergeGitHub overlays the non-empty fields of g onto the GitHub configuration.
+func (c *Config) mergeGitHub(g GitHubConfig) {
+	if g.Token != "" {
+		c.GitHub.Token = g.Token
+	}
+	if g.Owner != "" {
+		c.GitHub.Owner = g.Owner
+	}
+	if g.App != nil {
+		c.GitHub.App = g.App
+	}
+	if g.APIURL != "" {
+		c.GitHub.APIURL = g.APIURL
+		c.GitHub.UploadURL = g.UploadURL
+	}
+	if g.APIVersion != "" {
+		c.GitHub.APIVersion = g.APIVersion
+	}
+	if len(g.Repos) > 0 || g.HasDynamicRepos() {
+		c.GitHub.Repos = g.Repos
+		c.GitHub.AllRepos = g.AllRepos
+		c.GitHub.ReposByTopic = g.ReposByTopic
+		c.GitHub.ReposByTeam = g.ReposByTeam
+		for _, key := range []string{"github.repos", "github.all_repos", "github.repos_by_topic", "github.repos_by_team"} {
+			if c.Origin(key).Source == SourceFile {
+				delete(c.origins, key)
+			}
+		}
+	}
+	if len(g.ExcludeRepos) > 0 {
+		c.GitHub.ExcludeRepos = g.ExcludeRepos
+	}
+}
+
+// mergeScoped merges the Resources of src into dst by name.
+func mergeScoped(dst, src map[string]Resources) map[string]Resources {
+	for name, resources := range src {
+		if dst == nil {
+			dst = make(map[string]Resources)
+		}
+		merged := dst[name]
+		merged.merge(resources)
+		dst[name] = merged
+	}
+	return dst
+}
+
+// mergeValues copies the entries of src into dst.
+func mergeValues(dst, src map[string]string) map[string]string {
+	for name, value := range src {
+		if dst == nil {
+			dst = make(map[string]string)
+		}
+		dst[name] = value
+	}
+	return dst
+}
@@ -0,0 +1,310 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Source identifies the tier an effective configuration value came from.
+//
+// Tiers are applied in the following order, each one overriding the previous:
+//
+//	default < file < profile < env < keychain < flag
+type Source string
+
+const (
+	// SourceDefault is used for keys that were never set explicitly.
+	SourceDefault Source = "default"
+	// SourceFile is used for keys read from the configuration file.
+	SourceFile Source = "file"
+	// SourceProfile is used for keys set by the selected profile of the configuration file.
+	SourceProfile Source = "profile"
+	// SourceEnv is used for keys read from environment variables.
+	SourceEnv Source = "env"
+	// SourceKeychain is used for the token stored in the system keychain.
+	SourceKeychain Source = "keychain"
+	// SourceFlag is used for keys overridden by CLI flags.
+	SourceFlag Source = "flag"
+	// SourceCommand is used for file entries whose value comes from from_command.
+	SourceCommand Source = "command"
+	// SourceStore is used for file entries whose value comes from a secret store.
+	SourceStore Source = "store"
+	// SourceCredentials is used for a token stored by gajin login or the gh CLI.
+	SourceCredentials Source = "credentials"
+)
+
+// Origin records where a configuration key got its effective value.
+type Origin struct {
+	Source Source
+	Detail string // file path, profile, environment variable or flag name
+}
+
+func (o Origin) String() string {
+	if o.Detail == "" {
+		return string(o.Source)
+	}
+	return fmt.Sprintf("%s (%s)", o.Source, o.Detail)
+}
+
+// ResolvedKey is a single effective configuration key with its provenance.
+type ResolvedKey struct {
+	Key    string
+	Value  string
+	Secret bool
+	Origin Origin
+}
+
+// SetOrigin records the origin of a configuration key.
+func (c *Config) SetOrigin(key string, source Source, detail string) {
+	if c.origins == nil {
+		c.origins = make(map[string]Origin)
+	}
+	c.origins[key] = Origin{Source: source, Detail: detail}
+}
+
+// Origin returns the origin of a configuration key.
+func (c *Config) Origin(key string) Origin {
+	if origin, ok := c.origins[key]; ok {
+		return origin
+	}
+	return Origin{Source: SourceDefault}
+}
+
+// Resolve returns every effective configuration key sorted by key name.
+func (c *Config) Resolve() []ResolvedKey {
+	keys := []ResolvedKey{
+		{Key: "github.token", Value: c.GitHub.Token, Secret: true},
+		{Key: "github.owner", Value: c.GitHub.Owner},
+		{Key: "github.repos", Value: strings.Join(c.GitHub.Repos, ",")},
+	}
+	if c.GitHub.AllRepos {
+		keys = append(keys, ResolvedKey{Key: "github.all_repos", Value: "true"})
+	}
+	if len(c.GitHub.ReposByTopic) > 0 {
+		keys = append(keys, ResolvedKey{Key: "github.repos_by_topic", Value: strings.Join(c.GitHub.ReposByTopic, ",")})
+	}
+	if len(c.GitHub.ReposByTeam) > 0 {
+		keys = append(keys, ResolvedKey{Key: "github.repos_by_team", Value: strings.Join(c.GitHub.ReposByTeam, ",")})
+	}
+	if len(c.GitHub.ExcludeRepos) > 0 {
+		keys = append(keys, ResolvedKey{Key: "github.exclude_repos", Value: strings.Join(c.GitHub.ExcludeRepos, ",")})
+	}
+	for _, key := range []ResolvedKey{
+		{Key: "github.api_url", Value: c.GitHub.APIURL},
+		{Key: "github.upload_url", Value: c.GitHub.UploadURL},
+		{Key: "github.api_version", Value: c.GitHub.APIVersion},
+	} {
+		if key.Value != "" {
+			keys = append(keys, key)
+		}
+	}
+	if app := c.GitHub.App; app != nil {
+		keys = append(keys,
+			ResolvedKey{Key: "github.app.app_id", Value: strconv.FormatInt(app.AppID, 10)},
+			ResolvedKey{Key: "github.app.installation_id", Value: strconv.FormatInt(app.InstallationID, 10)},
+			ResolvedKey{Key: "github.app.private_key_path", Value: app.PrivateKeyPath},
+		)
+	}
+
+	if c.Settings.Concurrency.Repos != 0 {
+		keys = append(keys, ResolvedKey{Key: "settings.concurrency.repos", Value: strconv.Itoa(c.Settings.Concurrency.Repos)})
+	}
+	if c.Settings.Concurrency.Operations != 0 {
+		keys = append(keys, ResolvedKey{Key: "settings.concurrency.operations", Value: strconv.Itoa(c.Settings.Concurrency.Operations)})
+	}
+
+	if c.Settings.RateLimit.RequestsPerSecond != 0 {
+		keys = append(keys, ResolvedKey{Key: "settings.rate_limit.requests_per_second", Value: strconv.FormatFloat(c.Settings.RateLimit.RequestsPerSecond, 'g', -1, 64)})
+	}
+	if c.Settings.RateLimit.Burst != 0 {
+		keys = append(keys, ResolvedKey{Key: "settings.rate_limit.burst", Value: strconv.Itoa(c.Settings.RateLimit.Burst)})
+	}
+	if c.Settings.RateLimit.OnExhausted != "" {
+		keys = append(keys, ResolvedKey{Key: "settings.rate_limit.on_exhausted", Value: c.Settings.RateLimit.OnExhausted})
+	}
+	if c.Settings.RateLimit.PauseBelow != nil {
+		keys = append(keys, ResolvedKey{Key: "settings.rate_limit.pause_below", Value: strconv.Itoa(*c.Settings.RateLimit.PauseBelow)})
+	}
+
+	if c.Settings.Retry.MaxAttempts != 0 {
+		keys = append(keys, ResolvedKey{Key: "settings.retry.max_attempts", Value: strconv.Itoa(c.Settings.Retry.MaxAttempts)})
+	}
+	if c.Settings.Retry.InitialBackoff != 0 {
+		keys = append(keys, ResolvedKey{Key: "settings.retry.initial_backoff", Value: c.Settings.Retry.InitialBackoff.String()})
+	}
+	if c.Settings.Retry.MaxBackoff != 0 {
+		keys = append(keys, ResolvedKey{Key: "settings.retry.max_backoff", Value: c.Settings.Retry.MaxBackoff.String()})
+	}
+	if c.Settings.Retry.Jitter != nil {
+		keys = append(keys, ResolvedKey{Key: "settings.retry.jitter", Value: strconv.FormatFloat(*c.Settings.Retry.Jitter, 'g', -1, 64)})
+	}
+	if c.Settings.Retry.MaxWait != 0 {
+		keys = append(keys, ResolvedKey{Key: "settings.retry.max_wait", Value: c.Settings.Retry.MaxWait.String()})
+	}
+	if c.Settings.Retry.OperationRetries != nil {
+		keys = append(keys, ResolvedKey{Key: "settings.retry.operation_retries", Value: strconv.Itoa(*c.Settings.Retry.OperationRetries)})
+	}
+	if c.Settings.Cache.ETags != nil {
+		keys = append(keys, ResolvedKey{Key: "settings.cache.etags", Value: strconv.FormatBool(*c.Settings.Cache.ETags)})
+	}
+	if c.Settings.Cache.Dir != "" {
+		keys = append(keys, ResolvedKey{Key: "settings.cache.dir", Value: c.Settings.Cache.Dir})
+	}
+	if c.Settings.State.Enabled != nil {
+		keys = append(keys, ResolvedKey{Key: "settings.state.enabled", Value: strconv.FormatBool(*c.Settings.State.Enabled)})
+	}
+	if c.Settings.State.SkipUnchanged != nil {
+		keys = append(keys, ResolvedKey{Key: "settings.state.skip_unchanged", Value: strconv.FormatBool(*c.Settings.State.SkipUnchanged)})
+	}
+	if c.Settings.State.File != "" {
+		keys = append(keys, ResolvedKey{Key: "settings.state.file", Value: c.Settings.State.File})
+	}
+	if c.Settings.RequestTimeout != 0 {
+		keys = append(keys, ResolvedKey{Key: "settings.request_timeout", Value: c.Settings.RequestTimeout.String()})
+	}
+	if c.Settings.OperationTimeout != 0 {
+		keys = append(keys, ResolvedKey{Key: "settings.operation_timeout", Value: c.Settings.OperationTimeout.String()})
+	}
+	if c.Settings.ConfirmThreshold != 0 {
+		keys = append(keys, ResolvedKey{Key: "settings.confirm_threshold", Value: strconv.Itoa(c.Settings.ConfirmThreshold)})
+	}
+	if c.Settings.EnsureActionsEnabled {
+		keys = append(keys, ResolvedKey{Key: "settings.ensure_actions_enabled", Value: "true"})
+	}
+	if c.Settings.HTTP.Proxy != "" {
+		keys = append(keys, ResolvedKey{Key: "settings.http.proxy", Value: c.Settings.HTTP.Proxy, Secret: true})
+	}
+	if c.Settings.HTTP.CAFile != "" {
+		keys = append(keys, ResolvedKey{Key: "settings.http.ca_file", Value: c.Settings.HTTP.CAFile})
+	}
+	if c.Settings.Audit.File != "" {
+		keys = append(keys, ResolvedKey{Key: "settings.audit.file", Value: c.Settings.Audit.File})
+	}
+	if c.Settings.HTTP.InsecureSkipVerify {
+		keys = append(keys, ResolvedKey{Key: "settings.http.insecure_skip_verify", Value: "true"})
+	}
+
+	for name, env := range c.Environments {
+		prefix := SectionEnvironments + "." + name + "."
+		if env.WaitTimer != 0 {
+			keys = append(keys, ResolvedKey{Key: prefix + "wait_timer", Value: strconv.Itoa(env.WaitTimer)})
+		}
+		if len(env.Reviewers.Users) > 0 {
+			keys = append(keys, ResolvedKey{Key: prefix + "reviewers.users", Value: strings.Join(env.Reviewers.Users, ",")})
+		}
+		if len(env.Reviewers.Teams) > 0 {
+			keys = append(keys, ResolvedKey{Key: prefix + "reviewers.teams", Value: strings.Join(env.Reviewers.Teams, ",")})
+		}
+		if env.PreventSelfReview {
+			keys = append(keys, ResolvedKey{Key: prefix + "prevent_self_review", Value: "true"})
+		}
+		if len(env.Repos) > 0 {
+			keys = append(keys, ResolvedKey{Key: prefix + "repos", Value: strings.Join(env.Repos, ",")})
+		}
+	}
+
+	if p := c.ActionsPermissions; p != nil {
+		prefix := SectionActionsPermissions + "."
+		keys = append(keys, ResolvedKey{Key: prefix + "enabled", Value: strconv.FormatBool(p.IsEnabled())})
+		if p.AllowedActions != "" {
+			keys = append(keys, ResolvedKey{Key: prefix + "allowed_actions", Value: p.AllowedActions})
+		}
+		if p.GitHubOwnedAllowed {
+			keys = append(keys, ResolvedKey{Key: prefix + "github_owned_allowed", Value: "true"})
+		}
+		if p.VerifiedAllowed {
+			keys = append(keys, ResolvedKey{Key: prefix + "verified_allowed", Value: "true"})
+		}
+		if len(p.PatternsAllowed) > 0 {
+			keys = append(keys, ResolvedKey{Key: prefix + "patterns_allowed", Value: strings.Join(p.PatternsAllowed, ",")})
+		}
+		if len(p.Repos) > 0 {
+			keys = append(keys, ResolvedKey{Key: prefix + "repos", Value: strings.Join(p.Repos, ",")})
+		}
+	}
+
+	if p := c.WorkflowPermissions; p != nil {
+		prefix := SectionWorkflowPermissions + "."
+		keys = append(keys,
+			ResolvedKey{Key: prefix + "default", Value: p.Default},
+			ResolvedKey{Key: prefix + "can_approve_pull_requests", Value: strconv.FormatBool(p.CanApprovePullRequests)},
+		)
+		if len(p.Repos) > 0 {
+			keys = append(keys, ResolvedKey{Key: prefix + "repos", Value: strings.Join(p.Repos, ",")})
+		}
+	}
+
+	keys = appendResourceKeys(keys, "", c.Global())
+	for name, value := range c.OrganizationSecrets {
+		keys = append(keys, ResolvedKey{Key: entryKey(SectionOrganizationSecrets, "", name), Value: value, Secret: true})
+	}
+	for name, value := range c.OrganizationVariables {
+		keys = append(keys, ResolvedKey{Key: entryKey(SectionOrganizationVariables, "", name), Value: value})
+	}
+	for name, value := range c.UserCodespacesSecrets {
+		keys = append(keys, ResolvedKey{Key: entryKey(SectionUserCodespacesSecrets, "", name), Value: value, Secret: true})
+	}
+	for name, value := range c.Defaults.EnvironmentSecrets {
+		keys = append(keys, ResolvedKey{Key: scopePrefix(SectionDefaults) + entryKey(SectionEnvironmentSecrets, "", name), Value: value, Secret: true})
+	}
+	for name, value := range c.Defaults.EnvironmentVariables {
+		keys = append(keys, ResolvedKey{Key: scopePrefix(SectionDefaults) + entryKey(SectionEnvironmentVariables, "", name), Value: value})
+	}
+	for group, override := range c.GroupOverrides {
+		keys = appendResourceKeys(keys, scopePrefix(SectionGroupOverrides+"."+group), override)
+	}
+	for repo, override := range c.RepoOverrides {
+		keys = appendResourceKeys(keys, scopePrefix(SectionRepoOverrides+"."+repo), override)
+	}
+
+	for i := range keys {
+		keys[i].Origin = c.Origin(keys[i].Key)
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool {
+		return keys[i].Key < keys[j].Key
+	})
+	return keys
+}
+
+// appendResourceKeys appends every entry of r, prefixing keys with prefix.
+func appendResourceKeys(keys []ResolvedKey, prefix string, r Resources) []ResolvedKey {
+	for name, value := range r.RepositorySecrets {
+		keys = append(keys, ResolvedKey{Key: prefix + entryKey(SectionRepositorySecrets, "", name), Value: value, Secret: true})
+	}
+	for envName, secrets := range r.EnvironmentSecrets {
+		for name, value := range secrets {
+			keys = append(keys, ResolvedKey{Key: prefix + entryKey(SectionEnvironmentSecrets, envName, name), Value: value, Secret: true})
+		}
+	}
+	for name, value := range r.CodespacesSecrets {
+		keys = append(keys, ResolvedKey{Key: prefix + entryKey(SectionCodespacesSecrets, "", name), Value: value, Secret: true})
+	}
+	for name, value := range r.RepositoryVariables {
+		keys = append(keys, ResolvedKey{Key: prefix + entryKey(SectionRepositoryVariables, "", name), Value: value})
+	}
+	for envName, variables := range r.EnvironmentVariables {
+		for name, value := range variables {
+			keys = append(keys, ResolvedKey{Key: prefix + entryKey(SectionEnvironmentVariables, envName, name), Value: value})
+		}
+	}
+	return keys
+}
+
+// recordFileOrigins marks every key present after parsing as coming from the file.
+func (c *Config) recordFileOrigins(path string) {
+	for _, key := range c.Resolve() {
+		if key.Key == "github.token" && c.GitHub.Token == "" {
+			continue
+		}
+		if key.Key == "github.owner" && c.GitHub.Owner == "" {
+			continue
+		}
+		if key.Key == "github.repos" && len(c.GitHub.Repos) == 0 {
+			continue
+		}
+		c.SetOrigin(key.Key, SourceFile, path)
+	}
+}
@@ -0,0 +1,406 @@
+package config
+
+import (
+	"sort"
+)
+
+// Scoped sections hold Resources keyed by repository or group name.
+const (
+	SectionRepoOverrides  = "repo_overrides"
+	SectionGroupOverrides = "group_overrides"
+)
+
+// isScopedSection reports whether section holds Resources keyed by name.
+func isScopedSection(section string) bool {
+	return section == SectionRepoOverrides || section == SectionGroupOverrides
+}
+
+// scopedResources returns the Resources map of a scoped section.
+func (c *Config) scopedResources(section string) map[string]Resources {
+	switch section {
+	case SectionRepoOverrides:
+		return c.RepoOverrides
+	case SectionGroupOverrides:
+		return c.GroupOverrides
+	}
+	return nil
+}
+
+// Resources is a set of secrets and variables, as found at the top level of
+// the configuration or in a repo_overrides or group_overrides entry.
+type Resources struct {
+	RepositorySecrets    map[string]string            `yaml:"repository_secrets"`
+	EnvironmentSecrets   map[string]map[string]string `yaml:"environment_secrets"`
+	RepositoryVariables  map[string]string            `yaml:"repository_variables"`
+	EnvironmentVariables map[string]map[string]string `yaml:"environment_variables"`
+	CodespacesSecrets    map[string]string            `yaml:"codespaces_secrets"`
+}
+
+// IsEmpty reports whether no section contains any entry.
+func (r Resources) IsEmpty() bool {
+	return len(r.RepositorySecrets) == 0 && len(r.EnvironmentSecrets) == 0 &&
+		len(r.RepositoryVariables) == 0 && len(r.EnvironmentVariables) == 0 &&
+		len(r.CodespacesSecrets) == 0
+}
+
+// Len returns the number of entries in every section.
+func (r Resources) Len() int {
+	n := len(r.RepositorySecrets) + len(r.RepositoryVariables) + len(r.CodespacesSecrets)
+	for _, secrets := range r.EnvironmentSecrets {
+		n += len(secrets)
+	}
+	for _, variables := range r.EnvironmentVariables {
+		n += len(variables)
+	}
+	return n
+}
+
+// Global returns the top-level resources applied to every repository.
+func (c *Config) Global() Resources {
+	return Resources{
+		RepositorySecrets:    c.RepositorySecrets,
+		EnvironmentSecrets:   c.EnvironmentSecrets,
+		RepositoryVariables:  c.RepositoryVariables,
+		EnvironmentVariables: c.EnvironmentVariables,
+		CodespacesSecrets:    c.CodespacesSecrets,
+	}
+}
+
+// setGlobal replaces the top-level resources.
+func (c *Config) setGlobal(r Resources) {
+	c.RepositorySecrets = r.RepositorySecrets
+	c.EnvironmentSecrets = r.EnvironmentSecrets
+	c.RepositoryVariables = r.RepositoryVariables
+	c.EnvironmentVariables = r.EnvironmentVariables
+	c.CodespacesSecrets = r.CodespacesSecrets
+}
+
+// ResourcesFor returns the effective resources for a repository. Entries are
+// merged in order of increasing precedence:
+//
+//	global sections < group_overrides (by group name) < repo_overrides
+//
+// Defaults are then added to every environment that does not set them.
+//
+// The returned maps are copies and can be modified freely.
+func (c *Config) ResourcesFor(repo string) Resources {
+	var merged Resources
+	for _, layer := range c.layersFor(repo) {
+		merged.merge(c.scopeLayer(layer.scope, layer.resources, repo))
+		merged.remove(c.absentLayer(layer.scope, repo))
+	}
+	// Defaults are used unchanged, so applying them cannot fail
+	_ = c.applyDefaults(&merged, repo, func(_, _, _, value string) (string, error) {
+		return value, nil
+	})
+	return merged
+}
+
+// resourceLayer is the resources of a single scope, e.g. "repo_overrides.api".
+type resourceLayer struct {
+	scope     string
+	resources Resources
+}
+
+// layersFor returns the scopes that apply to a repository in order of
+// increasing precedence.
+func (c *Config) layersFor(repo string) []resourceLayer {
+	layers := []resourceLayer{{scope: "", resources: c.Global()}}
+	for _, group := range c.GroupsOf(repo) {
+		layers = append(layers, resourceLayer{scope: SectionGroupOverrides + "." + group, resources: c.GroupOverrides[group]})
+	}
+	if override, ok := c.RepoOverrides[repo]; ok {
+		layers = append(layers, resourceLayer{scope: SectionRepoOverrides + "." + repo, resources: override})
+	}
+	return layers
+}
+
+// AbsentFor returns the entries to delete from a repository (state: absent),
+// with empty values. An entry marked absent in one scope is kept if a scope of
+// higher precedence sets it again.
+func (c *Config) AbsentFor(repo string) Resources {
+	var absent Resources
+	for _, layer := range c.layersFor(repo) {
+		absent.remove(c.scopeLayer(layer.scope, layer.resources, repo))
+		absent.merge(c.absentLayer(layer.scope, repo))
+	}
+	return absent
+}
+
+// absentLayer returns the entries of a scope marked absent that apply to repo.
+func (c *Config) absentLayer(scope, repo string) Resources {
+	var absent Resources
+	for _, spec := range c.Specs {
+		if spec.State != StateAbsent || spec.Scope != scope || isAccountSection(spec.Section) || !spec.appliesToRepo(repo) {
+			continue
+		}
+		if len(spec.Environments) > 0 {
+			for _, envName := range spec.Environments {
+				absent.set(environmentSection(spec.Section), envName, spec.Name, "")
+			}
+			continue
+		}
+		absent.set(spec.Section, spec.Environment, spec.Name, "")
+	}
+	return absent
+}
+
+// scopeLayer applies the repos and environments restrictions of the entries
+// of a scope (see ValueSpec) for a repository.
+func (c *Config) scopeLayer(scope string, resources Resources, repo string) Resources {
+	var scoped Resources
+	_ = resources.each(func(section, environment, name, value string) error {
+		spec := c.Specs[scopePrefix(scope)+entryKey(section, environment, name)]
+		switch {
+		case spec == nil:
+			scoped.set(section, environment, name, value)
+		case !spec.appliesToRepo(repo):
+		case len(spec.Environments) > 0:
+			// A repository-level entry narrowed to environments
+			for _, envName := range spec.Environments {
+				scoped.set(environmentSection(section), envName, name, value)
+			}
+		default:
+			scoped.set(section, environment, name, value)
+		}
+		return nil
+	})
+	return scoped
+}
+
+// environmentSection returns the environment section matching a repository section.
+func environmentSection(section string) string {
+	if section == SectionRepositorySecrets {
+		return SectionEnvironmentSecrets
+	}
+	return SectionEnvironmentVariables
+}
+
+// GroupsOf returns the sorted names of the groups a repository belongs to.
+func (c *Config) GroupsOf(repo string) []string {
+	var groups []string
+	for group, members := range c.Groups {
+		for _, member := range members {
+			if member == repo {
+				groups = append(groups, group)
+				break
+			}
+		}
+	}
+	sort.Strings(groups)
+	return groups
+}
+
+// merge copies all entries of other into r, replacing existing entries.
+func (r *Resources) merge(other Resources) {
+	_ = other.each(func(section, environment, name, value string) error {
+		r.set(section, environment, name, value)
+		return nil
+	})
+}
+
+// remove deletes every entry of other from r.
+func (r *Resources) remove(other Resources) {
+	_ = other.each(func(section, environment, name, _ string) error {
+		switch section {
+		case SectionRepositorySecrets:
+			delete(r.RepositorySecrets, name)
+		case SectionRepositoryVariables:
+			delete(r.RepositoryVariables, name)
+		case SectionCodespacesSecrets:
+			delete(r.CodespacesSecrets, name)
+		case SectionEnvironmentSecrets:
+			delete(r.EnvironmentSecrets[environment], name)
+			if len(r.EnvironmentSecrets[environment]) == 0 {
+				delete(r.EnvironmentSecrets, environment)
+			}
+		case SectionEnvironmentVariables:
+			delete(r.EnvironmentVariables[environment], name)
+			if len(r.EnvironmentVariables[environment]) == 0 {
+				delete(r.EnvironmentVariables, environment)
+			}
+		}
+		return nil
+	})
+}
+
+// each calls fn for every entry, repository-level entries of a kind before
+// environment entries of the same kind, stopping at the first error.
+func (r Resources) each(fn func(section, environment, name, value string) error) error {
+	for name, value := range r.RepositorySecrets {
+		if err := fn(SectionRepositorySecrets, "", name, value); err != nil {
+			return err
+		}
+	}
+	for envName, secrets := range r.EnvironmentSecrets {
+		for name, value := range secrets {
+			if err := fn(SectionEnvironmentSecrets, envName, name, value); err != nil {
+				return err
+			}
+		}
+	}
+	for name, value := range r.RepositoryVariables {
+		if err := fn(SectionRepositoryVariables, "", name, value); err != nil {
+			return err
+		}
+	}
+	for envName, variables := range r.EnvironmentVariables {
+		for name, value := range variables {
+			if err := fn(SectionEnvironmentVariables, envName, name, value); err != nil {
+				return err
+			}
+		}
+	}
+	for name, value := range r.CodespacesSecrets {
+		if err := fn(SectionCodespacesSecrets, "", name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// set stores a value in the section map it belongs to.
+func (r *Resources) set(section, environment, name, value string) {
+	switch section {
+	case SectionRepositorySecrets:
+		if r.RepositorySecrets == nil {
+			r.RepositorySecrets = make(map[string]string)
+		}
+		r.RepositorySecrets[name] = value
+	case SectionRepositoryVariables:
+		if r.RepositoryVariables == nil {
+			r.RepositoryVariables = make(map[string]string)
+		}
+		r.RepositoryVariables[name] = value
+	case SectionCodespacesSecrets:
+		if r.CodespacesSecrets == nil {
+			r.CodespacesSecrets = make(map[string]string)
+		}
+		r.CodespacesSecrets[name] = value
+	case SectionEnvironmentSecrets:
+		if r.EnvironmentSecrets == nil {
+			r.EnvironmentSecrets = make(map[string]map[string]string)
+		}
+		if r.EnvironmentSecrets[environment] == nil {
+			r.EnvironmentSecrets[environment] = make(map[string]string)
+		}
+		r.EnvironmentSecrets[environment][name] = value
+	case SectionEnvironmentVariables:
+		if r.EnvironmentVariables == nil {
+			r.EnvironmentVariables = make(map[string]map[string]string)
+		}
+		if r.EnvironmentVariables[environment] == nil {
+			r.EnvironmentVariables[environment] = make(map[string]string)
+		}
+		r.EnvironmentVariables[environment][name] = value
+	}
+}
+
+// has reports whether an entry is set.
+func (r Resources) has(section, environment, name string) bool {
+	var ok bool
+	switch section {
+	case SectionRepositorySecrets:
+		_, ok = r.RepositorySecrets[name]
+	case SectionRepositoryVariables:
+		_, ok = r.RepositoryVariables[name]
+	case SectionCodespacesSecrets:
+		_, ok = r.CodespacesSecrets[name]
+	case SectionEnvironmentSecrets:
+		_, ok = r.EnvironmentSecrets[environment][name]
+	case SectionEnvironmentVariables:
+		_, ok = r.EnvironmentVariables[environment][name]
+	}
+	return ok
+}
+
+// validate checks that no key, environment name or value is empty. prefix is
+// the key prefix of the scope the resources belong to, used in KeyErrors.
+func (r Resources) validate(prefix string) error {
+	// Validate repository secrets
+	for key, value := range r.RepositorySecrets {
+		if key == "" {
+			return keyError(prefix+entryKey(SectionRepositorySecrets, "", key), "repository secret key cannot be empty")
+		}
+		if value == "" {
+			return keyError(prefix+entryKey(SectionRepositorySecrets, "", key), "repository secret value for '%s' cannot be empty", key)
+		}
+	}
+	if err := validateNames(func(name string) string {
+		return prefix + entryKey(SectionRepositorySecrets, "", name)
+	}, sectionKinds[SectionRepositorySecrets], r.RepositorySecrets); err != nil {
+		return err
+	}
+
+	// Validate environment secrets
+	for envName, secrets := range r.EnvironmentSecrets {
+		if envName == "" {
+			return keyError(prefix+SectionEnvironmentSecrets+".", "environment name cannot be empty")
+		}
+		for key, value := range secrets {
+			if key == "" {
+				return keyError(prefix+entryKey(SectionEnvironmentSecrets, envName, key), "environment secret key cannot be empty for environment '%s'", envName)
+			}
+			if value == "" {
+				return keyError(prefix+entryKey(SectionEnvironmentSecrets, envName, key), "environment secret value for '%s' in environment '%s' cannot be empty", key, envName)
+			}
+		}
+		if err := validateNames(func(name string) string {
+			return prefix + entryKey(SectionEnvironmentSecrets, envName, name)
+		}, sectionKinds[SectionEnvironmentSecrets], secrets); err != nil {
+			return err
+		}
+	}
+
+	// Validate Codespaces secrets
+	for key, value := range r.CodespacesSecrets {
+		if key == "" {
+			return keyError(prefix+entryKey(SectionCodespacesSecrets, "", key), "codespaces secret key cannot be empty")
+		}
+		if value == "" {
+			return keyError(prefix+entryKey(SectionCodespacesSecrets, "", key), "codespaces secret value for '%s' cannot be empty", key)
+		}
+	}
+	if err := validateNames(func(name string) string {
+		return prefix + entryKey(SectionCodespacesSecrets, "", name)
+	}, sectionKinds[SectionCodespacesSecrets], r.CodespacesSecrets); err != nil {
+		return err
+	}
+
+	// Validate repository variables
+	for key, value := range r.RepositoryVariables {
+		if key == "" {
+			return keyError(prefix+entryKey(SectionRepositoryVariables, "", key), "repository variable key cannot be empty")
+		}
+		if value == "" {
+			return keyError(prefix+entryKey(SectionRepositoryVariables, "", key), "repository variable value for '%s' cannot be empty", key)
+		}
+	}
+	if err := validateNames(func(name string) string {
+		return prefix + entryKey(SectionRepositoryVariables, "", name)
+	}, sectionKinds[SectionRepositoryVariables], r.RepositoryVariables); err != nil {
+		return err
+	}
+
+	// Validate environment variables
+	for envName, variables := range r.EnvironmentVariables {
+		if envName == "" {
+			return keyError(prefix+SectionEnvironmentVariables+".", "environment name cannot be empty")
+		}
+		for key, value := range variables {
+			if key == "" {
+				return keyError(prefix+entryKey(SectionEnvironmentVariables, envName, key), "environment variable key cannot be empty for environment '%s'", envName)
+			}
+			if value == "" {
+				return keyError(prefix+entryKey(SectionEnvironmentVariables, envName, key), "environment variable value for '%s' in environment '%s' cannot be empty", key, envName)
+			}
+		}
+		if err := validateNames(func(name string) string {
+			return prefix + entryKey(SectionEnvironmentVariables, envName, name)
+		}, sectionKinds[SectionEnvironmentVariables], variables); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,425 @@
+package config
+
+// SchemaID is the identifier of the configuration JSON Schema.
+const SchemaID = "https://github.com/azolfagharj/gajin/config.schema.json"
+
+// Schema returns a JSON Schema (draft 2020-12) describing the configuration
+// file. It can be used by editors to validate and complete configuration files.
+func Schema() map[string]interface{} {
+	ref := func(name string) map[string]interface{} {
+		return map[string]interface{}{"$ref": "#/$defs/" + name}
+	}
+	stringList := func(description string) map[string]interface{} {
+		return map[string]interface{}{
+			"description": description,
+			"type":        "array",
+			"items":       map[string]interface{}{"type": "string", "minLength": 1},
+		}
+	}
+	duration := func(description string) map[string]interface{} {
+		return map[string]interface{}{
+			"description": description,
+			"type":        "string",
+			"pattern":     `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`,
+		}
+	}
+
+	resourceProperties := map[string]interface{}{
+		SectionRepositorySecrets:    withDescription(ref("values"), "Repository-level secrets (encrypted)"),
+		SectionEnvironmentSecrets:   withDescription(ref("environmentValues"), "Environment-level secrets (encrypted), keyed by environment name"),
+		SectionRepositoryVariables:  withDescription(ref("values"), "Repository-level variables (plaintext)"),
+		SectionEnvironmentVariables: withDescription(ref("environmentValues"), "Environment-level variables (plaintext), keyed by environment name"),
+		SectionCodespacesSecrets:    withDescription(ref("values"), "Repository secrets available to Codespaces (encrypted)"),
+	}
+
+	configProperties := map[string]interface{}{
+		"github":        ref("github"),
+		SectionSettings: ref("settings"),
+		SectionEnvironments: map[string]interface{}{
+			"description":          "Protection rules of repository environments, keyed by environment name",
+			"type":                 "object",
+			"additionalProperties": ref("environment"),
+		},
+		SectionActionsPermissions:  ref("actionsPermissions"),
+		SectionWorkflowPermissions: ref("workflowPermissions"),
+		SectionDefaults: map[string]interface{}{
+			"description": "Entries inherited by every environment that does not set them",
+			"type":        "object",
+			"properties": map[string]interface{}{
+				SectionEnvironmentSecrets:   withDescription(ref("values"), "Secrets inherited by every environment"),
+				SectionEnvironmentVariables: withDescription(ref("values"), "Variables inherited by every environment"),
+			},
+			"additionalProperties": false,
+		},
+		"groups": map[string]interface{}{
+			"description":          "Named sets of repositories",
+			"type":                 "object",
+			"additionalProperties": stringList("Repositories of the group"),
+		},
+		SectionGroupOverrides: map[string]interface{}{
+			"description":          "Entries added to or replacing the global entries for the repositories of a group",
+			"type":                 "object",
+			"additionalProperties": ref("resources"),
+		},
+		SectionRepoOverrides: map[string]interface{}{
+			"description":          "Entries added to or replacing the global entries for a single repository",
+			"type":                 "object",
+			"additionalProperties": ref("resources"),
+		},
+	}
+	for section, schema := range resourceProperties {
+		configProperties[section] = schema
+	}
+	configProperties[SectionOrganizationSecrets] = map[string]interface{}{
+		"description":          "Secrets of the owner organization (encrypted)",
+		"type":                 "object",
+		"additionalProperties": ref("value"),
+	}
+	configProperties[SectionOrganizationVariables] = map[string]interface{}{
+		"description":          "Variables of the owner organization (plaintext)",
+		"type":                 "object",
+		"additionalProperties": ref("value"),
+	}
+
+	configProperties[SectionUserCodespacesSecrets] = map[string]interface{}{
+		"description":          "Codespaces secrets of the authenticated user (encrypted)",
+		"type":                 "object",
+		"additionalProperties": ref("value"),
+	}
+
+	configProperties[SectionTargets] = map[string]interface{}{
+		"description": "Other owners whose repositories are processed by the same run, each with its own token",
+		"type":        "array",
+		"items": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"owner":     map[string]interface{}{"type": "string", "description": "Organization or user owning the repositories"},
+				"token_env": map[string]interface{}{"type": "string", "description": "Environment variable holding the token of the owner (default: the credentials of github)"},
+				"repos":     stringList("Repositories of the owner to process"),
+			},
+			"required":             []interface{}{"owner"},
+			"additionalProperties": false,
+		},
+	}
+
+	configProperties[SectionDoppler] = map[string]interface{}{
+		"description": "Doppler configs whose secrets are imported into the global sections",
+		"type":        "array",
+		"items": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"project": map[string]interface{}{"type": "string", "description": "Doppler project"},
+				"config":  map[string]interface{}{"type": "string", "description": "Doppler config, e.g. prd"},
+				"section": map[string]interface{}{
+					"enum":        []interface{}{SectionRepositorySecrets, SectionEnvironmentSecrets, SectionRepositoryVariables, SectionEnvironmentVariables, SectionCodespacesSecrets},
+					"description": "Section receiving the secrets (default: repository_secrets)",
+				},
+				"environment":  map[string]interface{}{"type": "string", "description": "Target environment of environment sections"},
+				"include":      stringList("Glob patterns of the secret names to import"),
+				"exclude":      stringList("Glob patterns of the secret names to skip"),
+				"rename":       map[string]interface{}{"type": "object", "additionalProperties": map[string]interface{}{"type": "string"}, "description": "Doppler names mapped to GitHub names"},
+				"strip_prefix": map[string]interface{}{"type": "string", "description": "Prefix removed from the names that are not renamed"},
+				"add_prefix":   map[string]interface{}{"type": "string", "description": "Prefix added to the names that are not renamed"},
+			},
+			"additionalProperties": false,
+		},
+	}
+
+	hook := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"run":        map[string]interface{}{"type": "string", "description": "Command run by the system shell"},
+			"on_failure": map[string]interface{}{"enum": []interface{}{HookAbort, HookReport}, "description": "abort (default) fails the run, report reports the failure and continues"},
+			"timeout":    duration("Timeout of the command, e.g. 2m (default: --command-timeout)"),
+			"dir":        map[string]interface{}{"type": "string", "description": "Working directory, relative to the configuration file"},
+		},
+		"required":             []interface{}{"run"},
+		"additionalProperties": false,
+	}
+	configProperties[SectionHooks] = map[string]interface{}{
+		"description": "Commands run before and after the configuration is applied; require --allow-commands",
+		"type":        "object",
+		"properties": map[string]interface{}{
+			"pre_apply":  map[string]interface{}{"type": "array", "items": hook, "description": "Run before anything is applied"},
+			"post_apply": map[string]interface{}{"type": "array", "items": hook, "description": "Run after the repositories were processed"},
+		},
+		"additionalProperties": false,
+	}
+
+	notifyOn := map[string]interface{}{
+		"enum":        []interface{}{NotifyAlways, NotifyFailure, NotifyChange},
+		"description": "always (default), failure, or change: failures, changes or drift",
+	}
+	chat := func(description string) map[string]interface{} {
+		return map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"webhook_url": map[string]interface{}{"type": "string", "description": description},
+				"on":          notifyOn,
+			},
+			"additionalProperties": false,
+		}
+	}
+	configProperties[SectionNotifications] = map[string]interface{}{
+		"description": "Where the summary of every run is posted; profiles replace the notifications they configure",
+		"type":        "object",
+		"properties": map[string]interface{}{
+			"slack": chat("Slack incoming webhook URL (or " + EnvSlackWebhookKey + ")"),
+			"teams": chat("Microsoft Teams incoming webhook or workflow URL (or " + EnvTeamsWebhookKey + ")"),
+			"webhooks": map[string]interface{}{
+				"type":        "array",
+				"description": "URLs receiving the summary as JSON",
+				"items": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"url":     map[string]interface{}{"type": "string", "description": "Webhook URL"},
+						"headers": map[string]interface{}{"type": "object", "additionalProperties": map[string]interface{}{"type": "string"}, "description": "Headers added to the request, e.g. Authorization"},
+						"on":      notifyOn,
+					},
+					"required":             []interface{}{"url"},
+					"additionalProperties": false,
+				},
+			},
+		},
+		"additionalProperties": false,
+	}
+
+	profileProperties := make(map[string]interface{}, len(configProperties))
+	for key, schema := range configProperties {
+		profileProperties[key] = schema
+	}
+	configProperties["profiles"] = map[string]interface{}{
+		"description": "Named variants of the configuration, selected with --profile",
+		"type":        "object",
+		"additionalProperties": map[string]interface{}{
+			"type":                 "object",
+			"properties":           profileProperties,
+			"additionalProperties": false,
+		},
+	}
+
+	return map[string]interface{}{
+		"$schema":              "https://json-schema.org/draft/2020-12/schema",
+		"$id":                  SchemaID,
+		"title":                "gajin configuration",
+		"type":                 "object",
+		"properties":           configProperties,
+		"additionalProperties": false,
+		"$defs": map[string]interface{}{
+			"github": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"token":          map[string]interface{}{"type": "string", "description": "GitHub token (GH_TOKEN_WITH_ACTIONS_WRITE takes precedence)"},
+					"owner":          map[string]interface{}{"type": "string", "description": "Repository owner (user or organization)"},
+					"repos":          stringList("Repositories to process, as name or owner/name"),
+					"all_repos":      map[string]interface{}{"type": "boolean", "description": "Target every non-archived repository of the owner"},
+					"repos_by_topic": stringList("Add the owner's repositories tagged with any of these topics"),
+					"repos_by_team": map[string]interface{}{
+						"description": "Add the repositories these organization teams can access",
+						"oneOf": []interface{}{
+							map[string]interface{}{"type": "string", "minLength": 1},
+							stringList("Team slugs"),
+						},
+					},
+					"exclude_repos": stringList("Repositories removed from the targets"),
+					"api_url":       map[string]interface{}{"type": "string", "format": "uri", "description": "REST API URL of a GitHub Enterprise Server, e.g. https://github.example.com/api/v3/"},
+					"upload_url":    map[string]interface{}{"type": "string", "format": "uri", "description": "Upload URL of a GitHub Enterprise Server (default: api_url)"},
+					"api_version": map[string]interface{}{
+						"type":        "string",
+						"pattern":     `^\d{4}-\d{2}-\d{2}$`,
+						"description": "X-GitHub-Api-Version header sent with every request, e.g. 2022-11-28",
+					},
+					"app": map[string]interface{}{
+						"description": "Authenticate as a GitHub App installation instead of with a token",
+						"type":        "object",
+						"properties": map[string]interface{}{
+							"app_id":           map[string]interface{}{"type": "integer", "minimum": 1, "description": "GitHub App ID"},
+							"installation_id":  map[string]interface{}{"type": "integer", "minimum": 1, "description": "Installation ID of the app on the owner"},
+							"private_key_path": map[string]interface{}{"type": "string", "minLength": 1, "description": "PEM private key of the app, relative to the configuration file"},
+						},
+						"required":             []interface{}{"app_id", "installation_id", "private_key_path"},
+						"additionalProperties": false,
+					},
+				},
+				"additionalProperties": false,
+			},
+			"settings": map[string]interface{}{
+				"description": "Options that tune how the configuration is applied",
+				"type":        "object",
+				"properties": map[string]interface{}{
+					"concurrency": map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"repos":      map[string]interface{}{"type": "integer", "minimum": 1, "description": "Maximum number of repositories processed in parallel (default: 5)"},
+							"operations": map[string]interface{}{"type": "integer", "minimum": 1, "description": "Maximum number of entries applied in parallel within a repository (default: 1)"},
+						},
+						"additionalProperties": false,
+					},
+					"rate_limit": map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"requests_per_second": map[string]interface{}{"type": "number", "minimum": 0, "description": "Sustained rate of GitHub API requests (0: no limit)"},
+							"burst":               map[string]interface{}{"type": "integer", "minimum": 0, "description": "Number of requests allowed at once (default: requests_per_second rounded up)"},
+							"on_exhausted":        map[string]interface{}{"enum": []interface{}{RateLimitWait, RateLimitFail}, "description": "Wait for the limit (default) or fail requests exceeding it"},
+							"pause_below":         map[string]interface{}{"type": "integer", "minimum": 0, "description": "Pause requests until GitHub's rate limit resets when at most this many remain (default: 10)"},
+						},
+						"additionalProperties": false,
+					},
+					"retry": map[string]interface{}{
+						"description": "Retries of requests failing with 5xx responses, connection resets or secondary rate limits",
+						"type":        "object",
+						"properties": map[string]interface{}{
+							"max_attempts":      map[string]interface{}{"type": "integer", "minimum": 1, "description": "Attempts per request including the first (default: 3; 1 disables retries)"},
+							"initial_backoff":   duration("Delay before the first retry, doubled for every further retry (default: 1s)"),
+							"max_backoff":       duration("Maximum delay between two attempts (default: 30s)"),
+							"jitter":            map[string]interface{}{"type": "number", "minimum": 0, "maximum": 1, "description": "Fraction by which every delay is randomized (default: 0.2)"},
+							"max_wait":          duration("Longest total wait for rate limits as instructed by GitHub (default: 15m)"),
+							"operation_retries": map[string]interface{}{"type": "integer", "minimum": 0, "description": "Retries of a secret or variable write failing with a transient error, after the retries of its requests (default: 2; 0 disables them)"},
+						},
+						"additionalProperties": false,
+					},
+					"cache": map[string]interface{}{
+						"description": "Local cache of GitHub API responses",
+						"type":        "object",
+						"properties": map[string]interface{}{
+							"etags": map[string]interface{}{"type": "boolean", "description": "Revalidate cached GET responses with their ETags (default: true)"},
+							"dir":   map[string]interface{}{"type": "string", "minLength": 1, "description": "Cache directory, relative to the configuration file (default: gajin in the user cache directory)"},
+						},
+						"additionalProperties": false,
+					},
+					"state": map[string]interface{}{
+						"description": "State file recording the applied secrets and variables with salted hashes of their values",
+						"type":        "object",
+						"properties": map[string]interface{}{
+							"enabled":        map[string]interface{}{"type": "boolean", "description": "Keep the state file (default: true)"},
+							"skip_unchanged": map[string]interface{}{"type": "boolean", "description": "Skip secrets and variables whose value did not change (default: true)"},
+							"file":           map[string]interface{}{"type": "string", "minLength": 1, "description": "State file, relative to the configuration file (default: state.json in the cache directory)"},
+						},
+						"additionalProperties": false,
+					},
+					"audit": map[string]interface{}{
+						"description": "Audit log recording every change made on GitHub, without the values of secrets and variables",
+						"type":        "object",
+						"properties": map[string]interface{}{
+							"file": map[string]interface{}{"type": "string", "minLength": 1, "description": "Audit log file (JSON Lines) to append to, relative to the configuration file"},
+						},
+						"additionalProperties": false,
+					},
+					"request_timeout":        duration("Timeout of every GitHub API request attempt (default: 30s)"),
+					"operation_timeout":      duration("Deadline of every secret and variable operation, including retries and rate limit waits (default: 5m)"),
+					"ensure_actions_enabled": map[string]interface{}{"type": "boolean", "description": "Enable GitHub Actions on target repositories where it is disabled instead of skipping them"},
+					"confirm_threshold":      map[string]interface{}{"type": "integer", "minimum": 0, "description": "Number of secrets and variables to set above which interactive runs ask for confirmation (default: 50)"},
+					"http": map[string]interface{}{
+						"description": "Connection to the GitHub API",
+						"type":        "object",
+						"properties": map[string]interface{}{
+							"proxy":                map[string]interface{}{"type": "string", "format": "uri", "description": "Proxy URL for API requests (default: HTTPS_PROXY and related variables)"},
+							"ca_file":              map[string]interface{}{"type": "string", "minLength": 1, "description": "PEM bundle of additional trusted CA certificates, relative to the configuration file"},
+							"insecure_skip_verify": map[string]interface{}{"type": "boolean", "description": "Disable TLS certificate verification (unsafe)"},
+						},
+						"additionalProperties": false,
+					},
+				},
+				"additionalProperties": false,
+			},
+			"environment": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"wait_timer": map[string]interface{}{"type": "integer", "minimum": 0, "maximum": MaxWaitTimer, "description": "Minutes to wait before jobs referencing the environment run"},
+					"reviewers": map[string]interface{}{
+						"description": "Required reviewers of jobs referencing the environment",
+						"type":        "object",
+						"properties": map[string]interface{}{
+							"users": stringList("User logins"),
+							"teams": stringList("Team slugs of the owner organization"),
+						},
+						"additionalProperties": false,
+					},
+					"prevent_self_review": map[string]interface{}{"type": "boolean", "description": "Prevent users from approving runs they triggered"},
+					"repos":               stringList("Restrict the settings to these repositories"),
+				},
+				"additionalProperties": false,
+			},
+			"actionsPermissions": map[string]interface{}{
+				"description": "GitHub Actions policy of the target repositories",
+				"type":        "object",
+				"properties": map[string]interface{}{
+					"enabled":              map[string]interface{}{"type": "boolean", "description": "Enable GitHub Actions (default: true)"},
+					"allowed_actions":      map[string]interface{}{"enum": []interface{}{AllowedActionsAll, AllowedActionsLocalOnly, AllowedActionsSelected}, "description": "Actions and reusable workflows workflows may use (default: all)"},
+					"github_owned_allowed": map[string]interface{}{"type": "boolean", "description": "Allow actions created by GitHub (selected only)"},
+					"verified_allowed":     map[string]interface{}{"type": "boolean", "description": "Allow actions by verified Marketplace creators (selected only)"},
+					"patterns_allowed":     stringList("Allowed actions and reusable workflows, e.g. my-org/* (selected only)"),
+					"repos":                stringList("Restrict the policy to these repositories"),
+				},
+				"additionalProperties": false,
+			},
+			"workflowPermissions": map[string]interface{}{
+				"description": "Default GITHUB_TOKEN permissions of workflows in the target repositories",
+				"type":        "object",
+				"properties": map[string]interface{}{
+					"default":                   map[string]interface{}{"enum": []interface{}{WorkflowPermissionsRead, WorkflowPermissionsWrite}, "description": "read (contents and packages) or write (all scopes)"},
+					"can_approve_pull_requests": map[string]interface{}{"type": "boolean", "description": "Allow workflows to create and approve pull requests"},
+					"repos":                     stringList("Restrict the permissions to these repositories"),
+				},
+				"required":             []interface{}{"default"},
+				"additionalProperties": false,
+			},
+			"resources": map[string]interface{}{
+				"type":                 "object",
+				"properties":           resourceProperties,
+				"additionalProperties": false,
+			},
+			"values": map[string]interface{}{
+				"type":                 "object",
+				"additionalProperties": ref("value"),
+			},
+			"environmentValues": map[string]interface{}{
+				"type":                 "object",
+				"additionalProperties": ref("values"),
+			},
+			"value": map[string]interface{}{
+				"oneOf": []interface{}{
+					map[string]interface{}{"type": []interface{}{"string", "number", "boolean"}},
+					ref("valueSpec"),
+				},
+			},
+			"valueSpec": map[string]interface{}{
+				"description": "Structured value; only one value source (value, from_file, from_env, from_command, encrypted or a secret store reference) can be set",
+				"type":        "object",
+				"properties": map[string]interface{}{
+					"value":                map[string]interface{}{"type": "string"},
+					"from_file":            map[string]interface{}{"type": "string", "description": "Path of a file holding the value, relative to the configuration file"},
+					"from_env":             map[string]interface{}{"type": "string", "description": "Environment variable holding the value"},
+					"from_command":         map[string]interface{}{"type": "string", "description": "Shell command printing the value (requires --allow-commands)"},
+					"encrypted":            map[string]interface{}{"type": "string", "description": "age-encrypted value, ASCII-armored or base64 (requires --identity)"},
+					StoreAWSSecretsManager: map[string]interface{}{"type": "string", "description": "AWS Secrets Manager secret name or ARN, optionally followed by #key to select a key of a JSON secret"},
+					StoreGCPSecretManager:  map[string]interface{}{"type": "string", "description": "GCP Secret Manager resource name: projects/PROJECT/secrets/NAME[/versions/VERSION]"},
+					StoreAzureKeyVault:     map[string]interface{}{"type": "string", "description": "Azure Key Vault secret identifier: https://VAULT.vault.azure.net/secrets/NAME[/VERSION]"},
+					StoreOnePassword:       map[string]interface{}{"type": "string", "pattern": "^op://", "description": "1Password secret reference op://vault/item/field"},
+					"repos":                stringList("Restrict the entry to these repositories"),
+					"environments":         stringList("Set a repository-level entry in these environments instead, or restrict a default to them"),
+					"state": map[string]interface{}{
+						"description": "absent deletes the entry from the targeted repositories",
+						"enum":        []interface{}{StatePresent, StateAbsent},
+					},
+					"visibility": map[string]interface{}{
+						"description": "Repositories that can access an organization secret or variable (default: private)",
+						"enum":        []interface{}{VisibilityAll, VisibilityPrivate, VisibilitySelected},
+					},
+					"selected_repos": stringList("Repositories that can access an organization secret or variable with visibility selected, or a user Codespaces secret"),
+				},
+				"additionalProperties": false,
+			},
+		},
+	}
+}
+
+// withDescription returns a copy of schema with a description.
+func withDescription(schema map[string]interface{}, description string) map[string]interface{} {
+	result := make(map[string]interface{}, len(schema)+1)
+	for key, value := range schema {
+		result[key] = value
+	}
+	result["description"] = description
+	return result
+}
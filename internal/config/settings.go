@@ -0,0 +1,461 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// SectionSettings holds options that tune how the configuration is applied.
+const SectionSettings = "settings"
+
+// DefaultConcurrentRepos is the number of repositories processed in parallel
+// when settings.concurrency.repos is not set.
+const DefaultConcurrentRepos = 5
+
+// DefaultConcurrentOperations is the number of entries applied in parallel
+// within a repository when settings.concurrency.operations is not set.
+const DefaultConcurrentOperations = 1
+
+// Defaults of settings.retry.
+const (
+	DefaultRetryAttempts       = 3
+	DefaultRetryInitialBackoff = time.Second
+	DefaultRetryMaxBackoff     = 30 * time.Second
+	DefaultRetryJitter         = 0.2
+	DefaultRetryMaxWait        = 15 * time.Minute
+	// DefaultOperationRetries is the number of times a failed secret or
+	// variable write is retried as a whole, after the retries of its requests
+	DefaultOperationRetries = 2
+)
+
+// DefaultRequestTimeout bounds every GitHub API request attempt when
+// settings.request_timeout is not set.
+const DefaultRequestTimeout = 30 * time.Second
+
+// DefaultConfirmThreshold is the number of secrets and variables above which
+// interactive runs ask for confirmation when settings.confirm_threshold is not set.
+const DefaultConfirmThreshold = 50
+
+// DefaultOperationTimeout bounds every secret and variable operation, including
+// its retries, when settings.operation_timeout is not set.
+const DefaultOperationTimeout = 5 * time.Minute
+
+// DefaultRateLimitPauseBelow is the number of remaining primary rate limit
+// requests at or below which requests pause until the limit resets.
+const DefaultRateLimitPauseBelow = 10
+
+// Behaviors when the rate limit is exhausted.
+const (
+	RateLimitWait = "wait"
+	RateLimitFail = "fail"
+)
+
+// Settings tunes how the configuration is applied.
+type Settings struct {
+	Concurrency ConcurrencySettings `yaml:"concurrency"`
+	RateLimit   RateLimitSettings   `yaml:"rate_limit"`
+	Retry       RetrySettings       `yaml:"retry"`
+	Cache       CacheSettings       `yaml:"cache"`
+	State       StateSettings       `yaml:"state"`
+	HTTP        HTTPSettings        `yaml:"http"`
+	Audit       AuditSettings       `yaml:"audit"`
+	// RequestTimeout bounds every API request attempt (default: DefaultRequestTimeout)
+	RequestTimeout time.Duration `yaml:"request_timeout"`
+	// OperationTimeout bounds every secret and variable operation, including
+	// its retries and rate limit waits (default: DefaultOperationTimeout)
+	OperationTimeout time.Duration `yaml:"operation_timeout"`
+	// EnsureActionsEnabled enables GitHub Actions on target repositories where it
+	// is disabled instead of skipping them
+	EnsureActionsEnabled bool `yaml:"ensure_actions_enabled"`
+	// ConfirmThreshold is the number of secrets and variables to set above which
+	// interactive runs ask for confirmation; any deletion always asks
+	// (default: DefaultConfirmThreshold)
+	ConfirmThreshold int `yaml:"confirm_threshold"`
+}
+
+// ConfirmThresholdOrDefault returns the number of secrets and variables to set
+// above which interactive runs ask for confirmation.
+func (s Settings) ConfirmThresholdOrDefault() int {
+	if s.ConfirmThreshold == 0 {
+		return DefaultConfirmThreshold
+	}
+	return s.ConfirmThreshold
+}
+
+// RequestTimeoutOrDefault returns the timeout of every API request attempt.
+func (s Settings) RequestTimeoutOrDefault() time.Duration {
+	if s.RequestTimeout == 0 {
+		return DefaultRequestTimeout
+	}
+	return s.RequestTimeout
+}
+
+// OperationTimeoutOrDefault returns the deadline of every secret and variable operation.
+func (s Settings) OperationTimeoutOrDefault() time.Duration {
+	if s.OperationTimeout == 0 {
+		return DefaultOperationTimeout
+	}
+	return s.OperationTimeout
+}
+
+// HTTPSettings configures the connection to the GitHub API, e.g. behind a
+// corporate proxy with TLS interception.
+type HTTPSettings struct {
+	// Proxy is the URL of the proxy for API requests (default: HTTPS_PROXY and related variables)
+	Proxy string `yaml:"proxy"`
+	// CAFile is a PEM bundle of additional trusted CA certificates, relative to the configuration file
+	CAFile string `yaml:"ca_file"`
+	// InsecureSkipVerify disables TLS certificate verification
+	InsecureSkipVerify bool `yaml:"insecure_skip_verify"`
+}
+
+// CacheSettings controls the local cache of GitHub API responses.
+type CacheSettings struct {
+	// ETags revalidates cached GET responses with If-None-Match (default: true)
+	ETags *bool `yaml:"etags"`
+	// Dir is the cache directory, relative to the configuration file
+	// (default: gajin in the user cache directory)
+	Dir string `yaml:"dir"`
+}
+
+// ETagsEnabled reports whether GET responses are cached with their ETags.
+func (c CacheSettings) ETagsEnabled() bool {
+	return c.ETags == nil || *c.ETags
+}
+
+// Directory returns the cache directory.
+func (c CacheSettings) Directory() (string, error) {
+	if c.Dir != "" {
+		return c.Dir, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate the user cache directory: %w", err)
+	}
+	return filepath.Join(dir, "gajin"), nil
+}
+
+// StateSettings controls the state file, which records the secrets and
+// variables gajin applied with salted hashes of their values and timestamps.
+type StateSettings struct {
+	// Enabled keeps the state file (default: true)
+	Enabled *bool `yaml:"enabled"`
+	// SkipUnchanged skips secrets and variables whose value did not change (default: true)
+	SkipUnchanged *bool `yaml:"skip_unchanged"`
+	// File is the state file, relative to the configuration file
+	// (default: state.json in the cache directory)
+	File string `yaml:"file"`
+}
+
+// IsEnabled reports whether the state file is kept.
+func (s StateSettings) IsEnabled() bool {
+	return s.Enabled == nil || *s.Enabled
+}
+
+// SkipUnchangedEnabled reports whether unchanged values are skipped.
+func (s StateSettings) SkipUnchangedEnabled() bool {
+	return s.SkipUnchanged == nil || *s.SkipUnchanged
+}
+
+// Path returns the state file, in the directory of cache unless configured.
+func (s StateSettings) Path(cache CacheSettings) (string, error) {
+	if s.File != "" {
+		return s.File, nil
+	}
+	dir, err := cache.Directory()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "state.json"), nil
+}
+
+// AuditSettings controls the audit log, which records every change made on
+// GitHub without the values of secrets and variables.
+type AuditSettings struct {
+	// File is the audit log, relative to the configuration file (empty: no audit log)
+	File string `yaml:"file"`
+}
+
+// IsEnabled reports whether changes are recorded in the audit log.
+func (a AuditSettings) IsEnabled() bool {
+	return a.File != ""
+}
+
+// ConcurrencySettings limits the number of parallel API operations.
+type ConcurrencySettings struct {
+	// Repos is the maximum number of repositories processed in parallel (default: DefaultConcurrentRepos)
+	Repos int `yaml:"repos"`
+	// Operations is the maximum number of entries applied in parallel within a repository
+	Operations int `yaml:"operations"`
+}
+
+// RateLimitSettings limits the rate of GitHub API requests across the whole run.
+type RateLimitSettings struct {
+	// RequestsPerSecond is the sustained request rate (0: no limit)
+	RequestsPerSecond float64 `yaml:"requests_per_second"`
+	// Burst is the number of requests allowed at once (default: requests_per_second rounded up)
+	Burst int `yaml:"burst"`
+	// OnExhausted is RateLimitWait (default) to delay requests or RateLimitFail to fail them
+	OnExhausted string `yaml:"on_exhausted"`
+	// PauseBelow pauses requests until GitHub's primary rate limit resets when at
+	// most this many requests remain (default: DefaultRateLimitPauseBelow)
+	PauseBelow *int `yaml:"pause_below"`
+}
+
+// PauseThreshold returns the remaining request count at which requests pause.
+func (r RateLimitSettings) PauseThreshold() int {
+	if r.PauseBelow == nil {
+		return DefaultRateLimitPauseBelow
+	}
+	return *r.PauseBelow
+}
+
+// RetrySettings retries API requests failing with transient errors: 5xx
+// responses, connection resets and secondary rate limits.
+type RetrySettings struct {
+	// MaxAttempts is the number of attempts per request including the first (1: no retries)
+	MaxAttempts int `yaml:"max_attempts"`
+	// InitialBackoff is the delay before the first retry, doubled for every further retry
+	InitialBackoff time.Duration `yaml:"initial_backoff"`
+	// MaxBackoff caps the delay between two attempts
+	MaxBackoff time.Duration `yaml:"max_backoff"`
+	// Jitter randomizes every delay by up to this fraction (0 to 1)
+	Jitter *float64 `yaml:"jitter"`
+	// MaxWait bounds the total time a request waits for rate limits as
+	// instructed by GitHub before the error is reported
+	MaxWait time.Duration `yaml:"max_wait"`
+	// OperationRetries is the number of times a secret or variable write
+	// failing with a transient error is retried as a whole, with the same
+	// backoff (0: no retries)
+	OperationRetries *int `yaml:"operation_retries"`
+}
+
+// WithDefaults returns the retry settings with unset fields set to their defaults.
+func (r RetrySettings) WithDefaults() RetrySettings {
+	if r.MaxAttempts == 0 {
+		r.MaxAttempts = DefaultRetryAttempts
+	}
+	if r.InitialBackoff == 0 {
+		r.InitialBackoff = DefaultRetryInitialBackoff
+	}
+	if r.MaxBackoff == 0 {
+		r.MaxBackoff = DefaultRetryMaxBackoff
+	}
+	if r.Jitter == nil {
+		jitter := DefaultRetryJitter
+		r.Jitter = &jitter
+	}
+	if r.MaxWait == 0 {
+		r.MaxWait = DefaultRetryMaxWait
+	}
+	if r.OperationRetries == nil {
+		retries := DefaultOperationRetries
+		r.OperationRetries = &retries
+	}
+	return r
+}
+
+// MaxRepos returns the number of repositories to process in parallel.
+func (s ConcurrencySettings) MaxRepos() int {
+	if s.Repos == 0 {
+		return DefaultConcurrentRepos
+	}
+	return s.Repos
+}
+
+// MaxOperations returns the number of entries to apply in parallel within a repository.
+func (s ConcurrencySettings) MaxOperations() int {
+	if s.Operations == 0 {
+		return DefaultConcurrentOperations
+	}
+	return s.Operations
+}
+
+// merge overlays the non-zero settings of other onto s.
+func (s *Settings) merge(other Settings) {
+	if other.Concurrency.Repos != 0 {
+		s.Concurrency.Repos = other.Concurrency.Repos
+	}
+	if other.Concurrency.Operations != 0 {
+		s.Concurrency.Operations = other.Concurrency.Operations
+	}
+	if other.RateLimit.RequestsPerSecond != 0 {
+		s.RateLimit.RequestsPerSecond = other.RateLimit.RequestsPerSecond
+	}
+	if other.RateLimit.Burst != 0 {
+		s.RateLimit.Burst = other.RateLimit.Burst
+	}
+	if other.RateLimit.OnExhausted != "" {
+		s.RateLimit.OnExhausted = other.RateLimit.OnExhausted
+	}
+	if other.RateLimit.PauseBelow != nil {
+		s.RateLimit.PauseBelow = other.RateLimit.PauseBelow
+	}
+	if other.Retry.MaxAttempts != 0 {
+		s.Retry.MaxAttempts = other.Retry.MaxAttempts
+	}
+	if other.Retry.InitialBackoff != 0 {
+		s.Retry.InitialBackoff = other.Retry.InitialBackoff
+	}
+	if other.Retry.MaxBackoff != 0 {
+		s.Retry.MaxBackoff = other.Retry.MaxBackoff
+	}
+	if other.Retry.Jitter != nil {
+		s.Retry.Jitter = other.Retry.Jitter
+	}
+	if other.Retry.MaxWait != 0 {
+		s.Retry.MaxWait = other.Retry.MaxWait
+	}
+	if other.Retry.OperationRetries != nil {
+		s.Retry.OperationRetries = other.Retry.OperationRetries
+	}
+	if other.Cache.ETags != nil {
+		s.Cache.ETags = other.Cache.ETags
+	}
+	if other.Cache.Dir != "" {
+		s.Cache.Dir = other.Cache.Dir
+	}
+	if other.State.Enabled != nil {
+		s.State.Enabled = other.State.Enabled
+	}
+	if other.State.SkipUnchanged != nil {
+		s.State.SkipUnchanged = other.State.SkipUnchanged
+	}
+	if other.State.File != "" {
+		s.State.File = other.State.File
+	}
+	if other.Audit.File != "" {
+		s.Audit.File = other.Audit.File
+	}
+	if other.HTTP.Proxy != "" {
+		s.HTTP.Proxy = other.HTTP.Proxy
+	}
+	if other.HTTP.CAFile != "" {
+		s.HTTP.CAFile = other.HTTP.CAFile
+	}
+	if other.HTTP.InsecureSkipVerify {
+		s.HTTP.InsecureSkipVerify = true
+	}
+	if other.RequestTimeout != 0 {
+		s.RequestTimeout = other.RequestTimeout
+	}
+	if other.OperationTimeout != 0 {
+		s.OperationTimeout = other.OperationTimeout
+	}
+	if other.EnsureActionsEnabled {
+		s.EnsureActionsEnabled = true
+	}
+	if other.ConfirmThreshold != 0 {
+		s.ConfirmThreshold = other.ConfirmThreshold
+	}
+}
+
+// validate checks that the settings are within range.
+func (s Settings) validate() error {
+	if s.Concurrency.Repos < 0 {
+		return keyError("settings.concurrency.repos", "settings.concurrency.repos cannot be negative")
+	}
+	if s.Concurrency.Operations < 0 {
+		return keyError("settings.concurrency.operations", "settings.concurrency.operations cannot be negative")
+	}
+	if s.RateLimit.RequestsPerSecond < 0 {
+		return keyError("settings.rate_limit.requests_per_second", "settings.rate_limit.requests_per_second cannot be negative (use 0 for no limit)")
+	}
+	if s.RateLimit.Burst < 0 {
+		return keyError("settings.rate_limit.burst", "settings.rate_limit.burst cannot be negative")
+	}
+	switch s.RateLimit.OnExhausted {
+	case "", RateLimitWait, RateLimitFail:
+	default:
+		return keyError("settings.rate_limit.on_exhausted", "settings.rate_limit.on_exhausted must be '%s' or '%s', got '%s'", RateLimitWait, RateLimitFail, s.RateLimit.OnExhausted)
+	}
+	if s.RateLimit.PauseBelow != nil && *s.RateLimit.PauseBelow < 0 {
+		return keyError("settings.rate_limit.pause_below", "settings.rate_limit.pause_below cannot be negative")
+	}
+	if s.RequestTimeout < 0 {
+		return keyError("settings.request_timeout", "settings.request_timeout cannot be negative")
+	}
+	if s.OperationTimeout < 0 {
+		return keyError("settings.operation_timeout", "settings.operation_timeout cannot be negative")
+	}
+	if s.ConfirmThreshold < 0 {
+		return keyError("settings.confirm_threshold", "settings.confirm_threshold cannot be negative")
+	}
+	if s.HTTP.Proxy != "" {
+		u, err := url.Parse(s.HTTP.Proxy)
+		if err != nil || u.Host == "" {
+			return keyError("settings.http.proxy", "settings.http.proxy must be a URL like http://proxy.example.com:3128, got '%s'", s.HTTP.Proxy)
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5":
+		default:
+			return keyError("settings.http.proxy", "settings.http.proxy scheme must be http, https or socks5, got '%s'", u.Scheme)
+		}
+	}
+	if s.Retry.MaxAttempts < 0 {
+		return keyError("settings.retry.max_attempts", "settings.retry.max_attempts cannot be negative (use 1 to disable retries)")
+	}
+	if s.Retry.InitialBackoff < 0 {
+		return keyError("settings.retry.initial_backoff", "settings.retry.initial_backoff cannot be negative")
+	}
+	if s.Retry.MaxBackoff < 0 {
+		return keyError("settings.retry.max_backoff", "settings.retry.max_backoff cannot be negative")
+	}
+	if s.Retry.MaxWait < 0 {
+		return keyError("settings.retry.max_wait", "settings.retry.max_wait cannot be negative")
+	}
+	if s.Retry.Jitter != nil && (*s.Retry.Jitter < 0 || *s.Retry.Jitter > 1) {
+		return keyError("settings.retry.jitter", "settings.retry.jitter must be between 0 and 1, got %g", *s.Retry.Jitter)
+	}
+	if s.Retry.OperationRetries != nil && *s.Retry.OperationRetries < 0 {
+		return keyError("settings.retry.operation_retries", "settings.retry.operation_retries cannot be negative (use 0 to disable retries)")
+	}
+	return nil
+}
+
+// resolveSettingsPaths makes the cache directory, the state file, the audit
+// log and the CA bundle relative to the configuration file directory.
+func (c *Config) resolveSettingsPaths(baseDir string) {
+	for _, path := range []*string{&c.Settings.Cache.Dir, &c.Settings.State.File, &c.Settings.Audit.File, &c.Settings.HTTP.CAFile} {
+		if *path != "" && !filepath.IsAbs(*path) {
+			*path = filepath.Join(baseDir, *path)
+		}
+	}
+}
+
+// ApplyConcurrencyOverride applies the --concurrency CLI flag to the
+// configuration; 0 keeps the configured value.
+func (c *Config) ApplyConcurrencyOverride(repos int) {
+	if repos != 0 {
+		c.Settings.Concurrency.Repos = repos
+		c.SetOrigin("settings.concurrency.repos", SourceFlag, "--concurrency")
+	}
+}
+
+// ApplyOperationRetriesOverride applies the --max-retries CLI flag to the
+// configuration; nil keeps the configured value.
+func (c *Config) ApplyOperationRetriesOverride(retries *int) {
+	if retries != nil {
+		c.Settings.Retry.OperationRetries = retries
+		c.SetOrigin("settings.retry.operation_retries", SourceFlag, "--max-retries")
+	}
+}
+
+// ApplyHTTPOverrides applies the connection CLI flags to the configuration.
+func (c *Config) ApplyHTTPOverrides(proxy, caFile string, insecureSkipVerify bool) {
+	if proxy != "" {
+		c.Settings.HTTP.Proxy = proxy
+		c.SetOrigin("settings.http.proxy", SourceFlag, "--proxy")
+	}
+	if caFile != "" {
+		c.Settings.HTTP.CAFile = caFile
+		c.SetOrigin("settings.http.ca_file", SourceFlag, "--ca-file")
+	}
+	if insecureSkipVerify {
+		c.Settings.HTTP.InsecureSkipVerify = true
+		c.SetOrigin("settings.http.insecure_skip_verify", SourceFlag, "--insecure-skip-verify")
+	}
+}
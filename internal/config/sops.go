@@ -0,0 +1,60 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// sopsTimeout bounds the decryption of a SOPS-encrypted configuration file,
+// which may involve requests to a key management service.
+const sopsTimeout = 2 * time.Minute
+
+// sopsMetadataKey is the top-level key SOPS adds to the files it encrypts.
+const sopsMetadataKey = "sops"
+
+// isSopsEncrypted reports whether a YAML or JSON document was encrypted with
+// SOPS, i.e. has a top-level sops metadata mapping.
+func isSopsEncrypted(data []byte, format string) bool {
+	if format != FormatYAML && format != FormatJSON {
+		return false
+	}
+	// YAML is a superset of JSON, so both formats are parsed the same way
+	var doc map[string]yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return false
+	}
+	metadata, ok := doc[sopsMetadataKey]
+	return ok && metadata.Kind == yaml.MappingNode
+}
+
+// decryptSops decrypts a SOPS-encrypted configuration file with the sops
+// executable, which must be installed and have access to the keys.
+func decryptSops(path, format string) ([]byte, error) {
+	if _, err := exec.LookPath("sops"); err != nil {
+		return nil, fmt.Errorf("config file is encrypted with SOPS but the sops executable was not found in PATH")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sopsTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "sops", "--decrypt", "--input-type", format, "--output-type", format, path)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("sops decryption timed out after %s", sopsTimeout)
+		}
+		if msg := errorOutput(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("failed to decrypt config file with sops: %w: %s", err, msg)
+		}
+		return nil, fmt.Errorf("failed to decrypt config file with sops: %w", err)
+	}
+	return stdout.Bytes(), nil
+}
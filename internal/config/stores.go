@@ -0,0 +1,285 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"strings"
+	"unicode/utf8"
+
+	secretmanager "cloud.google.com/go/secretmanager/apiv1"
+	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/googleapis/gax-go/v2"
+)
+
+// Secret stores that entries can reference instead of holding a value.
+const (
+	StoreAWSSecretsManager = "aws_secretsmanager"
+	StoreGCPSecretManager  = "gcp_secret"
+	StoreAzureKeyVault     = "azure_keyvault"
+	StoreOnePassword       = "onepassword"
+)
+
+// onePasswordScheme prefixes 1Password secret references.
+const onePasswordScheme = "op://"
+
+// storeReference returns the secret store an entry references and the
+// reference itself, or empty strings if the entry does not use a store.
+func (s *ValueSpec) storeReference() (store, ref string) {
+	switch {
+	case s.AWSSecretsManager != "":
+		return StoreAWSSecretsManager, s.AWSSecretsManager
+	case s.GCPSecret != "":
+		return StoreGCPSecretManager, s.GCPSecret
+	case s.AzureKeyVault != "":
+		return StoreAzureKeyVault, s.AzureKeyVault
+	case s.OnePassword != "":
+		return StoreOnePassword, s.OnePassword
+	}
+	return "", ""
+}
+
+// resolveStoreReference fetches a value from a secret store. The cloud secret
+// managers are read with their SDKs and default credentials, 1Password with
+// its CLI.
+func resolveStoreReference(store, ref string, opts LoadOptions) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout(opts))
+	defer cancel()
+
+	var value string
+	var err error
+	switch store {
+	case StoreAWSSecretsManager:
+		value, err = resolveAWSSecret(ctx, ref)
+	case StoreGCPSecretManager:
+		value, err = resolveGCPSecret(ctx, ref)
+	case StoreAzureKeyVault:
+		value, err = resolveAzureSecret(ctx, ref)
+	case StoreOnePassword:
+		value, err = resolveOnePasswordSecret(ctx, ref)
+	default:
+		return "", fmt.Errorf("unknown secret store %s", store)
+	}
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", fmt.Errorf("%s lookup timed out after %s", store, commandTimeout(opts))
+		}
+		return "", fmt.Errorf("%s: %w", store, err)
+	}
+
+	if len(value) > MaxValueSize {
+		return "", fmt.Errorf("%s value is %d bytes, exceeding the GitHub limit of %d bytes", store, len(value), MaxValueSize)
+	}
+	if !utf8.ValidString(value) {
+		return "", fmt.Errorf("%s value is not valid UTF-8", store)
+	}
+	return value, nil
+}
+
+// awsSecretsManager is the part of the AWS Secrets Manager client used to
+// read secrets.
+type awsSecretsManager interface {
+	GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error)
+}
+
+// newAWSSecretsManager returns a Secrets Manager client using the default
+// credential chain of the AWS SDK (environment variables, AWS_PROFILE, SSO,
+// web identity and instance roles).
+var newAWSSecretsManager = func(ctx context.Context) (awsSecretsManager, error) {
+	cfg, err := awsconfig.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+	return secretsmanager.NewFromConfig(cfg), nil
+}
+
+// resolveAWSSecret reads a secret from AWS Secrets Manager. ref is a secret
+// name or ARN, optionally followed by #key to select a key of a JSON secret.
+func resolveAWSSecret(ctx context.Context, ref string) (string, error) {
+	id, jsonKey := splitJSONKey(ref)
+
+	client, err := newAWSSecretsManager(ctx)
+	if err != nil {
+		return "", err
+	}
+	out, err := client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{SecretId: &id})
+	if err != nil {
+		return "", err
+	}
+	if out.SecretString == nil {
+		return "", fmt.Errorf("secret %s has no string value (binary secrets are not supported)", id)
+	}
+
+	if jsonKey == "" {
+		return *out.SecretString, nil
+	}
+	return extractJSONKey(*out.SecretString, jsonKey)
+}
+
+// gcpSecretManager is the part of the GCP Secret Manager client used to read
+// secrets.
+type gcpSecretManager interface {
+	AccessSecretVersion(ctx context.Context, req *secretmanagerpb.AccessSecretVersionRequest, opts ...gax.CallOption) (*secretmanagerpb.AccessSecretVersionResponse, error)
+	Close() error
+}
+
+// newGCPSecretManager returns a Secret Manager client using Application
+// Default Credentials (GOOGLE_APPLICATION_CREDENTIALS, workload identity,
+// the metadata server or gcloud auth application-default login).
+var newGCPSecretManager = func(ctx context.Context) (gcpSecretManager, error) {
+	client, err := secretmanager.NewClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Secret Manager client: %w", err)
+	}
+	return client, nil
+}
+
+// resolveGCPSecret reads a secret version from GCP Secret Manager. ref is the
+// resource name projects/PROJECT/secrets/NAME[/versions/VERSION]; the latest
+// version is used if none is given.
+func resolveGCPSecret(ctx context.Context, ref string) (string, error) {
+	parts := strings.Split(ref, "/")
+	version := "latest"
+	switch {
+	case len(parts) == 4 && parts[0] == "projects" && parts[2] == "secrets":
+	case len(parts) == 6 && parts[0] == "projects" && parts[2] == "secrets" && parts[4] == "versions":
+		version = parts[5]
+	default:
+		return "", fmt.Errorf("invalid secret reference '%s' (expected projects/PROJECT/secrets/NAME[/versions/VERSION])", ref)
+	}
+	for _, part := range parts {
+		if part == "" {
+			return "", fmt.Errorf("invalid secret reference '%s' (expected projects/PROJECT/secrets/NAME[/versions/VERSION])", ref)
+		}
+	}
+
+	client, err := newGCPSecretManager(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+	name := fmt.Sprintf("projects/%s/secrets/%s/versions/%s", parts[1], parts[3], version)
+	out, err := client.AccessSecretVersion(ctx, &secretmanagerpb.AccessSecretVersionRequest{Name: name})
+	if err != nil {
+		return "", err
+	}
+	return string(out.GetPayload().GetData()), nil
+}
+
+// azureKeyVault is the part of the Azure Key Vault secrets client used to
+// read secrets.
+type azureKeyVault interface {
+	GetSecret(ctx context.Context, name, version string, options *azsecrets.GetSecretOptions) (azsecrets.GetSecretResponse, error)
+}
+
+// newAzureKeyVault returns a client of the vault at vaultURL using the
+// default Azure credential chain (environment service principals, workload
+// and managed identities, the Azure CLI and the Azure Developer CLI).
+var newAzureKeyVault = func(vaultURL string) (azureKeyVault, error) {
+	credential, err := azidentity.NewDefaultAzureCredential(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load Azure credentials: %w", err)
+	}
+	client, err := azsecrets.NewClient(vaultURL, credential, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Key Vault client: %w", err)
+	}
+	return client, nil
+}
+
+// resolveAzureSecret reads a secret from Azure Key Vault. ref is the secret
+// identifier https://VAULT.vault.azure.net/secrets/NAME[/VERSION].
+func resolveAzureSecret(ctx context.Context, ref string) (string, error) {
+	u, err := url.Parse(ref)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return "", fmt.Errorf("invalid secret identifier '%s' (expected https://VAULT.vault.azure.net/secrets/NAME[/VERSION])", ref)
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if (len(parts) != 2 && len(parts) != 3) || parts[0] != "secrets" || parts[1] == "" {
+		return "", fmt.Errorf("invalid secret identifier '%s' (expected https://VAULT.vault.azure.net/secrets/NAME[/VERSION])", ref)
+	}
+
+	client, err := newAzureKeyVault("https://" + u.Host)
+	if err != nil {
+		return "", err
+	}
+	version := ""
+	if len(parts) == 3 {
+		version = parts[2]
+	}
+	secret, err := client.GetSecret(ctx, parts[1], version, nil)
+	if err != nil {
+		return "", err
+	}
+	if secret.Value == nil {
+		return "", fmt.Errorf("secret %s has no value", ref)
+	}
+	return *secret.Value, nil
+}
+
+// resolveOnePasswordSecret reads an op://vault/item/[section/]field reference
+// with the 1Password CLI, signed in to an account or to a Connect server
+// (OP_CONNECT_HOST and OP_CONNECT_TOKEN).
+func resolveOnePasswordSecret(ctx context.Context, ref string) (string, error) {
+	if !strings.HasPrefix(ref, onePasswordScheme) {
+		return "", fmt.Errorf("invalid secret reference '%s' (expected op://vault/item/field)", ref)
+	}
+	out, err := runTool(ctx, "op", "read", "--no-newline", ref)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// splitJSONKey splits a "reference#key" string.
+func splitJSONKey(ref string) (id, key string) {
+	if i := strings.LastIndex(ref, "#"); i >= 0 {
+		return ref[:i], ref[i+1:]
+	}
+	return ref, ""
+}
+
+// extractJSONKey returns a top-level key of a JSON object. String values are
+// returned as is, other values in their JSON encoding.
+func extractJSONKey(document, key string) (string, error) {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(document), &object); err != nil {
+		return "", fmt.Errorf("secret is not a JSON object, cannot select key '%s'", key)
+	}
+	raw, ok := object[key]
+	if !ok {
+		return "", fmt.Errorf("secret has no key '%s'", key)
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s, nil
+	}
+	return string(raw), nil
+}
+
+// runTool runs a secret store CLI and returns its standard output.
+func runTool(ctx context.Context, name string, args ...string) ([]byte, error) {
+	if _, err := exec.LookPath(name); err != nil {
+		return nil, fmt.Errorf("the %s executable was not found in PATH", name)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := errorOutput(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s failed: %w: %s", name, err, msg)
+		}
+		return nil, fmt.Errorf("%s failed: %w", name, err)
+	}
+	return stdout.Bytes(), nil
+}
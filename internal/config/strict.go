@@ -0,0 +1,333 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Position is a location in the configuration file.
+type Position struct {
+	Line   int
+	Column int
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("line %d, column %d", p.Line, p.Column)
+}
+
+// KeyError is a validation error about a single configuration key. Its
+// message does not include the key's position; Validate adds it when known.
+type KeyError struct {
+	Key string
+	Err error
+}
+
+func (e *KeyError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *KeyError) Unwrap() error {
+	return e.Err
+}
+
+// keyError returns a KeyError for key with a formatted message.
+func keyError(key, format string, args ...interface{}) error {
+	return &KeyError{Key: key, Err: fmt.Errorf(format, args...)}
+}
+
+// withPosition prefixes err with the file position of the key it refers to.
+func (c *Config) withPosition(err error) error {
+	var keyErr *KeyError
+	if !errors.As(err, &keyErr) {
+		return err
+	}
+	pos, ok := c.positions[keyErr.Key]
+	if !ok || pos.Line == 0 {
+		return err
+	}
+	return fmt.Errorf("%s: %w", pos, err)
+}
+
+// recordPositions remembers the position of every key of a configuration node,
+// keyed like Resolve (e.g. "github.owner" or "repo_overrides.api.repository_secrets.KEY").
+func (c *Config) recordPositions(node *yaml.Node) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	c.positions = make(map[string]Position)
+	recordMappingPositions(c.positions, "", node, 0)
+}
+
+// positionDepth is the number of key levels recorded below each top-level section.
+var positionDepth = map[string]int{
+	"github":                     2,
+	SectionSettings:              2,
+	SectionEnvironments:          2,
+	SectionActionsPermissions:    1,
+	SectionWorkflowPermissions:   1,
+	SectionDefaults:              2,
+	"groups":                     1,
+	SectionRepositorySecrets:     1,
+	SectionRepositoryVariables:   1,
+	SectionCodespacesSecrets:     1,
+	SectionOrganizationSecrets:   1,
+	SectionOrganizationVariables: 1,
+	SectionUserCodespacesSecrets: 1,
+	SectionEnvironmentSecrets:    2,
+	SectionEnvironmentVariables:  2,
+}
+
+func recordMappingPositions(positions map[string]Position, prefix string, node *yaml.Node, depth int) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		name := prefix + key.Value
+		positions[name] = Position{Line: key.Line, Column: key.Column}
+
+		if value.Kind != yaml.MappingNode {
+			continue
+		}
+		switch {
+		case prefix == "" && isScopedSection(key.Value):
+			// repo_overrides.<name> holds the same sections as the top level
+			for j := 0; j+1 < len(value.Content); j += 2 {
+				scope, resources := value.Content[j], value.Content[j+1]
+				scopeName := name + "." + scope.Value
+				positions[scopeName] = Position{Line: scope.Line, Column: scope.Column}
+				if resources.Kind == yaml.MappingNode {
+					recordMappingPositions(positions, scopeName+".", resources, 0)
+				}
+			}
+		case depth == 0 && positionDepth[key.Value] > 0:
+			recordMappingPositions(positions, name+".", value, positionDepth[key.Value])
+		case depth > 1:
+			recordMappingPositions(positions, name+".", value, depth-1)
+		}
+	}
+}
+
+// yamlKeys returns the YAML keys of the fields of a struct type.
+func yamlKeys(t reflect.Type) []string {
+	var keys []string
+	for i := 0; i < t.NumField(); i++ {
+		tag := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		if tag != "" && tag != "-" {
+			keys = append(keys, tag)
+		}
+	}
+	return keys
+}
+
+var (
+	configKeys      = yamlKeys(reflect.TypeOf(Config{}))
+	githubKeys      = yamlKeys(reflect.TypeOf(GitHubConfig{}))
+	appKeys         = yamlKeys(reflect.TypeOf(GitHubAppConfig{}))
+	resourceKeys    = yamlKeys(reflect.TypeOf(Resources{}))
+	defaultsKeys    = yamlKeys(reflect.TypeOf(Defaults{}))
+	settingsKeys    = yamlKeys(reflect.TypeOf(Settings{}))
+	environmentKeys = yamlKeys(reflect.TypeOf(EnvironmentSettings{}))
+	reviewerKeys    = yamlKeys(reflect.TypeOf(EnvironmentReviewers{}))
+	actionsKeys     = yamlKeys(reflect.TypeOf(ActionsPermissions{}))
+	workflowKeys    = yamlKeys(reflect.TypeOf(WorkflowPermissions{}))
+	dopplerKeys     = yamlKeys(reflect.TypeOf(DopplerSource{}))
+	targetKeys      = yamlKeys(reflect.TypeOf(Target{}))
+	hooksKeys       = yamlKeys(reflect.TypeOf(Hooks{}))
+	hookKeys        = yamlKeys(reflect.TypeOf(Hook{}))
+	notifyKeys      = yamlKeys(reflect.TypeOf(Notifications{}))
+	// notificationKeys are the keys of each notification target
+	notificationKeys = map[string][]string{
+		"slack": yamlKeys(reflect.TypeOf(ChatNotification{})),
+		"teams": yamlKeys(reflect.TypeOf(ChatNotification{})),
+	}
+	webhookKeys = yamlKeys(reflect.TypeOf(WebhookNotification{}))
+	// settingsSectionKeys are the keys of each settings section
+	settingsSectionKeys = map[string][]string{
+		"concurrency": yamlKeys(reflect.TypeOf(ConcurrencySettings{})),
+		"rate_limit":  yamlKeys(reflect.TypeOf(RateLimitSettings{})),
+		"retry":       yamlKeys(reflect.TypeOf(RetrySettings{})),
+		"cache":       yamlKeys(reflect.TypeOf(CacheSettings{})),
+		"state":       yamlKeys(reflect.TypeOf(StateSettings{})),
+		"http":        yamlKeys(reflect.TypeOf(HTTPSettings{})),
+		"audit":       yamlKeys(reflect.TypeOf(AuditSettings{})),
+	}
+	valueSpecKeys = yamlKeys(reflect.TypeOf(ValueSpec{}))
+)
+
+// checkKnownKeys reports every key of the configuration node that does not
+// correspond to a configuration field, such as a misspelled section name.
+func checkKnownKeys(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	var errs []error
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if err := checkKey(key, "", configKeys); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if key.Value == SectionDoppler && value.Kind == yaml.SequenceNode {
+			for j, source := range value.Content {
+				if source.Kind == yaml.MappingNode {
+					errs = append(errs, checkMappingKeys(source, fmt.Sprintf("%s[%d].", SectionDoppler, j), dopplerKeys)...)
+				}
+			}
+			continue
+		}
+		if key.Value == SectionTargets && value.Kind == yaml.SequenceNode {
+			for j, target := range value.Content {
+				if target.Kind == yaml.MappingNode {
+					errs = append(errs, checkMappingKeys(target, fmt.Sprintf("%s[%d].", SectionTargets, j), targetKeys)...)
+				}
+			}
+			continue
+		}
+		if value.Kind != yaml.MappingNode {
+			continue
+		}
+
+		switch key.Value {
+		case "github":
+			errs = append(errs, checkMappingKeys(value, "github.", githubKeys)...)
+			for j := 0; j+1 < len(value.Content); j += 2 {
+				if value.Content[j].Value == "app" && value.Content[j+1].Kind == yaml.MappingNode {
+					errs = append(errs, checkMappingKeys(value.Content[j+1], "github.app.", appKeys)...)
+				}
+			}
+		case SectionDefaults:
+			errs = append(errs, checkMappingKeys(value, SectionDefaults+".", defaultsKeys)...)
+		case SectionSettings:
+			errs = append(errs, checkMappingKeys(value, SectionSettings+".", settingsKeys)...)
+			for j := 0; j+1 < len(value.Content); j += 2 {
+				name, section := value.Content[j].Value, value.Content[j+1]
+				if known, ok := settingsSectionKeys[name]; ok && section.Kind == yaml.MappingNode {
+					errs = append(errs, checkMappingKeys(section, SectionSettings+"."+name+".", known)...)
+				}
+			}
+		case SectionEnvironments:
+			for j := 0; j+1 < len(value.Content); j += 2 {
+				name, env := value.Content[j].Value, value.Content[j+1]
+				if env.Kind != yaml.MappingNode {
+					continue
+				}
+				prefix := SectionEnvironments + "." + name + "."
+				errs = append(errs, checkMappingKeys(env, prefix, environmentKeys)...)
+				for k := 0; k+1 < len(env.Content); k += 2 {
+					if env.Content[k].Value == "reviewers" && env.Content[k+1].Kind == yaml.MappingNode {
+						errs = append(errs, checkMappingKeys(env.Content[k+1], prefix+"reviewers.", reviewerKeys)...)
+					}
+				}
+			}
+		case SectionActionsPermissions:
+			errs = append(errs, checkMappingKeys(value, SectionActionsPermissions+".", actionsKeys)...)
+		case SectionWorkflowPermissions:
+			errs = append(errs, checkMappingKeys(value, SectionWorkflowPermissions+".", workflowKeys)...)
+		case SectionNotifications:
+			errs = append(errs, checkMappingKeys(value, SectionNotifications+".", notifyKeys)...)
+			for j := 0; j+1 < len(value.Content); j += 2 {
+				name, target := value.Content[j].Value, value.Content[j+1]
+				if known, ok := notificationKeys[name]; ok && target.Kind == yaml.MappingNode {
+					errs = append(errs, checkMappingKeys(target, SectionNotifications+"."+name+".", known)...)
+				}
+				if name == "webhooks" && target.Kind == yaml.SequenceNode {
+					for k, webhook := range target.Content {
+						if webhook.Kind == yaml.MappingNode {
+							errs = append(errs, checkMappingKeys(webhook, fmt.Sprintf("%s.webhooks[%d].", SectionNotifications, k), webhookKeys)...)
+						}
+					}
+				}
+			}
+		case SectionHooks:
+			errs = append(errs, checkMappingKeys(value, SectionHooks+".", hooksKeys)...)
+			for j := 0; j+1 < len(value.Content); j += 2 {
+				stage, hooks := value.Content[j].Value, value.Content[j+1]
+				if hooks.Kind != yaml.SequenceNode {
+					continue
+				}
+				for k, hook := range hooks.Content {
+					if hook.Kind == yaml.MappingNode {
+						errs = append(errs, checkMappingKeys(hook, fmt.Sprintf("%s.%s[%d].", SectionHooks, stage, k), hookKeys)...)
+					}
+				}
+			}
+		case SectionRepoOverrides, SectionGroupOverrides:
+			for j := 0; j+1 < len(value.Content); j += 2 {
+				if resources := value.Content[j+1]; resources.Kind == yaml.MappingNode {
+					errs = append(errs, checkMappingKeys(resources, key.Value+"."+value.Content[j].Value+".", resourceKeys)...)
+				}
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// checkMappingKeys checks the keys of a mapping node against known.
+func checkMappingKeys(node *yaml.Node, prefix string, known []string) []error {
+	var errs []error
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if err := checkKey(node.Content[i], prefix, known); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// checkKey returns an error if key is not one of known, suggesting the closest
+// known key for likely typos.
+func checkKey(key *yaml.Node, prefix string, known []string) error {
+	for _, k := range known {
+		if key.Value == k {
+			return nil
+		}
+	}
+
+	msg := fmt.Sprintf("unknown key '%s%s'", prefix, key.Value)
+	if suggestion := closestKey(key.Value, known); suggestion != "" {
+		msg += fmt.Sprintf(" (did you mean '%s'?)", suggestion)
+	}
+	if key.Line > 0 {
+		msg = Position{Line: key.Line, Column: key.Column}.String() + ": " + msg
+	}
+	return errors.New(msg)
+}
+
+// closestKey returns the known key closest to key, if it is close enough to be
+// a likely typo.
+func closestKey(key string, known []string) string {
+	sorted := append([]string(nil), known...)
+	sort.Strings(sorted)
+
+	best, bestDistance := "", 4
+	for _, k := range sorted {
+		if d := editDistance(key, k); d < bestDistance {
+			best, bestDistance = k, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// SectionTargets lists other owners whose repositories are processed by the
+// same run.
+const SectionTargets = "targets"
+
+// Target adds the repositories of another owner to the run, e.g.
+//
+//	targets:
+//	  - owner: other-org
+//	    token_env: OTHER_ORG_TOKEN
+//	    repos: [shared-lib, docs]
+//
+// Its repositories receive the same entries as those of github.repos and are
+// addressed as owner/name, e.g. in repo_overrides and groups.
+type Target struct {
+	Owner string `yaml:"owner"`
+	// TokenEnv names the environment variable holding the token of the
+	// owner (default: the credentials of github)
+	TokenEnv string   `yaml:"token_env"`
+	Repos    []string `yaml:"repos"`
+
+	// Token is the token read from TokenEnv
+	Token string `yaml:"-"`
+}
+
+// TargetRepos returns the repositories of targets as owner/name.
+func (c *Config) TargetRepos() []string {
+	var repos []string
+	for _, target := range c.Targets {
+		for _, repo := range target.Repos {
+			repos = append(repos, target.Owner+"/"+repo)
+		}
+	}
+	return repos
+}
+
+// OwnerTokens returns the tokens of the owners of targets with token_env,
+// keyed by owner. Other owners use the credentials of github.
+func (c *Config) OwnerTokens() map[string]string {
+	tokens := make(map[string]string)
+	for _, target := range c.Targets {
+		if target.Token != "" {
+			tokens[target.Owner] = target.Token
+		}
+	}
+	return tokens
+}
+
+// resolveTargetTokens reads the tokens of targets from the environment.
+func (c *Config) resolveTargetTokens() {
+	for i := range c.Targets {
+		if c.Targets[i].TokenEnv != "" {
+			c.Targets[i].Token = os.Getenv(c.Targets[i].TokenEnv)
+		}
+	}
+}
+
+// validateTargets checks that every target names a distinct owner other than
+// github.owner, its repositories by name and a token that is set.
+func (c *Config) validateTargets() error {
+	seen := make(map[string]bool, len(c.Targets))
+	for i, target := range c.Targets {
+		key := fmt.Sprintf("%s[%d]", SectionTargets, i)
+		owner := strings.ToLower(target.Owner)
+		switch {
+		case target.Owner == "":
+			return fmt.Errorf("%s: owner is required", key)
+		case strings.Contains(target.Owner, "/"):
+			return fmt.Errorf("%s: owner '%s' must be an organization or user name", key, target.Owner)
+		case strings.EqualFold(target.Owner, c.GitHub.Owner):
+			return fmt.Errorf("%s: owner '%s' is github.owner; list its repositories in github.repos", key, target.Owner)
+		case seen[owner]:
+			return fmt.Errorf("%s: owner '%s' is listed more than once", key, target.Owner)
+		case target.TokenEnv != "" && target.Token == "":
+			return fmt.Errorf("%s: environment variable %s of token_env is not set", key, target.TokenEnv)
+		}
+		seen[owner] = true
+		for _, repo := range target.Repos {
+			if repo == "" || strings.Contains(repo, "/") {
+				return fmt.Errorf("%s: repository '%s' must be a name of a repository of %s", key, repo, target.Owner)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package config
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// TemplateData is the data available to templates in configuration values,
+// e.g. API_URL: "https://{{ .Repo }}.example.com".
+type TemplateData struct {
+	Owner       string
+	Repo        string
+	Environment string // empty for repository-level entries
+	Name        string // name of the secret or variable
+}
+
+// isTemplate reports whether a value contains template actions.
+func isTemplate(value string) bool {
+	return strings.Contains(value, "{{")
+}
+
+// templateFuncs returns the functions available to templates. Relative paths
+// passed to file are resolved against baseDir.
+func templateFuncs(baseDir string) template.FuncMap {
+	return template.FuncMap{
+		"env": os.Getenv,
+		"file": func(path string) (string, error) {
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(baseDir, path)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return "", err
+			}
+			return string(data), nil
+		},
+		"b64enc": func(s string) string {
+			return base64.StdEncoding.EncodeToString([]byte(s))
+		},
+		"b64dec": func(s string) (string, error) {
+			data, err := base64.StdEncoding.DecodeString(s)
+			if err != nil {
+				return "", err
+			}
+			return string(data), nil
+		},
+		"upper":     strings.ToUpper,
+		"lower":     strings.ToLower,
+		"trimSpace": strings.TrimSpace,
+		"replace": func(old, new, s string) string {
+			return strings.ReplaceAll(s, old, new)
+		},
+		"default": func(fallback, value string) string {
+			if value == "" {
+				return fallback
+			}
+			return value
+		},
+	}
+}
+
+// parseTemplate parses a template value.
+func parseTemplate(value, baseDir string) (*template.Template, error) {
+	return template.New("value").Funcs(templateFuncs(baseDir)).Option("missingkey=error").Parse(value)
+}
+
+// renderValue renders a template value. Values without template actions are
+// returned unchanged.
+func renderValue(value, baseDir string, data TemplateData) (string, error) {
+	if !isTemplate(value) {
+		return value, nil
+	}
+
+	tmpl, err := parseTemplate(value, baseDir)
+	if err != nil {
+		return "", err
+	}
+	var out strings.Builder
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// checkTemplate returns an error if a template value cannot be parsed.
+func checkTemplate(key, value string) error {
+	if !isTemplate(value) {
+		return nil
+	}
+	if _, err := parseTemplate(value, ""); err != nil {
+		return keyError(key, "invalid template in %s: %w", key, err)
+	}
+	return nil
+}
+
+// validateTemplates checks that every literal repository-level value with
+// template syntax parses.
+func (c *Config) validateTemplates() error {
+	for _, key := range c.Resolve() {
+		section := strings.SplitN(key.Key, ".", 2)[0]
+		if section == "github" || section == SectionSettings || section == SectionEnvironments || section == SectionActionsPermissions || section == SectionWorkflowPermissions || isAccountSection(section) || !c.isLiteral(key.Key) {
+			continue
+		}
+		if err := checkTemplate(key.Key, key.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isLiteral reports whether the value of a key was written in the
+// configuration file, as opposed to loaded from a file, environment variable,
+// command or secret store. Only literal values are rendered as templates.
+func (c *Config) isLiteral(key string) bool {
+	if c.imported[key] {
+		return false
+	}
+	spec, ok := c.Specs[key]
+	return !ok || !spec.external()
+}
+
+// RenderedResourcesFor returns the effective resources of a repository, like
+// ResourcesFor, with template values rendered for the repository.
+func (c *Config) RenderedResourcesFor(repo string) (Resources, error) {
+	owner, repoName := c.SplitRepo(repo)
+	var merged Resources
+	for _, layer := range c.layersFor(repo) {
+		rendered, err := c.renderLayer(layer, repo)
+		if err != nil {
+			return Resources{}, err
+		}
+		merged.merge(c.scopeLayer(layer.scope, rendered, repo))
+		merged.remove(c.absentLayer(layer.scope, repo))
+	}
+
+	err := c.applyDefaults(&merged, repo, func(section, environment, name, value string) (string, error) {
+		key := scopePrefix(SectionDefaults) + entryKey(section, "", name)
+		if !c.isLiteral(key) {
+			return value, nil
+		}
+		rendered, err := renderValue(value, c.baseDir, TemplateData{
+			Owner:       owner,
+			Repo:        repoName,
+			Environment: environment,
+			Name:        name,
+		})
+		if err != nil {
+			return "", keyError(key, "failed to render %s for %s: %w", key, repo, err)
+		}
+		if len(rendered) > MaxValueSize {
+			return "", keyError(key, "%s rendered for %s is %d bytes, exceeding the GitHub limit of %d bytes", key, repo, len(rendered), MaxValueSize)
+		}
+		return rendered, nil
+	})
+	if err != nil {
+		return Resources{}, err
+	}
+	return merged, nil
+}
+
+// renderLayer renders the template values of a single scope.
+func (c *Config) renderLayer(layer resourceLayer, repo string) (Resources, error) {
+	owner, repoName := c.SplitRepo(repo)
+	var rendered Resources
+	err := layer.resources.each(func(section, environment, name, value string) error {
+		key := scopePrefix(layer.scope) + entryKey(section, environment, name)
+		if c.isLiteral(key) {
+			var err error
+			value, err = renderValue(value, c.baseDir, TemplateData{
+				Owner:       owner,
+				Repo:        repoName,
+				Environment: environment,
+				Name:        name,
+			})
+			if err != nil {
+				return keyError(key, "failed to render %s for %s: %w", key, repo, err)
+			}
+			if len(value) > MaxValueSize {
+				return keyError(key, "%s rendered for %s is %d bytes, exceeding the GitHub limit of %d bytes", key, repo, len(value), MaxValueSize)
+			}
+		}
+		rendered.set(section, environment, name, value)
+		return nil
+	})
+	if err != nil {
+		return Resources{}, err
+	}
+	return rendered, nil
+}
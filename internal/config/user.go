@@ -0,0 +1,80 @@
+package config
+
+import "sort"
+
+// SectionUserCodespacesSecrets holds Codespaces secrets of the authenticated user.
+const SectionUserCodespacesSecrets = "user_codespaces_secrets"
+
+// isAccountSection reports whether section holds entries set once on an
+// account (the owner organization or the authenticated user) rather than on
+// each repository.
+func isAccountSection(section string) bool {
+	return isOrganizationSection(section) || section == SectionUserCodespacesSecrets
+}
+
+// UserSecretEntry is a Codespaces secret of the authenticated user.
+type UserSecretEntry struct {
+	Name  string
+	Value string
+	// SelectedRepos lists the repositories whose codespaces can access the
+	// secret; nil leaves the current list unchanged
+	SelectedRepos []string
+}
+
+// UserCodespacesSecretsList returns the user Codespaces secrets to set, sorted by name.
+func (c *Config) UserCodespacesSecretsList() []UserSecretEntry {
+	entries := make([]UserSecretEntry, 0, len(c.UserCodespacesSecrets))
+	for name, value := range c.UserCodespacesSecrets {
+		entry := UserSecretEntry{Name: name, Value: value}
+		if spec, ok := c.Specs[entryKey(SectionUserCodespacesSecrets, "", name)]; ok {
+			entry.SelectedRepos = spec.SelectedRepos
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+	return entries
+}
+
+// AbsentUserCodespacesSecrets returns the sorted names of the user Codespaces
+// secrets marked with state: absent.
+func (c *Config) AbsentUserCodespacesSecrets() []string {
+	return c.absentOrganizationEntries(SectionUserCodespacesSecrets)
+}
+
+// validateUserCodespaces checks the user Codespaces secrets.
+func (c *Config) validateUserCodespaces() error {
+	for name, value := range c.UserCodespacesSecrets {
+		key := entryKey(SectionUserCodespacesSecrets, "", name)
+		if name == "" {
+			return keyError(key, "user codespaces secret key cannot be empty")
+		}
+		if value == "" {
+			return keyError(key, "user codespaces secret value for '%s' cannot be empty", name)
+		}
+	}
+	if err := validateNames(func(name string) string {
+		return entryKey(SectionUserCodespacesSecrets, "", name)
+	}, sectionKinds[SectionUserCodespacesSecrets], c.UserCodespacesSecrets); err != nil {
+		return err
+	}
+
+	for key, spec := range c.Specs {
+		if spec.Section != SectionUserCodespacesSecrets {
+			continue
+		}
+		if spec.Visibility != "" {
+			return keyError(key, "%s: visibility cannot be used in %s; list the repositories in selected_repos", key, spec.Section)
+		}
+		for _, repo := range spec.SelectedRepos {
+			if repo == "" {
+				return keyError(key, "%s: selected_repos entries cannot be empty", key)
+			}
+		}
+		if len(spec.Repos) > 0 || len(spec.Environments) > 0 {
+			return keyError(key, "%s: repos and environments cannot be used in %s; use selected_repos", key, spec.Section)
+		}
+	}
+	return nil
+}
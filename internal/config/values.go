@@ -0,0 +1,546 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// unresolvedValue stands in for the values of entries read with SkipValues.
+const unresolvedValue = "(unresolved)"
+
+// MaxValueSize is the largest secret or variable value GitHub accepts (48 KB).
+const MaxValueSize = 48 * 1024
+
+// Entry states.
+const (
+	StatePresent = "present"
+	StateAbsent  = "absent"
+)
+
+// Section names of the configuration file.
+const (
+	SectionRepositorySecrets    = "repository_secrets"
+	SectionEnvironmentSecrets   = "environment_secrets"
+	SectionRepositoryVariables  = "repository_variables"
+	SectionEnvironmentVariables = "environment_variables"
+	SectionCodespacesSecrets    = "codespaces_secrets"
+)
+
+// ValueSpec is the structured form of a secret or variable entry, e.g.
+//
+//	SECRET1: { from_file: ./certs/key.pem }
+//	SECRET2: { from_env: CI_DEPLOY_TOKEN }
+//	SECRET3: { from_command: "op read op://vault/item/field" }
+//	SECRET6: { encrypted: "-----BEGIN AGE ENCRYPTED FILE-----..." }
+//	SECRET7: { aws_secretsmanager: "prod/db#password" }
+//	SECRET8: { gcp_secret: "projects/p/secrets/name/versions/latest" }
+//	SECRET9: { azure_keyvault: "https://myvault.vault.azure.net/secrets/name" }
+//	SECRET10: { onepassword: "op://vault/item/field" }
+//	SECRET4: { value: x, repos: [api, worker], environments: [production] }
+//	SECRET5: { state: absent }
+//
+// Plain scalar entries do not produce a ValueSpec.
+type ValueSpec struct {
+	Value    string `yaml:"value"`
+	FromFile string `yaml:"from_file"`
+	FromEnv  string `yaml:"from_env"`
+	// FromCommand is run through the system shell; requires LoadOptions.AllowCommands
+	FromCommand string `yaml:"from_command"`
+	// Encrypted is an age-encrypted value; requires LoadOptions.Identity
+	Encrypted string `yaml:"encrypted"`
+
+	// References to secret stores, resolved at load time
+	AWSSecretsManager string `yaml:"aws_secretsmanager"`
+	GCPSecret         string `yaml:"gcp_secret"`
+	AzureKeyVault     string `yaml:"azure_keyvault"`
+	OnePassword       string `yaml:"onepassword"`
+
+	// Repos restricts the entry to these repositories
+	Repos []string `yaml:"repos"`
+	// Environments turns a repository-level entry into an entry of these
+	// environments, or restricts a default to them
+	Environments []string `yaml:"environments"`
+	// State is StatePresent (the default) or StateAbsent to delete the entry
+	State string `yaml:"state"`
+
+	// Visibility and SelectedRepos control access to organization secrets and
+	// variables; SelectedRepos also applies to user Codespaces secrets
+	Visibility    string   `yaml:"visibility"`
+	SelectedRepos []string `yaml:"selected_repos"`
+
+	// Location of the entry in the configuration
+	Scope       string `yaml:"-"` // e.g. "repo_overrides.api", empty at the top level
+	Section     string `yaml:"-"`
+	Environment string `yaml:"-"`
+	Name        string `yaml:"-"`
+}
+
+// Key returns the configuration key of the entry, as used by Resolve.
+func (s *ValueSpec) Key() string {
+	return scopePrefix(s.Scope) + entryKey(s.Section, s.Environment, s.Name)
+}
+
+// appliesToRepo reports whether the entry applies to a repository.
+func (s *ValueSpec) appliesToRepo(repo string) bool {
+	return len(s.Repos) == 0 || contains(s.Repos, repo)
+}
+
+// appliesToEnvironment reports whether a default applies to an environment.
+func (s *ValueSpec) appliesToEnvironment(environment string) bool {
+	return len(s.Environments) == 0 || contains(s.Environments, environment)
+}
+
+// validateScope checks the repos and environments restrictions of the entry.
+func (s *ValueSpec) validateScope() error {
+	for _, repo := range s.Repos {
+		if repo == "" {
+			return keyError(s.Key(), "%s: repos entries cannot be empty", s.Key())
+		}
+	}
+	for _, environment := range s.Environments {
+		if environment == "" {
+			return keyError(s.Key(), "%s: environments entries cannot be empty", s.Key())
+		}
+	}
+	if len(s.Environments) > 0 && s.Environment != "" {
+		return keyError(s.Key(), "%s: environments cannot be set on an entry of a specific environment", s.Key())
+	}
+	if len(s.Environments) > 0 && s.Section == SectionCodespacesSecrets {
+		return keyError(s.Key(), "%s: Codespaces secrets do not support environments", s.Key())
+	}
+
+	switch s.State {
+	case "", StatePresent:
+	case StateAbsent:
+		if len(s.sources()) > 0 {
+			return keyError(s.Key(), "%s: an entry with state absent cannot have a value", s.Key())
+		}
+		if s.Scope == SectionDefaults {
+			return keyError(s.Key(), "%s: state absent cannot be used in defaults", s.Key())
+		}
+		if err := validateName(s.Key(), sectionKinds[s.Section], s.Name); err != nil {
+			return err
+		}
+	default:
+		return keyError(s.Key(), "%s: invalid state '%s' (expected present or absent)", s.Key(), s.State)
+	}
+	return nil
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// entryKey builds the configuration key of a secret or variable entry.
+func entryKey(section, environment, name string) string {
+	if environment != "" {
+		return section + "." + environment + "." + name
+	}
+	return section + "." + name
+}
+
+// scopePrefix returns the key prefix of entries in a scope such as
+// "repo_overrides.api".
+func scopePrefix(scope string) string {
+	if scope == "" {
+		return ""
+	}
+	return scope + "."
+}
+
+// UnmarshalYAML decodes the configuration, extracting structured value entries
+// into Specs so the section maps only hold plain strings.
+// Unknown keys are rejected so that typos in section names are not ignored.
+func (c *Config) UnmarshalYAML(node *yaml.Node) error {
+	if err := checkKnownKeys(node); err != nil {
+		return err
+	}
+
+	specs, err := extractValueSpecs(node)
+	if err != nil {
+		return err
+	}
+
+	type plain Config
+	if err := node.Decode((*plain)(c)); err != nil {
+		return err
+	}
+	c.Specs = specs
+	c.recordPositions(node)
+	return nil
+}
+
+// extractValueSpecs finds structured entries in the value sections of the
+// configuration and its scoped sections, replacing them with plain scalars.
+func extractValueSpecs(node *yaml.Node) (map[string]*ValueSpec, error) {
+	if node.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+
+	specs := make(map[string]*ValueSpec)
+	if err := extractSections(node, "", specs); err != nil {
+		return nil, err
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		section := node.Content[i].Value
+		if section == SectionDefaults && node.Content[i+1].Kind == yaml.MappingNode {
+			defaults := node.Content[i+1]
+			for j := 0; j+1 < len(defaults.Content); j += 2 {
+				switch defaults.Content[j].Value {
+				case SectionEnvironmentSecrets, SectionEnvironmentVariables:
+					if err := extractEntries(defaults.Content[j+1], SectionDefaults, defaults.Content[j].Value, "", specs); err != nil {
+						return nil, err
+					}
+				}
+			}
+			continue
+		}
+		if !isScopedSection(section) || node.Content[i+1].Kind != yaml.MappingNode {
+			continue
+		}
+		scopes := node.Content[i+1]
+		for j := 0; j+1 < len(scopes.Content); j += 2 {
+			if err := extractSections(scopes.Content[j+1], section+"."+scopes.Content[j].Value, specs); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(specs) == 0 {
+		return nil, nil
+	}
+	return specs, nil
+}
+
+// extractSections extracts structured entries from the value sections of a
+// mapping node. scope is empty for the top level of the configuration.
+func extractSections(node *yaml.Node, scope string, specs map[string]*ValueSpec) error {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		section := node.Content[i].Value
+		switch section {
+		case SectionRepositorySecrets, SectionRepositoryVariables, SectionCodespacesSecrets, SectionOrganizationSecrets, SectionOrganizationVariables, SectionUserCodespacesSecrets:
+			if err := extractEntries(node.Content[i+1], scope, section, "", specs); err != nil {
+				return err
+			}
+		case SectionEnvironmentSecrets, SectionEnvironmentVariables:
+			envs := node.Content[i+1]
+			if envs.Kind != yaml.MappingNode {
+				continue
+			}
+			for j := 0; j+1 < len(envs.Content); j += 2 {
+				if err := extractEntries(envs.Content[j+1], scope, section, envs.Content[j].Value, specs); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// extractEntries extracts the structured entries of a section. Entries marked
+// for deletion are removed from the section and only kept in specs.
+func extractEntries(entries *yaml.Node, scope, section, environment string, specs map[string]*ValueSpec) error {
+	if entries.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	content := entries.Content[:0]
+	defer func() { entries.Content = content }()
+
+	for i := 0; i+1 < len(entries.Content); i += 2 {
+		name, value := entries.Content[i], entries.Content[i+1]
+		if value.Kind != yaml.MappingNode {
+			content = append(content, name, value)
+			continue
+		}
+
+		if errs := checkMappingKeys(value, "", valueSpecKeys); len(errs) > 0 {
+			return fmt.Errorf("invalid value for '%s': %w", name.Value, errors.Join(errs...))
+		}
+
+		spec := &ValueSpec{}
+		if err := value.Decode(spec); err != nil {
+			return fmt.Errorf("line %d: invalid value for '%s': %w", value.Line, name.Value, err)
+		}
+		spec.Scope = scope
+		spec.Section = section
+		spec.Environment = environment
+		spec.Name = name.Value
+		specs[spec.Key()] = spec
+		if spec.State == StateAbsent {
+			continue
+		}
+
+		// Leave an empty placeholder that is filled in by resolveValues
+		*value = yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: spec.Value, Line: value.Line, Column: value.Column}
+		content = append(content, name, value)
+	}
+	return nil
+}
+
+// resolveValues loads the values of structured entries. Relative file paths
+// are resolved against baseDir.
+func (c *Config) resolveValues(baseDir string, opts LoadOptions) error {
+	keys := make([]string, 0, len(c.Specs))
+	for key := range c.Specs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		spec := c.Specs[key]
+		if spec.State == StateAbsent {
+			continue
+		}
+		value, err := spec.resolve(baseDir, opts)
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		c.setValue(spec, value)
+		switch {
+		case spec.FromFile != "":
+			c.SetOrigin(key, SourceFile, spec.FromFile)
+		case spec.FromEnv != "":
+			c.SetOrigin(key, SourceEnv, spec.FromEnv)
+		case spec.FromCommand != "":
+			c.SetOrigin(key, SourceCommand, commandName(spec.FromCommand))
+		default:
+			if store, ref := spec.storeReference(); store != "" {
+				c.SetOrigin(key, SourceStore, store+":"+ref)
+			}
+		}
+	}
+	return nil
+}
+
+// external reports whether the value of the entry is not written in the
+// configuration file as plain text.
+func (s *ValueSpec) external() bool {
+	sources := s.sources()
+	return len(sources) > 0 && (len(sources) > 1 || sources[0] != "value")
+}
+
+// sources returns the keys of the value sources set on the entry.
+func (s *ValueSpec) sources() []string {
+	var keys []string
+	for _, source := range []struct {
+		key   string
+		value string
+	}{
+		{"value", s.Value},
+		{"from_file", s.FromFile},
+		{"from_env", s.FromEnv},
+		{"from_command", s.FromCommand},
+		{"encrypted", s.Encrypted},
+		{StoreAWSSecretsManager, s.AWSSecretsManager},
+		{StoreGCPSecretManager, s.GCPSecret},
+		{StoreAzureKeyVault, s.AzureKeyVault},
+		{StoreOnePassword, s.OnePassword},
+	} {
+		if source.value != "" {
+			keys = append(keys, source.key)
+		}
+	}
+	return keys
+}
+
+// resolve returns the effective value of the entry.
+func (s *ValueSpec) resolve(baseDir string, opts LoadOptions) (string, error) {
+	if sources := s.sources(); len(sources) > 1 {
+		return "", fmt.Errorf("only one value source can be set, got %s", strings.Join(sources, " and "))
+	}
+	if opts.SkipValues && s.external() {
+		return unresolvedValue, nil
+	}
+
+	switch {
+	case s.FromFile != "":
+		return s.readFile(baseDir)
+	case s.FromEnv != "":
+		value, ok := os.LookupEnv(s.FromEnv)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", s.FromEnv)
+		}
+		return value, nil
+	case s.FromCommand != "":
+		return s.runCommand(baseDir, opts)
+	case s.Encrypted != "":
+		return decryptAge(s.Encrypted, opts.Identity)
+	default:
+		if store, ref := s.storeReference(); store != "" {
+			return resolveStoreReference(store, ref, opts)
+		}
+		return s.Value, nil
+	}
+}
+
+// commandTimeout returns the timeout of each external command.
+func commandTimeout(opts LoadOptions) time.Duration {
+	if opts.CommandTimeout <= 0 {
+		return DefaultCommandTimeout
+	}
+	return opts.CommandTimeout
+}
+
+// runCommand executes a from_command entry and returns its standard output
+// without the trailing newline.
+func (s *ValueSpec) runCommand(baseDir string, opts LoadOptions) (string, error) {
+	if !opts.AllowCommands {
+		return "", fmt.Errorf("from_command values execute arbitrary commands and are disabled; pass --allow-commands to enable them")
+	}
+
+	timeout := commandTimeout(opts)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", s.FromCommand)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", s.FromCommand)
+	}
+	cmd.Dir = baseDir
+	// Do not wait for grandchildren that keep the output pipes open after a timeout
+	cmd.WaitDelay = time.Second
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", fmt.Errorf("command timed out after %s", timeout)
+		}
+		if msg := errorOutput(stderr.String()); msg != "" {
+			return "", fmt.Errorf("command failed: %w: %s", err, msg)
+		}
+		return "", fmt.Errorf("command failed: %w", err)
+	}
+
+	value := strings.TrimSuffix(stdout.String(), "\n")
+	value = strings.TrimSuffix(value, "\r")
+	if len(value) > MaxValueSize {
+		return "", fmt.Errorf("command output is %d bytes, exceeding the GitHub limit of %d bytes", len(value), MaxValueSize)
+	}
+	if !utf8.ValidString(value) {
+		return "", fmt.Errorf("command output is not valid UTF-8; base64 encode binary content first")
+	}
+
+	return value, nil
+}
+
+// maxErrorOutput caps the standard error of a failed command quoted in its
+// error.
+const maxErrorOutput = 200
+
+// commandName returns the executable of a from_command value, which is
+// recorded as its origin instead of the command, whose arguments or variable
+// assignments may hold credentials.
+func commandName(command string) string {
+	for _, field := range strings.Fields(command) {
+		if !strings.Contains(field, "=") {
+			return filepath.Base(field)
+		}
+	}
+	return "command"
+}
+
+// errorOutput returns the first line of the standard error of a failed
+// command, truncated to maxErrorOutput bytes, since commands may echo the
+// secret or their credentials on failure.
+func errorOutput(stderr string) string {
+	msg, _, _ := strings.Cut(strings.TrimSpace(stderr), "\n")
+	msg = strings.TrimSpace(msg)
+	if len(msg) > maxErrorOutput {
+		msg = strings.ToValidUTF8(msg[:maxErrorOutput], "") + "..."
+	}
+	return msg
+}
+
+// readFile loads the value of a from_file entry.
+func (s *ValueSpec) readFile(baseDir string) (string, error) {
+	path := s.FromFile
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(baseDir, path)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read value file: %w", err)
+	}
+	if info.Size() > MaxValueSize {
+		return "", fmt.Errorf("value file %s is %d bytes, exceeding the GitHub limit of %d bytes", s.FromFile, info.Size(), MaxValueSize)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read value file: %w", err)
+	}
+	if !utf8.Valid(data) {
+		return "", fmt.Errorf("value file %s is not valid UTF-8; base64 encode binary content first", s.FromFile)
+	}
+
+	return string(data), nil
+}
+
+// setValue stores the resolved value of a structured entry.
+func (c *Config) setValue(spec *ValueSpec, value string) {
+	if spec.Scope == SectionDefaults {
+		c.Defaults.set(spec.Section, spec.Name, value)
+		return
+	}
+	switch spec.Section {
+	case SectionOrganizationSecrets:
+		if c.OrganizationSecrets == nil {
+			c.OrganizationSecrets = make(map[string]string)
+		}
+		c.OrganizationSecrets[spec.Name] = value
+		return
+	case SectionOrganizationVariables:
+		if c.OrganizationVariables == nil {
+			c.OrganizationVariables = make(map[string]string)
+		}
+		c.OrganizationVariables[spec.Name] = value
+		return
+	case SectionUserCodespacesSecrets:
+		if c.UserCodespacesSecrets == nil {
+			c.UserCodespacesSecrets = make(map[string]string)
+		}
+		c.UserCodespacesSecrets[spec.Name] = value
+		return
+	}
+	if spec.Scope != "" {
+		section, name, _ := strings.Cut(spec.Scope, ".")
+		scoped := c.scopedResources(section)
+		resources := scoped[name]
+		resources.set(spec.Section, spec.Environment, spec.Name, value)
+		scoped[name] = resources
+		return
+	}
+
+	global := c.Global()
+	global.set(spec.Section, spec.Environment, spec.Name, value)
+	c.setGlobal(global)
+}
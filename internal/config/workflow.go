@@ -0,0 +1,50 @@
+package config
+
+// SectionWorkflowPermissions declares the default GITHUB_TOKEN permissions of repositories.
+const SectionWorkflowPermissions = "workflow_permissions"
+
+// Default GITHUB_TOKEN permissions of workflows.
+const (
+	WorkflowPermissionsRead  = "read"
+	WorkflowPermissionsWrite = "write"
+)
+
+// WorkflowPermissions declares the default permissions granted to the
+// GITHUB_TOKEN of workflows in the target repositories.
+type WorkflowPermissions struct {
+	// Default is "read" (contents and packages only) or "write" (all scopes)
+	Default string `yaml:"default"`
+	// CanApprovePullRequests allows workflows to create and approve pull requests
+	CanApprovePullRequests bool `yaml:"can_approve_pull_requests"`
+	// Repos restricts the permissions to these repositories
+	Repos []string `yaml:"repos"`
+}
+
+// WorkflowPermissionsFor returns the workflow permissions to apply to a
+// repository, or nil if there are none.
+func (c *Config) WorkflowPermissionsFor(repo string) *WorkflowPermissions {
+	p := c.WorkflowPermissions
+	if p == nil || (len(p.Repos) > 0 && !contains(p.Repos, repo)) {
+		return nil
+	}
+	return p
+}
+
+// validateWorkflowPermissions checks the workflow_permissions section.
+func (c *Config) validateWorkflowPermissions() error {
+	p := c.WorkflowPermissions
+	if p == nil {
+		return nil
+	}
+	key := SectionWorkflowPermissions
+
+	if p.Default != WorkflowPermissionsRead && p.Default != WorkflowPermissionsWrite {
+		return keyError(key+".default", "%s.default must be 'read' or 'write', got '%s'", key, p.Default)
+	}
+	for _, repo := range p.Repos {
+		if repo == "" {
+			return keyError(key+".repos", "%s.repos entries cannot be empty", key)
+		}
+	}
+	return nil
+}
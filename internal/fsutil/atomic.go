@@ -0,0 +1,79 @@
+// Package fsutil provides crash-safe file helpers for local metadata such as
+// the state file, caches and reports.
+package fsutil
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// WriteFileAtomic writes data to path so that readers observe either the old
+// or the new content, never a partial write. The data is written to a
+// temporary file in the same directory, fsync'd and renamed over path.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmpName := tmp.Name()
+
+	// Remove the temporary file on any failure before the rename
+	committed := false
+	defer func() {
+		if !committed {
+			tmp.Close()
+			os.Remove(tmpName)
+		}
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		return fmt.Errorf("failed to write temporary file: %w", err)
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		return fmt.Errorf("failed to set file permissions: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return fmt.Errorf("failed to sync temporary file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temporary file: %w", err)
+	}
+
+	if err := os.Rename(tmpName, path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	committed = true
+
+	// Persist the rename itself
+	return syncDir(dir)
+}
+
+// UpdateFile runs a locked read-modify-write cycle on path. fn receives the
+// current content (nil if the file does not exist) and returns the new
+// content, which is written atomically while the lock is held.
+func UpdateFile(path string, perm os.FileMode, fn func(current []byte) ([]byte, error)) error {
+	lock, err := LockFile(path)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	current, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	updated, err := fn(current)
+	if err != nil {
+		return err
+	}
+
+	return WriteFileAtomic(path, updated, perm)
+}
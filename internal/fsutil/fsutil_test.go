@@ -0,0 +1,74 @@
+package fsutil
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "nested", "state.json")
+
+	require.NoError(t, WriteFileAtomic(path, []byte("first"), 0o600))
+	require.NoError(t, WriteFileAtomic(path, []byte("second"), 0o600))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(data))
+
+	// No temporary files are left behind
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestTryLockFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	lock, err := LockFile(path)
+	require.NoError(t, err)
+
+	_, err = TryLockFile(path)
+	assert.True(t, errors.Is(err, ErrLocked))
+
+	require.NoError(t, lock.Unlock())
+
+	lock, err = TryLockFile(path)
+	require.NoError(t, err)
+	assert.NoError(t, lock.Unlock())
+}
+
+func TestUpdateFile_Concurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "counter")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := UpdateFile(path, 0o600, func(current []byte) ([]byte, error) {
+				n := 0
+				if len(current) > 0 {
+					var err error
+					if n, err = strconv.Atoi(string(current)); err != nil {
+						return nil, err
+					}
+				}
+				return []byte(strconv.Itoa(n + 1)), nil
+			})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "20", string(data))
+}
@@ -0,0 +1,65 @@
+package fsutil
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ErrLocked is returned by TryLockFile when another process holds the lock.
+var ErrLocked = errors.New("file is locked by another process")
+
+// Lock is an advisory inter-process lock guarding a file. The lock is held on
+// a sidecar "<path>.lock" file so the guarded file can be replaced atomically
+// while locked.
+type Lock struct {
+	file *os.File
+}
+
+// LockFile acquires an exclusive lock for path, blocking until it is available.
+func LockFile(path string) (*Lock, error) {
+	return acquire(path, true)
+}
+
+// TryLockFile acquires an exclusive lock for path without blocking.
+// It returns ErrLocked if the lock is held elsewhere.
+func TryLockFile(path string) (*Lock, error) {
+	return acquire(path, false)
+}
+
+func acquire(path string, wait bool) (*Lock, error) {
+	lockPath := path + ".lock"
+	if err := os.MkdirAll(filepath.Dir(lockPath), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create lock directory: %w", err)
+	}
+
+	f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file %s: %w", lockPath, err)
+	}
+
+	if err := lockHandle(f, wait); err != nil {
+		f.Close()
+		if errors.Is(err, ErrLocked) {
+			return nil, fmt.Errorf("%s: %w", path, ErrLocked)
+		}
+		return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+
+	return &Lock{file: f}, nil
+}
+
+// Unlock releases the lock. The sidecar lock file is left in place so that
+// concurrent lockers always agree on the same inode.
+func (l *Lock) Unlock() error {
+	if l == nil || l.file == nil {
+		return nil
+	}
+	err := unlockHandle(l.file)
+	if closeErr := l.file.Close(); err == nil {
+		err = closeErr
+	}
+	l.file = nil
+	return err
+}
@@ -0,0 +1,90 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-github/v57/github"
+)
+
+// ActionsPermissions holds the GitHub Actions policy of a repository.
+type ActionsPermissions struct {
+	// Enabled turns GitHub Actions on or off
+	Enabled bool
+	// AllowedActions is "all", "local_only" or "selected"; empty keeps the
+	// current policy, and it is ignored when disabled
+	AllowedActions string
+	// GitHubOwnedAllowed, VerifiedAllowed and PatternsAllowed list the allowed
+	// actions when AllowedActions is "selected"
+	GitHubOwnedAllowed bool
+	VerifiedAllowed    bool
+	PatternsAllowed    []string
+}
+
+// GetActionsEnabled reports whether GitHub Actions is enabled for a repository.
+func (c *githubClient) GetActionsEnabled(ctx context.Context, owner, repo string) (bool, error) {
+	permissions, _, err := c.client.Repositories.GetActionsPermissions(ctx, owner, repo)
+	if err != nil {
+		return false, handleGitHubError(err, owner, repo, "", "", "")
+	}
+	return permissions.GetEnabled(), nil
+}
+
+// SetActionsPermissions replaces the GitHub Actions policy of a repository,
+// including the list of allowed actions when only selected actions are allowed.
+func (c *githubClient) SetActionsPermissions(ctx context.Context, owner, repo string, permissions ActionsPermissions) error {
+	edit := github.ActionsPermissionsRepository{Enabled: github.Bool(permissions.Enabled)}
+	if permissions.Enabled && permissions.AllowedActions != "" {
+		edit.AllowedActions = github.String(permissions.AllowedActions)
+	}
+	if _, _, err := c.client.Repositories.EditActionsPermissions(ctx, owner, repo, edit); err != nil {
+		return handleGitHubError(err, owner, repo, "", "", "")
+	}
+
+	if !permissions.Enabled || permissions.AllowedActions != "selected" {
+		return nil
+	}
+	// go-github omits an empty pattern list, which would keep the current patterns
+	allowed := struct {
+		GitHubOwnedAllowed bool     `json:"github_owned_allowed"`
+		VerifiedAllowed    bool     `json:"verified_allowed"`
+		PatternsAllowed    []string `json:"patterns_allowed"`
+	}{permissions.GitHubOwnedAllowed, permissions.VerifiedAllowed, permissions.PatternsAllowed}
+	if allowed.PatternsAllowed == nil {
+		allowed.PatternsAllowed = []string{}
+	}
+	req, err := c.client.NewRequest(http.MethodPut, fmt.Sprintf("repos/%v/%v/actions/permissions/selected-actions", owner, repo), allowed)
+	if err != nil {
+		return err
+	}
+	if _, err := c.client.Do(ctx, req, nil); err != nil {
+		return handleGitHubError(err, owner, repo, "", "", "")
+	}
+	return nil
+}
+
+// WorkflowPermissions holds the default permissions of the GITHUB_TOKEN of
+// workflows in a repository.
+type WorkflowPermissions struct {
+	// Default is "read" or "write"
+	Default string
+	// CanApprovePullRequests allows workflows to create and approve pull requests
+	CanApprovePullRequests bool
+}
+
+// SetWorkflowPermissions replaces the default GITHUB_TOKEN permissions of a repository.
+func (c *githubClient) SetWorkflowPermissions(ctx context.Context, owner, repo string, permissions WorkflowPermissions) error {
+	body := struct {
+		DefaultWorkflowPermissions   string `json:"default_workflow_permissions"`
+		CanApprovePullRequestReviews bool   `json:"can_approve_pull_request_reviews"`
+	}{permissions.Default, permissions.CanApprovePullRequests}
+	req, err := c.client.NewRequest(http.MethodPut, fmt.Sprintf("repos/%v/%v/actions/permissions/workflow", owner, repo), body)
+	if err != nil {
+		return err
+	}
+	if _, err := c.client.Do(ctx, req, nil); err != nil {
+		return handleGitHubError(err, owner, repo, "", "", "")
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetActionsPermissions_Selected(t *testing.T) {
+	var permissions, selected map[string]interface{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/o/r/actions/permissions", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&permissions))
+		w.Write([]byte(`{}`))
+	})
+	mux.HandleFunc("/repos/o/r/actions/permissions/selected-actions", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&selected))
+		w.WriteHeader(http.StatusNoContent)
+	})
+	client := newTestClient(t, mux)
+
+	err := client.SetActionsPermissions(context.Background(), "o", "r", ActionsPermissions{
+		Enabled:            true,
+		AllowedActions:     "selected",
+		GitHubOwnedAllowed: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"enabled": true, "allowed_actions": "selected"}, permissions)
+	// An empty pattern list is sent explicitly to clear the current patterns
+	assert.Equal(t, map[string]interface{}{
+		"github_owned_allowed": true,
+		"verified_allowed":     false,
+		"patterns_allowed":     []interface{}{},
+	}, selected)
+}
+
+func TestSetActionsPermissions_Disabled(t *testing.T) {
+	var permissions map[string]interface{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/o/r/actions/permissions", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&permissions))
+		w.Write([]byte(`{}`))
+	})
+	mux.HandleFunc("/repos/o/r/actions/permissions/selected-actions", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("selected actions must not be set when Actions is disabled")
+	})
+	client := newTestClient(t, mux)
+
+	err := client.SetActionsPermissions(context.Background(), "o", "r", ActionsPermissions{AllowedActions: "selected"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"enabled": false}, permissions)
+}
+
+func TestSetWorkflowPermissions(t *testing.T) {
+	var body map[string]interface{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/o/r/actions/permissions/workflow", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.WriteHeader(http.StatusNoContent)
+	})
+	client := newTestClient(t, mux)
+
+	err := client.SetWorkflowPermissions(context.Background(), "o", "r", WorkflowPermissions{Default: "read"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"default_workflow_permissions":     "read",
+		"can_approve_pull_request_reviews": false,
+	}, body)
+}
+
+func TestGetActionsEnabled(t *testing.T) {
+	var enableBody map[string]interface{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/o/r/actions/permissions", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			w.Write([]byte(`{"enabled": false}`))
+		case http.MethodPut:
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&enableBody))
+			w.Write([]byte(`{}`))
+		}
+	})
+	client := newTestClient(t, mux)
+
+	enabled, err := client.GetActionsEnabled(context.Background(), "o", "r")
+	require.NoError(t, err)
+	assert.False(t, enabled)
+
+	// Enabling Actions without an allowed actions policy keeps the current one
+	require.NoError(t, client.SetActionsPermissions(context.Background(), "o", "r", ActionsPermissions{Enabled: true}))
+	assert.Equal(t, map[string]interface{}{"enabled": true}, enableBody)
+}
@@ -0,0 +1,47 @@
+package github
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/bradleyfalzon/ghinstallation/v2"
+)
+
+// AppCredentials identifies a GitHub App installation.
+type AppCredentials struct {
+	AppID          int64
+	InstallationID int64
+	// PrivateKeyPath is the PEM private key of the app
+	PrivateKeyPath string
+}
+
+// NewAppClient creates a GitHub client authenticated as a GitHub App
+// installation. Installation tokens are minted with the app's private key and
+// refreshed automatically when they expire, so long runs keep working.
+func NewAppClient(creds AppCredentials, opts Options) (Client, error) {
+	base, err := newBaseTransport(opts.Network)
+	if err != nil {
+		return nil, err
+	}
+	tr, err := newAppTransport(base, creds)
+	if err != nil {
+		return nil, err
+	}
+	opts.cacheScope = fmt.Sprintf("app:%d:%d", creds.AppID, creds.InstallationID)
+	client, err := newGitHubClient(tr, opts)
+	if err != nil {
+		return nil, err
+	}
+	// Installation tokens are minted by the same server
+	tr.BaseURL = strings.TrimSuffix(client.BaseURL.String(), "/")
+	return withAudit(withOperationRetry(&githubClient{client: client, operationTimeout: opts.OperationTimeout}, opts), opts), nil
+}
+
+func newAppTransport(base http.RoundTripper, creds AppCredentials) (*ghinstallation.Transport, error) {
+	tr, err := ghinstallation.NewKeyFromFile(base, creds.AppID, creds.InstallationID, creds.PrivateKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load GitHub App private key %s: %w", creds.PrivateKeyPath, err)
+	}
+	return tr, nil
+}
@@ -0,0 +1,77 @@
+package github
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeAppKey(t *testing.T) string {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "app.pem")
+	block := &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(block), 0o600))
+	return path
+}
+
+func TestNewAppClient_RefreshesExpiringTokens(t *testing.T) {
+	var minted atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/app/installations/7/access_tokens", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Contains(t, r.Header.Get("Authorization"), "Bearer ")
+		n := minted.Add(1)
+		// Tokens close to expiry are refreshed before the next request
+		expires := time.Now().Add(30 * time.Second).UTC().Format(time.RFC3339)
+		fmt.Fprintf(w, `{"token": "installation-%d", "expires_at": %q}`, n, expires)
+	})
+	var tokens []string
+	mux.HandleFunc("/repos/o/", func(w http.ResponseWriter, r *http.Request) {
+		tokens = append(tokens, r.Header.Get("Authorization"))
+		fmt.Fprint(w, `{"id": 42, "name": "r"}`)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	tr, err := newAppTransport(http.DefaultTransport, AppCredentials{AppID: 1, InstallationID: 7, PrivateKeyPath: writeAppKey(t)})
+	require.NoError(t, err)
+	tr.BaseURL = server.URL
+
+	client := &githubClient{client: github.NewClient(newHTTPClient(tr, Options{}))}
+	baseURL, err := url.Parse(server.URL + "/")
+	require.NoError(t, err)
+	client.client.BaseURL = baseURL
+
+	for i := 0; i < 2; i++ {
+		id, err := client.GetRepositoryID(context.Background(), "o", fmt.Sprintf("r%d", i))
+		require.NoError(t, err)
+		assert.Equal(t, int64(42), id)
+	}
+	assert.Equal(t, []string{"token installation-1", "token installation-2"}, tokens)
+}
+
+func TestNewAppClient_InvalidKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.pem")
+	require.NoError(t, os.WriteFile(path, []byte("not a key"), 0o600))
+
+	_, err := NewAppClient(AppCredentials{AppID: 1, InstallationID: 7, PrivateKeyPath: path}, Options{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to load GitHub App private key")
+}
@@ -0,0 +1,211 @@
+package github
+
+import "context"
+
+// Operations of a Change.
+const (
+	ChangeCreate = "create"
+	ChangeUpdate = "update"
+	// ChangeSet is a write that GitHub does not report as a create or an update
+	ChangeSet    = "set"
+	ChangeDelete = "delete"
+)
+
+// Change is a write made through the client, e.g. for an audit log. It never
+// holds the written values.
+type Change struct {
+	// Operation is ChangeCreate, ChangeUpdate, ChangeSet or ChangeDelete
+	Operation string
+	// Resource is the kind of the written resource, e.g. repository_secret
+	Resource string
+	// Owner is the owner of the repository or the organization (empty: the
+	// authenticated user)
+	Owner       string
+	Repo        string
+	Environment string
+	Name        string
+	// Err is the error of a failed write
+	Err error
+}
+
+// ChangeRecorder records the writes made through a client. It must be safe
+// for concurrent use.
+type ChangeRecorder interface {
+	RecordChange(change Change)
+}
+
+// auditClient reports every write to a ChangeRecorder once it succeeded or
+// failed for good, i.e. after the retries of operationRetryClient.
+type auditClient struct {
+	Client
+	recorder ChangeRecorder
+}
+
+// withAudit wraps client to record its writes as configured by opts.
+func withAudit(client Client, opts Options) Client {
+	if opts.Changes == nil {
+		return client
+	}
+	return &auditClient{Client: client, recorder: opts.Changes}
+}
+
+// record reports a write of a resource of a repository or organization.
+func (c *auditClient) record(operation, resource, owner, repo, environment, name string, err error) {
+	c.recorder.RecordChange(Change{Operation: operation, Resource: resource, Owner: owner, Repo: repo, Environment: environment, Name: name, Err: err})
+}
+
+// setOperation is the operation of a write reporting whether it created the
+// resource; a failed write is neither.
+func setOperation(created bool, err error) string {
+	switch {
+	case err != nil:
+		return ChangeSet
+	case created:
+		return ChangeCreate
+	}
+	return ChangeUpdate
+}
+
+func (c *auditClient) SetRepositorySecret(ctx context.Context, owner, repo, name, secretValue string) (bool, error) {
+	created, err := c.Client.SetRepositorySecret(ctx, owner, repo, name, secretValue)
+	c.record(setOperation(created, err), "repository_secret", owner, repo, "", name, err)
+	return created, err
+}
+
+func (c *auditClient) DeleteRepositorySecret(ctx context.Context, owner, repo, name string) error {
+	err := c.Client.DeleteRepositorySecret(ctx, owner, repo, name)
+	c.record(ChangeDelete, "repository_secret", owner, repo, "", name, err)
+	return err
+}
+
+func (c *auditClient) SetEnvironmentSecret(ctx context.Context, owner, repo, environment, name, secretValue string) (bool, error) {
+	created, err := c.Client.SetEnvironmentSecret(ctx, owner, repo, environment, name, secretValue)
+	c.record(setOperation(created, err), "environment_secret", owner, repo, environment, name, err)
+	return created, err
+}
+
+func (c *auditClient) DeleteEnvironmentSecret(ctx context.Context, owner, repo, environment, name string) error {
+	err := c.Client.DeleteEnvironmentSecret(ctx, owner, repo, environment, name)
+	c.record(ChangeDelete, "environment_secret", owner, repo, environment, name, err)
+	return err
+}
+
+func (c *auditClient) SetCodespacesSecret(ctx context.Context, owner, repo, name, secretValue string) (bool, error) {
+	created, err := c.Client.SetCodespacesSecret(ctx, owner, repo, name, secretValue)
+	c.record(setOperation(created, err), "codespaces_secret", owner, repo, "", name, err)
+	return created, err
+}
+
+func (c *auditClient) DeleteCodespacesSecret(ctx context.Context, owner, repo, name string) error {
+	err := c.Client.DeleteCodespacesSecret(ctx, owner, repo, name)
+	c.record(ChangeDelete, "codespaces_secret", owner, repo, "", name, err)
+	return err
+}
+
+func (c *auditClient) SetUserCodespacesSecret(ctx context.Context, name, secretValue string, selectedRepoIDs []int64) error {
+	err := c.Client.SetUserCodespacesSecret(ctx, name, secretValue, selectedRepoIDs)
+	c.record(ChangeSet, "user_codespaces_secret", "", "", "", name, err)
+	return err
+}
+
+func (c *auditClient) DeleteUserCodespacesSecret(ctx context.Context, name string) error {
+	err := c.Client.DeleteUserCodespacesSecret(ctx, name)
+	c.record(ChangeDelete, "user_codespaces_secret", "", "", "", name, err)
+	return err
+}
+
+func (c *auditClient) SetDependabotSecret(ctx context.Context, owner, repo, name, secretValue string) error {
+	err := c.Client.SetDependabotSecret(ctx, owner, repo, name, secretValue)
+	c.record(ChangeSet, "dependabot_secret", owner, repo, "", name, err)
+	return err
+}
+
+func (c *auditClient) DeleteDependabotSecret(ctx context.Context, owner, repo, name string) error {
+	err := c.Client.DeleteDependabotSecret(ctx, owner, repo, name)
+	c.record(ChangeDelete, "dependabot_secret", owner, repo, "", name, err)
+	return err
+}
+
+func (c *auditClient) SetOrganizationSecret(ctx context.Context, org, name, secretValue, visibility string, selectedRepoIDs []int64) error {
+	err := c.Client.SetOrganizationSecret(ctx, org, name, secretValue, visibility, selectedRepoIDs)
+	c.record(ChangeSet, "organization_secret", org, "", "", name, err)
+	return err
+}
+
+func (c *auditClient) DeleteOrganizationSecret(ctx context.Context, org, name string) error {
+	err := c.Client.DeleteOrganizationSecret(ctx, org, name)
+	c.record(ChangeDelete, "organization_secret", org, "", "", name, err)
+	return err
+}
+
+func (c *auditClient) SetOrganizationSecretRepositories(ctx context.Context, org, name string, repoIDs []int64) error {
+	err := c.Client.SetOrganizationSecretRepositories(ctx, org, name, repoIDs)
+	c.record(ChangeUpdate, "organization_secret_repositories", org, "", "", name, err)
+	return err
+}
+
+func (c *auditClient) SetOrganizationVariable(ctx context.Context, org, name, value, visibility string, selectedRepoIDs []int64) error {
+	err := c.Client.SetOrganizationVariable(ctx, org, name, value, visibility, selectedRepoIDs)
+	c.record(ChangeSet, "organization_variable", org, "", "", name, err)
+	return err
+}
+
+func (c *auditClient) DeleteOrganizationVariable(ctx context.Context, org, name string) error {
+	err := c.Client.DeleteOrganizationVariable(ctx, org, name)
+	c.record(ChangeDelete, "organization_variable", org, "", "", name, err)
+	return err
+}
+
+func (c *auditClient) SetRepositoryVariable(ctx context.Context, owner, repo, name, value string) (bool, error) {
+	created, err := c.Client.SetRepositoryVariable(ctx, owner, repo, name, value)
+	c.record(setOperation(created, err), "repository_variable", owner, repo, "", name, err)
+	return created, err
+}
+
+func (c *auditClient) DeleteRepositoryVariable(ctx context.Context, owner, repo, name string) error {
+	err := c.Client.DeleteRepositoryVariable(ctx, owner, repo, name)
+	c.record(ChangeDelete, "repository_variable", owner, repo, "", name, err)
+	return err
+}
+
+func (c *auditClient) SetEnvironmentVariable(ctx context.Context, owner, repo, environment, name, value string) (bool, error) {
+	created, err := c.Client.SetEnvironmentVariable(ctx, owner, repo, environment, name, value)
+	c.record(setOperation(created, err), "environment_variable", owner, repo, environment, name, err)
+	return created, err
+}
+
+func (c *auditClient) DeleteEnvironmentVariable(ctx context.Context, owner, repo, environment, name string) error {
+	err := c.Client.DeleteEnvironmentVariable(ctx, owner, repo, environment, name)
+	c.record(ChangeDelete, "environment_variable", owner, repo, environment, name, err)
+	return err
+}
+
+func (c *auditClient) CreateEnvironment(ctx context.Context, owner, repo, environment string) error {
+	err := c.Client.CreateEnvironment(ctx, owner, repo, environment)
+	c.record(ChangeCreate, "environment", owner, repo, environment, "", err)
+	return err
+}
+
+func (c *auditClient) SetEnvironmentProtection(ctx context.Context, owner, repo, environment string, protection EnvironmentProtection) error {
+	err := c.Client.SetEnvironmentProtection(ctx, owner, repo, environment, protection)
+	c.record(ChangeUpdate, "environment_protection", owner, repo, environment, "", err)
+	return err
+}
+
+func (c *auditClient) SetActionsPermissions(ctx context.Context, owner, repo string, permissions ActionsPermissions) error {
+	err := c.Client.SetActionsPermissions(ctx, owner, repo, permissions)
+	c.record(ChangeUpdate, "actions_permissions", owner, repo, "", "", err)
+	return err
+}
+
+func (c *auditClient) SetWorkflowPermissions(ctx context.Context, owner, repo string, permissions WorkflowPermissions) error {
+	err := c.Client.SetWorkflowPermissions(ctx, owner, repo, permissions)
+	c.record(ChangeUpdate, "workflow_permissions", owner, repo, "", "", err)
+	return err
+}
+
+func (c *auditClient) SetSecret(ctx context.Context, owner, repo, name, secretValue string) error {
+	err := c.Client.SetSecret(ctx, owner, repo, name, secretValue)
+	c.record(ChangeSet, "repository_secret", owner, repo, "", name, err)
+	return err
+}
@@ -0,0 +1,64 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// changeLog is a ChangeRecorder keeping the recorded changes.
+type changeLog struct {
+	mu      sync.Mutex
+	changes []Change
+}
+
+func (l *changeLog) RecordChange(change Change) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.changes = append(l.changes, change)
+}
+
+func TestAuditClient_RecordsWrites(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/owner/repo/actions/variables/REGION", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`{"name":"REGION","value":"eu","created_at":"2024-01-01T00:00:00Z","updated_at":"2024-01-01T00:00:00Z"}`))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/repos/owner/repo/actions/secrets/TOKEN", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+	mux.HandleFunc("/repos/owner/repo/environments/staging", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"name":"staging"}`))
+	})
+
+	changes := &changeLog{}
+	client := withAudit(newTestClient(t, mux), Options{Changes: changes})
+	ctx := context.Background()
+
+	// Reads are not recorded
+	_, err := client.GetRepositoryVariable(ctx, "owner", "repo", "REGION")
+	require.NoError(t, err)
+	_, err = client.SetRepositoryVariable(ctx, "owner", "repo", "REGION", "us")
+	require.NoError(t, err)
+	deleteErr := client.DeleteRepositorySecret(ctx, "owner", "repo", "TOKEN")
+	require.Error(t, deleteErr)
+	require.NoError(t, client.CreateEnvironment(ctx, "owner", "repo", "staging"))
+
+	assert.Equal(t, []Change{
+		{Operation: ChangeUpdate, Resource: "repository_variable", Owner: "owner", Repo: "repo", Name: "REGION"},
+		{Operation: ChangeDelete, Resource: "repository_secret", Owner: "owner", Repo: "repo", Name: "TOKEN", Err: deleteErr},
+		{Operation: ChangeCreate, Resource: "environment", Owner: "owner", Repo: "repo", Environment: "staging"},
+	}, changes.changes)
+}
+
+func TestAuditClient_Disabled(t *testing.T) {
+	client := newTestClient(t, http.NewServeMux())
+	assert.Same(t, client, withAudit(client, Options{}))
+}
@@ -2,33 +2,98 @@ package github
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/google/go-github/v57/github"
 	"golang.org/x/oauth2"
+	"golang.org/x/sync/singleflight"
 )
 
 // Client is the interface for GitHub API operations.
 type Client interface {
 	// Repository Secrets
 	GetPublicKey(ctx context.Context, owner, repo string) (*PublicKey, error)
-	SetRepositorySecret(ctx context.Context, owner, repo, name, secretValue string) error
+	SetRepositorySecret(ctx context.Context, owner, repo, name, secretValue string) (created bool, err error)
 	GetRepositorySecret(ctx context.Context, owner, repo, name string) (*SecretMetadata, error)
+	DeleteRepositorySecret(ctx context.Context, owner, repo, name string) error
+	ListRepositorySecrets(ctx context.Context, owner, repo string) ([]SecretMetadata, error)
 
 	// Environment Secrets
 	GetEnvironmentPublicKey(ctx context.Context, owner, repo, environment string) (*PublicKey, error)
-	SetEnvironmentSecret(ctx context.Context, owner, repo, environment, name, secretValue string) error
+	SetEnvironmentSecret(ctx context.Context, owner, repo, environment, name, secretValue string) (created bool, err error)
 	GetEnvironmentSecret(ctx context.Context, owner, repo, environment, name string) (*SecretMetadata, error)
+	DeleteEnvironmentSecret(ctx context.Context, owner, repo, environment, name string) error
+	ListEnvironmentSecrets(ctx context.Context, owner, repo, environment string) ([]SecretMetadata, error)
+
+	// Codespaces Secrets
+	GetCodespacesPublicKey(ctx context.Context, owner, repo string) (*PublicKey, error)
+	SetCodespacesSecret(ctx context.Context, owner, repo, name, secretValue string) (created bool, err error)
+	GetCodespacesSecret(ctx context.Context, owner, repo, name string) (*SecretMetadata, error)
+	DeleteCodespacesSecret(ctx context.Context, owner, repo, name string) error
+	ListCodespacesSecrets(ctx context.Context, owner, repo string) ([]SecretMetadata, error)
+
+	// User Codespaces Secrets (of the authenticated user)
+	GetUserCodespacesPublicKey(ctx context.Context) (*PublicKey, error)
+	SetUserCodespacesSecret(ctx context.Context, name, secretValue string, selectedRepoIDs []int64) error
+	GetUserCodespacesSecret(ctx context.Context, name string) (*SecretMetadata, error)
+	DeleteUserCodespacesSecret(ctx context.Context, name string) error
+
+	// Dependabot Secrets
+	GetDependabotPublicKey(ctx context.Context, owner, repo string) (*PublicKey, error)
+	SetDependabotSecret(ctx context.Context, owner, repo, name, secretValue string) error
+	GetDependabotSecret(ctx context.Context, owner, repo, name string) (*SecretMetadata, error)
+	DeleteDependabotSecret(ctx context.Context, owner, repo, name string) error
+
+	// Organization Secrets
+	GetOrganizationPublicKey(ctx context.Context, org string) (*PublicKey, error)
+	SetOrganizationSecret(ctx context.Context, org, name, secretValue, visibility string, selectedRepoIDs []int64) error
+	GetOrganizationSecret(ctx context.Context, org, name string) (*OrganizationSecretMetadata, error)
+	DeleteOrganizationSecret(ctx context.Context, org, name string) error
+	SetOrganizationSecretRepositories(ctx context.Context, org, name string, repoIDs []int64) error
+	ListOrganizationSecretRepositories(ctx context.Context, org, name string) ([]Repository, error)
+
+	// Organization Variables
+	SetOrganizationVariable(ctx context.Context, org, name, value, visibility string, selectedRepoIDs []int64) error
+	GetOrganizationVariable(ctx context.Context, org, name string) (*OrganizationVariableMetadata, error)
+	DeleteOrganizationVariable(ctx context.Context, org, name string) error
+	ListOrganizationVariables(ctx context.Context, org string) ([]OrganizationVariableMetadata, error)
+	ListOrganizationVariableRepositories(ctx context.Context, org, name string) ([]Repository, error)
 
 	// Repository Variables
-	SetRepositoryVariable(ctx context.Context, owner, repo, name, value string) error
+	SetRepositoryVariable(ctx context.Context, owner, repo, name, value string) (created bool, err error)
 	GetRepositoryVariable(ctx context.Context, owner, repo, name string) (*VariableMetadata, error)
+	DeleteRepositoryVariable(ctx context.Context, owner, repo, name string) error
+	ListRepositoryVariables(ctx context.Context, owner, repo string) ([]VariableMetadata, error)
 
 	// Environment Variables
-	SetEnvironmentVariable(ctx context.Context, owner, repo, environment, name, value string) error
+	SetEnvironmentVariable(ctx context.Context, owner, repo, environment, name, value string) (created bool, err error)
 	GetEnvironmentVariable(ctx context.Context, owner, repo, environment, name string) (*VariableMetadata, error)
+	DeleteEnvironmentVariable(ctx context.Context, owner, repo, environment, name string) error
+	ListEnvironmentVariables(ctx context.Context, owner, repo, environment string) ([]VariableMetadata, error)
+
+	// Environments
+	EnvironmentExists(ctx context.Context, owner, repo, environment string) (bool, error)
+	ListEnvironments(ctx context.Context, owner, repo string) ([]string, error)
+	CreateEnvironment(ctx context.Context, owner, repo, environment string) error
+	SetEnvironmentProtection(ctx context.Context, owner, repo, environment string, protection EnvironmentProtection) error
+
+	// Actions Policy
+	GetActionsEnabled(ctx context.Context, owner, repo string) (bool, error)
+	SetActionsPermissions(ctx context.Context, owner, repo string, permissions ActionsPermissions) error
+	SetWorkflowPermissions(ctx context.Context, owner, repo string, permissions WorkflowPermissions) error
 
 	// Helper methods
 	GetRepositoryID(ctx context.Context, owner, repo string) (int64, error)
+	GetAuthenticatedUser(ctx context.Context) (string, error)
+	ListOwnerRepositories(ctx context.Context, owner string, opts *ListRepositoriesOptions) ([]Repository, error)
+	ListTeamRepositories(ctx context.Context, org, teamSlug string) ([]Repository, error)
+	GetRepositoriesMetadata(ctx context.Context, owner string, repos []string) (map[string]RepositoryMetadata, error)
 
 	// Legacy methods (for backward compatibility during migration)
 	SetSecret(ctx context.Context, owner, repo, name, secretValue string) error
@@ -59,46 +124,179 @@ type VariableMetadata struct {
 // githubClient implements the Client interface using go-github.
 type githubClient struct {
 	client *github.Client
+
+	// repoIDs caches repository IDs by lowercase owner/repo for the lifetime of the client
+	repoIDs sync.Map
+	// lookups shares concurrent lookups of repository IDs and public keys
+	lookups singleflight.Group
+	// operationTimeout bounds every secret and variable operation (0: no deadline)
+	operationTimeout time.Duration
+}
+
+// Options configures the GitHub client.
+type Options struct {
+	// RateLimit limits the rate of API requests; the zero value disables it
+	RateLimit RateLimit
+	// BaseURL is the REST API URL of a GitHub Enterprise Server (default: api.github.com)
+	BaseURL string
+	// UploadURL is the upload URL of a GitHub Enterprise Server (default: BaseURL)
+	UploadURL string
+	// APIVersion overrides the X-GitHub-Api-Version header sent with every request
+	APIVersion string
+	// Retry retries requests failing with transient errors; the zero value disables it
+	Retry Retry
+	// OperationRetries is the number of times a secret or variable write
+	// failing with a transient error is retried as a whole, with the backoff
+	// of Retry (0: no retries)
+	OperationRetries int
+	// Pacing pauses requests while the primary rate limit is nearly exhausted
+	Pacing Pacing
+	// ETagCache revalidates GET responses instead of fetching them again (nil: disabled)
+	ETagCache ETagCache
+	// Network sets the proxy and TLS options of the connection
+	Network Network
+	// RequestTimeout bounds every request attempt (0: no timeout)
+	RequestTimeout time.Duration
+	// OperationTimeout bounds every secret and variable operation, including
+	// its retries and rate limit waits (0: no deadline)
+	OperationTimeout time.Duration
+	// Observer is notified of every request attempt, retry and rate limit pause (nil: none)
+	Observer ClientObserver
+	// Changes records every write made through the client (nil: none)
+	Changes ChangeRecorder
+
+	// cacheScope identifies the credentials of the client in ETagCache keys
+	cacheScope string
 }
 
 // NewClient creates a new GitHub client.
 func NewClient(token string) Client {
-	ctx := context.Background()
+	// The default options cannot fail
+	client, _ := NewClientWithOptions(token, Options{})
+	return client
+}
+
+// NewClientWithOptions creates a new GitHub client with the given options.
+func NewClientWithOptions(token string, opts Options) (Client, error) {
+	base, err := newBaseTransport(opts.Network)
+	if err != nil {
+		return nil, err
+	}
 	ts := oauth2.StaticTokenSource(
 		&oauth2.Token{AccessToken: token},
 	)
-	tc := oauth2.NewClient(ctx, ts)
+	transport := &oauth2.Transport{Source: ts, Base: base}
+
+	sum := sha256.Sum256([]byte(token))
+	opts.cacheScope = "token:" + hex.EncodeToString(sum[:])
+	client, err := newGitHubClient(transport, opts)
+	if err != nil {
+		return nil, err
+	}
+	return withAudit(withOperationRetry(&githubClient{client: client, operationTimeout: opts.OperationTimeout}, opts), opts), nil
+}
+
+// newHTTPClient returns an HTTP client sending requests through transport,
+// behind the request timeout, the ETag cache, primary rate limit pacing, the
+// client-side rate limiter and the retry policy when configured.
+func newHTTPClient(transport http.RoundTripper, opts Options) *http.Client {
+	if opts.RequestTimeout > 0 {
+		transport = newTimeoutTransport(transport, opts.RequestTimeout)
+	}
+	if opts.Observer != nil {
+		transport = newObserverTransport(transport, opts.Observer)
+	}
+	if opts.ETagCache != nil {
+		transport = newETagTransport(transport, opts.ETagCache, opts.cacheScope+"\n"+opts.APIVersion)
+	}
+	if opts.Pacing.Enabled {
+		pacing := newPacingTransport(transport, opts.Pacing)
+		pacing.observer = opts.Observer
+		transport = pacing
+	}
+	if opts.RateLimit.RequestsPerSecond > 0 {
+		transport = newRateLimitTransport(transport, opts.RateLimit)
+	}
+	// Every retry goes through the rate limiter again
+	if opts.Retry.MaxAttempts > 1 || opts.Retry.MaxWait > 0 {
+		retry := newRetryTransport(transport, opts.Retry)
+		retry.observer = opts.Observer
+		transport = retry
+	}
+	return &http.Client{Transport: transport}
+}
 
-	return &githubClient{
-		client: github.NewClient(tc),
+// operation derives the context of one secret or variable operation from
+// ctx, bounded by the operation timeout, so that a stuck operation fails
+// instead of blocking its caller until the run ends.
+func (c *githubClient) operation(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.operationTimeout <= 0 {
+		return ctx, func() {}
 	}
+	return context.WithTimeout(ctx, c.operationTimeout)
 }
 
 // GetPublicKey retrieves the public key for a repository.
 func (c *githubClient) GetPublicKey(ctx context.Context, owner, repo string) (*PublicKey, error) {
-	key, _, err := c.client.Actions.GetRepoPublicKey(ctx, owner, repo)
-	if err != nil {
-		return nil, err
-	}
+	return shareLookup(ctx, &c.lookups, "actions-key "+owner+"/"+repo, func() (*PublicKey, error) {
+		key, _, err := c.client.Actions.GetRepoPublicKey(ctx, owner, repo)
+		if err != nil {
+			return nil, err
+		}
 
-	return &PublicKey{
-		KeyID: key.GetKeyID(),
-		Key:   key.GetKey(),
-	}, nil
+		return &PublicKey{
+			KeyID: key.GetKeyID(),
+			Key:   key.GetKey(),
+		}, nil
+	})
 }
 
-// GetRepositoryID retrieves the repository ID.
+// GetRepositoryID retrieves the repository ID. IDs are cached for the
+// lifetime of the client, since environment operations look them up
+// repeatedly, and repository listings and GetRepositoriesMetadata resolve
+// them in bulk. Concurrent lookups of the same ID send one request.
 func (c *githubClient) GetRepositoryID(ctx context.Context, owner, repo string) (int64, error) {
-	repository, _, err := c.client.Repositories.Get(ctx, owner, repo)
+	if id, ok := c.repoIDs.Load(repoIDKey(owner, repo)); ok {
+		return id.(int64), nil
+	}
+
+	return shareLookup(ctx, &c.lookups, "repo-id "+repoIDKey(owner, repo), func() (int64, error) {
+		repository, _, err := c.client.Repositories.Get(ctx, owner, repo)
+		if err != nil {
+			return 0, handleGitHubError(err, owner, repo, "", "", "")
+		}
+		c.cacheRepositoryID(owner, repo, repository.GetID())
+		return repository.GetID(), nil
+	})
+}
+
+// cacheRepositoryID remembers the ID of a repository for GetRepositoryID.
+func (c *githubClient) cacheRepositoryID(owner, repo string, id int64) {
+	if id != 0 {
+		c.repoIDs.Store(repoIDKey(owner, repo), id)
+	}
+}
+
+// repoIDKey returns the cache key of a repository. Names are
+// case-insensitive, and IDs never change while a repository exists.
+func repoIDKey(owner, repo string) string {
+	return strings.ToLower(owner + "/" + repo)
+}
+
+// GetAuthenticatedUser returns the login of the user the token belongs to.
+// GitHub App installation tokens do not belong to a user and fail.
+func (c *githubClient) GetAuthenticatedUser(ctx context.Context) (string, error) {
+	user, _, err := c.client.Users.Get(ctx, "")
 	if err != nil {
-		return 0, handleGitHubError(err, owner, repo, "", "", "")
+		return "", fmt.Errorf("failed to get the authenticated user: %w", err)
 	}
-	return repository.GetID(), nil
+	return user.GetLogin(), nil
 }
 
-// SetRepositorySecret sets a secret for a repository (alias for SetSecret for backward compatibility).
-func (c *githubClient) SetRepositorySecret(ctx context.Context, owner, repo, name, secretValue string) error {
-	return c.SetSecret(ctx, owner, repo, name, secretValue)
+// SetSecret sets a secret for a repository (alias for SetRepositorySecret for backward compatibility).
+func (c *githubClient) SetSecret(ctx context.Context, owner, repo, name, secretValue string) error {
+	_, err := c.SetRepositorySecret(ctx, owner, repo, name, secretValue)
+	return err
 }
 
 // GetRepositorySecret retrieves metadata about a repository secret (alias for GetSecret for backward compatibility).
@@ -108,6 +306,9 @@ func (c *githubClient) GetRepositorySecret(ctx context.Context, owner, repo, nam
 
 // GetSecret retrieves metadata about a secret (legacy method).
 func (c *githubClient) GetSecret(ctx context.Context, owner, repo, name string) (*SecretMetadata, error) {
+	ctx, cancel := c.operation(ctx)
+	defer cancel()
+
 	secret, _, err := c.client.Actions.GetRepoSecret(ctx, owner, repo, name)
 	if err != nil {
 		return nil, handleGitHubError(err, owner, repo, "", "repository_secret", name)
@@ -119,4 +320,3 @@ func (c *githubClient) GetSecret(ctx context.Context, owner, repo, name string)
 		UpdatedAt: secret.UpdatedAt.String(),
 	}, nil
 }
-
@@ -0,0 +1,49 @@
+package github
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-github/v57/github"
+)
+
+// newTestClient returns a client talking to a fake GitHub API served by mux.
+func newTestClient(t *testing.T, mux *http.ServeMux) *githubClient {
+	t.Helper()
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	client := github.NewClient(nil)
+	baseURL, err := url.Parse(server.URL + "/")
+	if err != nil {
+		t.Fatalf("failed to parse server URL: %v", err)
+	}
+	client.BaseURL = baseURL
+
+	return &githubClient{client: client}
+}
+
+// writePage writes one page of a paginated response, adding a Link header
+// pointing at the next page unless page is the last one.
+func writePage(w http.ResponseWriter, r *http.Request, page, lastPage int, body string) {
+	if page < lastPage {
+		next := *r.URL
+		query := next.Query()
+		query.Set("page", fmt.Sprint(page+1))
+		next.RawQuery = query.Encode()
+		w.Header().Set("Link", fmt.Sprintf(`<http://%s%s>; rel="next"`, r.Host, next.String()))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprint(w, body)
+}
+
+// pageNumber returns the requested page, defaulting to 1.
+func pageNumber(r *http.Request) int {
+	page := 1
+	fmt.Sscan(r.URL.Query().Get("page"), &page)
+	return page
+}
@@ -0,0 +1,192 @@
+package github
+
+import (
+	"context"
+
+	"github.com/google/go-github/v57/github"
+)
+
+// GetCodespacesPublicKey retrieves the public key for a repository's Codespaces
+// secrets, which differs from the key used for Actions secrets.
+func (c *githubClient) GetCodespacesPublicKey(ctx context.Context, owner, repo string) (*PublicKey, error) {
+	return shareLookup(ctx, &c.lookups, "codespaces-key "+owner+"/"+repo, func() (*PublicKey, error) {
+		key, _, err := c.client.Codespaces.GetRepoPublicKey(ctx, owner, repo)
+		if err != nil {
+			return nil, handleGitHubError(err, owner, repo, "", "codespaces_secret", "")
+		}
+
+		return &PublicKey{
+			KeyID: key.GetKeyID(),
+			Key:   key.GetKey(),
+		}, nil
+	})
+}
+
+// SetCodespacesSecret sets a Codespaces secret for a repository and reports
+// whether the secret was created. The secretValue is plaintext and will be
+// encrypted automatically.
+func (c *githubClient) SetCodespacesSecret(ctx context.Context, owner, repo, name, secretValue string) (bool, error) {
+	ctx, cancel := c.operation(ctx)
+	defer cancel()
+
+	publicKey, err := c.GetCodespacesPublicKey(ctx, owner, repo)
+	if err != nil {
+		return false, err
+	}
+
+	encrypted, err := encryptForKey(publicKey, secretValue)
+	if err != nil {
+		return false, err
+	}
+
+	secret := &github.EncryptedSecret{
+		Name:           name,
+		EncryptedValue: encrypted,
+		KeyID:          publicKey.KeyID,
+	}
+
+	resp, err := c.client.Codespaces.CreateOrUpdateRepoSecret(ctx, owner, repo, secret)
+	if err != nil {
+		return false, handleGitHubError(err, owner, repo, "", "codespaces_secret", name)
+	}
+
+	return isCreated(resp), nil
+}
+
+// GetCodespacesSecret retrieves metadata about a repository Codespaces secret.
+func (c *githubClient) GetCodespacesSecret(ctx context.Context, owner, repo, name string) (*SecretMetadata, error) {
+	ctx, cancel := c.operation(ctx)
+	defer cancel()
+
+	secret, _, err := c.client.Codespaces.GetRepoSecret(ctx, owner, repo, name)
+	if err != nil {
+		return nil, handleGitHubError(err, owner, repo, "", "codespaces_secret", name)
+	}
+
+	return &SecretMetadata{
+		Name:      secret.Name,
+		CreatedAt: secret.CreatedAt.String(),
+		UpdatedAt: secret.UpdatedAt.String(),
+	}, nil
+}
+
+// ListCodespacesSecrets returns the metadata of every Codespaces secret of a
+// repository, walking every page of results.
+func (c *githubClient) ListCodespacesSecrets(ctx context.Context, owner, repo string) ([]SecretMetadata, error) {
+	ctx, cancel := c.operation(ctx)
+	defer cancel()
+
+	opts := &github.ListOptions{PerPage: listPageSize}
+
+	var all []SecretMetadata
+	for {
+		secrets, resp, err := c.client.Codespaces.ListRepoSecrets(ctx, owner, repo, opts)
+		if err != nil {
+			return nil, handleGitHubError(err, owner, repo, "", "", "")
+		}
+		all = appendSecrets(all, secrets)
+		if resp.NextPage == 0 {
+			return all, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// DeleteCodespacesSecret deletes a repository Codespaces secret.
+// Deleting a secret that does not exist is not an error.
+func (c *githubClient) DeleteCodespacesSecret(ctx context.Context, owner, repo, name string) error {
+	ctx, cancel := c.operation(ctx)
+	defer cancel()
+
+	_, err := c.client.Codespaces.DeleteRepoSecret(ctx, owner, repo, name)
+	if err != nil && !isNotFound(err) {
+		return handleGitHubError(err, owner, repo, "", "codespaces_secret", name)
+	}
+	return nil
+}
+
+// GetUserCodespacesPublicKey retrieves the public key for the authenticated
+// user's Codespaces secrets.
+func (c *githubClient) GetUserCodespacesPublicKey(ctx context.Context) (*PublicKey, error) {
+	return shareLookup(ctx, &c.lookups, "user-codespaces-key", func() (*PublicKey, error) {
+		key, _, err := c.client.Codespaces.GetUserPublicKey(ctx)
+		if err != nil {
+			return nil, &SecretError{Type: "user_codespaces_secret", Err: err}
+		}
+
+		return &PublicKey{
+			KeyID: key.GetKeyID(),
+			Key:   key.GetKey(),
+		}, nil
+	})
+}
+
+// SetUserCodespacesSecret sets a Codespaces secret of the authenticated user.
+// A non-nil selectedRepoIDs replaces the repositories whose codespaces can
+// access the secret; nil leaves the current list unchanged. The secretValue is
+// plaintext and will be encrypted automatically.
+func (c *githubClient) SetUserCodespacesSecret(ctx context.Context, name, secretValue string, selectedRepoIDs []int64) error {
+	ctx, cancel := c.operation(ctx)
+	defer cancel()
+
+	publicKey, err := c.GetUserCodespacesPublicKey(ctx)
+	if err != nil {
+		return err
+	}
+
+	encrypted, err := encryptForKey(publicKey, secretValue)
+	if err != nil {
+		return err
+	}
+
+	secret := &github.EncryptedSecret{
+		Name:           name,
+		EncryptedValue: encrypted,
+		KeyID:          publicKey.KeyID,
+	}
+
+	if _, err := c.client.Codespaces.CreateOrUpdateUserSecret(ctx, secret); err != nil {
+		return &SecretError{Type: "user_codespaces_secret", Name: name, Err: err}
+	}
+
+	if selectedRepoIDs == nil {
+		return nil
+	}
+	ids := append(github.SelectedRepoIDs{}, selectedRepoIDs...)
+	if _, err := c.client.Codespaces.SetSelectedReposForUserSecret(ctx, name, ids); err != nil {
+		return &SecretError{Type: "user_codespaces_secret", Name: name, Err: err}
+	}
+
+	return nil
+}
+
+// GetUserCodespacesSecret retrieves metadata about a Codespaces secret of the
+// authenticated user.
+func (c *githubClient) GetUserCodespacesSecret(ctx context.Context, name string) (*SecretMetadata, error) {
+	ctx, cancel := c.operation(ctx)
+	defer cancel()
+
+	secret, _, err := c.client.Codespaces.GetUserSecret(ctx, name)
+	if err != nil {
+		return nil, &SecretError{Type: "user_codespaces_secret", Name: name, Err: err}
+	}
+
+	return &SecretMetadata{
+		Name:      secret.Name,
+		CreatedAt: secret.CreatedAt.String(),
+		UpdatedAt: secret.UpdatedAt.String(),
+	}, nil
+}
+
+// DeleteUserCodespacesSecret deletes a Codespaces secret of the authenticated user.
+// Deleting a secret that does not exist is not an error.
+func (c *githubClient) DeleteUserCodespacesSecret(ctx context.Context, name string) error {
+	ctx, cancel := c.operation(ctx)
+	defer cancel()
+
+	_, err := c.client.Codespaces.DeleteUserSecret(ctx, name)
+	if err != nil && !isNotFound(err) {
+		return &SecretError{Type: "user_codespaces_secret", Name: name, Err: err}
+	}
+	return nil
+}
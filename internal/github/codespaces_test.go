@@ -0,0 +1,110 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetCodespacesSecret(t *testing.T) {
+	mux := http.NewServeMux()
+	// Codespaces secrets use their own public key endpoint
+	mux.HandleFunc("/repos/o/r/codespaces/secrets/public-key", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"key_id": "codespaces-key", "key": "AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8="}`))
+	})
+	var body map[string]interface{}
+	mux.HandleFunc("/repos/o/r/codespaces/secrets/DEV_TOKEN", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.WriteHeader(http.StatusCreated)
+	})
+	client := newTestClient(t, mux)
+
+	created, err := client.SetCodespacesSecret(context.Background(), "o", "r", "DEV_TOKEN", "s3cr3t")
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, "codespaces-key", body["key_id"])
+	assert.NotEmpty(t, body["encrypted_value"])
+}
+
+func TestListCodespacesSecrets(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/o/r/codespaces/secrets", func(w http.ResponseWriter, r *http.Request) {
+		switch page := pageNumber(r); page {
+		case 1:
+			writePage(w, r, page, 2, `{"total_count": 2, "secrets": [{"name": "DEV_TOKEN"}]}`)
+		case 2:
+			writePage(w, r, page, 2, `{"total_count": 2, "secrets": [{"name": "NPM_TOKEN"}]}`)
+		}
+	})
+	client := newTestClient(t, mux)
+
+	secrets, err := client.ListCodespacesSecrets(context.Background(), "o", "r")
+	require.NoError(t, err)
+	require.Len(t, secrets, 2)
+	assert.Equal(t, "DEV_TOKEN", secrets[0].Name)
+	assert.Equal(t, "NPM_TOKEN", secrets[1].Name)
+}
+
+func TestDeleteCodespacesSecret(t *testing.T) {
+	mux := http.NewServeMux()
+	deleted := false
+	mux.HandleFunc("/repos/o/r/codespaces/secrets/DEV_TOKEN", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodDelete, r.Method)
+		deleted = true
+		w.WriteHeader(http.StatusNoContent)
+	})
+	client := newTestClient(t, mux)
+
+	require.NoError(t, client.DeleteCodespacesSecret(context.Background(), "o", "r", "DEV_TOKEN"))
+	assert.True(t, deleted)
+}
+
+func TestSetUserCodespacesSecret(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/user/codespaces/secrets/public-key", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"key_id": "user-key", "key": "AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8="}`))
+	})
+	var body map[string]interface{}
+	mux.HandleFunc("/user/codespaces/secrets/DOTFILES_TOKEN", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.WriteHeader(http.StatusCreated)
+	})
+	var repos map[string]interface{}
+	mux.HandleFunc("/user/codespaces/secrets/DOTFILES_TOKEN/repositories", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&repos))
+		w.WriteHeader(http.StatusNoContent)
+	})
+	client := newTestClient(t, mux)
+
+	// Without a repository list, the current access is left unchanged
+	require.NoError(t, client.SetUserCodespacesSecret(context.Background(), "DOTFILES_TOKEN", "s3cr3t", nil))
+	assert.Equal(t, "user-key", body["key_id"])
+	assert.Nil(t, repos)
+
+	require.NoError(t, client.SetUserCodespacesSecret(context.Background(), "DOTFILES_TOKEN", "s3cr3t", []int64{3}))
+	assert.Equal(t, []interface{}{3.0}, repos["selected_repository_ids"])
+}
+
+func TestGetUserCodespacesSecret_NotFound(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/user/codespaces/secrets/MISSING", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message": "Not Found"}`, http.StatusNotFound)
+	})
+	client := newTestClient(t, mux)
+
+	_, err := client.GetUserCodespacesSecret(context.Background(), "MISSING")
+	var secretErr *SecretError
+	require.ErrorAs(t, err, &secretErr)
+	assert.Contains(t, err.Error(), "for the authenticated user")
+
+	require.NoError(t, client.DeleteUserCodespacesSecret(context.Background(), "MISSING"))
+}
@@ -0,0 +1,83 @@
+package github
+
+import (
+	"context"
+
+	"github.com/google/go-github/v57/github"
+)
+
+// GetDependabotPublicKey retrieves the public key for a repository's Dependabot
+// secrets, which differs from the key used for Actions secrets.
+func (c *githubClient) GetDependabotPublicKey(ctx context.Context, owner, repo string) (*PublicKey, error) {
+	return shareLookup(ctx, &c.lookups, "dependabot-key "+owner+"/"+repo, func() (*PublicKey, error) {
+		key, _, err := c.client.Dependabot.GetRepoPublicKey(ctx, owner, repo)
+		if err != nil {
+			return nil, handleGitHubError(err, owner, repo, "", "dependabot_secret", "")
+		}
+
+		return &PublicKey{
+			KeyID: key.GetKeyID(),
+			Key:   key.GetKey(),
+		}, nil
+	})
+}
+
+// SetDependabotSecret sets a Dependabot secret for a repository.
+// The secretValue is plaintext and will be encrypted automatically.
+func (c *githubClient) SetDependabotSecret(ctx context.Context, owner, repo, name, secretValue string) error {
+	ctx, cancel := c.operation(ctx)
+	defer cancel()
+
+	publicKey, err := c.GetDependabotPublicKey(ctx, owner, repo)
+	if err != nil {
+		return err
+	}
+
+	encrypted, err := encryptForKey(publicKey, secretValue)
+	if err != nil {
+		return err
+	}
+
+	secret := &github.DependabotEncryptedSecret{
+		Name:           name,
+		EncryptedValue: encrypted,
+		KeyID:          publicKey.KeyID,
+	}
+
+	_, err = c.client.Dependabot.CreateOrUpdateRepoSecret(ctx, owner, repo, secret)
+	if err != nil {
+		return handleGitHubError(err, owner, repo, "", "dependabot_secret", name)
+	}
+
+	return nil
+}
+
+// GetDependabotSecret retrieves metadata about a repository Dependabot secret.
+func (c *githubClient) GetDependabotSecret(ctx context.Context, owner, repo, name string) (*SecretMetadata, error) {
+	ctx, cancel := c.operation(ctx)
+	defer cancel()
+
+	secret, _, err := c.client.Dependabot.GetRepoSecret(ctx, owner, repo, name)
+	if err != nil {
+		return nil, handleGitHubError(err, owner, repo, "", "dependabot_secret", name)
+	}
+
+	return &SecretMetadata{
+		Name:      secret.Name,
+		CreatedAt: secret.CreatedAt.String(),
+		UpdatedAt: secret.UpdatedAt.String(),
+	}, nil
+}
+
+// DeleteDependabotSecret deletes a repository Dependabot secret.
+// Deleting a secret that does not exist is not an error.
+func (c *githubClient) DeleteDependabotSecret(ctx context.Context, owner, repo, name string) error {
+	ctx, cancel := c.operation(ctx)
+	defer cancel()
+
+	_, err := c.client.Dependabot.DeleteRepoSecret(ctx, owner, repo, name)
+	if err != nil && !isNotFound(err) {
+		return handleGitHubError(err, owner, repo, "", "dependabot_secret", name)
+	}
+	return nil
+}
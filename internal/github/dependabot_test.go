@@ -0,0 +1,47 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetDependabotSecret(t *testing.T) {
+	mux := http.NewServeMux()
+	// Dependabot secrets use their own public key endpoint
+	mux.HandleFunc("/repos/o/r/dependabot/secrets/public-key", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"key_id": "dependabot-key", "key": "AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8="}`))
+	})
+	mux.HandleFunc("/repos/o/r/actions/secrets/public-key", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("the Actions public key must not be used for Dependabot secrets")
+	})
+	var body map[string]interface{}
+	mux.HandleFunc("/repos/o/r/dependabot/secrets/NPM_TOKEN", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.WriteHeader(http.StatusCreated)
+	})
+	client := newTestClient(t, mux)
+
+	require.NoError(t, client.SetDependabotSecret(context.Background(), "o", "r", "NPM_TOKEN", "s3cr3t"))
+	assert.Equal(t, "dependabot-key", body["key_id"])
+	assert.NotEmpty(t, body["encrypted_value"])
+}
+
+func TestSetDependabotSecret_Forbidden(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/o/r/dependabot/secrets/public-key", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message": "Resource not accessible by integration"}`, http.StatusForbidden)
+	})
+	client := newTestClient(t, mux)
+
+	err := client.SetDependabotSecret(context.Background(), "o", "r", "NPM_TOKEN", "s3cr3t")
+	var secretErr *SecretError
+	require.ErrorAs(t, err, &secretErr)
+	assert.Equal(t, "dependabot_secret", secretErr.Type)
+}
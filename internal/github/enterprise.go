@@ -0,0 +1,48 @@
+package github
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-github/v57/github"
+)
+
+// headerAPIVersion is the header selecting the REST API version.
+const headerAPIVersion = "X-GitHub-Api-Version"
+
+// newGitHubClient creates a go-github client sending requests through
+// transport, pointed at the GitHub Enterprise Server URLs of opts if set.
+func newGitHubClient(transport http.RoundTripper, opts Options) (*github.Client, error) {
+	if opts.APIVersion != "" {
+		transport = &apiVersionTransport{base: transport, version: opts.APIVersion}
+	}
+	client := github.NewClient(newHTTPClient(transport, opts))
+	if opts.BaseURL == "" {
+		return client, nil
+	}
+
+	uploadURL := opts.UploadURL
+	if uploadURL == "" {
+		uploadURL = opts.BaseURL
+	}
+	client, err := client.WithEnterpriseURLs(opts.BaseURL, uploadURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GitHub Enterprise URL: %w", err)
+	}
+	return client, nil
+}
+
+// apiVersionTransport replaces the API version go-github sends by default,
+// for GitHub Enterprise Server instances that lag behind github.com.
+type apiVersionTransport struct {
+	base    http.RoundTripper
+	version string
+}
+
+// RoundTrip sends req with the configured API version.
+func (t *apiVersionTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// RoundTrippers must not modify the caller's request
+	req = req.Clone(req.Context())
+	req.Header.Set(headerAPIVersion, t.version)
+	return t.base.RoundTrip(req)
+}
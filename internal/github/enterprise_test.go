@@ -0,0 +1,50 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewClientWithOptions_Enterprise(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/repos/o/r", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "2021-06-01", r.Header.Get("X-GitHub-Api-Version"))
+		assert.Equal(t, "Bearer ghe-token", r.Header.Get("Authorization"))
+		fmt.Fprint(w, `{"id": 42, "name": "r"}`)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	// The /api/v3/ path is added to enterprise URLs when missing
+	client, err := NewClientWithOptions("ghe-token", Options{BaseURL: server.URL, APIVersion: "2021-06-01"})
+	require.NoError(t, err)
+
+	id, err := client.GetRepositoryID(context.Background(), "o", "r")
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), id)
+
+	gh := client.(*githubClient).client
+	assert.Equal(t, server.URL+"/api/v3/", gh.BaseURL.String())
+	assert.Equal(t, server.URL+"/api/uploads/", gh.UploadURL.String())
+}
+
+func TestNewClientWithOptions_SplitUploadURL(t *testing.T) {
+	client, err := NewClientWithOptions("t", Options{BaseURL: "https://api.ghe.example.com/", UploadURL: "https://uploads.ghe.example.com/"})
+	require.NoError(t, err)
+
+	gh := client.(*githubClient).client
+	assert.Equal(t, "https://api.ghe.example.com/", gh.BaseURL.String())
+	assert.Equal(t, "https://uploads.ghe.example.com/api/uploads/", gh.UploadURL.String())
+}
+
+func TestNewClientWithOptions_InvalidURL(t *testing.T) {
+	_, err := NewClientWithOptions("t", Options{BaseURL: "http://[::1"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid GitHub Enterprise URL")
+}
@@ -2,4 +2,3 @@ package github
 
 // Note: Integration tests for environment secrets would require actual GitHub API access
 // Error type tests are in errors_test.go
-
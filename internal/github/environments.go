@@ -0,0 +1,115 @@
+package github
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/v57/github"
+)
+
+// EnvironmentExists reports whether a repository has the environment.
+func (c *githubClient) EnvironmentExists(ctx context.Context, owner, repo, environment string) (bool, error) {
+	_, _, err := c.client.Repositories.GetEnvironment(ctx, owner, repo, environment)
+	if isNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, handleGitHubError(err, owner, repo, "", "", "")
+	}
+	return true, nil
+}
+
+// ListEnvironments returns the names of the environments of a repository,
+// walking every page of results.
+func (c *githubClient) ListEnvironments(ctx context.Context, owner, repo string) ([]string, error) {
+	opts := &github.EnvironmentListOptions{ListOptions: github.ListOptions{PerPage: listPageSize}}
+	var names []string
+	for {
+		environments, resp, err := c.client.Repositories.ListEnvironments(ctx, owner, repo, opts)
+		if err != nil {
+			return nil, handleGitHubError(err, owner, repo, "", "", "")
+		}
+		for _, environment := range environments.Environments {
+			names = append(names, environment.GetName())
+		}
+		if resp.NextPage == 0 {
+			return names, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// CreateEnvironment creates an environment of a repository without protection
+// rules. Creating an environment that already exists leaves it unchanged.
+func (c *githubClient) CreateEnvironment(ctx context.Context, owner, repo, environment string) error {
+	if _, _, err := c.client.Repositories.GetEnvironment(ctx, owner, repo, environment); err == nil {
+		return nil
+	}
+
+	_, _, err := c.client.Repositories.CreateUpdateEnvironment(ctx, owner, repo, environment, &github.CreateUpdateEnvironment{})
+	if err != nil {
+		return handleGitHubError(err, owner, repo, "", "", "")
+	}
+	return nil
+}
+
+// EnvironmentProtection holds the protection rules of an environment.
+type EnvironmentProtection struct {
+	// WaitTimer delays jobs that reference the environment, in minutes
+	WaitTimer int
+	// ReviewerUsers and ReviewerTeams are the logins and team slugs of the
+	// required reviewers; teams belong to the repository owner
+	ReviewerUsers []string
+	ReviewerTeams []string
+	// PreventSelfReview stops users from approving runs they triggered
+	PreventSelfReview bool
+}
+
+// SetEnvironmentProtection creates an environment if needed and replaces its
+// wait timer, required reviewers and self-review setting. The deployment
+// branch policy and admin bypass setting of an existing environment are kept.
+func (c *githubClient) SetEnvironmentProtection(ctx context.Context, owner, repo, environment string, protection EnvironmentProtection) error {
+	reviewers, err := c.environmentReviewers(ctx, owner, protection)
+	if err != nil {
+		return err
+	}
+
+	update := &github.CreateUpdateEnvironment{
+		WaitTimer:         github.Int(protection.WaitTimer),
+		Reviewers:         reviewers,
+		PreventSelfReview: github.Bool(protection.PreventSelfReview),
+	}
+	if existing, _, err := c.client.Repositories.GetEnvironment(ctx, owner, repo, environment); err == nil {
+		update.DeploymentBranchPolicy = existing.DeploymentBranchPolicy
+		update.CanAdminsBypass = existing.CanAdminsBypass
+	} else if !isNotFound(err) {
+		return handleGitHubError(err, owner, repo, environment, "", "")
+	}
+
+	_, _, err = c.client.Repositories.CreateUpdateEnvironment(ctx, owner, repo, environment, update)
+	if err != nil {
+		return handleGitHubError(err, owner, repo, "", "", "")
+	}
+	return nil
+}
+
+// environmentReviewers resolves the reviewer logins and team slugs of
+// protection to the IDs the API expects.
+func (c *githubClient) environmentReviewers(ctx context.Context, org string, protection EnvironmentProtection) ([]*github.EnvReviewers, error) {
+	reviewers := make([]*github.EnvReviewers, 0, len(protection.ReviewerUsers)+len(protection.ReviewerTeams))
+	for _, login := range protection.ReviewerUsers {
+		user, _, err := c.client.Users.Get(ctx, login)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up reviewer %s: %w", login, err)
+		}
+		reviewers = append(reviewers, &github.EnvReviewers{Type: github.String("User"), ID: user.ID})
+	}
+	for _, slug := range protection.ReviewerTeams {
+		team, _, err := c.client.Teams.GetTeamBySlug(ctx, org, slug)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up reviewer team %s/%s: %w", org, slug, err)
+		}
+		reviewers = append(reviewers, &github.EnvReviewers{Type: github.String("Team"), ID: team.ID})
+	}
+	return reviewers, nil
+}
@@ -0,0 +1,133 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateEnvironment(t *testing.T) {
+	mux := http.NewServeMux()
+	created := false
+	mux.HandleFunc("/repos/o/r/environments/staging", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			http.Error(w, `{"message": "Not Found"}`, http.StatusNotFound)
+		case http.MethodPut:
+			created = true
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"name": "staging"}`))
+		}
+	})
+	mux.HandleFunc("/repos/o/r/environments/production", func(w http.ResponseWriter, r *http.Request) {
+		// An existing environment is not updated, keeping its protection rules
+		assert.Equal(t, http.MethodGet, r.Method)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"name": "production"}`))
+	})
+	client := newTestClient(t, mux)
+
+	require.NoError(t, client.CreateEnvironment(context.Background(), "o", "r", "staging"))
+	assert.True(t, created)
+	require.NoError(t, client.CreateEnvironment(context.Background(), "o", "r", "production"))
+}
+
+func TestListEnvironments(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/o/r/environments", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("page") == "2" {
+			w.Write([]byte(`{"total_count": 2, "environments": [{"name": "staging"}]}`))
+			return
+		}
+		w.Header().Set("Link", `<https://api.github.com/repos/o/r/environments?page=2>; rel="next"`)
+		w.Write([]byte(`{"total_count": 2, "environments": [{"name": "production"}]}`))
+	})
+	mux.HandleFunc("/repos/o/missing/environments", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message": "Not Found"}`, http.StatusNotFound)
+	})
+	client := newTestClient(t, mux)
+
+	environments, err := client.ListEnvironments(context.Background(), "o", "r")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"production", "staging"}, environments)
+
+	_, err = client.ListEnvironments(context.Background(), "o", "missing")
+	require.Error(t, err)
+}
+
+func TestEnvironmentExists(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/o/r/environments/production", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"name": "production"}`))
+	})
+	mux.HandleFunc("/repos/o/r/environments/staging", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message": "Not Found"}`, http.StatusNotFound)
+	})
+	client := newTestClient(t, mux)
+
+	exists, err := client.EnvironmentExists(context.Background(), "o", "r", "production")
+	require.NoError(t, err)
+	assert.True(t, exists)
+	exists, err = client.EnvironmentExists(context.Background(), "o", "r", "staging")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestSetEnvironmentProtection(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/alice", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"login": "alice", "id": 11}`))
+	})
+	mux.HandleFunc("/orgs/o/teams/platform", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"slug": "platform", "id": 22}`))
+	})
+	var body map[string]interface{}
+	mux.HandleFunc("/repos/o/r/environments/production", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case http.MethodGet:
+			w.Write([]byte(`{"name": "production", "can_admins_bypass": false, "deployment_branch_policy": {"protected_branches": true, "custom_branch_policies": false}}`))
+		case http.MethodPut:
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			w.Write([]byte(`{"name": "production"}`))
+		}
+	})
+	client := newTestClient(t, mux)
+
+	err := client.SetEnvironmentProtection(context.Background(), "o", "r", "production", EnvironmentProtection{
+		WaitTimer:         30,
+		ReviewerUsers:     []string{"alice"},
+		ReviewerTeams:     []string{"platform"},
+		PreventSelfReview: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 30.0, body["wait_timer"])
+	assert.Equal(t, true, body["prevent_self_review"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"type": "User", "id": 11.0},
+		map[string]interface{}{"type": "Team", "id": 22.0},
+	}, body["reviewers"])
+	// Settings that are not managed are kept
+	assert.Equal(t, false, body["can_admins_bypass"])
+	assert.Equal(t, map[string]interface{}{"protected_branches": true, "custom_branch_policies": false}, body["deployment_branch_policy"])
+}
+
+func TestSetEnvironmentProtection_UnknownReviewer(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/ghost", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message": "Not Found"}`, http.StatusNotFound)
+	})
+	client := newTestClient(t, mux)
+
+	err := client.SetEnvironmentProtection(context.Background(), "o", "r", "production", EnvironmentProtection{ReviewerUsers: []string{"ghost"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to look up reviewer ghost")
+}
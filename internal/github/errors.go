@@ -1,9 +1,12 @@
 package github
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 
+	"github.com/bradleyfalzon/ghinstallation/v2"
 	"github.com/google/go-github/v57/github"
 )
 
@@ -28,17 +31,54 @@ func (e *RepositoryNotFoundError) Error() string {
 	return fmt.Sprintf("repository %s/%s not found or access denied", e.Owner, e.Repo)
 }
 
+// OrganizationNotFoundError represents an error when an organization is not found.
+type OrganizationNotFoundError struct {
+	Org string
+}
+
+func (e *OrganizationNotFoundError) Error() string {
+	return fmt.Sprintf("organization %s not found or access denied. Organization secrets and variables require an organization owner token", e.Org)
+}
+
+// WriteAccessError represents a repository the token can read but not write to.
+type WriteAccessError struct {
+	Owner string
+	Repo  string
+	// Permission is the permission of the token, e.g. "read"
+	Permission string
+}
+
+func (e *WriteAccessError) Error() string {
+	return fmt.Sprintf("the token only has %s access to repository %s/%s, but secrets and variables require write access", e.Permission, e.Owner, e.Repo)
+}
+
+// RateLimitExceededError represents a request rejected by the client-side rate limit.
+type RateLimitExceededError struct {
+	RequestsPerSecond float64
+	Burst             int
+}
+
+func (e *RateLimitExceededError) Error() string {
+	return fmt.Sprintf("client-side rate limit exceeded (%g requests/second, burst %d)", e.RequestsPerSecond, e.Burst)
+}
+
 // SecretError represents an error related to secret operations.
 type SecretError struct {
-	Type        string // "repository_secret", "environment_secret"
+	Type        string // "repository_secret", "environment_secret", "codespaces_secret", "dependabot_secret", "organization_secret", "user_codespaces_secret"
 	Owner       string
-	Repo        string
+	Repo        string // empty for organization secrets
 	Environment string // optional, empty for repository secrets
 	Name        string
 	Err         error
 }
 
 func (e *SecretError) Error() string {
+	if e.Type == "user_codespaces_secret" {
+		return fmt.Sprintf("failed to set %s '%s' for the authenticated user: %v", e.Type, e.Name, e.Err)
+	}
+	if e.Repo == "" {
+		return fmt.Sprintf("failed to set %s '%s' for organization %s: %v", e.Type, e.Name, e.Owner, e.Err)
+	}
 	if e.Environment != "" {
 		return fmt.Sprintf("failed to set %s '%s' in environment '%s' for repository %s/%s: %v", e.Type, e.Name, e.Environment, e.Owner, e.Repo, e.Err)
 	}
@@ -51,15 +91,18 @@ func (e *SecretError) Unwrap() error {
 
 // VariableError represents an error related to variable operations.
 type VariableError struct {
-	Type        string // "repository_variable", "environment_variable"
+	Type        string // "repository_variable", "environment_variable", "organization_variable"
 	Owner       string
-	Repo        string
+	Repo        string // empty for organization variables
 	Environment string // optional, empty for repository variables
 	Name        string
 	Err         error
 }
 
 func (e *VariableError) Error() string {
+	if e.Repo == "" {
+		return fmt.Sprintf("failed to set %s '%s' for organization %s: %v", e.Type, e.Name, e.Owner, e.Err)
+	}
 	if e.Environment != "" {
 		return fmt.Sprintf("failed to set %s '%s' in environment '%s' for repository %s/%s: %v", e.Type, e.Name, e.Environment, e.Owner, e.Repo, e.Err)
 	}
@@ -70,6 +113,70 @@ func (e *VariableError) Unwrap() error {
 	return e.Err
 }
 
+// fineGrainedTokenPrefix starts every fine-grained personal access token.
+const fineGrainedTokenPrefix = "github_pat_"
+
+// IsFineGrainedToken reports whether token is a fine-grained personal access token.
+func IsFineGrainedToken(token string) bool {
+	return strings.HasPrefix(token, fineGrainedTokenPrefix)
+}
+
+// fineGrainedPermissions are the fine-grained token permissions required by
+// each resource type.
+var fineGrainedPermissions = map[string]string{
+	"repository_secret":      `repository permission "Secrets: Read and write"`,
+	"repository_variable":    `repository permission "Variables: Read and write"`,
+	"environment_secret":     `repository permission "Environments: Read and write"`,
+	"environment_variable":   `repository permission "Environments: Read and write"`,
+	"codespaces_secret":      `repository permission "Codespaces secrets: Read and write"`,
+	"dependabot_secret":      `repository permission "Dependabot secrets: Read and write"`,
+	"organization_secret":    `organization permission "Secrets: Read and write"`,
+	"organization_variable":  `organization permission "Variables: Read and write"`,
+	"user_codespaces_secret": `account permission "Codespaces user secrets: Read and write"`,
+}
+
+// TokenPermissionError adds guidance about the permissions of the token to an
+// error caused by a 403 or 404 response.
+type TokenPermissionError struct {
+	Err  error
+	Hint string
+}
+
+func (e *TokenPermissionError) Error() string {
+	return fmt.Sprintf("%v (hint: %s)", e.Err, e.Hint)
+}
+
+func (e *TokenPermissionError) Unwrap() error {
+	return e.Err
+}
+
+// tokenPermissionHint explains which permissions a 403 or 404 response is
+// likely missing. GitHub lists the accepted fine-grained permissions of a
+// forbidden request in the X-Accepted-GitHub-Permissions header; otherwise
+// the hint is derived from the resource type when the request was sent with
+// a fine-grained token, whose errors are otherwise too generic to debug.
+func tokenPermissionHint(resp *http.Response, repo, resourceType string) string {
+	if resp == nil || (resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusNotFound) {
+		return ""
+	}
+	if accepted := resp.Header.Get("X-Accepted-GitHub-Permissions"); accepted != "" && resp.StatusCode == http.StatusForbidden {
+		return fmt.Sprintf("the token needs one of these fine-grained permission sets: %s", accepted)
+	}
+	if resp.Request == nil || !IsFineGrainedToken(strings.TrimPrefix(resp.Request.Header.Get("Authorization"), "Bearer ")) {
+		return ""
+	}
+
+	permission, ok := fineGrainedPermissions[resourceType]
+	if !ok {
+		permission = "permissions required by this operation"
+	}
+	hint := "the fine-grained token needs the " + permission
+	if resp.StatusCode == http.StatusNotFound && repo != "" {
+		hint += ", and the repository must be selected in its repository access"
+	}
+	return hint
+}
+
 // handleGitHubError converts GitHub API errors to custom error types.
 func handleGitHubError(err error, owner, repo, environment, resourceType, name string) error {
 	if err == nil {
@@ -82,8 +189,22 @@ func handleGitHubError(err error, owner, repo, environment, resourceType, name s
 		return err
 	}
 
+	converted := convertGitHubError(ghErr, owner, repo, environment, resourceType, name)
+	if hint := tokenPermissionHint(ghErr.Response, repo, resourceType); hint != "" {
+		return &TokenPermissionError{Err: converted, Hint: hint}
+	}
+	return converted
+}
+
+// convertGitHubError converts a GitHub API error response to a custom error type.
+func convertGitHubError(ghErr *github.ErrorResponse, owner, repo, environment, resourceType, name string) error {
+	var err error = ghErr
+
 	// Handle 404 errors
 	if ghErr.Response != nil && ghErr.Response.StatusCode == http.StatusNotFound {
+		if repo == "" {
+			return &OrganizationNotFoundError{Org: owner}
+		}
 		if environment != "" {
 			return &EnvironmentNotFoundError{
 				Owner:       owner,
@@ -98,7 +219,7 @@ func handleGitHubError(err error, owner, repo, environment, resourceType, name s
 	}
 
 	// Wrap other errors based on resource type
-	if resourceType == "repository_secret" || resourceType == "environment_secret" {
+	if resourceType == "repository_secret" || resourceType == "environment_secret" || resourceType == "codespaces_secret" || resourceType == "dependabot_secret" || resourceType == "organization_secret" {
 		return &SecretError{
 			Type:        resourceType,
 			Owner:       owner,
@@ -109,7 +230,7 @@ func handleGitHubError(err error, owner, repo, environment, resourceType, name s
 		}
 	}
 
-	if resourceType == "repository_variable" || resourceType == "environment_variable" {
+	if resourceType == "repository_variable" || resourceType == "environment_variable" || resourceType == "organization_variable" {
 		return &VariableError{
 			Type:        resourceType,
 			Owner:       owner,
@@ -123,3 +244,77 @@ func handleGitHubError(err error, owner, repo, environment, resourceType, name s
 	return err
 }
 
+// isNotFound reports whether err is a GitHub API 404 response.
+func isNotFound(err error) bool {
+	ghErr, ok := err.(*github.ErrorResponse)
+	return ok && ghErr.Response != nil && ghErr.Response.StatusCode == http.StatusNotFound
+}
+
+// IsAuthenticationError reports whether err was caused by rejected
+// credentials: a 401 response of the API, or an installation token of a
+// GitHub App that could not be created.
+func IsAuthenticationError(err error) bool {
+	var ghErr *github.ErrorResponse
+	if errors.As(err, &ghErr) && ghErr.Response != nil && ghErr.Response.StatusCode == http.StatusUnauthorized {
+		return true
+	}
+	var appErr *ghinstallation.HTTPError
+	return errors.As(err, &appErr)
+}
+
+// IsPermissionError reports whether err was caused by a token lacking the
+// permissions of an operation: a 403 response of the API, or an error that
+// explains which permissions the token is missing.
+func IsPermissionError(err error) bool {
+	var permErr *TokenPermissionError
+	var accessErr *WriteAccessError
+	if errors.As(err, &permErr) || errors.As(err, &accessErr) {
+		return true
+	}
+	return hasStatus(err, http.StatusForbidden)
+}
+
+// IsNotFoundError reports whether err was caused by a missing repository,
+// environment or organization, or any other 404 response of the API.
+func IsNotFoundError(err error) bool {
+	var repoErr *RepositoryNotFoundError
+	var envErr *EnvironmentNotFoundError
+	var orgErr *OrganizationNotFoundError
+	if errors.As(err, &repoErr) || errors.As(err, &envErr) || errors.As(err, &orgErr) {
+		return true
+	}
+	return hasStatus(err, http.StatusNotFound)
+}
+
+// IsRateLimitError reports whether err was caused by a rate limit: the
+// client-side one, the primary or secondary rate limit of GitHub, or a 429
+// response.
+func IsRateLimitError(err error) bool {
+	var limitErr *RateLimitExceededError
+	var rateErr *github.RateLimitError
+	var abuseErr *github.AbuseRateLimitError
+	if errors.As(err, &limitErr) || errors.As(err, &rateErr) || errors.As(err, &abuseErr) {
+		return true
+	}
+	return hasStatus(err, http.StatusTooManyRequests)
+}
+
+// IsValidationError reports whether GitHub rejected a request as invalid,
+// e.g. a malformed name or value: a 400 or 422 response.
+func IsValidationError(err error) bool {
+	return hasStatus(err, http.StatusBadRequest, http.StatusUnprocessableEntity)
+}
+
+// hasStatus reports whether err wraps a GitHub API response with one of statuses.
+func hasStatus(err error, statuses ...int) bool {
+	var ghErr *github.ErrorResponse
+	if !errors.As(err, &ghErr) || ghErr.Response == nil {
+		return false
+	}
+	for _, status := range statuses {
+		if ghErr.Response.StatusCode == status {
+			return true
+		}
+	}
+	return false
+}
@@ -1,9 +1,12 @@
 package github
 
 import (
+	"context"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/bradleyfalzon/ghinstallation/v2"
 	"github.com/google/go-github/v57/github"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -41,11 +44,11 @@ func TestSecretError(t *testing.T) {
 	}
 
 	err := &SecretError{
-		Type:        "repository_secret",
-		Owner:        "owner",
-		Repo:         "repo",
-		Name:         "SECRET1",
-		Err:          innerErr,
+		Type:  "repository_secret",
+		Owner: "owner",
+		Repo:  "repo",
+		Name:  "SECRET1",
+		Err:   innerErr,
 	}
 
 	msg := err.Error()
@@ -144,3 +147,96 @@ func TestHandleGitHubError_VariableError(t *testing.T) {
 	assert.Equal(t, "production", varErr.Environment)
 }
 
+func TestHandleGitHubError_FineGrainedTokenHint(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPut, "https://api.github.com/repos/owner/repo/actions/variables/VAR1", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer github_pat_11ABC")
+
+	for status, hint := range map[int]string{
+		http.StatusForbidden: `hint: the fine-grained token needs the repository permission "Variables: Read and write")`,
+		http.StatusNotFound:  `hint: the fine-grained token needs the repository permission "Variables: Read and write", and the repository must be selected in its repository access)`,
+	} {
+		ghErr := &github.ErrorResponse{Response: &http.Response{StatusCode: status, Request: req}, Message: "Resource not accessible by personal access token"}
+		err := handleGitHubError(ghErr, "owner", "repo", "", "repository_variable", "VAR1")
+
+		var hintErr *TokenPermissionError
+		require.ErrorAs(t, err, &hintErr)
+		assert.Contains(t, err.Error(), hint)
+	}
+
+	// Classic tokens get no hint
+	req.Header.Set("Authorization", "Bearer ghp_abc")
+	ghErr := &github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusNotFound, Request: req}}
+	_, ok := handleGitHubError(ghErr, "owner", "repo", "", "repository_variable", "VAR1").(*RepositoryNotFoundError)
+	assert.True(t, ok)
+}
+
+func TestHandleGitHubError_AcceptedPermissionsHint(t *testing.T) {
+	ghErr := &github.ErrorResponse{
+		Response: &http.Response{
+			StatusCode: http.StatusForbidden,
+			Header:     http.Header{"X-Accepted-Github-Permissions": {"secrets=write"}},
+		},
+		Message: "Resource not accessible by integration",
+	}
+
+	err := handleGitHubError(ghErr, "owner", "repo", "", "repository_secret", "SECRET1")
+	assert.Contains(t, err.Error(), "hint: the token needs one of these fine-grained permission sets: secrets=write")
+	var secretErr *SecretError
+	assert.ErrorAs(t, err, &secretErr)
+}
+
+func TestFineGrainedTokenHint_Client(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message": "Not Found"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewClientWithOptions("github_pat_11ABC", Options{BaseURL: server.URL + "/"})
+	require.NoError(t, err)
+	_, err = client.SetRepositoryVariable(context.Background(), "owner", "repo", "VAR1", "x")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "repository must be selected in its repository access")
+}
+
+func TestIsAuthenticationError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"message": "Bad credentials"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewClientWithOptions("expired", Options{BaseURL: server.URL + "/"})
+	require.NoError(t, err)
+	_, err = client.SetRepositoryVariable(context.Background(), "owner", "repo", "VAR1", "x")
+	require.Error(t, err)
+	assert.True(t, IsAuthenticationError(err))
+
+	assert.True(t, IsAuthenticationError(&ghinstallation.HTTPError{Message: "could not refresh installation token"}))
+	assert.False(t, IsAuthenticationError(&RepositoryNotFoundError{Owner: "owner", Repo: "repo"}))
+}
+
+func TestErrorCategories(t *testing.T) {
+	response := func(status int) error {
+		return &github.ErrorResponse{Response: &http.Response{StatusCode: status}, Message: http.StatusText(status)}
+	}
+
+	assert.True(t, IsPermissionError(response(http.StatusForbidden)))
+	assert.True(t, IsPermissionError(&TokenPermissionError{Err: &RepositoryNotFoundError{Owner: "owner", Repo: "repo"}, Hint: "hint"}))
+	assert.True(t, IsPermissionError(&WriteAccessError{Owner: "owner", Repo: "repo", Permission: "read"}))
+	assert.False(t, IsPermissionError(response(http.StatusNotFound)))
+
+	assert.True(t, IsNotFoundError(&VariableError{Type: "repository_variable", Err: response(http.StatusNotFound)}))
+	assert.True(t, IsNotFoundError(&EnvironmentNotFoundError{Owner: "owner", Repo: "repo", Environment: "production"}))
+	assert.False(t, IsNotFoundError(response(http.StatusUnprocessableEntity)))
+
+	assert.True(t, IsRateLimitError(&RateLimitExceededError{RequestsPerSecond: 1, Burst: 1}))
+	assert.True(t, IsRateLimitError(&github.AbuseRateLimitError{Message: "secondary rate limit"}))
+	assert.True(t, IsRateLimitError(response(http.StatusTooManyRequests)))
+	assert.False(t, IsRateLimitError(response(http.StatusForbidden)))
+
+	assert.True(t, IsValidationError(&SecretError{Type: "repository_secret", Err: response(http.StatusUnprocessableEntity)}))
+	assert.True(t, IsValidationError(response(http.StatusBadRequest)))
+	assert.False(t, IsValidationError(response(http.StatusInternalServerError)))
+}
@@ -0,0 +1,172 @@
+package github
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/azolfagharj/gajin/internal/fsutil"
+)
+
+// CachedResponse is a GET response stored with its ETag.
+type CachedResponse struct {
+	ETag       string      `json:"etag"`
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+}
+
+// ETagCache stores GET responses so they can be revalidated with
+// If-None-Match. A 304 Not Modified does not count against the rate limit.
+type ETagCache interface {
+	Get(key string) (*CachedResponse, bool)
+	Set(key string, response *CachedResponse)
+}
+
+// memoryETagCache keeps responses for the lifetime of the process.
+type memoryETagCache struct {
+	mu      sync.Mutex
+	entries map[string]*CachedResponse
+}
+
+// NewMemoryETagCache returns an ETag cache held in memory.
+func NewMemoryETagCache() ETagCache {
+	return &memoryETagCache{entries: make(map[string]*CachedResponse)}
+}
+
+func (c *memoryETagCache) Get(key string) (*CachedResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	return entry, ok
+}
+
+func (c *memoryETagCache) Set(key string, response *CachedResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = response
+}
+
+// fileETagCache keeps one file per response in a directory, so the cache
+// is reused by later runs.
+type fileETagCache struct {
+	dir string
+}
+
+// NewFileETagCache returns an ETag cache persisted in dir. Entries are
+// readable only by the current user, since variables are cached in plaintext.
+func NewFileETagCache(dir string) ETagCache {
+	return &fileETagCache{dir: dir}
+}
+
+func (c *fileETagCache) Get(key string) (*CachedResponse, bool) {
+	data, err := os.ReadFile(filepath.Join(c.dir, key+".json"))
+	if err != nil {
+		return nil, false
+	}
+	var response CachedResponse
+	// A corrupted entry is a cache miss
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, false
+	}
+	return &response, true
+}
+
+func (c *fileETagCache) Set(key string, response *CachedResponse) {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return
+	}
+	// Failing to cache only costs a full request next time
+	_ = fsutil.WriteFileAtomic(filepath.Join(c.dir, key+".json"), data, 0o600)
+}
+
+// etagTransport revalidates cached GET responses with If-None-Match and
+// serves them again when GitHub answers 304 Not Modified.
+type etagTransport struct {
+	base  http.RoundTripper
+	cache ETagCache
+	// scope separates the entries of different credentials
+	scope string
+}
+
+func newETagTransport(base http.RoundTripper, cache ETagCache, scope string) *etagTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &etagTransport{base: base, cache: cache, scope: scope}
+}
+
+// RoundTrip sends req, conditionally if a response to it is cached.
+func (t *etagTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || req.Header.Get("If-None-Match") != "" {
+		return t.base.RoundTrip(req)
+	}
+
+	key := t.key(req)
+	cached, ok := t.cache.Get(key)
+	if ok {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if ok && resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		header := cached.Header.Clone()
+		// Keep the current rate limit for pacing
+		for name, values := range resp.Header {
+			if strings.HasPrefix(http.CanonicalHeaderKey(name), "X-Ratelimit-") {
+				header[name] = values
+			}
+		}
+		return &http.Response{
+			Status:        http.StatusText(cached.StatusCode),
+			StatusCode:    cached.StatusCode,
+			Proto:         resp.Proto,
+			ProtoMajor:    resp.ProtoMajor,
+			ProtoMinor:    resp.ProtoMinor,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewReader(cached.Body)),
+			ContentLength: int64(len(cached.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	etag := resp.Header.Get("ETag")
+	if resp.StatusCode != http.StatusOK || etag == "" {
+		return resp, nil
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	t.cache.Set(key, &CachedResponse{ETag: etag, StatusCode: resp.StatusCode, Header: resp.Header.Clone(), Body: body})
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+// key identifies a request by credentials, URL and the headers selecting
+// the representation.
+func (t *etagTransport) key(req *http.Request) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		t.scope,
+		req.URL.String(),
+		req.Header.Get("Accept"),
+		req.Header.Get(headerAPIVersion),
+	}, "\n")))
+	return hex.EncodeToString(sum[:])
+}
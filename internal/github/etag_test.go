@@ -0,0 +1,104 @@
+package github
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newETagServer serves a public key with an ETag, answering 304 to matching
+// conditional requests, and counts both kinds of responses.
+func newETagServer(t *testing.T) (*httptest.Server, *int, *int) {
+	var full, notModified int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", fmt.Sprint(5000-full-notModified))
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		full++
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, `{"key_id": "1", "key": "abc"}`)
+	}))
+	t.Cleanup(server.Close)
+	return server, &full, &notModified
+}
+
+func get(t *testing.T, client *http.Client, url string) (*http.Response, string) {
+	t.Helper()
+	resp, err := client.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, string(body)
+}
+
+func TestETagTransport_Revalidates(t *testing.T) {
+	server, full, notModified := newETagServer(t)
+	client := &http.Client{Transport: newETagTransport(nil, NewMemoryETagCache(), "token:a")}
+
+	_, body := get(t, client, server.URL+"/repos/o/r/actions/secrets/public-key")
+	assert.Equal(t, `{"key_id": "1", "key": "abc"}`, body)
+
+	resp, body := get(t, client, server.URL+"/repos/o/r/actions/secrets/public-key")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `{"key_id": "1", "key": "abc"}`, body)
+	// The rate limit of the 304 replaces the cached one
+	assert.Equal(t, "4999", resp.Header.Get("X-RateLimit-Remaining"))
+	assert.Equal(t, 1, *full)
+	assert.Equal(t, 1, *notModified)
+}
+
+func TestETagTransport_ScopeAndMethod(t *testing.T) {
+	server, full, _ := newETagServer(t)
+	cache := NewMemoryETagCache()
+
+	get(t, &http.Client{Transport: newETagTransport(nil, cache, "token:a")}, server.URL)
+	// Another identity does not reuse the entry
+	get(t, &http.Client{Transport: newETagTransport(nil, cache, "token:b")}, server.URL)
+	assert.Equal(t, 2, *full)
+
+	// Only GET requests are cached
+	client := &http.Client{Transport: newETagTransport(nil, cache, "token:a")}
+	req, err := http.NewRequest(http.MethodDelete, server.URL, nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, 3, *full)
+}
+
+func TestFileETagCache_PersistsAcrossClients(t *testing.T) {
+	server, full, notModified := newETagServer(t)
+	dir := filepath.Join(t.TempDir(), "etags")
+
+	get(t, &http.Client{Transport: newETagTransport(nil, NewFileETagCache(dir), "token:a")}, server.URL)
+	_, body := get(t, &http.Client{Transport: newETagTransport(nil, NewFileETagCache(dir), "token:a")}, server.URL)
+	assert.Equal(t, `{"key_id": "1", "key": "abc"}`, body)
+	assert.Equal(t, 1, *full)
+	assert.Equal(t, 1, *notModified)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	if runtime.GOOS != "windows" {
+		info, err := entries[0].Info()
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	}
+
+	// A corrupted entry is a cache miss
+	require.NoError(t, os.WriteFile(filepath.Join(dir, entries[0].Name()), []byte("{"), 0o600))
+	get(t, &http.Client{Transport: newETagTransport(nil, NewFileETagCache(dir), "token:a")}, server.URL)
+	assert.Equal(t, 2, *full)
+}
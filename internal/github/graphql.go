@@ -0,0 +1,128 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// graphqlBatchSize is the number of repositories fetched by one GraphQL query.
+const graphqlBatchSize = 100
+
+// RepositoryMetadata holds the repository details needed before applying changes.
+type RepositoryMetadata struct {
+	ID            int64
+	Archived      bool
+	DefaultBranch string
+	// Permission is the access of the authenticated user, e.g. ADMIN, WRITE
+	// or READ; empty when it is not known, as for GitHub App installations
+	Permission string
+}
+
+// CanWrite reports whether the authenticated user may write secrets and
+// variables. Unknown permissions are assumed to allow it.
+func (m RepositoryMetadata) CanWrite() bool {
+	switch m.Permission {
+	case "READ", "TRIAGE":
+		return false
+	}
+	return true
+}
+
+type graphqlRequest struct {
+	Query     string            `json:"query"`
+	Variables map[string]string `json:"variables"`
+}
+
+type graphqlRepository struct {
+	DatabaseID       int64 `json:"databaseId"`
+	IsArchived       bool  `json:"isArchived"`
+	DefaultBranchRef *struct {
+		Name string `json:"name"`
+	} `json:"defaultBranchRef"`
+	ViewerPermission string `json:"viewerPermission"`
+}
+
+type graphqlError struct {
+	Type    string   `json:"type"`
+	Path    []string `json:"path"`
+	Message string   `json:"message"`
+}
+
+type graphqlResponse struct {
+	Data   map[string]*graphqlRepository `json:"data"`
+	Errors []graphqlError                `json:"errors"`
+}
+
+// GetRepositoriesMetadata fetches the metadata of many repositories of an
+// owner through the GraphQL API, up to graphqlBatchSize repositories per
+// request instead of one REST request each. The result is keyed by the names
+// in repos; repositories that do not exist or are not accessible are left
+// out. Fetched IDs are cached for GetRepositoryID.
+func (c *githubClient) GetRepositoriesMetadata(ctx context.Context, owner string, repos []string) (map[string]RepositoryMetadata, error) {
+	result := make(map[string]RepositoryMetadata, len(repos))
+	for start := 0; start < len(repos); start += graphqlBatchSize {
+		end := start + graphqlBatchSize
+		if end > len(repos) {
+			end = len(repos)
+		}
+		if err := c.fetchRepositoriesMetadata(ctx, owner, repos[start:end], result); err != nil {
+			return nil, fmt.Errorf("failed to fetch repository metadata for %s: %w", owner, err)
+		}
+	}
+	return result, nil
+}
+
+// fetchRepositoriesMetadata runs one GraphQL query for batch, aliasing every
+// repository as r<index>, and stores the results in result.
+func (c *githubClient) fetchRepositoriesMetadata(ctx context.Context, owner string, batch []string, result map[string]RepositoryMetadata) error {
+	var params, fields strings.Builder
+	variables := map[string]string{"owner": owner}
+	params.WriteString("$owner: String!")
+	for i, repo := range batch {
+		variables[fmt.Sprintf("n%d", i)] = repo
+		fmt.Fprintf(&params, ", $n%d: String!", i)
+		fmt.Fprintf(&fields, " r%d: repository(owner: $owner, name: $n%d) { databaseId isArchived defaultBranchRef { name } viewerPermission }", i, i)
+	}
+	query := fmt.Sprintf("query(%s) {%s }", params.String(), fields.String())
+
+	req, err := c.client.NewRequest(http.MethodPost, graphqlURL(c.client.BaseURL.String()), graphqlRequest{Query: query, Variables: variables})
+	if err != nil {
+		return err
+	}
+	var resp graphqlResponse
+	if _, err := c.client.Do(ctx, req, &resp); err != nil {
+		return err
+	}
+
+	for _, gqlErr := range resp.Errors {
+		// Missing repositories are reported per alias and left to the caller
+		if gqlErr.Type != "NOT_FOUND" {
+			return fmt.Errorf("GraphQL error: %s", gqlErr.Message)
+		}
+	}
+
+	for i, repo := range batch {
+		data := resp.Data[fmt.Sprintf("r%d", i)]
+		if data == nil {
+			continue
+		}
+		metadata := RepositoryMetadata{ID: data.DatabaseID, Archived: data.IsArchived, Permission: data.ViewerPermission}
+		if data.DefaultBranchRef != nil {
+			metadata.DefaultBranch = data.DefaultBranchRef.Name
+		}
+		result[repo] = metadata
+		c.cacheRepositoryID(owner, repo, data.DatabaseID)
+	}
+	return nil
+}
+
+// graphqlURL returns the GraphQL endpoint for a REST API base URL. GitHub
+// Enterprise Server serves GraphQL at /api/graphql next to /api/v3.
+func graphqlURL(baseURL string) string {
+	if prefix, ok := strings.CutSuffix(baseURL, "/api/v3/"); ok {
+		return prefix + "/api/graphql"
+	}
+	return "graphql"
+}
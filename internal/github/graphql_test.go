@@ -0,0 +1,82 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetRepositoriesMetadata(t *testing.T) {
+	var batches []int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		var req graphqlRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "my-org", req.Variables["owner"])
+
+		batch := len(req.Variables) - 1
+		batches = append(batches, batch)
+		data := make([]string, 0, batch)
+		var errs []string
+		for i := 0; i < batch; i++ {
+			name := req.Variables[fmt.Sprintf("n%d", i)]
+			switch name {
+			case "missing":
+				data = append(data, fmt.Sprintf(`"r%d":null`, i))
+				errs = append(errs, fmt.Sprintf(`{"type":"NOT_FOUND","path":["r%d"],"message":"Could not resolve to a Repository"}`, i))
+			case "legacy":
+				data = append(data, fmt.Sprintf(`"r%d":{"databaseId":9,"isArchived":true,"defaultBranchRef":{"name":"master"},"viewerPermission":"READ"}`, i))
+			default:
+				data = append(data, fmt.Sprintf(`"r%d":{"databaseId":%d,"isArchived":false,"defaultBranchRef":{"name":"main"}}`, i, 1000+i))
+			}
+		}
+		fmt.Fprintf(w, `{"data":{%s},"errors":[%s]}`, strings.Join(data, ","), strings.Join(errs, ","))
+	})
+	mux.HandleFunc("/repos/", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected REST request %s", r.URL.Path)
+	})
+	client := newTestClient(t, mux)
+
+	repos := []string{"legacy", "missing"}
+	for i := 0; i < 150; i++ {
+		repos = append(repos, fmt.Sprintf("repo-%d", i))
+	}
+	metadata, err := client.GetRepositoriesMetadata(context.Background(), "my-org", repos)
+	require.NoError(t, err)
+	assert.Equal(t, []int{100, 52}, batches)
+	assert.Len(t, metadata, 151)
+	assert.Equal(t, RepositoryMetadata{ID: 9, Archived: true, DefaultBranch: "master", Permission: "READ"}, metadata["legacy"])
+	assert.False(t, metadata["legacy"].CanWrite())
+	assert.NotContains(t, metadata, "missing")
+	assert.Equal(t, RepositoryMetadata{ID: 1002, DefaultBranch: "main"}, metadata["repo-0"])
+	assert.True(t, metadata["repo-0"].CanWrite())
+
+	// IDs are cached for later REST calls
+	id, err := client.GetRepositoryID(context.Background(), "My-Org", "Legacy")
+	require.NoError(t, err)
+	assert.Equal(t, int64(9), id)
+}
+
+func TestGetRepositoriesMetadata_Error(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":null,"errors":[{"type":"FORBIDDEN","message":"Resource not accessible by integration"}]}`)
+	})
+	client := newTestClient(t, mux)
+
+	_, err := client.GetRepositoriesMetadata(context.Background(), "my-org", []string{"api"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Resource not accessible by integration")
+}
+
+func TestGraphQLURL(t *testing.T) {
+	assert.Equal(t, "graphql", graphqlURL("https://api.github.com/"))
+	assert.Equal(t, "https://ghe.example.com/api/graphql", graphqlURL("https://ghe.example.com/api/v3/"))
+}
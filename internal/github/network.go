@@ -0,0 +1,82 @@
+package github
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// Network configures how the client connects to the GitHub API.
+type Network struct {
+	// Proxy is the proxy URL (default: HTTPS_PROXY and related variables)
+	Proxy string
+	// CAFile is a PEM bundle of CA certificates trusted in addition to the system ones
+	CAFile string
+	// InsecureSkipVerify disables TLS certificate verification
+	InsecureSkipVerify bool
+}
+
+// isZero reports whether n keeps the default transport.
+func (n Network) isZero() bool {
+	return n == Network{}
+}
+
+// newBaseTransport returns the transport sending requests over the network,
+// configured with the proxy and TLS options of n.
+func newBaseTransport(n Network) (http.RoundTripper, error) {
+	if n.isZero() {
+		return http.DefaultTransport, nil
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if n.Proxy != "" {
+		proxyURL, err := url.Parse(n.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if n.CAFile != "" || n.InsecureSkipVerify {
+		tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+		if transport.TLSClientConfig != nil {
+			tlsConfig = transport.TLSClientConfig.Clone()
+		}
+		if n.CAFile != "" {
+			pool, err := certPool(n.CAFile)
+			if err != nil {
+				return nil, err
+			}
+			tlsConfig.RootCAs = pool
+		}
+		tlsConfig.InsecureSkipVerify = n.InsecureSkipVerify
+		transport.TLSClientConfig = tlsConfig
+	}
+	return transport, nil
+}
+
+// NewTransport returns a transport configured with the proxy and TLS options
+// of n, for requests sent to other services than GitHub, e.g. notifications.
+func NewTransport(n Network) (http.RoundTripper, error) {
+	return newBaseTransport(n)
+}
+
+// certPool returns the system certificate pool with the certificates of the
+// PEM file at path added.
+func certPool(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA file: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no PEM certificates found in CA file %s", path)
+	}
+	return pool, nil
+}
@@ -0,0 +1,80 @@
+package github
+
+import (
+	"context"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTLSRepoServer(t *testing.T) *httptest.Server {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id": 42, "name": "r"}`)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestNewClientWithOptions_CAFile(t *testing.T) {
+	server := newTLSRepoServer(t)
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	require.NoError(t, os.WriteFile(caFile, certPEM, 0o600))
+
+	// The test server certificate is not trusted by default
+	client, err := NewClientWithOptions("t", Options{BaseURL: server.URL})
+	require.NoError(t, err)
+	_, err = client.GetRepositoryID(context.Background(), "o", "r")
+	require.Error(t, err)
+
+	client, err = NewClientWithOptions("t", Options{BaseURL: server.URL, Network: Network{CAFile: caFile}})
+	require.NoError(t, err)
+	id, err := client.GetRepositoryID(context.Background(), "o", "r")
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), id)
+}
+
+func TestNewClientWithOptions_InsecureSkipVerify(t *testing.T) {
+	server := newTLSRepoServer(t)
+
+	client, err := NewClientWithOptions("t", Options{BaseURL: server.URL, Network: Network{InsecureSkipVerify: true}})
+	require.NoError(t, err)
+	id, err := client.GetRepositoryID(context.Background(), "o", "r")
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), id)
+}
+
+func TestNewClientWithOptions_Proxy(t *testing.T) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.String())
+		fmt.Fprint(w, `{"id": 42, "name": "r"}`)
+	}))
+	t.Cleanup(proxy.Close)
+
+	client, err := NewClientWithOptions("t", Options{BaseURL: "http://github.example.invalid/", Network: Network{Proxy: proxy.URL}})
+	require.NoError(t, err)
+	_, err = client.GetRepositoryID(context.Background(), "o", "r")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"http://github.example.invalid/api/v3/repos/o/r"}, proxied)
+}
+
+func TestNewClientWithOptions_InvalidCAFile(t *testing.T) {
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, []byte("not a certificate"), 0o600))
+
+	_, err := NewClientWithOptions("t", Options{Network: Network{CAFile: caFile}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no PEM certificates found")
+
+	_, err = NewClientWithOptions("t", Options{Network: Network{CAFile: filepath.Join(t.TempDir(), "missing.pem")}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read CA file")
+}
@@ -0,0 +1,88 @@
+package github
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ClientObserver receives events about the API requests of a client, e.g. to
+// emit metrics or tracing spans. Methods are called concurrently from the
+// goroutines sending requests and should return quickly. Embed
+// NoopObserver to implement only some of them.
+type ClientObserver interface {
+	// RequestStarted is called before every attempt of a request is sent
+	RequestStarted(req *http.Request)
+	// RequestFinished is called once the response headers of an attempt
+	// arrived or the attempt failed, with the time it took
+	RequestFinished(req *http.Request, resp *http.Response, err error, duration time.Duration)
+	// RequestRetried is called before a failed request is sent again, with
+	// the number of the next attempt and the delay before it
+	RequestRetried(req *http.Request, attempt int, delay time.Duration)
+	// RateLimited is called when requests pause for a rate limit
+	RateLimited(event RateLimitEvent)
+	// OperationRetried is called before a failed secret or variable write is
+	// run again as a whole, with the number of the next attempt, the delay
+	// before it and the error of the failed attempt
+	OperationRetried(operation string, attempt int, delay time.Duration, err error)
+}
+
+// RateLimitEvent describes a pause for a rate limit.
+type RateLimitEvent struct {
+	// Resource is the rate limit resource, e.g. core, graphql or search
+	Resource string
+	// Remaining is the number of requests left in the current window
+	Remaining int
+	// Wait is how long requests pause
+	Wait time.Duration
+	// Secondary reports a secondary rate limit, which GitHub imposes on
+	// bursts of requests regardless of the remaining budget
+	Secondary bool
+}
+
+// NoopObserver is a ClientObserver ignoring every event.
+type NoopObserver struct{}
+
+func (NoopObserver) RequestStarted(*http.Request) {}
+
+func (NoopObserver) RequestFinished(*http.Request, *http.Response, error, time.Duration) {}
+
+func (NoopObserver) RequestRetried(*http.Request, int, time.Duration) {}
+
+func (NoopObserver) RateLimited(RateLimitEvent) {}
+
+func (NoopObserver) OperationRetried(string, int, time.Duration, error) {}
+
+// observerTransport reports every request attempt to an observer.
+type observerTransport struct {
+	base     http.RoundTripper
+	observer ClientObserver
+}
+
+func newObserverTransport(base http.RoundTripper, observer ClientObserver) *observerTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &observerTransport{base: base, observer: observer}
+}
+
+// RoundTrip sends req, reporting when it starts and finishes.
+func (t *observerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.observer.RequestStarted(req)
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	t.observer.RequestFinished(req, resp, err, time.Since(start))
+	return resp, err
+}
+
+// rateLimitEvent describes the rate limit of a response that is retried
+// after wait.
+func rateLimitEvent(resp *http.Response, wait time.Duration) RateLimitEvent {
+	event := RateLimitEvent{
+		Resource:  resp.Header.Get("X-RateLimit-Resource"),
+		Wait:      wait,
+		Secondary: resp.Header.Get("Retry-After") != "",
+	}
+	event.Remaining, _ = strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	return event
+}
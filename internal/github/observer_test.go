@@ -0,0 +1,100 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingObserver records the events it receives.
+type recordingObserver struct {
+	NoopObserver
+	mu         sync.Mutex
+	started    int
+	statuses   []int
+	retries    []int
+	rateLimits []RateLimitEvent
+}
+
+func (o *recordingObserver) RequestStarted(*http.Request) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.started++
+}
+
+func (o *recordingObserver) RequestFinished(_ *http.Request, resp *http.Response, err error, _ time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if err == nil {
+		o.statuses = append(o.statuses, resp.StatusCode)
+	}
+}
+
+func (o *recordingObserver) RequestRetried(_ *http.Request, attempt int, _ time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.retries = append(o.retries, attempt)
+}
+
+func (o *recordingObserver) RateLimited(event RateLimitEvent) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.rateLimits = append(o.rateLimits, event)
+}
+
+func TestClientObserver(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch calls.Add(1) {
+		case 1:
+			w.WriteHeader(http.StatusBadGateway)
+		case 2:
+			w.Header().Set("Retry-After", "0")
+			w.Header().Set("X-RateLimit-Resource", "core")
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			fmt.Fprint(w, `{"id": 42}`)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	observer := &recordingObserver{}
+	client, err := NewClientWithOptions("token", Options{
+		BaseURL:  server.URL + "/",
+		Retry:    Retry{MaxAttempts: 3, InitialBackoff: time.Millisecond},
+		Observer: observer,
+	})
+	require.NoError(t, err)
+
+	id, err := client.GetRepositoryID(context.Background(), "o", "r")
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), id)
+
+	assert.Equal(t, 3, observer.started)
+	assert.Equal(t, []int{http.StatusBadGateway, http.StatusForbidden, http.StatusOK}, observer.statuses)
+	assert.Equal(t, []int{2, 3}, observer.retries)
+	assert.Equal(t, []RateLimitEvent{{Resource: "core", Secondary: true}}, observer.rateLimits)
+}
+
+func TestPacingTransport_Observer(t *testing.T) {
+	observer := &recordingObserver{}
+	tr := newPacingTransport(nil, Pacing{Enabled: true, Reserve: 10})
+	tr.observer = observer
+	now := time.Unix(1700000000, 0)
+	tr.now = func() time.Time { return now }
+	tr.sleep = func(context.Context, time.Duration) error { return nil }
+	tr.budgets["core"] = &rateBudget{remaining: 5, reset: now.Add(time.Minute)}
+
+	assert.Equal(t, time.Minute+time.Second, tr.wait("core"))
+	assert.Equal(t, time.Minute+time.Second, tr.wait("core"))
+	// Observers are notified once per reset
+	assert.Equal(t, []RateLimitEvent{{Resource: "core", Remaining: 5, Wait: time.Minute + time.Second}}, observer.rateLimits)
+}
@@ -0,0 +1,195 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+)
+
+// operationRetryClient retries secret and variable writes failing with a
+// transient error as a whole, on top of the retries of their requests: a
+// write spans several requests, e.g. fetching the public key before the
+// PUT, and its requests give up after Retry.MaxAttempts attempts or the
+// operation timeout.
+type operationRetryClient struct {
+	Client
+	retries int
+	retry   Retry
+	// observer is notified of retried operations (nil: none)
+	observer ClientObserver
+	// sleep waits for d or until ctx is done; replaced in tests
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// withOperationRetry wraps client to retry writes as configured by opts.
+func withOperationRetry(client Client, opts Options) Client {
+	if opts.OperationRetries <= 0 {
+		return client
+	}
+	return &operationRetryClient{Client: client, retries: opts.OperationRetries, retry: opts.Retry, observer: opts.Observer, sleep: sleepContext}
+}
+
+// do runs write, retrying it while it fails with a transient error.
+func (c *operationRetryClient) do(ctx context.Context, operation string, write func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := write()
+		if err == nil || attempt > c.retries || ctx.Err() != nil || !isTransientOperationError(err) {
+			return err
+		}
+		delay := c.retry.backoff(attempt)
+		if c.observer != nil {
+			c.observer.OperationRetried(operation, attempt+1, delay, err)
+		}
+		if err := c.sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// doCreated runs write like do, returning whether it created the entry.
+func (c *operationRetryClient) doCreated(ctx context.Context, operation string, write func() (bool, error)) (bool, error) {
+	var created bool
+	err := c.do(ctx, operation, func() (err error) {
+		created, err = write()
+		return err
+	})
+	return created, err
+}
+
+// isTransientOperationError reports whether a failed write is worth
+// retrying: 5xx and 429 responses, rate limits, timeouts and connection
+// failures. Errors such as missing permissions or invalid values are not.
+func isTransientOperationError(err error) bool {
+	var ghErr *github.ErrorResponse
+	if errors.As(err, &ghErr) && ghErr.Response != nil {
+		return ghErr.Response.StatusCode >= http.StatusInternalServerError || ghErr.Response.StatusCode == http.StatusTooManyRequests
+	}
+	var abuseErr *github.AbuseRateLimitError
+	var rateErr *github.RateLimitError
+	if errors.As(err, &abuseErr) || errors.As(err, &rateErr) {
+		return true
+	}
+	return errors.Is(err, context.DeadlineExceeded) || isTransientError(err)
+}
+
+func (c *operationRetryClient) SetRepositorySecret(ctx context.Context, owner, repo, name, secretValue string) (bool, error) {
+	return c.doCreated(ctx, "set repository secret", func() (bool, error) {
+		return c.Client.SetRepositorySecret(ctx, owner, repo, name, secretValue)
+	})
+}
+
+func (c *operationRetryClient) DeleteRepositorySecret(ctx context.Context, owner, repo, name string) error {
+	return c.do(ctx, "delete repository secret", func() error {
+		return c.Client.DeleteRepositorySecret(ctx, owner, repo, name)
+	})
+}
+
+func (c *operationRetryClient) SetEnvironmentSecret(ctx context.Context, owner, repo, environment, name, secretValue string) (bool, error) {
+	return c.doCreated(ctx, "set environment secret", func() (bool, error) {
+		return c.Client.SetEnvironmentSecret(ctx, owner, repo, environment, name, secretValue)
+	})
+}
+
+func (c *operationRetryClient) DeleteEnvironmentSecret(ctx context.Context, owner, repo, environment, name string) error {
+	return c.do(ctx, "delete environment secret", func() error {
+		return c.Client.DeleteEnvironmentSecret(ctx, owner, repo, environment, name)
+	})
+}
+
+func (c *operationRetryClient) SetCodespacesSecret(ctx context.Context, owner, repo, name, secretValue string) (bool, error) {
+	return c.doCreated(ctx, "set codespaces secret", func() (bool, error) {
+		return c.Client.SetCodespacesSecret(ctx, owner, repo, name, secretValue)
+	})
+}
+
+func (c *operationRetryClient) DeleteCodespacesSecret(ctx context.Context, owner, repo, name string) error {
+	return c.do(ctx, "delete codespaces secret", func() error {
+		return c.Client.DeleteCodespacesSecret(ctx, owner, repo, name)
+	})
+}
+
+func (c *operationRetryClient) SetUserCodespacesSecret(ctx context.Context, name, secretValue string, selectedRepoIDs []int64) error {
+	return c.do(ctx, "set user codespaces secret", func() error {
+		return c.Client.SetUserCodespacesSecret(ctx, name, secretValue, selectedRepoIDs)
+	})
+}
+
+func (c *operationRetryClient) DeleteUserCodespacesSecret(ctx context.Context, name string) error {
+	return c.do(ctx, "delete user codespaces secret", func() error {
+		return c.Client.DeleteUserCodespacesSecret(ctx, name)
+	})
+}
+
+func (c *operationRetryClient) SetDependabotSecret(ctx context.Context, owner, repo, name, secretValue string) error {
+	return c.do(ctx, "set dependabot secret", func() error {
+		return c.Client.SetDependabotSecret(ctx, owner, repo, name, secretValue)
+	})
+}
+
+func (c *operationRetryClient) DeleteDependabotSecret(ctx context.Context, owner, repo, name string) error {
+	return c.do(ctx, "delete dependabot secret", func() error {
+		return c.Client.DeleteDependabotSecret(ctx, owner, repo, name)
+	})
+}
+
+func (c *operationRetryClient) SetOrganizationSecret(ctx context.Context, org, name, secretValue, visibility string, selectedRepoIDs []int64) error {
+	return c.do(ctx, "set organization secret", func() error {
+		return c.Client.SetOrganizationSecret(ctx, org, name, secretValue, visibility, selectedRepoIDs)
+	})
+}
+
+func (c *operationRetryClient) DeleteOrganizationSecret(ctx context.Context, org, name string) error {
+	return c.do(ctx, "delete organization secret", func() error {
+		return c.Client.DeleteOrganizationSecret(ctx, org, name)
+	})
+}
+
+func (c *operationRetryClient) SetOrganizationSecretRepositories(ctx context.Context, org, name string, repoIDs []int64) error {
+	return c.do(ctx, "set organization secret repositories", func() error {
+		return c.Client.SetOrganizationSecretRepositories(ctx, org, name, repoIDs)
+	})
+}
+
+func (c *operationRetryClient) SetOrganizationVariable(ctx context.Context, org, name, value, visibility string, selectedRepoIDs []int64) error {
+	return c.do(ctx, "set organization variable", func() error {
+		return c.Client.SetOrganizationVariable(ctx, org, name, value, visibility, selectedRepoIDs)
+	})
+}
+
+func (c *operationRetryClient) DeleteOrganizationVariable(ctx context.Context, org, name string) error {
+	return c.do(ctx, "delete organization variable", func() error {
+		return c.Client.DeleteOrganizationVariable(ctx, org, name)
+	})
+}
+
+func (c *operationRetryClient) SetRepositoryVariable(ctx context.Context, owner, repo, name, value string) (bool, error) {
+	return c.doCreated(ctx, "set repository variable", func() (bool, error) {
+		return c.Client.SetRepositoryVariable(ctx, owner, repo, name, value)
+	})
+}
+
+func (c *operationRetryClient) DeleteRepositoryVariable(ctx context.Context, owner, repo, name string) error {
+	return c.do(ctx, "delete repository variable", func() error {
+		return c.Client.DeleteRepositoryVariable(ctx, owner, repo, name)
+	})
+}
+
+func (c *operationRetryClient) SetEnvironmentVariable(ctx context.Context, owner, repo, environment, name, value string) (bool, error) {
+	return c.doCreated(ctx, "set environment variable", func() (bool, error) {
+		return c.Client.SetEnvironmentVariable(ctx, owner, repo, environment, name, value)
+	})
+}
+
+func (c *operationRetryClient) DeleteEnvironmentVariable(ctx context.Context, owner, repo, environment, name string) error {
+	return c.do(ctx, "delete environment variable", func() error {
+		return c.Client.DeleteEnvironmentVariable(ctx, owner, repo, environment, name)
+	})
+}
+
+func (c *operationRetryClient) SetSecret(ctx context.Context, owner, repo, name, secretValue string) error {
+	_, err := c.SetRepositorySecret(ctx, owner, repo, name, secretValue)
+	return err
+}
@@ -0,0 +1,82 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestOperationRetryClient returns a client retrying the writes of a test
+// client serving mux, recording its delays instead of sleeping.
+func newTestOperationRetryClient(t *testing.T, mux *http.ServeMux, retries int) (*operationRetryClient, *[]time.Duration) {
+	var delays []time.Duration
+	client := withOperationRetry(newTestClient(t, mux), Options{OperationRetries: retries, Retry: Retry{InitialBackoff: time.Second}}).(*operationRetryClient)
+	client.sleep = func(ctx context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return nil
+	}
+	return client, &delays
+}
+
+func TestOperationRetry_RetriesTransientErrors(t *testing.T) {
+	var attempts atomic.Int32
+	mux := http.NewServeMux()
+	// Updating fails with 502 twice, and so does the fallback to creating
+	mux.HandleFunc("/repos/owner/repo/actions/variables/REGION", func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Load() < 2 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/repos/owner/repo/actions/variables", func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	client, delays := newTestOperationRetryClient(t, mux, 2)
+	created, err := client.SetRepositoryVariable(context.Background(), "owner", "repo", "REGION", "eu")
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, *delays)
+}
+
+func TestOperationRetry_GivesUpAfterMaxRetries(t *testing.T) {
+	var calls atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/owner/repo/actions/secrets/TOKEN", func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	client, delays := newTestOperationRetryClient(t, mux, 2)
+	err := client.DeleteRepositorySecret(context.Background(), "owner", "repo", "TOKEN")
+	require.Error(t, err)
+	assert.Equal(t, int32(3), calls.Load())
+	assert.Len(t, *delays, 2)
+}
+
+func TestOperationRetry_PermanentErrors(t *testing.T) {
+	var calls atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/owner/repo/actions/secrets/TOKEN", func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusForbidden)
+	})
+
+	client, delays := newTestOperationRetryClient(t, mux, 2)
+	err := client.DeleteRepositorySecret(context.Background(), "owner", "repo", "TOKEN")
+	require.Error(t, err)
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Empty(t, *delays)
+}
+
+func TestOperationRetry_Disabled(t *testing.T) {
+	client := newTestClient(t, http.NewServeMux())
+	assert.Same(t, client, withOperationRetry(client, Options{}))
+}
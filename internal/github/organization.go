@@ -0,0 +1,276 @@
+package github
+
+import (
+	"context"
+
+	"github.com/google/go-github/v57/github"
+)
+
+// Organization secret visibilities.
+const (
+	VisibilityAll      = "all"
+	VisibilityPrivate  = "private"
+	VisibilitySelected = "selected"
+)
+
+// OrganizationSecretMetadata represents metadata about an organization secret.
+type OrganizationSecretMetadata struct {
+	Name       string
+	Visibility string
+	CreatedAt  string
+	UpdatedAt  string
+}
+
+// OrganizationVariableMetadata represents an organization variable.
+type OrganizationVariableMetadata struct {
+	Name       string
+	Value      string
+	Visibility string
+	CreatedAt  string
+	UpdatedAt  string
+}
+
+// GetOrganizationPublicKey retrieves the public key for an organization's secrets.
+func (c *githubClient) GetOrganizationPublicKey(ctx context.Context, org string) (*PublicKey, error) {
+	return shareLookup(ctx, &c.lookups, "organization-key "+org, func() (*PublicKey, error) {
+		key, _, err := c.client.Actions.GetOrgPublicKey(ctx, org)
+		if err != nil {
+			return nil, handleGitHubError(err, org, "", "", "organization_secret", "")
+		}
+
+		return &PublicKey{
+			KeyID: key.GetKeyID(),
+			Key:   key.GetKey(),
+		}, nil
+	})
+}
+
+// SetOrganizationSecret sets an organization secret. selectedRepoIDs is only
+// used with VisibilitySelected and replaces the repositories that can access
+// the secret, so an empty list revokes access from every repository. The
+// secretValue is plaintext and will be encrypted automatically.
+func (c *githubClient) SetOrganizationSecret(ctx context.Context, org, name, secretValue, visibility string, selectedRepoIDs []int64) error {
+	ctx, cancel := c.operation(ctx)
+	defer cancel()
+
+	publicKey, err := c.GetOrganizationPublicKey(ctx, org)
+	if err != nil {
+		return err
+	}
+
+	encrypted, err := encryptForKey(publicKey, secretValue)
+	if err != nil {
+		return err
+	}
+
+	secret := &github.EncryptedSecret{
+		Name:           name,
+		EncryptedValue: encrypted,
+		KeyID:          publicKey.KeyID,
+		Visibility:     visibility,
+	}
+
+	_, err = c.client.Actions.CreateOrUpdateOrgSecret(ctx, org, secret)
+	if err != nil {
+		return handleGitHubError(err, org, "", "", "organization_secret", name)
+	}
+
+	// The create call omits an empty repository list, so the access list is
+	// always replaced separately
+	if visibility == VisibilitySelected {
+		return c.SetOrganizationSecretRepositories(ctx, org, name, selectedRepoIDs)
+	}
+
+	return nil
+}
+
+// SetOrganizationSecretRepositories replaces the repositories that can access
+// an organization secret with visibility selected.
+func (c *githubClient) SetOrganizationSecretRepositories(ctx context.Context, org, name string, repoIDs []int64) error {
+	ctx, cancel := c.operation(ctx)
+	defer cancel()
+
+	ids := github.SelectedRepoIDs(repoIDs)
+	if ids == nil {
+		ids = github.SelectedRepoIDs{}
+	}
+
+	_, err := c.client.Actions.SetSelectedReposForOrgSecret(ctx, org, name, ids)
+	if err != nil {
+		return handleGitHubError(err, org, "", "", "organization_secret", name)
+	}
+
+	return nil
+}
+
+// ListOrganizationSecretRepositories returns the repositories that can access
+// an organization secret with visibility selected, walking every page of results.
+func (c *githubClient) ListOrganizationSecretRepositories(ctx context.Context, org, name string) ([]Repository, error) {
+	ctx, cancel := c.operation(ctx)
+	defer cancel()
+
+	opts := &github.ListOptions{PerPage: listPageSize}
+
+	var all []*github.Repository
+	for {
+		list, resp, err := c.client.Actions.ListSelectedReposForOrgSecret(ctx, org, name, opts)
+		if err != nil {
+			return nil, handleGitHubError(err, org, "", "", "organization_secret", name)
+		}
+		all = append(all, list.Repositories...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return toRepositories(all), nil
+}
+
+// GetOrganizationSecret retrieves metadata about an organization secret.
+func (c *githubClient) GetOrganizationSecret(ctx context.Context, org, name string) (*OrganizationSecretMetadata, error) {
+	ctx, cancel := c.operation(ctx)
+	defer cancel()
+
+	secret, _, err := c.client.Actions.GetOrgSecret(ctx, org, name)
+	if err != nil {
+		return nil, handleGitHubError(err, org, "", "", "organization_secret", name)
+	}
+
+	return &OrganizationSecretMetadata{
+		Name:       secret.Name,
+		Visibility: secret.Visibility,
+		CreatedAt:  secret.CreatedAt.String(),
+		UpdatedAt:  secret.UpdatedAt.String(),
+	}, nil
+}
+
+// DeleteOrganizationSecret deletes an organization secret.
+// Deleting a secret that does not exist is not an error.
+func (c *githubClient) DeleteOrganizationSecret(ctx context.Context, org, name string) error {
+	ctx, cancel := c.operation(ctx)
+	defer cancel()
+
+	_, err := c.client.Actions.DeleteOrgSecret(ctx, org, name)
+	if err != nil && !isNotFound(err) {
+		return handleGitHubError(err, org, "", "", "organization_secret", name)
+	}
+	return nil
+}
+
+// SetOrganizationVariable sets an organization variable. selectedRepoIDs is
+// only used with VisibilitySelected and replaces the repositories that can
+// access the variable, so an empty list revokes access from every repository.
+func (c *githubClient) SetOrganizationVariable(ctx context.Context, org, name, value, visibility string, selectedRepoIDs []int64) error {
+	ctx, cancel := c.operation(ctx)
+	defer cancel()
+
+	variable := &github.ActionsVariable{
+		Name:       name,
+		Value:      value,
+		Visibility: github.String(visibility),
+	}
+	if visibility == VisibilitySelected {
+		// A non-nil list is sent even when empty, revoking access from every repository
+		ids := github.SelectedRepoIDs{}
+		ids = append(ids, selectedRepoIDs...)
+		variable.SelectedRepositoryIDs = &ids
+	}
+
+	// Try to update first, if it doesn't exist, create it
+	_, err := c.client.Actions.UpdateOrgVariable(ctx, org, variable)
+	if err != nil {
+		_, err = c.client.Actions.CreateOrgVariable(ctx, org, variable)
+		if err != nil {
+			return handleGitHubError(err, org, "", "", "organization_variable", name)
+		}
+	}
+
+	return nil
+}
+
+// GetOrganizationVariable retrieves an organization variable (including its value).
+func (c *githubClient) GetOrganizationVariable(ctx context.Context, org, name string) (*OrganizationVariableMetadata, error) {
+	ctx, cancel := c.operation(ctx)
+	defer cancel()
+
+	variable, _, err := c.client.Actions.GetOrgVariable(ctx, org, name)
+	if err != nil {
+		return nil, handleGitHubError(err, org, "", "", "organization_variable", name)
+	}
+
+	return &OrganizationVariableMetadata{
+		Name:       variable.Name,
+		Value:      variable.Value,
+		Visibility: variable.GetVisibility(),
+		CreatedAt:  variable.CreatedAt.String(),
+		UpdatedAt:  variable.UpdatedAt.String(),
+	}, nil
+}
+
+// ListOrganizationVariables returns every variable of an organization,
+// including values and visibility, walking every page of results.
+func (c *githubClient) ListOrganizationVariables(ctx context.Context, org string) ([]OrganizationVariableMetadata, error) {
+	ctx, cancel := c.operation(ctx)
+	defer cancel()
+
+	opts := &github.ListOptions{PerPage: listPageSize}
+
+	var all []OrganizationVariableMetadata
+	for {
+		variables, resp, err := c.client.Actions.ListOrgVariables(ctx, org, opts)
+		if err != nil {
+			return nil, handleGitHubError(err, org, "", "", "", "")
+		}
+		for _, variable := range variables.Variables {
+			all = append(all, OrganizationVariableMetadata{
+				Name:       variable.Name,
+				Value:      variable.Value,
+				Visibility: variable.GetVisibility(),
+				CreatedAt:  variable.GetCreatedAt().String(),
+				UpdatedAt:  variable.GetUpdatedAt().String(),
+			})
+		}
+		if resp.NextPage == 0 {
+			return all, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// ListOrganizationVariableRepositories returns the repositories that can access
+// an organization variable with visibility selected, walking every page of results.
+func (c *githubClient) ListOrganizationVariableRepositories(ctx context.Context, org, name string) ([]Repository, error) {
+	ctx, cancel := c.operation(ctx)
+	defer cancel()
+
+	opts := &github.ListOptions{PerPage: listPageSize}
+
+	var all []*github.Repository
+	for {
+		list, resp, err := c.client.Actions.ListSelectedReposForOrgVariable(ctx, org, name, opts)
+		if err != nil {
+			return nil, handleGitHubError(err, org, "", "", "organization_variable", name)
+		}
+		all = append(all, list.Repositories...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return toRepositories(all), nil
+}
+
+// DeleteOrganizationVariable deletes an organization variable.
+// Deleting a variable that does not exist is not an error.
+func (c *githubClient) DeleteOrganizationVariable(ctx context.Context, org, name string) error {
+	ctx, cancel := c.operation(ctx)
+	defer cancel()
+
+	_, err := c.client.Actions.DeleteOrgVariable(ctx, org, name)
+	if err != nil && !isNotFound(err) {
+		return handleGitHubError(err, org, "", "", "organization_variable", name)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetOrganizationSecret(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orgs/my-org/actions/secrets/public-key", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"key_id": "org-key", "key": "AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8="}`))
+	})
+	var body map[string]interface{}
+	mux.HandleFunc("/orgs/my-org/actions/secrets/NPM_TOKEN", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.WriteHeader(http.StatusCreated)
+	})
+	var repos map[string]interface{}
+	mux.HandleFunc("/orgs/my-org/actions/secrets/NPM_TOKEN/repositories", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&repos))
+		w.WriteHeader(http.StatusNoContent)
+	})
+	client := newTestClient(t, mux)
+
+	err := client.SetOrganizationSecret(context.Background(), "my-org", "NPM_TOKEN", "s3cr3t", VisibilitySelected, []int64{1, 2})
+	require.NoError(t, err)
+	assert.Equal(t, "org-key", body["key_id"])
+	assert.Equal(t, "selected", body["visibility"])
+	assert.NotEmpty(t, body["encrypted_value"])
+	assert.Equal(t, []interface{}{1.0, 2.0}, repos["selected_repository_ids"])
+
+	// An empty list revokes access from every repository
+	err = client.SetOrganizationSecret(context.Background(), "my-org", "NPM_TOKEN", "s3cr3t", VisibilitySelected, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{}, repos["selected_repository_ids"])
+}
+
+func TestListOrganizationSecretRepositories(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orgs/my-org/actions/secrets/NPM_TOKEN/repositories", func(w http.ResponseWriter, r *http.Request) {
+		switch page := pageNumber(r); page {
+		case 1:
+			writePage(w, r, page, 2, `{"total_count": 2, "repositories": [{"id": 1, "name": "api"}]}`)
+		case 2:
+			writePage(w, r, page, 2, `{"total_count": 2, "repositories": [{"id": 2, "name": "web"}]}`)
+		}
+	})
+	client := newTestClient(t, mux)
+
+	repos, err := client.ListOrganizationSecretRepositories(context.Background(), "my-org", "NPM_TOKEN")
+	require.NoError(t, err)
+	require.Len(t, repos, 2)
+	assert.Equal(t, "api", repos[0].Name)
+	assert.Equal(t, int64(2), repos[1].ID)
+}
+
+func TestGetOrganizationPublicKey_NotFound(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orgs/my-user/actions/secrets/public-key", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message": "Not Found"}`, http.StatusNotFound)
+	})
+	client := newTestClient(t, mux)
+
+	_, err := client.GetOrganizationPublicKey(context.Background(), "my-user")
+	var orgErr *OrganizationNotFoundError
+	require.ErrorAs(t, err, &orgErr)
+	assert.Equal(t, "my-user", orgErr.Org)
+}
+
+func TestSetOrganizationVariable_CreatesMissing(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orgs/my-org/actions/variables/REGION", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPatch, r.Method)
+		http.Error(w, `{"message": "Not Found"}`, http.StatusNotFound)
+	})
+	var body map[string]interface{}
+	mux.HandleFunc("/orgs/my-org/actions/variables", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.WriteHeader(http.StatusCreated)
+	})
+	client := newTestClient(t, mux)
+
+	err := client.SetOrganizationVariable(context.Background(), "my-org", "REGION", "eu-west-1", VisibilitySelected, []int64{7})
+	require.NoError(t, err)
+	assert.Equal(t, "REGION", body["name"])
+	assert.Equal(t, "eu-west-1", body["value"])
+	assert.Equal(t, "selected", body["visibility"])
+	assert.Equal(t, []interface{}{7.0}, body["selected_repository_ids"])
+}
+
+func TestSetOrganizationVariable_EmptySelection(t *testing.T) {
+	mux := http.NewServeMux()
+	var body map[string]interface{}
+	mux.HandleFunc("/orgs/my-org/actions/variables/REGION", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPatch, r.Method)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.WriteHeader(http.StatusNoContent)
+	})
+	client := newTestClient(t, mux)
+
+	err := client.SetOrganizationVariable(context.Background(), "my-org", "REGION", "eu-west-1", VisibilitySelected, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{}, body["selected_repository_ids"])
+}
+
+func TestListOrganizationVariables(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orgs/my-org/actions/variables", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		switch page := pageNumber(r); page {
+		case 1:
+			writePage(w, r, page, 2, `{"total_count": 2, "variables": [{"name": "REGION", "value": "eu-west-1", "visibility": "all"}]}`)
+		case 2:
+			writePage(w, r, page, 2, `{"total_count": 2, "variables": [{"name": "STAGE", "value": "prod", "visibility": "selected"}]}`)
+		}
+	})
+	mux.HandleFunc("/orgs/my-user/actions/variables", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message": "Not Found"}`, http.StatusNotFound)
+	})
+	client := newTestClient(t, mux)
+
+	variables, err := client.ListOrganizationVariables(context.Background(), "my-org")
+	require.NoError(t, err)
+	require.Len(t, variables, 2)
+	assert.Equal(t, "REGION", variables[0].Name)
+	assert.Equal(t, "eu-west-1", variables[0].Value)
+	assert.Equal(t, VisibilityAll, variables[0].Visibility)
+	assert.Equal(t, VisibilitySelected, variables[1].Visibility)
+
+	_, err = client.ListOrganizationVariables(context.Background(), "my-user")
+	var orgErr *OrganizationNotFoundError
+	require.ErrorAs(t, err, &orgErr)
+}
@@ -0,0 +1,251 @@
+package github
+
+import (
+	"context"
+	"strings"
+)
+
+// ownerClient sends the requests of some owners to clients authenticated for
+// them, e.g. with the token of another organization, and all other requests
+// to the embedded default client.
+type ownerClient struct {
+	Client
+	// owners maps lowercase owners to their client
+	owners map[string]Client
+}
+
+// NewOwnerClient returns a client sending the requests of the owners of
+// owners to their client, and all other requests, including those of the
+// authenticated user, to client. Owners are matched case-insensitively, like
+// on GitHub.
+func NewOwnerClient(client Client, owners map[string]Client) Client {
+	if len(owners) == 0 {
+		return client
+	}
+	byOwner := make(map[string]Client, len(owners))
+	for owner, routed := range owners {
+		byOwner[strings.ToLower(owner)] = routed
+	}
+	return &ownerClient{Client: client, owners: byOwner}
+}
+
+// ClientForOwner returns the client the requests of owner are sent to if
+// client was returned by NewOwnerClient, or client itself.
+func ClientForOwner(client Client, owner string) Client {
+	if routed, ok := client.(*ownerClient); ok {
+		return routed.forOwner(owner)
+	}
+	return client
+}
+
+// forOwner returns the client of owner.
+func (c *ownerClient) forOwner(owner string) Client {
+	if client, ok := c.owners[strings.ToLower(owner)]; ok {
+		return client
+	}
+	return c.Client
+}
+
+func (c *ownerClient) GetPublicKey(ctx context.Context, owner, repo string) (*PublicKey, error) {
+	return c.forOwner(owner).GetPublicKey(ctx, owner, repo)
+}
+
+func (c *ownerClient) SetRepositorySecret(ctx context.Context, owner, repo, name, secretValue string) (created bool, err error) {
+	return c.forOwner(owner).SetRepositorySecret(ctx, owner, repo, name, secretValue)
+}
+
+func (c *ownerClient) GetRepositorySecret(ctx context.Context, owner, repo, name string) (*SecretMetadata, error) {
+	return c.forOwner(owner).GetRepositorySecret(ctx, owner, repo, name)
+}
+
+func (c *ownerClient) DeleteRepositorySecret(ctx context.Context, owner, repo, name string) error {
+	return c.forOwner(owner).DeleteRepositorySecret(ctx, owner, repo, name)
+}
+
+func (c *ownerClient) ListRepositorySecrets(ctx context.Context, owner, repo string) ([]SecretMetadata, error) {
+	return c.forOwner(owner).ListRepositorySecrets(ctx, owner, repo)
+}
+
+func (c *ownerClient) GetEnvironmentPublicKey(ctx context.Context, owner, repo, environment string) (*PublicKey, error) {
+	return c.forOwner(owner).GetEnvironmentPublicKey(ctx, owner, repo, environment)
+}
+
+func (c *ownerClient) SetEnvironmentSecret(ctx context.Context, owner, repo, environment, name, secretValue string) (created bool, err error) {
+	return c.forOwner(owner).SetEnvironmentSecret(ctx, owner, repo, environment, name, secretValue)
+}
+
+func (c *ownerClient) GetEnvironmentSecret(ctx context.Context, owner, repo, environment, name string) (*SecretMetadata, error) {
+	return c.forOwner(owner).GetEnvironmentSecret(ctx, owner, repo, environment, name)
+}
+
+func (c *ownerClient) DeleteEnvironmentSecret(ctx context.Context, owner, repo, environment, name string) error {
+	return c.forOwner(owner).DeleteEnvironmentSecret(ctx, owner, repo, environment, name)
+}
+
+func (c *ownerClient) ListEnvironmentSecrets(ctx context.Context, owner, repo, environment string) ([]SecretMetadata, error) {
+	return c.forOwner(owner).ListEnvironmentSecrets(ctx, owner, repo, environment)
+}
+
+func (c *ownerClient) GetCodespacesPublicKey(ctx context.Context, owner, repo string) (*PublicKey, error) {
+	return c.forOwner(owner).GetCodespacesPublicKey(ctx, owner, repo)
+}
+
+func (c *ownerClient) SetCodespacesSecret(ctx context.Context, owner, repo, name, secretValue string) (created bool, err error) {
+	return c.forOwner(owner).SetCodespacesSecret(ctx, owner, repo, name, secretValue)
+}
+
+func (c *ownerClient) GetCodespacesSecret(ctx context.Context, owner, repo, name string) (*SecretMetadata, error) {
+	return c.forOwner(owner).GetCodespacesSecret(ctx, owner, repo, name)
+}
+
+func (c *ownerClient) DeleteCodespacesSecret(ctx context.Context, owner, repo, name string) error {
+	return c.forOwner(owner).DeleteCodespacesSecret(ctx, owner, repo, name)
+}
+
+func (c *ownerClient) ListCodespacesSecrets(ctx context.Context, owner, repo string) ([]SecretMetadata, error) {
+	return c.forOwner(owner).ListCodespacesSecrets(ctx, owner, repo)
+}
+
+func (c *ownerClient) GetDependabotPublicKey(ctx context.Context, owner, repo string) (*PublicKey, error) {
+	return c.forOwner(owner).GetDependabotPublicKey(ctx, owner, repo)
+}
+
+func (c *ownerClient) SetDependabotSecret(ctx context.Context, owner, repo, name, secretValue string) error {
+	return c.forOwner(owner).SetDependabotSecret(ctx, owner, repo, name, secretValue)
+}
+
+func (c *ownerClient) GetDependabotSecret(ctx context.Context, owner, repo, name string) (*SecretMetadata, error) {
+	return c.forOwner(owner).GetDependabotSecret(ctx, owner, repo, name)
+}
+
+func (c *ownerClient) DeleteDependabotSecret(ctx context.Context, owner, repo, name string) error {
+	return c.forOwner(owner).DeleteDependabotSecret(ctx, owner, repo, name)
+}
+
+func (c *ownerClient) GetOrganizationPublicKey(ctx context.Context, org string) (*PublicKey, error) {
+	return c.forOwner(org).GetOrganizationPublicKey(ctx, org)
+}
+
+func (c *ownerClient) SetOrganizationSecret(ctx context.Context, org, name, secretValue, visibility string, selectedRepoIDs []int64) error {
+	return c.forOwner(org).SetOrganizationSecret(ctx, org, name, secretValue, visibility, selectedRepoIDs)
+}
+
+func (c *ownerClient) GetOrganizationSecret(ctx context.Context, org, name string) (*OrganizationSecretMetadata, error) {
+	return c.forOwner(org).GetOrganizationSecret(ctx, org, name)
+}
+
+func (c *ownerClient) DeleteOrganizationSecret(ctx context.Context, org, name string) error {
+	return c.forOwner(org).DeleteOrganizationSecret(ctx, org, name)
+}
+
+func (c *ownerClient) SetOrganizationSecretRepositories(ctx context.Context, org, name string, repoIDs []int64) error {
+	return c.forOwner(org).SetOrganizationSecretRepositories(ctx, org, name, repoIDs)
+}
+
+func (c *ownerClient) ListOrganizationSecretRepositories(ctx context.Context, org, name string) ([]Repository, error) {
+	return c.forOwner(org).ListOrganizationSecretRepositories(ctx, org, name)
+}
+
+func (c *ownerClient) SetOrganizationVariable(ctx context.Context, org, name, value, visibility string, selectedRepoIDs []int64) error {
+	return c.forOwner(org).SetOrganizationVariable(ctx, org, name, value, visibility, selectedRepoIDs)
+}
+
+func (c *ownerClient) GetOrganizationVariable(ctx context.Context, org, name string) (*OrganizationVariableMetadata, error) {
+	return c.forOwner(org).GetOrganizationVariable(ctx, org, name)
+}
+
+func (c *ownerClient) DeleteOrganizationVariable(ctx context.Context, org, name string) error {
+	return c.forOwner(org).DeleteOrganizationVariable(ctx, org, name)
+}
+
+func (c *ownerClient) ListOrganizationVariables(ctx context.Context, org string) ([]OrganizationVariableMetadata, error) {
+	return c.forOwner(org).ListOrganizationVariables(ctx, org)
+}
+
+func (c *ownerClient) ListOrganizationVariableRepositories(ctx context.Context, org, name string) ([]Repository, error) {
+	return c.forOwner(org).ListOrganizationVariableRepositories(ctx, org, name)
+}
+
+func (c *ownerClient) SetRepositoryVariable(ctx context.Context, owner, repo, name, value string) (created bool, err error) {
+	return c.forOwner(owner).SetRepositoryVariable(ctx, owner, repo, name, value)
+}
+
+func (c *ownerClient) GetRepositoryVariable(ctx context.Context, owner, repo, name string) (*VariableMetadata, error) {
+	return c.forOwner(owner).GetRepositoryVariable(ctx, owner, repo, name)
+}
+
+func (c *ownerClient) DeleteRepositoryVariable(ctx context.Context, owner, repo, name string) error {
+	return c.forOwner(owner).DeleteRepositoryVariable(ctx, owner, repo, name)
+}
+
+func (c *ownerClient) ListRepositoryVariables(ctx context.Context, owner, repo string) ([]VariableMetadata, error) {
+	return c.forOwner(owner).ListRepositoryVariables(ctx, owner, repo)
+}
+
+func (c *ownerClient) SetEnvironmentVariable(ctx context.Context, owner, repo, environment, name, value string) (created bool, err error) {
+	return c.forOwner(owner).SetEnvironmentVariable(ctx, owner, repo, environment, name, value)
+}
+
+func (c *ownerClient) GetEnvironmentVariable(ctx context.Context, owner, repo, environment, name string) (*VariableMetadata, error) {
+	return c.forOwner(owner).GetEnvironmentVariable(ctx, owner, repo, environment, name)
+}
+
+func (c *ownerClient) DeleteEnvironmentVariable(ctx context.Context, owner, repo, environment, name string) error {
+	return c.forOwner(owner).DeleteEnvironmentVariable(ctx, owner, repo, environment, name)
+}
+
+func (c *ownerClient) ListEnvironmentVariables(ctx context.Context, owner, repo, environment string) ([]VariableMetadata, error) {
+	return c.forOwner(owner).ListEnvironmentVariables(ctx, owner, repo, environment)
+}
+
+func (c *ownerClient) EnvironmentExists(ctx context.Context, owner, repo, environment string) (bool, error) {
+	return c.forOwner(owner).EnvironmentExists(ctx, owner, repo, environment)
+}
+
+func (c *ownerClient) ListEnvironments(ctx context.Context, owner, repo string) ([]string, error) {
+	return c.forOwner(owner).ListEnvironments(ctx, owner, repo)
+}
+
+func (c *ownerClient) CreateEnvironment(ctx context.Context, owner, repo, environment string) error {
+	return c.forOwner(owner).CreateEnvironment(ctx, owner, repo, environment)
+}
+
+func (c *ownerClient) SetEnvironmentProtection(ctx context.Context, owner, repo, environment string, protection EnvironmentProtection) error {
+	return c.forOwner(owner).SetEnvironmentProtection(ctx, owner, repo, environment, protection)
+}
+
+func (c *ownerClient) GetActionsEnabled(ctx context.Context, owner, repo string) (bool, error) {
+	return c.forOwner(owner).GetActionsEnabled(ctx, owner, repo)
+}
+
+func (c *ownerClient) SetActionsPermissions(ctx context.Context, owner, repo string, permissions ActionsPermissions) error {
+	return c.forOwner(owner).SetActionsPermissions(ctx, owner, repo, permissions)
+}
+
+func (c *ownerClient) SetWorkflowPermissions(ctx context.Context, owner, repo string, permissions WorkflowPermissions) error {
+	return c.forOwner(owner).SetWorkflowPermissions(ctx, owner, repo, permissions)
+}
+
+func (c *ownerClient) GetRepositoryID(ctx context.Context, owner, repo string) (int64, error) {
+	return c.forOwner(owner).GetRepositoryID(ctx, owner, repo)
+}
+
+func (c *ownerClient) ListOwnerRepositories(ctx context.Context, owner string, opts *ListRepositoriesOptions) ([]Repository, error) {
+	return c.forOwner(owner).ListOwnerRepositories(ctx, owner, opts)
+}
+
+func (c *ownerClient) ListTeamRepositories(ctx context.Context, org, teamSlug string) ([]Repository, error) {
+	return c.forOwner(org).ListTeamRepositories(ctx, org, teamSlug)
+}
+
+func (c *ownerClient) GetRepositoriesMetadata(ctx context.Context, owner string, repos []string) (map[string]RepositoryMetadata, error) {
+	return c.forOwner(owner).GetRepositoriesMetadata(ctx, owner, repos)
+}
+
+func (c *ownerClient) SetSecret(ctx context.Context, owner, repo, name, secretValue string) error {
+	return c.forOwner(owner).SetSecret(ctx, owner, repo, name, secretValue)
+}
+
+func (c *ownerClient) GetSecret(ctx context.Context, owner, repo, name string) (*SecretMetadata, error) {
+	return c.forOwner(owner).GetSecret(ctx, owner, repo, name)
+}
@@ -0,0 +1,39 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOwnerClient(t *testing.T) {
+	// handler answers the variable requests of a server, recording the owners
+	handler := func(owners *[]string) *http.ServeMux {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/repos/{owner}/{repo}/actions/variables/REGION", func(w http.ResponseWriter, r *http.Request) {
+			*owners = append(*owners, r.PathValue("owner"))
+			w.Write([]byte(`{"name":"REGION","value":"eu","created_at":"2024-01-01T00:00:00Z","updated_at":"2024-01-01T00:00:00Z"}`))
+		})
+		return mux
+	}
+	var defaultOwners, otherOwners []string
+	defaultClient := newTestClient(t, handler(&defaultOwners))
+	otherClient := newTestClient(t, handler(&otherOwners))
+
+	client := NewOwnerClient(defaultClient, map[string]Client{"Other-Org": otherClient})
+	ctx := context.Background()
+	for _, owner := range []string{"my-org", "other-org", "OTHER-ORG"} {
+		_, err := client.GetRepositoryVariable(ctx, owner, "api", "REGION")
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, []string{"my-org"}, defaultOwners)
+	assert.Equal(t, []string{"other-org", "OTHER-ORG"}, otherOwners)
+	assert.Same(t, defaultClient, NewOwnerClient(defaultClient, nil))
+	assert.Same(t, otherClient, ClientForOwner(client, "OTHER-org"))
+	assert.Same(t, defaultClient, ClientForOwner(client, "my-org"))
+	assert.Same(t, defaultClient, ClientForOwner(defaultClient, "other-org"))
+}
@@ -0,0 +1,149 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Pacing pauses requests when the primary rate limit reported by GitHub is
+// nearly exhausted, until it resets.
+type Pacing struct {
+	// Enabled turns pacing on
+	Enabled bool
+	// Reserve is the number of remaining requests at or below which requests pause
+	Reserve int
+	// OnPause is called once per reset before requests pause
+	OnPause func(resource string, remaining int, reset time.Time)
+}
+
+// rateBudget is the last known primary rate limit of a resource.
+type rateBudget struct {
+	remaining int
+	reset     time.Time
+	// notified is the reset OnPause was last called for
+	notified time.Time
+}
+
+// pacingTransport tracks the X-RateLimit-* headers of every response, shared
+// by all goroutines using the client, and delays requests while the budget
+// of their resource is exhausted.
+type pacingTransport struct {
+	base   http.RoundTripper
+	pacing Pacing
+	// observer is notified of pauses (nil: none)
+	observer ClientObserver
+
+	mu      sync.Mutex
+	budgets map[string]*rateBudget
+
+	// now and sleep are replaced in tests
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+func newPacingTransport(base http.RoundTripper, pacing Pacing) *pacingTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &pacingTransport{
+		base:    base,
+		pacing:  pacing,
+		budgets: make(map[string]*rateBudget),
+		now:     time.Now,
+		sleep:   sleepContext,
+	}
+}
+
+// RoundTrip waits for the rate limit to reset if needed, sends req and
+// records the rate limit of the response.
+func (t *pacingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resource := rateLimitResource(req)
+	if wait := t.wait(resource); wait > 0 {
+		if err := t.sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if resp != nil {
+		t.record(resp)
+	}
+	return resp, err
+}
+
+// wait returns how long a request to resource must wait for the rate limit
+// to reset.
+func (t *pacingTransport) wait(resource string) time.Duration {
+	t.mu.Lock()
+	budget, ok := t.budgets[resource]
+	if !ok || budget.remaining > t.pacing.Reserve {
+		t.mu.Unlock()
+		return 0
+	}
+	now := t.now()
+	if !budget.reset.After(now) {
+		t.mu.Unlock()
+		return 0
+	}
+	// A second of margin for clock skew with GitHub
+	wait := budget.reset.Sub(now) + time.Second
+	notify := !budget.notified.Equal(budget.reset)
+	budget.notified = budget.reset
+	remaining, reset := budget.remaining, budget.reset
+	t.mu.Unlock()
+
+	if notify && t.pacing.OnPause != nil {
+		t.pacing.OnPause(resource, remaining, reset)
+	}
+	if notify && t.observer != nil {
+		t.observer.RateLimited(RateLimitEvent{Resource: resource, Remaining: remaining, Wait: wait})
+	}
+	return wait
+}
+
+// record updates the budget of the resource of resp from its headers.
+// Responses of concurrent requests arrive out of order, so within a rate
+// limit window the lowest remaining count wins.
+func (t *pacingTransport) record(resp *http.Response) {
+	remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	resetUnix, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return
+	}
+	reset := time.Unix(resetUnix, 0)
+	resource := resp.Header.Get("X-RateLimit-Resource")
+	if resource == "" {
+		resource = "core"
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	budget, ok := t.budgets[resource]
+	switch {
+	case !ok:
+		t.budgets[resource] = &rateBudget{remaining: remaining, reset: reset}
+	case reset.After(budget.reset):
+		budget.remaining = remaining
+		budget.reset = reset
+	case reset.Equal(budget.reset) && remaining < budget.remaining:
+		budget.remaining = remaining
+	}
+}
+
+// rateLimitResource returns the rate limit resource a request counts against.
+func rateLimitResource(req *http.Request) string {
+	switch {
+	case strings.HasSuffix(req.URL.Path, "/graphql"):
+		return "graphql"
+	case strings.Contains(req.URL.Path, "/search/"):
+		return "search"
+	}
+	return "core"
+}
@@ -0,0 +1,93 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPacingTransport_PausesUntilReset(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	reset := now.Add(90 * time.Second)
+	var remaining atomic.Int32
+	remaining.Store(12)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(int(remaining.Add(-1))))
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+		w.Header().Set("X-RateLimit-Resource", "core")
+		fmt.Fprint(w, `{}`)
+	}))
+	t.Cleanup(server.Close)
+
+	var pauses []string
+	tr := newPacingTransport(nil, Pacing{Enabled: true, Reserve: 10, OnPause: func(resource string, remaining int, _ time.Time) {
+		pauses = append(pauses, fmt.Sprintf("%s:%d", resource, remaining))
+	}})
+	tr.now = func() time.Time { return now }
+	var waits []time.Duration
+	tr.sleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+	client := &http.Client{Transport: tr}
+
+	// 11 then 10 remaining: the third request waits for the reset
+	for i := 0; i < 4; i++ {
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+	assert.Equal(t, []time.Duration{91 * time.Second, 91 * time.Second}, waits)
+	// The pause is reported once per reset
+	assert.Equal(t, []string{"core:10"}, pauses)
+
+	// Once the window has passed, requests are no longer delayed
+	tr.now = func() time.Time { return reset.Add(time.Second) }
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Len(t, waits, 2)
+}
+
+func TestPacingTransport_Record(t *testing.T) {
+	tr := newPacingTransport(nil, Pacing{Enabled: true})
+	response := func(remaining int, reset int64, resource string) *http.Response {
+		resp := &http.Response{Header: http.Header{}}
+		resp.Header.Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		resp.Header.Set("X-RateLimit-Reset", strconv.FormatInt(reset, 10))
+		resp.Header.Set("X-RateLimit-Resource", resource)
+		return resp
+	}
+
+	tr.record(response(50, 100, "core"))
+	// A late response of the same window does not raise the remaining count
+	tr.record(response(60, 100, "core"))
+	assert.Equal(t, 50, tr.budgets["core"].remaining)
+	// A new window replaces it
+	tr.record(response(4999, 200, "core"))
+	assert.Equal(t, 4999, tr.budgets["core"].remaining)
+	// Other resources are tracked separately
+	tr.record(response(1, 150, "graphql"))
+	assert.Equal(t, 1, tr.budgets["graphql"].remaining)
+	assert.Equal(t, 4999, tr.budgets["core"].remaining)
+}
+
+func TestRateLimitResource(t *testing.T) {
+	for path, resource := range map[string]string{
+		"/repos/o/r/actions/secrets": "core",
+		"/graphql":                   "graphql",
+		"/api/graphql":               "graphql",
+		"/search/repositories":       "search",
+	} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		assert.Equal(t, resource, rateLimitResource(req), path)
+	}
+}
@@ -0,0 +1,58 @@
+package github
+
+import (
+	"math"
+	"net/http"
+
+	"golang.org/x/time/rate"
+)
+
+// Behaviors when the client-side request budget is exhausted.
+const (
+	RateLimitWait = "wait"
+	RateLimitFail = "fail"
+)
+
+// RateLimit configures the client-side limiter applied to every API request.
+type RateLimit struct {
+	// RequestsPerSecond is the sustained request rate (0: no limit)
+	RequestsPerSecond float64
+	// Burst is the number of requests allowed at once (default: RequestsPerSecond rounded up)
+	Burst int
+	// OnExhausted is RateLimitWait (default) or RateLimitFail
+	OnExhausted string
+}
+
+// rateLimitTransport delays or rejects requests exceeding the configured rate.
+type rateLimitTransport struct {
+	base    http.RoundTripper
+	limiter *rate.Limiter
+	limit   RateLimit
+}
+
+func newRateLimitTransport(base http.RoundTripper, limit RateLimit) *rateLimitTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if limit.Burst <= 0 {
+		limit.Burst = int(math.Ceil(limit.RequestsPerSecond))
+	}
+	return &rateLimitTransport{
+		base:    base,
+		limiter: rate.NewLimiter(rate.Limit(limit.RequestsPerSecond), limit.Burst),
+		limit:   limit,
+	}
+}
+
+// RoundTrip waits for the limiter, or fails immediately when it is exhausted
+// and the limit is configured to fail.
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.limit.OnExhausted == RateLimitFail {
+		if !t.limiter.Allow() {
+			return nil, &RateLimitExceededError{RequestsPerSecond: t.limit.RequestsPerSecond, Burst: t.limit.Burst}
+		}
+	} else if err := t.limiter.Wait(req.Context()); err != nil {
+		return nil, err
+	}
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,57 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitTransport_Fail(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	t.Cleanup(server.Close)
+
+	client := &http.Client{Transport: newRateLimitTransport(nil, RateLimit{RequestsPerSecond: 0.01, Burst: 1, OnExhausted: RateLimitFail})}
+
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	_, err = client.Get(server.URL)
+	var limitErr *RateLimitExceededError
+	require.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, 1, limitErr.Burst)
+}
+
+func TestRateLimitTransport_Wait(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	t.Cleanup(server.Close)
+
+	client := &http.Client{Transport: newRateLimitTransport(nil, RateLimit{RequestsPerSecond: 20})}
+
+	start := time.Now()
+	for i := 0; i < 25; i++ {
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+	// The burst of 20 is served at once, the remaining requests wait for the limiter
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+	assert.Equal(t, int32(25), requests.Load())
+
+	// Waiting stops when the request context is cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	_, err = client.Do(req)
+	require.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,218 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/v57/github"
+)
+
+// listPageSize is the page size used for paginated list calls (GitHub maximum).
+const listPageSize = 100
+
+// Repository holds the repository metadata used to select target repositories.
+type Repository struct {
+	Name       string
+	ID         int64
+	Topics     []string
+	Archived   bool
+	Visibility string
+}
+
+// ListRepositoriesOptions filters the repositories returned by
+// ListOwnerRepositories. The zero value lists every non-archived repository.
+type ListRepositoriesOptions struct {
+	// Type is one of "all" (default), "public", "private", "forks" or "sources".
+	Type string
+	// IncludeArchived also returns archived repositories, which are read-only
+	// and cannot receive secrets.
+	IncludeArchived bool
+	// Visibility is one of "public", "private" or "internal"; empty matches all.
+	Visibility string
+}
+
+func (o *ListRepositoriesOptions) validate() error {
+	switch o.Type {
+	case "", "all", "public", "private", "forks", "sources":
+	default:
+		return fmt.Errorf("unsupported repository type '%s' (must be all, public, private, forks or sources)", o.Type)
+	}
+	switch o.Visibility {
+	case "", "public", "private", "internal":
+	default:
+		return fmt.Errorf("unsupported repository visibility '%s' (must be public, private or internal)", o.Visibility)
+	}
+	return nil
+}
+
+// matches reports whether repo passes the filters of o.
+func (o *ListRepositoriesOptions) matches(repo *github.Repository) bool {
+	if repo.GetArchived() && !o.IncludeArchived {
+		return false
+	}
+	if o.Visibility != "" && repositoryVisibility(repo) != o.Visibility {
+		return false
+	}
+	switch o.Type {
+	case "public":
+		return !repo.GetPrivate()
+	case "private":
+		return repo.GetPrivate()
+	case "forks":
+		return repo.GetFork()
+	case "sources":
+		return !repo.GetFork()
+	}
+	return true
+}
+
+// repositoryVisibility returns the visibility of repo, derived from its
+// private flag when the API did not report it.
+func repositoryVisibility(repo *github.Repository) string {
+	if visibility := repo.GetVisibility(); visibility != "" {
+		return visibility
+	}
+	if repo.GetPrivate() {
+		return "private"
+	}
+	return "public"
+}
+
+// ListOwnerRepositories returns the repositories owned by an organization or
+// user that match opts, walking every page of results. A nil opts lists every
+// non-archived repository.
+func (c *githubClient) ListOwnerRepositories(ctx context.Context, owner string, opts *ListRepositoriesOptions) ([]Repository, error) {
+	if opts == nil {
+		opts = &ListRepositoriesOptions{}
+	}
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	repos, err := c.listOrgRepositories(ctx, owner, opts.Type)
+	var ghErr *github.ErrorResponse
+	if errors.As(err, &ghErr) && ghErr.Response != nil && ghErr.Response.StatusCode == http.StatusNotFound {
+		// Not an organization, list the user's repositories instead
+		repos, err = c.listUserRepositories(ctx, owner)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list repositories for %s: %w", owner, err)
+	}
+
+	result := make([]Repository, 0, len(repos))
+	for _, repo := range repos {
+		c.cacheRepositoryID(owner, repo.GetName(), repo.GetID())
+		if opts.matches(repo) {
+			result = append(result, toRepository(repo))
+		}
+	}
+	return result, nil
+}
+
+// listOrgRepositories lists an organization's repositories of the given type,
+// leaving the filtering of user repositories to the caller.
+func (c *githubClient) listOrgRepositories(ctx context.Context, org, repoType string) ([]*github.Repository, error) {
+	if repoType == "" {
+		repoType = "all"
+	}
+	opts := &github.RepositoryListByOrgOptions{
+		Type:        repoType,
+		ListOptions: github.ListOptions{PerPage: listPageSize},
+	}
+
+	var all []*github.Repository
+	for {
+		repos, resp, err := c.client.Repositories.ListByOrg(ctx, org, opts)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, repos...)
+		if resp.NextPage == 0 {
+			return all, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// listUserRepositories lists a user's repositories. Private repositories are
+// only included when the user is the authenticated user.
+func (c *githubClient) listUserRepositories(ctx context.Context, user string) ([]*github.Repository, error) {
+	authenticated, _, err := c.client.Users.Get(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	listUser := user
+	opts := &github.RepositoryListOptions{
+		Type:        "owner",
+		ListOptions: github.ListOptions{PerPage: listPageSize},
+	}
+	if strings.EqualFold(authenticated.GetLogin(), user) {
+		// An empty user lists the authenticated user's repositories, including private ones
+		listUser = ""
+		opts.Type = ""
+		opts.Affiliation = "owner"
+	}
+
+	var all []*github.Repository
+	for {
+		repos, resp, err := c.client.Repositories.List(ctx, listUser, opts)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, repos...)
+		if resp.NextPage == 0 {
+			return all, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// ListTeamRepositories returns all non-archived repositories a team of an
+// organization has access to, walking every page of results.
+func (c *githubClient) ListTeamRepositories(ctx context.Context, org, teamSlug string) ([]Repository, error) {
+	opts := &github.ListOptions{PerPage: listPageSize}
+
+	var all []*github.Repository
+	for {
+		repos, resp, err := c.client.Teams.ListTeamReposBySlug(ctx, org, teamSlug, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list repositories of team %s/%s: %w", org, teamSlug, err)
+		}
+		for _, repo := range repos {
+			c.cacheRepositoryID(org, repo.GetName(), repo.GetID())
+		}
+		all = append(all, repos...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return toRepositories(all), nil
+}
+
+// toRepositories converts API repositories, dropping archived ones.
+func toRepositories(repos []*github.Repository) []Repository {
+	result := make([]Repository, 0, len(repos))
+	for _, repo := range repos {
+		if repo.GetArchived() {
+			continue
+		}
+		result = append(result, toRepository(repo))
+	}
+	return result
+}
+
+func toRepository(repo *github.Repository) Repository {
+	return Repository{
+		Name:       repo.GetName(),
+		ID:         repo.GetID(),
+		Topics:     repo.Topics,
+		Archived:   repo.GetArchived(),
+		Visibility: repo.GetVisibility(),
+	}
+}
@@ -0,0 +1,132 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListOwnerRepositories_Organization(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orgs/my-org/repos", func(w http.ResponseWriter, r *http.Request) {
+		switch page := pageNumber(r); page {
+		case 1:
+			writePage(w, r, page, 2, `[{"name":"api","id":1,"topics":["deploys-with-gajin"]},{"name":"old","archived":true}]`)
+		case 2:
+			writePage(w, r, page, 2, `[{"name":"web","id":2}]`)
+		}
+	})
+	client := newTestClient(t, mux)
+
+	repos, err := client.ListOwnerRepositories(context.Background(), "my-org", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []Repository{
+		{Name: "api", ID: 1, Topics: []string{"deploys-with-gajin"}},
+		{Name: "web", ID: 2},
+	}, repos)
+
+	// Listed IDs are cached, so no further request is needed
+	id, err := client.GetRepositoryID(context.Background(), "my-org", "web")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), id)
+}
+
+func TestListOwnerRepositories_AuthenticatedUser(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orgs/octocat/repos", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message":"Not Found"}`)
+	})
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"login":"Octocat"}`)
+	})
+	mux.HandleFunc("/user/repos", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "owner", r.URL.Query().Get("affiliation"))
+		writePage(w, r, pageNumber(r), 1, `[{"name":"dotfiles"},{"name":"private-notes","private":true}]`)
+	})
+	client := newTestClient(t, mux)
+
+	repos, err := client.ListOwnerRepositories(context.Background(), "octocat", nil)
+	require.NoError(t, err)
+	require.Len(t, repos, 2)
+	assert.Equal(t, "dotfiles", repos[0].Name)
+	assert.Equal(t, "private-notes", repos[1].Name)
+}
+
+func TestListOwnerRepositories_Filters(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orgs/my-org/repos", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "private", r.URL.Query().Get("type"))
+		writePage(w, r, pageNumber(r), 1, `[
+			{"name":"api","id":1,"private":true,"visibility":"private"},
+			{"name":"docs","id":2,"private":true,"visibility":"internal"},
+			{"name":"legacy","id":3,"private":true,"visibility":"private","archived":true}
+		]`)
+	})
+	client := newTestClient(t, mux)
+
+	repos, err := client.ListOwnerRepositories(context.Background(), "my-org", &ListRepositoriesOptions{
+		Type:            "private",
+		IncludeArchived: true,
+		Visibility:      "private",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []Repository{
+		{Name: "api", ID: 1, Visibility: "private"},
+		{Name: "legacy", ID: 3, Archived: true, Visibility: "private"},
+	}, repos)
+
+	_, err = client.ListOwnerRepositories(context.Background(), "my-org", &ListRepositoriesOptions{Visibility: "secret"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported repository visibility 'secret'")
+}
+
+func TestListTeamRepositories(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orgs/my-org/teams/platform-team/repos", func(w http.ResponseWriter, r *http.Request) {
+		switch page := pageNumber(r); page {
+		case 1:
+			writePage(w, r, page, 2, `[{"name":"infra","id":1}]`)
+		case 2:
+			writePage(w, r, page, 2, `[{"name":"old","archived":true},{"name":"terraform","id":3}]`)
+		}
+	})
+	client := newTestClient(t, mux)
+
+	repos, err := client.ListTeamRepositories(context.Background(), "my-org", "platform-team")
+	require.NoError(t, err)
+	assert.Equal(t, []Repository{{Name: "infra", ID: 1}, {Name: "terraform", ID: 3}}, repos)
+}
+
+func TestGetRepositoryID_Cached(t *testing.T) {
+	calls := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/o/r", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		fmt.Fprint(w, `{"id": 42, "name": "r"}`)
+	})
+	mux.HandleFunc("/repos/o/missing", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusNotFound)
+	})
+	client := newTestClient(t, mux)
+	ctx := context.Background()
+
+	for _, repo := range []string{"r", "r", "R"} {
+		id, err := client.GetRepositoryID(ctx, "o", repo)
+		require.NoError(t, err)
+		assert.Equal(t, int64(42), id)
+	}
+	assert.Equal(t, 1, calls)
+
+	// Failures are not cached
+	for i := 0; i < 2; i++ {
+		_, err := client.GetRepositoryID(ctx, "o", "missing")
+		require.Error(t, err)
+	}
+	assert.Equal(t, 3, calls)
+}
@@ -0,0 +1,205 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// Retry configures retries of requests failing with transient errors.
+type Retry struct {
+	// MaxAttempts is the number of attempts per request including the first (0 or 1: no retries)
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry, doubled for every further retry
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between two attempts (0: no cap)
+	MaxBackoff time.Duration
+	// Jitter randomizes every delay by up to this fraction
+	Jitter float64
+	// MaxWait bounds the total time a request waits for rate limits as
+	// instructed by GitHub (0: no limit). These waits do not count as attempts.
+	MaxWait time.Duration
+}
+
+// retryTransport retries requests failing with 5xx responses and connection
+// resets with exponential backoff, and requests hitting a rate limit after
+// the wait GitHub instructs.
+type retryTransport struct {
+	base  http.RoundTripper
+	retry Retry
+	// observer is notified of retries and rate limits (nil: none)
+	observer ClientObserver
+	// sleep waits for d or until ctx is done; replaced in tests
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+func newRetryTransport(base http.RoundTripper, retry Retry) *retryTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &retryTransport{base: base, retry: retry, sleep: sleepContext}
+}
+
+// RoundTrip sends req, retrying transient failures. Requests whose body
+// cannot be replayed are sent once.
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+
+	var waited time.Duration
+	for attempt, sent := 1, 1; ; sent++ {
+		resp, err := t.base.RoundTrip(req)
+		if !replayable {
+			return resp, err
+		}
+		delay, rateLimited, retry := t.retryDelay(req, resp, err, attempt)
+		if !retry {
+			return resp, err
+		}
+		if rateLimited {
+			if t.retry.MaxWait > 0 && waited+delay > t.retry.MaxWait {
+				return resp, err
+			}
+			waited += delay
+		} else {
+			if attempt >= t.retry.MaxAttempts {
+				return resp, err
+			}
+			attempt++
+		}
+		// Waiting past the deadline of the operation would only delay its failure
+		if deadline, ok := req.Context().Deadline(); ok && time.Now().Add(delay).After(deadline) {
+			return resp, err
+		}
+		if resp != nil {
+			// Drain the body so the connection can be reused
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+			resp.Body.Close()
+		}
+		if t.observer != nil {
+			if rateLimited {
+				t.observer.RateLimited(rateLimitEvent(resp, delay))
+			}
+			t.observer.RequestRetried(req, sent+1, delay)
+		}
+		if err := t.sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// retryDelay reports whether the outcome of an attempt is worth retrying,
+// how long to wait before the next attempt and whether the wait was
+// instructed by a rate limit.
+func (t *retryTransport) retryDelay(req *http.Request, resp *http.Response, err error, attempt int) (delay time.Duration, rateLimited, retry bool) {
+	if err != nil {
+		if req.Context().Err() != nil || !isTransientError(err) {
+			return 0, false, false
+		}
+		return t.backoff(attempt), false, true
+	}
+
+	switch resp.StatusCode {
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return t.backoff(attempt), false, true
+	case http.StatusForbidden, http.StatusTooManyRequests:
+		// Rate limits tell how long to wait; other 403s are permanent
+		if wait, ok := rateLimitWait(resp, time.Now()); ok {
+			return wait, true, true
+		}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			return t.backoff(attempt), false, true
+		}
+	}
+	return 0, false, false
+}
+
+// rateLimitWait returns the wait GitHub instructs for a rate limited
+// response: the Retry-After header of secondary rate limits, in seconds or as
+// a date, or the time until X-RateLimit-Reset once the primary rate limit is
+// exhausted.
+func rateLimitWait(resp *http.Response, now time.Time) (time.Duration, bool) {
+	if value := resp.Header.Get("Retry-After"); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+			return time.Duration(seconds) * time.Second, true
+		}
+		if date, err := http.ParseTime(value); err == nil {
+			return nonNegative(date.Sub(now)), true
+		}
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			return nonNegative(time.Unix(reset, 0).Sub(now)), true
+		}
+	}
+	return 0, false
+}
+
+func nonNegative(d time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// backoff returns the delay after the given failed attempt.
+func (t *retryTransport) backoff(attempt int) time.Duration {
+	return t.retry.backoff(attempt)
+}
+
+// backoff returns the delay after the given failed attempt: InitialBackoff
+// doubled for every previous retry, capped at MaxBackoff and randomized by
+// Jitter.
+func (r Retry) backoff(attempt int) time.Duration {
+	delay := r.InitialBackoff
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if r.MaxBackoff > 0 && delay >= r.MaxBackoff {
+			break
+		}
+	}
+	if r.MaxBackoff > 0 && delay > r.MaxBackoff {
+		delay = r.MaxBackoff
+	}
+	if r.Jitter > 0 {
+		delay += time.Duration((rand.Float64()*2 - 1) * r.Jitter * float64(delay))
+	}
+	return delay
+}
+
+// isTransientError reports whether a request error is worth retrying:
+// connection resets and refusals, unexpected EOFs and network timeouts.
+func isTransientError(err error) bool {
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
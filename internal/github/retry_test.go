@@ -0,0 +1,236 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRetryTransport returns a retry transport recording its delays
+// instead of sleeping.
+func newTestRetryTransport(base http.RoundTripper, retry Retry) (*retryTransport, *[]time.Duration) {
+	var delays []time.Duration
+	tr := newRetryTransport(base, retry)
+	tr.sleep = func(ctx context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return nil
+	}
+	return tr, &delays
+}
+
+func TestRetryTransport_RetriesServerErrors(t *testing.T) {
+	var calls atomic.Int32
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+
+	tr, delays := newTestRetryTransport(nil, Retry{MaxAttempts: 3, InitialBackoff: time.Second})
+	req, err := http.NewRequest(http.MethodPut, server.URL, strings.NewReader(`{"value":"x"}`))
+	require.NoError(t, err)
+
+	resp, err := (&http.Client{Transport: tr}).Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, *delays)
+	// The body is replayed on every attempt
+	assert.Equal(t, []string{`{"value":"x"}`, `{"value":"x"}`, `{"value":"x"}`}, bodies)
+}
+
+func TestRetryTransport_GivesUpAfterMaxAttempts(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(server.Close)
+
+	tr, _ := newTestRetryTransport(nil, Retry{MaxAttempts: 3, InitialBackoff: time.Millisecond})
+	resp, err := (&http.Client{Transport: tr}).Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestRetryTransport_SecondaryRateLimit(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch calls.Add(1) {
+		case 1:
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			fmt.Fprint(w, `{}`)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	tr, delays := newTestRetryTransport(nil, Retry{MaxAttempts: 3, InitialBackoff: time.Second})
+	resp, err := (&http.Client{Transport: tr}).Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []time.Duration{7 * time.Second}, *delays)
+}
+
+func TestRetryTransport_RateLimitWaitsDoNotCountAsAttempts(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch calls.Add(1) {
+		case 1, 2, 3:
+			w.Header().Set("Retry-After", "60")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			fmt.Fprint(w, `{}`)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	tr, delays := newTestRetryTransport(nil, Retry{MaxAttempts: 2, MaxWait: 5 * time.Minute})
+	resp, err := (&http.Client{Transport: tr}).Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []time.Duration{time.Minute, time.Minute, time.Minute}, *delays)
+}
+
+func TestRetryTransport_RateLimitMaxWait(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	t.Cleanup(server.Close)
+
+	// Waiting longer than MaxWait surfaces the rate limit error instead
+	tr, delays := newTestRetryTransport(nil, Retry{MaxAttempts: 3, MaxWait: 5 * time.Minute})
+	resp, err := (&http.Client{Transport: tr}).Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Equal(t, []time.Duration{2 * time.Minute, 2 * time.Minute}, *delays)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestRetryTransport_WaitPastDeadline(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	t.Cleanup(server.Close)
+
+	// A wait ending after the deadline of the request fails right away
+	tr, delays := newTestRetryTransport(nil, Retry{MaxAttempts: 3, MaxWait: 5 * time.Minute})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	resp, err := (&http.Client{Transport: tr}).Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Empty(t, *delays)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestRateLimitWait(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for name, tc := range map[string]struct {
+		header http.Header
+		wait   time.Duration
+		ok     bool
+	}{
+		"seconds":        {http.Header{"Retry-After": {"30"}}, 30 * time.Second, true},
+		"date":           {http.Header{"Retry-After": {now.Add(90 * time.Second).Format(http.TimeFormat)}}, 90 * time.Second, true},
+		"primary reset":  {http.Header{"X-Ratelimit-Remaining": {"0"}, "X-Ratelimit-Reset": {fmt.Sprint(now.Add(10 * time.Minute).Unix())}}, 10 * time.Minute, true},
+		"reset passed":   {http.Header{"X-Ratelimit-Remaining": {"0"}, "X-Ratelimit-Reset": {fmt.Sprint(now.Add(-time.Minute).Unix())}}, 0, true},
+		"remaining left": {http.Header{"X-Ratelimit-Remaining": {"10"}, "X-Ratelimit-Reset": {fmt.Sprint(now.Unix())}}, 0, false},
+		"no headers":     {http.Header{}, 0, false},
+	} {
+		wait, ok := rateLimitWait(&http.Response{Header: tc.header}, now)
+		assert.Equal(t, tc.ok, ok, name)
+		assert.Equal(t, tc.wait, wait, name)
+	}
+}
+
+func TestRetryTransport_PermanentErrors(t *testing.T) {
+	for _, status := range []int{http.StatusForbidden, http.StatusNotFound, http.StatusUnprocessableEntity} {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(status)
+		}))
+
+		tr, _ := newTestRetryTransport(nil, Retry{MaxAttempts: 3})
+		resp, err := (&http.Client{Transport: tr}).Get(server.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, int32(1), calls.Load(), "status %d", status)
+		server.Close()
+	}
+}
+
+// roundTripFunc adapts a function to http.RoundTripper.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestRetryTransport_ConnectionReset(t *testing.T) {
+	calls := 0
+	base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		calls++
+		if calls == 1 {
+			return nil, fmt.Errorf("read tcp: %w", syscall.ECONNRESET)
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+	})
+
+	tr, delays := newTestRetryTransport(base, Retry{MaxAttempts: 2, InitialBackoff: time.Second})
+	req, err := http.NewRequest(http.MethodGet, "https://api.github.com/", nil)
+	require.NoError(t, err)
+	resp, err := tr.RoundTrip(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 2, calls)
+	assert.Len(t, *delays, 1)
+}
+
+func TestRetryTransport_Backoff(t *testing.T) {
+	tr := newRetryTransport(nil, Retry{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second})
+	var delays []time.Duration
+	for attempt := 1; attempt <= 5; attempt++ {
+		delays = append(delays, tr.backoff(attempt))
+	}
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}, delays)
+
+	tr.retry.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		delay := tr.backoff(2)
+		assert.GreaterOrEqual(t, delay, time.Second)
+		assert.LessOrEqual(t, delay, 3*time.Second)
+	}
+}
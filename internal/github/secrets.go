@@ -2,95 +2,69 @@ package github
 
 import (
 	"context"
-	"crypto/rand"
 	"encoding/base64"
 	"fmt"
+	"net/http"
 
 	"github.com/google/go-github/v57/github"
-	"golang.org/x/crypto/blake2b"
-	"golang.org/x/crypto/nacl/box"
+
+	"github.com/azolfagharj/gajin/pkg/sealedbox"
 )
 
-// SetSecret sets a secret for a repository using GitHub's encrypted secrets API.
+// SetRepositorySecret sets a secret for a repository using GitHub's encrypted
+// secrets API and reports whether the secret was created rather than updated.
 // The secretValue is plaintext and will be encrypted automatically.
-func (c *githubClient) SetSecret(ctx context.Context, owner, repo, name, secretValue string) error {
+func (c *githubClient) SetRepositorySecret(ctx context.Context, owner, repo, name, secretValue string) (bool, error) {
+	ctx, cancel := c.operation(ctx)
+	defer cancel()
+
 	// Get the repository's public key
 	publicKey, err := c.GetPublicKey(ctx, owner, repo)
 	if err != nil {
-		return fmt.Errorf("failed to get public key: %w", err)
-	}
-
-	// Decode the public key
-	keyBytes, err := base64.StdEncoding.DecodeString(publicKey.Key)
-	if err != nil {
-		return fmt.Errorf("failed to decode public key: %w", err)
+		return false, fmt.Errorf("failed to get public key: %w", err)
 	}
 
-	// Convert to [32]byte for nacl/box
-	var publicKeyBytes [32]byte
-	copy(publicKeyBytes[:], keyBytes)
-
-	// Encrypt the secret value using NaCl box
-	encrypted, err := encryptSecret([]byte(secretValue), &publicKeyBytes)
+	// Encrypt the secret value using a sealed box
+	encrypted, err := encryptForKey(publicKey, secretValue)
 	if err != nil {
-		return fmt.Errorf("failed to encrypt secret: %w", err)
+		return false, err
 	}
 
 	// Create the secret
 	secret := &github.EncryptedSecret{
 		Name:           name,
-		EncryptedValue: base64.StdEncoding.EncodeToString(encrypted),
+		EncryptedValue: encrypted,
 		KeyID:          publicKey.KeyID,
 	}
 
-	_, err = c.client.Actions.CreateOrUpdateRepoSecret(ctx, owner, repo, secret)
+	resp, err := c.client.Actions.CreateOrUpdateRepoSecret(ctx, owner, repo, secret)
 	if err != nil {
-		return handleGitHubError(err, owner, repo, "", "repository_secret", name)
+		return false, handleGitHubError(err, owner, repo, "", "repository_secret", name)
 	}
 
-	return nil
+	return isCreated(resp), nil
+}
+
+// isCreated reports whether a create-or-update request created the resource,
+// which GitHub answers with 201 Created instead of 204 No Content.
+func isCreated(resp *github.Response) bool {
+	return resp != nil && resp.StatusCode == http.StatusCreated
 }
 
-// encryptSecret encrypts a secret value using NaCl sealed box format.
-// GitHub Actions Secrets API expects LibSodium sealed box format (crypto_box_seal):
-// Format: [ephemeral public key (32 bytes)][encrypted ciphertext + MAC (16 bytes)]
-//
-// The nonce is derived using BLAKE2b with a 24-byte output (NOT truncated from 64 bytes).
-// This matches libsodium's crypto_generichash with outlen=24.
-func encryptSecret(plaintext []byte, publicKey *[32]byte) ([]byte, error) {
-	// Generate ephemeral key pair
-	publicKeyEphemeral, privateKeyEphemeral, err := box.GenerateKey(rand.Reader)
+// encryptForKey encrypts a plaintext secret value with a GitHub public key and
+// returns the base64 encoded sealed box expected by the API.
+func encryptForKey(publicKey *PublicKey, secretValue string) (string, error) {
+	key, err := sealedbox.ParsePublicKey(publicKey.Key)
 	if err != nil {
-		return nil, err
+		return "", fmt.Errorf("failed to decode public key: %w", err)
 	}
 
-	// Derive nonce using BLAKE2b with 24-byte output (crypto_generichash)
-	// IMPORTANT: This is NOT the same as blake2b.Sum512()[:24]
-	// LibSodium uses blake2b with digest_size=24, which produces different output
-	nonceInput := make([]byte, 64)
-	copy(nonceInput[:32], publicKeyEphemeral[:])
-	copy(nonceInput[32:], publicKey[:])
-
-	h, err := blake2b.New(24, nil)
+	encrypted, err := sealedbox.Seal([]byte(secretValue), key)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create blake2b hash: %w", err)
+		return "", fmt.Errorf("failed to encrypt secret: %w", err)
 	}
-	h.Write(nonceInput)
-	nonceBytes := h.Sum(nil)
-
-	var nonce [24]byte
-	copy(nonce[:], nonceBytes)
-
-	// Encrypt the secret using NaCl box
-	encrypted := box.Seal(nil, plaintext, &nonce, publicKey, privateKeyEphemeral)
-
-	// Prepend the ephemeral public key
-	// Format: [ephemeral public key (32 bytes)][encrypted ciphertext]
-	result := make([]byte, 32+len(encrypted))
-	copy(result[:32], publicKeyEphemeral[:])
-	copy(result[32:], encrypted)
 
-	return result, nil
+	return base64.StdEncoding.EncodeToString(encrypted), nil
 }
 
 // EncryptSecretValue encrypts a plaintext secret value for a repository.
@@ -101,18 +75,12 @@ func EncryptSecretValue(ctx context.Context, client Client, owner, repo, secretV
 		return nil, fmt.Errorf("failed to get public key: %w", err)
 	}
 
-	// Decode the public key
-	keyBytes, err := base64.StdEncoding.DecodeString(publicKey.Key)
+	key, err := sealedbox.ParsePublicKey(publicKey.Key)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode public key: %w", err)
 	}
 
-	// Convert to [32]byte for nacl/box
-	var publicKeyBytes [32]byte
-	copy(publicKeyBytes[:], keyBytes)
-
-	// Encrypt the secret value
-	encrypted, err := encryptSecret([]byte(secretValue), &publicKeyBytes)
+	encrypted, err := sealedbox.Seal([]byte(secretValue), key)
 	if err != nil {
 		return nil, fmt.Errorf("failed to encrypt secret: %w", err)
 	}
@@ -122,71 +90,70 @@ func EncryptSecretValue(ctx context.Context, client Client, owner, repo, secretV
 
 // GetEnvironmentPublicKey retrieves the public key for an environment.
 func (c *githubClient) GetEnvironmentPublicKey(ctx context.Context, owner, repo, environment string) (*PublicKey, error) {
-	// Get repository ID first
-	repoID, err := c.GetRepositoryID(ctx, owner, repo)
-	if err != nil {
-		return nil, err
-	}
+	return shareLookup(ctx, &c.lookups, "environment-key "+owner+"/"+repo+"/"+environment, func() (*PublicKey, error) {
+		// Get repository ID first
+		repoID, err := c.GetRepositoryID(ctx, owner, repo)
+		if err != nil {
+			return nil, err
+		}
 
-	key, _, err := c.client.Actions.GetEnvPublicKey(ctx, int(repoID), environment)
-	if err != nil {
-		return nil, handleGitHubError(err, owner, repo, environment, "environment_secret", "")
-	}
+		key, _, err := c.client.Actions.GetEnvPublicKey(ctx, int(repoID), environment)
+		if err != nil {
+			return nil, handleGitHubError(err, owner, repo, environment, "environment_secret", "")
+		}
 
-	return &PublicKey{
-		KeyID: key.GetKeyID(),
-		Key:   key.GetKey(),
-	}, nil
+		return &PublicKey{
+			KeyID: key.GetKeyID(),
+			Key:   key.GetKey(),
+		}, nil
+	})
 }
 
-// SetEnvironmentSecret sets a secret for an environment using GitHub's encrypted secrets API.
+// SetEnvironmentSecret sets a secret for an environment using GitHub's
+// encrypted secrets API and reports whether the secret was created.
 // The secretValue is plaintext and will be encrypted automatically.
-func (c *githubClient) SetEnvironmentSecret(ctx context.Context, owner, repo, environment, name, secretValue string) error {
+func (c *githubClient) SetEnvironmentSecret(ctx context.Context, owner, repo, environment, name, secretValue string) (bool, error) {
+	ctx, cancel := c.operation(ctx)
+	defer cancel()
+
 	// Get the environment's public key
 	publicKey, err := c.GetEnvironmentPublicKey(ctx, owner, repo, environment)
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	// Decode the public key
-	keyBytes, err := base64.StdEncoding.DecodeString(publicKey.Key)
-	if err != nil {
-		return fmt.Errorf("failed to decode public key: %w", err)
-	}
-
-	// Convert to [32]byte for nacl/box
-	var publicKeyBytes [32]byte
-	copy(publicKeyBytes[:], keyBytes)
-
-	// Encrypt the secret value using NaCl box
-	encrypted, err := encryptSecret([]byte(secretValue), &publicKeyBytes)
+	// Encrypt the secret value using a sealed box
+	encrypted, err := encryptForKey(publicKey, secretValue)
 	if err != nil {
-		return fmt.Errorf("failed to encrypt secret: %w", err)
+		return false, err
 	}
 
 	// Get repository ID
 	repoID, err := c.GetRepositoryID(ctx, owner, repo)
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	// Create the secret
 	secret := &github.EncryptedSecret{
 		Name:           name,
-		EncryptedValue: base64.StdEncoding.EncodeToString(encrypted),
+		EncryptedValue: encrypted,
 		KeyID:          publicKey.KeyID,
 	}
 
-	_, err = c.client.Actions.CreateOrUpdateEnvSecret(ctx, int(repoID), environment, secret)
+	resp, err := c.client.Actions.CreateOrUpdateEnvSecret(ctx, int(repoID), environment, secret)
 	if err != nil {
-		return handleGitHubError(err, owner, repo, environment, "environment_secret", name)
+		return false, handleGitHubError(err, owner, repo, environment, "environment_secret", name)
 	}
 
-	return nil
+	return isCreated(resp), nil
 }
 
 // GetEnvironmentSecret retrieves metadata about an environment secret.
 func (c *githubClient) GetEnvironmentSecret(ctx context.Context, owner, repo, environment, name string) (*SecretMetadata, error) {
+	ctx, cancel := c.operation(ctx)
+	defer cancel()
+
 	// Get repository ID
 	repoID, err := c.GetRepositoryID(ctx, owner, repo)
 	if err != nil {
@@ -205,9 +172,12 @@ func (c *githubClient) GetEnvironmentSecret(ctx context.Context, owner, repo, en
 	}, nil
 }
 
-// SetRepositoryVariable sets a variable for a repository.
-// Variables are stored as plaintext (no encryption).
-func (c *githubClient) SetRepositoryVariable(ctx context.Context, owner, repo, name, value string) error {
+// SetRepositoryVariable sets a variable for a repository and reports whether
+// the variable was created. Variables are stored as plaintext (no encryption).
+func (c *githubClient) SetRepositoryVariable(ctx context.Context, owner, repo, name, value string) (bool, error) {
+	ctx, cancel := c.operation(ctx)
+	defer cancel()
+
 	variable := &github.ActionsVariable{
 		Name:  name,
 		Value: value,
@@ -215,19 +185,23 @@ func (c *githubClient) SetRepositoryVariable(ctx context.Context, owner, repo, n
 
 	// Try to update first, if it doesn't exist, create it
 	_, err := c.client.Actions.UpdateRepoVariable(ctx, owner, repo, variable)
+	if err == nil {
+		return false, nil
+	}
+	// If update fails, try to create
+	_, err = c.client.Actions.CreateRepoVariable(ctx, owner, repo, variable)
 	if err != nil {
-		// If update fails, try to create
-		_, err = c.client.Actions.CreateRepoVariable(ctx, owner, repo, variable)
-		if err != nil {
-			return handleGitHubError(err, owner, repo, "", "repository_variable", name)
-		}
+		return false, handleGitHubError(err, owner, repo, "", "repository_variable", name)
 	}
 
-	return nil
+	return true, nil
 }
 
 // GetRepositoryVariable retrieves a repository variable (including its value).
 func (c *githubClient) GetRepositoryVariable(ctx context.Context, owner, repo, name string) (*VariableMetadata, error) {
+	ctx, cancel := c.operation(ctx)
+	defer cancel()
+
 	variable, _, err := c.client.Actions.GetRepoVariable(ctx, owner, repo, name)
 	if err != nil {
 		return nil, handleGitHubError(err, owner, repo, "", "repository_variable", name)
@@ -241,13 +215,16 @@ func (c *githubClient) GetRepositoryVariable(ctx context.Context, owner, repo, n
 	}, nil
 }
 
-// SetEnvironmentVariable sets a variable for an environment.
-// Variables are stored as plaintext (no encryption).
-func (c *githubClient) SetEnvironmentVariable(ctx context.Context, owner, repo, environment, name, value string) error {
+// SetEnvironmentVariable sets a variable for an environment and reports
+// whether the variable was created. Variables are stored as plaintext (no encryption).
+func (c *githubClient) SetEnvironmentVariable(ctx context.Context, owner, repo, environment, name, value string) (bool, error) {
+	ctx, cancel := c.operation(ctx)
+	defer cancel()
+
 	// Get repository ID
 	repoID, err := c.GetRepositoryID(ctx, owner, repo)
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	variable := &github.ActionsVariable{
@@ -257,19 +234,23 @@ func (c *githubClient) SetEnvironmentVariable(ctx context.Context, owner, repo,
 
 	// Try to update first, if it doesn't exist, create it
 	_, err = c.client.Actions.UpdateEnvVariable(ctx, int(repoID), environment, variable)
+	if err == nil {
+		return false, nil
+	}
+	// If update fails, try to create
+	_, err = c.client.Actions.CreateEnvVariable(ctx, int(repoID), environment, variable)
 	if err != nil {
-		// If update fails, try to create
-		_, err = c.client.Actions.CreateEnvVariable(ctx, int(repoID), environment, variable)
-		if err != nil {
-			return handleGitHubError(err, owner, repo, environment, "environment_variable", name)
-		}
+		return false, handleGitHubError(err, owner, repo, environment, "environment_variable", name)
 	}
 
-	return nil
+	return true, nil
 }
 
 // GetEnvironmentVariable retrieves an environment variable (including its value).
 func (c *githubClient) GetEnvironmentVariable(ctx context.Context, owner, repo, environment, name string) (*VariableMetadata, error) {
+	ctx, cancel := c.operation(ctx)
+	defer cancel()
+
 	// Get repository ID
 	repoID, err := c.GetRepositoryID(ctx, owner, repo)
 	if err != nil {
@@ -289,3 +270,221 @@ func (c *githubClient) GetEnvironmentVariable(ctx context.Context, owner, repo,
 	}, nil
 }
 
+// DeleteRepositorySecret deletes a repository secret.
+// Deleting a secret that does not exist is not an error; a missing repository
+// is reported as a RepositoryNotFoundError.
+func (c *githubClient) DeleteRepositorySecret(ctx context.Context, owner, repo, name string) error {
+	ctx, cancel := c.operation(ctx)
+	defer cancel()
+
+	_, err := c.client.Actions.DeleteRepoSecret(ctx, owner, repo, name)
+	if isNotFound(err) {
+		return c.checkDeleteTarget(ctx, owner, repo, "")
+	}
+	if err != nil {
+		return handleGitHubError(err, owner, repo, "", "repository_secret", name)
+	}
+	return nil
+}
+
+// DeleteEnvironmentSecret deletes an environment secret.
+// Deleting a secret that does not exist is not an error; a missing environment
+// is reported as an EnvironmentNotFoundError.
+func (c *githubClient) DeleteEnvironmentSecret(ctx context.Context, owner, repo, environment, name string) error {
+	ctx, cancel := c.operation(ctx)
+	defer cancel()
+
+	// Get repository ID
+	repoID, err := c.GetRepositoryID(ctx, owner, repo)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.client.Actions.DeleteEnvSecret(ctx, int(repoID), environment, name)
+	if isNotFound(err) {
+		return c.checkDeleteTarget(ctx, owner, repo, environment)
+	}
+	if err != nil {
+		return handleGitHubError(err, owner, repo, environment, "environment_secret", name)
+	}
+	return nil
+}
+
+// DeleteRepositoryVariable deletes a repository variable.
+// Deleting a variable that does not exist is not an error; a missing repository
+// is reported as a RepositoryNotFoundError.
+func (c *githubClient) DeleteRepositoryVariable(ctx context.Context, owner, repo, name string) error {
+	ctx, cancel := c.operation(ctx)
+	defer cancel()
+
+	_, err := c.client.Actions.DeleteRepoVariable(ctx, owner, repo, name)
+	if isNotFound(err) {
+		return c.checkDeleteTarget(ctx, owner, repo, "")
+	}
+	if err != nil {
+		return handleGitHubError(err, owner, repo, "", "repository_variable", name)
+	}
+	return nil
+}
+
+// DeleteEnvironmentVariable deletes an environment variable.
+// Deleting a variable that does not exist is not an error; a missing environment
+// is reported as an EnvironmentNotFoundError.
+func (c *githubClient) DeleteEnvironmentVariable(ctx context.Context, owner, repo, environment, name string) error {
+	ctx, cancel := c.operation(ctx)
+	defer cancel()
+
+	// Get repository ID
+	repoID, err := c.GetRepositoryID(ctx, owner, repo)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.client.Actions.DeleteEnvVariable(ctx, int(repoID), environment, name)
+	if isNotFound(err) {
+		return c.checkDeleteTarget(ctx, owner, repo, environment)
+	}
+	if err != nil {
+		return handleGitHubError(err, owner, repo, environment, "environment_variable", name)
+	}
+	return nil
+}
+
+// checkDeleteTarget is called when a delete request returns 404, which GitHub
+// uses both for a missing entry and for a missing repository or environment.
+// It returns nil if the repository (and environment, if set) exists, so that
+// deleting a missing entry stays a no-op, and the typed not-found error otherwise.
+func (c *githubClient) checkDeleteTarget(ctx context.Context, owner, repo, environment string) error {
+	if environment == "" {
+		_, err := c.GetRepositoryID(ctx, owner, repo)
+		return err
+	}
+	_, _, err := c.client.Repositories.GetEnvironment(ctx, owner, repo, environment)
+	if err != nil {
+		return handleGitHubError(err, owner, repo, environment, "", "")
+	}
+	return nil
+}
+
+// ListRepositorySecrets returns the metadata of every secret of a repository,
+// walking every page of results.
+func (c *githubClient) ListRepositorySecrets(ctx context.Context, owner, repo string) ([]SecretMetadata, error) {
+	ctx, cancel := c.operation(ctx)
+	defer cancel()
+
+	opts := &github.ListOptions{PerPage: listPageSize}
+
+	var all []SecretMetadata
+	for {
+		secrets, resp, err := c.client.Actions.ListRepoSecrets(ctx, owner, repo, opts)
+		if err != nil {
+			return nil, handleGitHubError(err, owner, repo, "", "", "")
+		}
+		all = appendSecrets(all, secrets)
+		if resp.NextPage == 0 {
+			return all, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// appendSecrets appends the secrets of one page of a list response.
+func appendSecrets(all []SecretMetadata, secrets *github.Secrets) []SecretMetadata {
+	for _, secret := range secrets.Secrets {
+		all = append(all, SecretMetadata{
+			Name:      secret.Name,
+			CreatedAt: secret.CreatedAt.String(),
+			UpdatedAt: secret.UpdatedAt.String(),
+		})
+	}
+	return all
+}
+
+// ListEnvironmentSecrets returns the metadata of every secret of an
+// environment, walking every page of results.
+func (c *githubClient) ListEnvironmentSecrets(ctx context.Context, owner, repo, environment string) ([]SecretMetadata, error) {
+	ctx, cancel := c.operation(ctx)
+	defer cancel()
+
+	repoID, err := c.GetRepositoryID(ctx, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &github.ListOptions{PerPage: listPageSize}
+
+	var all []SecretMetadata
+	for {
+		secrets, resp, err := c.client.Actions.ListEnvSecrets(ctx, int(repoID), environment, opts)
+		if err != nil {
+			return nil, handleGitHubError(err, owner, repo, environment, "", "")
+		}
+		all = appendSecrets(all, secrets)
+		if resp.NextPage == 0 {
+			return all, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// ListRepositoryVariables returns every variable of a repository, including
+// values, walking every page of results.
+func (c *githubClient) ListRepositoryVariables(ctx context.Context, owner, repo string) ([]VariableMetadata, error) {
+	ctx, cancel := c.operation(ctx)
+	defer cancel()
+
+	opts := &github.ListOptions{PerPage: listPageSize}
+
+	var all []VariableMetadata
+	for {
+		variables, resp, err := c.client.Actions.ListRepoVariables(ctx, owner, repo, opts)
+		if err != nil {
+			return nil, handleGitHubError(err, owner, repo, "", "", "")
+		}
+		all = appendVariables(all, variables)
+		if resp.NextPage == 0 {
+			return all, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// appendVariables appends the variables of one page of a list response.
+func appendVariables(all []VariableMetadata, variables *github.ActionsVariables) []VariableMetadata {
+	for _, variable := range variables.Variables {
+		all = append(all, VariableMetadata{
+			Name:      variable.Name,
+			Value:     variable.Value,
+			CreatedAt: variable.GetCreatedAt().String(),
+			UpdatedAt: variable.GetUpdatedAt().String(),
+		})
+	}
+	return all
+}
+
+// ListEnvironmentVariables returns every variable of an environment,
+// including values, walking every page of results.
+func (c *githubClient) ListEnvironmentVariables(ctx context.Context, owner, repo, environment string) ([]VariableMetadata, error) {
+	ctx, cancel := c.operation(ctx)
+	defer cancel()
+
+	repoID, err := c.GetRepositoryID(ctx, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &github.ListOptions{PerPage: listPageSize}
+
+	var all []VariableMetadata
+	for {
+		variables, resp, err := c.client.Actions.ListEnvVariables(ctx, int(repoID), environment, opts)
+		if err != nil {
+			return nil, handleGitHubError(err, owner, repo, environment, "", "")
+		}
+		all = appendVariables(all, variables)
+		if resp.NextPage == 0 {
+			return all, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
@@ -1,219 +1,244 @@
 package github
 
 import (
-	"crypto/rand"
-	"encoding/base64"
+	"context"
+	"net/http"
 	"testing"
 
-	"golang.org/x/crypto/blake2b"
-	"golang.org/x/crypto/nacl/box"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
-func TestEncryptSecret(t *testing.T) {
-	// Generate a test public key
-	publicKeyEphemeral, _, err := box.GenerateKey(rand.Reader)
-	if err != nil {
-		t.Fatalf("Failed to generate test key: %v", err)
-	}
-
-	// Use the ephemeral public key as the recipient public key for testing
-	var publicKey [32]byte
-	copy(publicKey[:], publicKeyEphemeral[:])
-
-	plaintext := []byte("test-secret-value")
-
-	// Encrypt the secret
-	encrypted, err := encryptSecret(plaintext, &publicKey)
-	if err != nil {
-		t.Fatalf("encryptSecret failed: %v", err)
-	}
-
-	// Verify the encrypted data structure
-	// Format: [ephemeral public key (32 bytes)][encrypted ciphertext]
-	if len(encrypted) < 32 {
-		t.Fatalf("encrypted data too short: got %d bytes, expected at least 32", len(encrypted))
-	}
-
-	// Extract ephemeral public key
-	var extractedEphemeralKey [32]byte
-	copy(extractedEphemeralKey[:], encrypted[:32])
-
-	// Verify that ephemeral key is not all zeros
-	allZeros := true
-	for _, b := range extractedEphemeralKey {
-		if b != 0 {
-			allZeros = false
-			break
-		}
-	}
-	if allZeros {
-		t.Error("ephemeral public key is all zeros")
-	}
-
-	// Verify encrypted ciphertext exists
-	if len(encrypted) <= 32 {
-		t.Error("encrypted ciphertext is missing")
-	}
-
-	// Verify that encrypted data is different from plaintext
-	if string(encrypted) == string(plaintext) {
-		t.Error("encrypted data is the same as plaintext")
-	}
+func TestSetRepositorySecret_Created(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/o/r/actions/secrets/public-key", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"key_id": "k", "key": "AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8="}`))
+	})
+	mux.HandleFunc("/repos/o/r/actions/secrets/NEW", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+	mux.HandleFunc("/repos/o/r/actions/secrets/EXISTING", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	client := newTestClient(t, mux)
+
+	created, err := client.SetRepositorySecret(context.Background(), "o", "r", "NEW", "v")
+	require.NoError(t, err)
+	assert.True(t, created)
+	created, err = client.SetRepositorySecret(context.Background(), "o", "r", "EXISTING", "v")
+	require.NoError(t, err)
+	assert.False(t, created)
 }
 
-func TestEncryptSecret_DifferentResults(t *testing.T) {
-	// Generate a test public key
-	publicKeyEphemeral, _, err := box.GenerateKey(rand.Reader)
-	if err != nil {
-		t.Fatalf("Failed to generate test key: %v", err)
-	}
-
-	var publicKey [32]byte
-	copy(publicKey[:], publicKeyEphemeral[:])
-
-	plaintext := []byte("test-secret-value")
-
-	// Encrypt the same plaintext twice
-	encrypted1, err := encryptSecret(plaintext, &publicKey)
-	if err != nil {
-		t.Fatalf("encryptSecret failed: %v", err)
-	}
-
-	encrypted2, err := encryptSecret(plaintext, &publicKey)
-	if err != nil {
-		t.Fatalf("encryptSecret failed: %v", err)
-	}
-
-	// Verify that each encryption produces different results (due to random ephemeral keys)
-	if string(encrypted1) == string(encrypted2) {
-		t.Error("encryptSecret produces the same result for the same input")
-	}
+func TestSetRepositoryVariable_Created(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/o/r/actions/variables/EXISTING", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPatch, r.Method)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/repos/o/r/actions/variables/NEW", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message": "Not Found"}`, http.StatusNotFound)
+	})
+	mux.HandleFunc("/repos/o/r/actions/variables", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		w.WriteHeader(http.StatusCreated)
+	})
+	client := newTestClient(t, mux)
+
+	created, err := client.SetRepositoryVariable(context.Background(), "o", "r", "NEW", "v")
+	require.NoError(t, err)
+	assert.True(t, created)
+	created, err = client.SetRepositoryVariable(context.Background(), "o", "r", "EXISTING", "v")
+	require.NoError(t, err)
+	assert.False(t, created)
 }
 
-func TestEncryptSecret_EmptyInput(t *testing.T) {
-	publicKeyEphemeral, _, err := box.GenerateKey(rand.Reader)
-	if err != nil {
-		t.Fatalf("Failed to generate test key: %v", err)
-	}
-
-	var publicKey [32]byte
-	copy(publicKey[:], publicKeyEphemeral[:])
-
-	// Test with empty input
-	encrypted, err := encryptSecret([]byte{}, &publicKey)
-	if err != nil {
-		t.Fatalf("encryptSecret failed with empty input: %v", err)
-	}
-
-	// Should still produce valid encrypted data
-	if len(encrypted) < 32 {
-		t.Error("encrypted data too short for empty input")
-	}
+func TestDeleteRepositorySecret(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/o/r", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": 42, "name": "r"}`))
+	})
+	deleted := false
+	mux.HandleFunc("/repos/o/r/actions/secrets/OLD_TOKEN", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodDelete, r.Method)
+		deleted = true
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/repos/o/r/actions/secrets/MISSING", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message": "Not Found"}`, http.StatusNotFound)
+	})
+	mux.HandleFunc("/repos/o/r/actions/secrets/FORBIDDEN", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message": "Resource not accessible by integration"}`, http.StatusForbidden)
+	})
+	mux.HandleFunc("/repos/o/missing/", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message": "Not Found"}`, http.StatusNotFound)
+	})
+	client := newTestClient(t, mux)
+
+	require.NoError(t, client.DeleteRepositorySecret(context.Background(), "o", "r", "OLD_TOKEN"))
+	assert.True(t, deleted)
+
+	// Deleting a missing secret is not an error
+	require.NoError(t, client.DeleteRepositorySecret(context.Background(), "o", "r", "MISSING"))
+
+	err := client.DeleteRepositorySecret(context.Background(), "o", "r", "FORBIDDEN")
+	var secretErr *SecretError
+	require.ErrorAs(t, err, &secretErr)
+	assert.Equal(t, "FORBIDDEN", secretErr.Name)
+
+	// A 404 caused by a missing repository is reported
+	err = client.DeleteRepositorySecret(context.Background(), "o", "missing", "OLD_TOKEN")
+	var repoErr *RepositoryNotFoundError
+	require.ErrorAs(t, err, &repoErr)
+	assert.Equal(t, "missing", repoErr.Repo)
 }
 
-func TestEncryptSecret_Base64Encoding(t *testing.T) {
-	publicKeyEphemeral, _, err := box.GenerateKey(rand.Reader)
-	if err != nil {
-		t.Fatalf("Failed to generate test key: %v", err)
-	}
-
-	var publicKey [32]byte
-	copy(publicKey[:], publicKeyEphemeral[:])
-
-	plaintext := []byte("test-secret-value")
-
-	encrypted, err := encryptSecret(plaintext, &publicKey)
-	if err != nil {
-		t.Fatalf("encryptSecret failed: %v", err)
-	}
-
-	// Verify that encrypted data can be base64 encoded (as it will be in SetSecret)
-	encoded := base64.StdEncoding.EncodeToString(encrypted)
-	decoded, err := base64.StdEncoding.DecodeString(encoded)
-	if err != nil {
-		t.Fatalf("Failed to decode base64: %v", err)
-	}
+func TestDeleteEnvironmentVariable(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/o/r", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": 42, "name": "r"}`))
+	})
+	deleted := false
+	mux.HandleFunc("/repositories/42/environments/production/variables/OLD_VAR", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodDelete, r.Method)
+		deleted = true
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/repositories/42/environments/", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message": "Not Found"}`, http.StatusNotFound)
+	})
+	mux.HandleFunc("/repos/o/r/environments/production", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"name": "production"}`))
+	})
+	mux.HandleFunc("/repos/o/r/environments/staging", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message": "Not Found"}`, http.StatusNotFound)
+	})
+	client := newTestClient(t, mux)
+
+	require.NoError(t, client.DeleteEnvironmentVariable(context.Background(), "o", "r", "production", "OLD_VAR"))
+	assert.True(t, deleted)
+
+	// Deleting a missing variable from an existing environment is not an error
+	require.NoError(t, client.DeleteEnvironmentVariable(context.Background(), "o", "r", "production", "MISSING"))
+
+	err := client.DeleteEnvironmentVariable(context.Background(), "o", "r", "staging", "OLD_VAR")
+	var envErr *EnvironmentNotFoundError
+	require.ErrorAs(t, err, &envErr)
+	assert.Equal(t, "staging", envErr.Environment)
+}
 
-	if len(decoded) != len(encrypted) {
-		t.Errorf("Decoded length mismatch: got %d, expected %d", len(decoded), len(encrypted))
-	}
+func TestListRepositorySecrets(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/o/r/actions/secrets", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "100", r.URL.Query().Get("per_page"))
+		switch page := pageNumber(r); page {
+		case 1:
+			writePage(w, r, page, 2, `{"total_count": 3, "secrets": [{"name": "API_KEY", "created_at": "2024-01-01T00:00:00Z", "updated_at": "2024-01-02T00:00:00Z"}, {"name": "DB_URL"}]}`)
+		case 2:
+			writePage(w, r, page, 2, `{"total_count": 3, "secrets": [{"name": "TOKEN"}]}`)
+		}
+	})
+	mux.HandleFunc("/repos/o/missing/actions/secrets", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message": "Not Found"}`, http.StatusNotFound)
+	})
+	client := newTestClient(t, mux)
+
+	secrets, err := client.ListRepositorySecrets(context.Background(), "o", "r")
+	require.NoError(t, err)
+	require.Len(t, secrets, 3)
+	assert.Equal(t, "API_KEY", secrets[0].Name)
+	assert.Equal(t, "2024-01-02 00:00:00 +0000 UTC", secrets[0].UpdatedAt)
+	assert.Equal(t, "DB_URL", secrets[1].Name)
+	assert.Equal(t, "TOKEN", secrets[2].Name)
+
+	_, err = client.ListRepositorySecrets(context.Background(), "o", "missing")
+	var repoErr *RepositoryNotFoundError
+	require.ErrorAs(t, err, &repoErr)
 }
 
-func TestNonceDerivation_Blake2b24(t *testing.T) {
-	// Test that we use BLAKE2b with 24-byte output, NOT truncated 64-byte output
-	// This is critical for compatibility with libsodium crypto_box_seal
-
-	ephemeralPK := make([]byte, 32)
-	recipientPK := make([]byte, 32)
-
-	// Fill with known values
-	for i := range ephemeralPK {
-		ephemeralPK[i] = byte(i)
-	}
-	for i := range recipientPK {
-		recipientPK[i] = byte(i + 32)
-	}
-
-	input := make([]byte, 64)
-	copy(input[:32], ephemeralPK)
-	copy(input[32:], recipientPK)
-
-	// Method 1: WRONG - Sum512 and truncate (old buggy code)
-	hash512 := blake2b.Sum512(input)
-	nonce1 := hash512[:24]
-
-	// Method 2: CORRECT - blake2b with 24-byte output (libsodium behavior)
-	h, err := blake2b.New(24, nil)
-	if err != nil {
-		t.Fatalf("Failed to create blake2b hash: %v", err)
-	}
-	h.Write(input)
-	nonce2 := h.Sum(nil)
-
-	// These MUST be different - if they're equal, our fix is wrong
-	if string(nonce1) == string(nonce2) {
-		t.Error("BLAKE2b-512[:24] and BLAKE2b-24 produced same output - this would break sealed box")
-	}
-
-	// Verify nonce2 is 24 bytes
-	if len(nonce2) != 24 {
-		t.Errorf("Expected nonce length 24, got %d", len(nonce2))
-	}
+func TestListEnvironmentSecrets(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/o/r", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": 42, "name": "r"}`))
+	})
+	mux.HandleFunc("/repositories/42/environments/production/secrets", func(w http.ResponseWriter, r *http.Request) {
+		switch page := pageNumber(r); page {
+		case 1:
+			writePage(w, r, page, 2, `{"total_count": 2, "secrets": [{"name": "DEPLOY_KEY"}]}`)
+		case 2:
+			writePage(w, r, page, 2, `{"total_count": 2, "secrets": [{"name": "DB_PASSWORD"}]}`)
+		}
+	})
+	mux.HandleFunc("/repositories/42/environments/missing/secrets", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message": "Not Found"}`, http.StatusNotFound)
+	})
+	client := newTestClient(t, mux)
+
+	secrets, err := client.ListEnvironmentSecrets(context.Background(), "o", "r", "production")
+	require.NoError(t, err)
+	require.Len(t, secrets, 2)
+	assert.Equal(t, "DEPLOY_KEY", secrets[0].Name)
+	assert.Equal(t, "DB_PASSWORD", secrets[1].Name)
+
+	_, err = client.ListEnvironmentSecrets(context.Background(), "o", "r", "missing")
+	var envErr *EnvironmentNotFoundError
+	require.ErrorAs(t, err, &envErr)
+	assert.Equal(t, "missing", envErr.Environment)
 }
 
-func TestEncryptSecret_OutputFormat(t *testing.T) {
-	// Test that the output format matches libsodium sealed box:
-	// [ephemeral_pk (32 bytes)][ciphertext + MAC (16 bytes)]
-	publicKeyEphemeral, _, err := box.GenerateKey(rand.Reader)
-	if err != nil {
-		t.Fatalf("Failed to generate test key: %v", err)
-	}
-
-	var publicKey [32]byte
-	copy(publicKey[:], publicKeyEphemeral[:])
-
-	plaintext := []byte("hello")
-	encrypted, err := encryptSecret(plaintext, &publicKey)
-	if err != nil {
-		t.Fatalf("encryptSecret failed: %v", err)
-	}
-
-	// Expected length: 32 (ephemeral pk) + len(plaintext) + 16 (MAC)
-	expectedLen := 32 + len(plaintext) + 16
-	if len(encrypted) != expectedLen {
-		t.Errorf("Expected encrypted length %d, got %d", expectedLen, len(encrypted))
-	}
-
-	// First 32 bytes should be a valid public key (non-zero)
-	allZeros := true
-	for i := 0; i < 32; i++ {
-		if encrypted[i] != 0 {
-			allZeros = false
-			break
+func TestListRepositoryVariables(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/o/r/actions/variables", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "100", r.URL.Query().Get("per_page"))
+		switch page := pageNumber(r); page {
+		case 1:
+			writePage(w, r, page, 2, `{"total_count": 2, "variables": [{"name": "REGION", "value": "us-east-1"}]}`)
+		case 2:
+			writePage(w, r, page, 2, `{"total_count": 2, "variables": [{"name": "STAGE", "value": "prod"}]}`)
 		}
-	}
-	if allZeros {
-		t.Error("Ephemeral public key in output is all zeros")
-	}
+	})
+	client := newTestClient(t, mux)
+
+	variables, err := client.ListRepositoryVariables(context.Background(), "o", "r")
+	require.NoError(t, err)
+	require.Len(t, variables, 2)
+	assert.Equal(t, "REGION", variables[0].Name)
+	assert.Equal(t, "us-east-1", variables[0].Value)
+	assert.Equal(t, "STAGE", variables[1].Name)
+	assert.Equal(t, "prod", variables[1].Value)
 }
 
+func TestListEnvironmentVariables(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/o/r", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": 42, "name": "r"}`))
+	})
+	mux.HandleFunc("/repositories/42/environments/production/variables", func(w http.ResponseWriter, r *http.Request) {
+		switch page := pageNumber(r); page {
+		case 1:
+			writePage(w, r, page, 2, `{"total_count": 2, "variables": [{"name": "URL", "value": "https://example.com"}]}`)
+		case 2:
+			writePage(w, r, page, 2, `{"total_count": 2, "variables": [{"name": "REPLICAS", "value": "3"}]}`)
+		}
+	})
+	mux.HandleFunc("/repositories/42/environments/missing/variables", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message": "Not Found"}`, http.StatusNotFound)
+	})
+	client := newTestClient(t, mux)
+
+	variables, err := client.ListEnvironmentVariables(context.Background(), "o", "r", "production")
+	require.NoError(t, err)
+	require.Len(t, variables, 2)
+	assert.Equal(t, "https://example.com", variables[0].Value)
+	assert.Equal(t, "REPLICAS", variables[1].Name)
+
+	_, err = client.ListEnvironmentVariables(context.Background(), "o", "r", "missing")
+	var envErr *EnvironmentNotFoundError
+	require.ErrorAs(t, err, &envErr)
+}
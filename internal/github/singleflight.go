@@ -0,0 +1,29 @@
+package github
+
+import (
+	"context"
+	"strings"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// shareLookup runs lookup once for all goroutines asking for key at the same
+// time, e.g. the workers applying the entries of a repository, which all need
+// its ID or public key first. Results are not kept once lookup returns. The
+// lookup runs with the context of the goroutine that started it; the others
+// stop waiting for it when their own context is done.
+func shareLookup[T any](ctx context.Context, group *singleflight.Group, key string, lookup func() (T, error)) (T, error) {
+	results := group.DoChan(strings.ToLower(key), func() (interface{}, error) {
+		return lookup()
+	})
+	var zero T
+	select {
+	case result := <-results:
+		if result.Err != nil {
+			return zero, result.Err
+		}
+		return result.Val.(T), nil
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+}
@@ -0,0 +1,69 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShareLookup_ConcurrentPublicKeyLookups(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/owner/repo/actions/secrets/public-key", func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-release
+		w.Write([]byte(`{"key_id": "1", "key": "2Sg8iYjAxxmI2LvUXpJjkYrMxURPc8r+dB7TJyvvcCU="}`))
+	})
+	client := newTestClient(t, mux)
+
+	var wg sync.WaitGroup
+	keys := make([]*PublicKey, 5)
+	for i := range keys {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key, err := client.GetPublicKey(context.Background(), "owner", "repo")
+			assert.NoError(t, err)
+			keys[i] = key
+		}(i)
+	}
+	// Let every lookup join the one in flight
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+	for _, key := range keys {
+		require.NotNil(t, key)
+		assert.Equal(t, "1", key.KeyID)
+	}
+
+	// Lookups are not cached once they returned
+	_, err := client.GetPublicKey(context.Background(), "owner", "repo")
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestShareLookup_CancelledWaiter(t *testing.T) {
+	client := newTestClient(t, http.NewServeMux())
+	started, release := make(chan struct{}), make(chan struct{})
+	go shareLookup(context.Background(), &client.lookups, "key", func() (int, error) {
+		close(started)
+		<-release
+		return 1, nil
+	})
+	<-started
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := shareLookup(ctx, &client.lookups, "key", func() (int, error) { return 2, nil })
+	assert.ErrorIs(t, err, context.Canceled)
+	close(release)
+}
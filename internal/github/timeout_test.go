@@ -0,0 +1,85 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeoutTransport(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/hang" {
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+			return
+		}
+		fmt.Fprint(w, `{}`)
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+
+	client := &http.Client{Transport: newTimeoutTransport(nil, 50*time.Millisecond)}
+
+	resp, err := client.Get(server.URL + "/ok")
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, `{}`, string(body))
+
+	_, err = client.Get(server.URL + "/hang")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "request timed out after 50ms")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestTimeoutTransport_RetriedAttempt(t *testing.T) {
+	calls := 0
+	base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		calls++
+		if calls == 1 {
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+	})
+
+	tr, _ := newTestRetryTransport(newTimeoutTransport(base, 10*time.Millisecond), Retry{MaxAttempts: 2})
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "https://api.github.com/", nil)
+	require.NoError(t, err)
+	resp, err := tr.RoundTrip(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, 2, calls)
+}
+
+func TestOperationTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+
+	client, err := NewClientWithOptions("token", Options{BaseURL: server.URL + "/", OperationTimeout: 50 * time.Millisecond})
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, err = client.SetRepositoryVariable(context.Background(), "o", "r", "A", "a")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
@@ -2,4 +2,3 @@ package github
 
 // Note: Integration tests for variables would require actual GitHub API access
 // Error type tests are in errors_test.go
-
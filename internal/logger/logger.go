@@ -1,9 +1,12 @@
 package logger
 
 import (
+	"io"
 	"os"
+	"time"
 
 	"github.com/charmbracelet/log"
+	"github.com/muesli/termenv"
 )
 
 // Logger wraps the charmbracelet log logger.
@@ -11,10 +14,36 @@ type Logger struct {
 	*log.Logger
 }
 
+// Verbosity selects which messages are logged.
+type Verbosity int
+
+const (
+	// Normal logs informational messages, warnings and errors
+	Normal Verbosity = iota
+	// Quiet logs errors only
+	Quiet
+	// Verbose logs debug messages as well
+	Verbose
+)
+
+// Format selects how log entries are written.
+type Format int
+
+const (
+	// Text writes a human-readable line per entry, colored on a terminal
+	Text Format = iota
+	// JSON writes a JSON object with a timestamp per entry, e.g. for log
+	// aggregation
+	JSON
+)
+
 // New creates a new logger instance.
-func New(verbose bool) *Logger {
+func New(verbosity Verbosity) *Logger {
 	level := log.InfoLevel
-	if verbose {
+	switch verbosity {
+	case Quiet:
+		level = log.ErrorLevel
+	case Verbose:
 		level = log.DebugLevel
 	}
 
@@ -23,8 +52,27 @@ func New(verbose bool) *Logger {
 	return &Logger{Logger: l}
 }
 
+// SetFormat switches the formatter of the log entries.
+func (l *Logger) SetFormat(format Format) {
+	switch format {
+	case JSON:
+		l.Logger.SetFormatter(log.JSONFormatter)
+		l.Logger.SetReportTimestamp(true)
+		l.Logger.SetTimeFormat(time.RFC3339)
+	default:
+		l.Logger.SetFormatter(log.TextFormatter)
+		l.Logger.SetReportTimestamp(false)
+	}
+}
+
 // SetLevel sets the log level.
 func (l *Logger) SetLevel(level log.Level) {
 	l.Logger.SetLevel(level)
 }
 
+// SetOutput redirects the log to w, keeping the colors detected for standard
+// error, e.g. to draw a status line below the log on the terminal.
+func (l *Logger) SetOutput(w io.Writer) {
+	l.Logger.SetOutput(w)
+	l.Logger.SetColorProfile(termenv.NewOutput(os.Stderr).EnvColorProfile())
+}
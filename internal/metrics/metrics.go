@@ -0,0 +1,231 @@
+// Package metrics collects counters, gauges and histograms and writes them
+// in the Prometheus text exposition format, e.g. for the textfile collector
+// of node_exporter.
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/azolfagharj/gajin/internal/fsutil"
+)
+
+// Types of metrics.
+const (
+	typeCounter   = "counter"
+	typeGauge     = "gauge"
+	typeHistogram = "histogram"
+)
+
+// Registry holds metrics in the order they were registered. It is safe for
+// concurrent use.
+type Registry struct {
+	mu      sync.Mutex
+	metrics []*metric
+}
+
+// metric is a metric with its series, keyed by their label values.
+type metric struct {
+	name    string
+	help    string
+	typ     string
+	labels  []string
+	buckets []float64
+	series  map[string]*series
+}
+
+// series holds the value of a metric for one set of label values.
+type series struct {
+	labelValues []string
+	value       float64
+	// counts are the cumulative bucket counts of histograms
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) register(name, help, typ string, buckets []float64, labels []string) *metric {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m := &metric{name: name, help: help, typ: typ, labels: labels, buckets: buckets, series: make(map[string]*series)}
+	r.metrics = append(r.metrics, m)
+	return m
+}
+
+// get returns the series of labelValues, creating it. The registry must be locked.
+func (m *metric) get(labelValues []string) *series {
+	if len(labelValues) != len(m.labels) {
+		panic(fmt.Sprintf("metrics: %s has %d labels, got %d values", m.name, len(m.labels), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	s, ok := m.series[key]
+	if !ok {
+		s = &series{labelValues: append([]string(nil), labelValues...), counts: make([]uint64, len(m.buckets))}
+		m.series[key] = s
+	}
+	return s
+}
+
+// Counter is a value that only increases.
+type Counter struct {
+	registry *Registry
+	metric   *metric
+}
+
+// Counter registers a counter with the given label names.
+func (r *Registry) Counter(name, help string, labels ...string) *Counter {
+	return &Counter{registry: r, metric: r.register(name, help, typeCounter, nil, labels)}
+}
+
+// Add adds v, which must not be negative, to the series of labelValues.
+func (c *Counter) Add(v float64, labelValues ...string) {
+	c.registry.mu.Lock()
+	defer c.registry.mu.Unlock()
+	c.metric.get(labelValues).value += v
+}
+
+// Inc adds one to the series of labelValues.
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Gauge is a value that can go up and down.
+type Gauge struct {
+	registry *Registry
+	metric   *metric
+}
+
+// Gauge registers a gauge with the given label names.
+func (r *Registry) Gauge(name, help string, labels ...string) *Gauge {
+	return &Gauge{registry: r, metric: r.register(name, help, typeGauge, nil, labels)}
+}
+
+// Set sets the series of labelValues to v.
+func (g *Gauge) Set(v float64, labelValues ...string) {
+	g.registry.mu.Lock()
+	defer g.registry.mu.Unlock()
+	g.metric.get(labelValues).value = v
+}
+
+// Histogram counts observations in buckets.
+type Histogram struct {
+	registry *Registry
+	metric   *metric
+}
+
+// Histogram registers a histogram with the given upper bounds of its buckets,
+// in increasing order, and label names.
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	return &Histogram{registry: r, metric: r.register(name, help, typeHistogram, buckets, labels)}
+}
+
+// Observe adds v to the series of labelValues.
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	h.registry.mu.Lock()
+	defer h.registry.mu.Unlock()
+	s := h.metric.get(labelValues)
+	for i, bound := range h.metric.buckets {
+		if v <= bound {
+			s.counts[i]++
+		}
+	}
+	s.sum += v
+	s.count++
+}
+
+// WriteTo writes the metrics to w in the Prometheus text exposition format.
+// Series are sorted by their label values; metrics without series are
+// written with their help only.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var buf bytes.Buffer
+	for _, m := range r.metrics {
+		fmt.Fprintf(&buf, "# HELP %s %s\n", m.name, escapeHelp(m.help))
+		fmt.Fprintf(&buf, "# TYPE %s %s\n", m.name, m.typ)
+		keys := make([]string, 0, len(m.series))
+		for key := range m.series {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			s := m.series[key]
+			if m.typ != typeHistogram {
+				fmt.Fprintf(&buf, "%s%s %s\n", m.name, labelPairs(m.labels, s.labelValues, "", ""), formatValue(s.value))
+				continue
+			}
+			for i, bound := range m.buckets {
+				fmt.Fprintf(&buf, "%s_bucket%s %d\n", m.name, labelPairs(m.labels, s.labelValues, "le", formatValue(bound)), s.counts[i])
+			}
+			fmt.Fprintf(&buf, "%s_bucket%s %d\n", m.name, labelPairs(m.labels, s.labelValues, "le", "+Inf"), s.count)
+			fmt.Fprintf(&buf, "%s_sum%s %s\n", m.name, labelPairs(m.labels, s.labelValues, "", ""), formatValue(s.sum))
+			fmt.Fprintf(&buf, "%s_count%s %d\n", m.name, labelPairs(m.labels, s.labelValues, "", ""), s.count)
+		}
+	}
+	n, err := w.Write(buf.Bytes())
+	return int64(n), err
+}
+
+// WriteFile writes the metrics to path atomically, so that a collector never
+// reads a partially written file.
+func (r *Registry) WriteFile(path string) error {
+	var buf bytes.Buffer
+	if _, err := r.WriteTo(&buf); err != nil {
+		return err
+	}
+	return fsutil.WriteFileAtomic(path, buf.Bytes(), 0o644)
+}
+
+// labelPairs formats the labels of a series, with an extra label unless
+// extraName is empty.
+func labelPairs(names, values []string, extraName, extraValue string) string {
+	var pairs []string
+	for i, name := range names {
+		pairs = append(pairs, name+`="`+escapeLabel(values[i])+`"`)
+	}
+	if extraName != "" {
+		pairs = append(pairs, extraName+`="`+extraValue+`"`)
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+// formatValue formats a sample value like Prometheus does.
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
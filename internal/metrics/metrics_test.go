@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_WriteTo(t *testing.T) {
+	registry := NewRegistry()
+	entries := registry.Counter("gajin_entries_total", "Secrets and variables processed.", "kind", "status")
+	remaining := registry.Gauge("gajin_rate_limit_remaining", "Requests left.", "resource")
+	duration := registry.Histogram("gajin_repository_duration_seconds", "Time to process a repository.", []float64{1, 5})
+	registry.Gauge("gajin_unused", `Help with a \ and
+a newline.`)
+
+	entries.Inc("repository_secret", "updated")
+	entries.Add(2, "repository_secret", "created")
+	entries.Inc("repository_secret", "created")
+	entries.Inc("environment_variable", "failed")
+	remaining.Set(4999, "core")
+	remaining.Set(4998, "core")
+	duration.Observe(0.5)
+	duration.Observe(3)
+	duration.Observe(7)
+
+	var buf strings.Builder
+	_, err := registry.WriteTo(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, `# HELP gajin_entries_total Secrets and variables processed.
+# TYPE gajin_entries_total counter
+gajin_entries_total{kind="environment_variable",status="failed"} 1
+gajin_entries_total{kind="repository_secret",status="created"} 3
+gajin_entries_total{kind="repository_secret",status="updated"} 1
+# HELP gajin_rate_limit_remaining Requests left.
+# TYPE gajin_rate_limit_remaining gauge
+gajin_rate_limit_remaining{resource="core"} 4998
+# HELP gajin_repository_duration_seconds Time to process a repository.
+# TYPE gajin_repository_duration_seconds histogram
+gajin_repository_duration_seconds_bucket{le="1"} 1
+gajin_repository_duration_seconds_bucket{le="5"} 2
+gajin_repository_duration_seconds_bucket{le="+Inf"} 3
+gajin_repository_duration_seconds_sum 10.5
+gajin_repository_duration_seconds_count 3
+# HELP gajin_unused Help with a \\ and\na newline.
+# TYPE gajin_unused gauge
+`, buf.String())
+}
+
+func TestRegistry_EscapesLabelValues(t *testing.T) {
+	registry := NewRegistry()
+	registry.Counter("errors_total", "Errors.", "error").Inc("say \"hi\"\n")
+
+	var buf strings.Builder
+	_, err := registry.WriteTo(&buf)
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), `errors_total{error="say \"hi\"\n"} 1`)
+}
+
+func TestRegistry_Concurrent(t *testing.T) {
+	registry := NewRegistry()
+	counter := registry.Counter("requests_total", "Requests.", "method")
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			counter.Inc("GET")
+		}()
+	}
+	wg.Wait()
+
+	var buf strings.Builder
+	_, err := registry.WriteTo(&buf)
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), `requests_total{method="GET"} 50`)
+}
+
+func TestRegistry_WriteFile(t *testing.T) {
+	registry := NewRegistry()
+	registry.Gauge("gajin_last_run_success", "Whether the last run succeeded.").Set(1)
+
+	path := filepath.Join(t.TempDir(), "gajin.prom")
+	require.NoError(t, registry.WriteFile(path))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "# HELP gajin_last_run_success Whether the last run succeeded.\n# TYPE gajin_last_run_success gauge\ngajin_last_run_success 1\n", string(data))
+}
+
+func TestCounter_PanicsOnWrongLabelCount(t *testing.T) {
+	counter := NewRegistry().Counter("entries_total", "Entries.", "kind")
+	assert.Panics(t, func() { counter.Inc() })
+}
@@ -0,0 +1,161 @@
+// Package notify posts the summaries of runs to chat services and webhooks,
+// so that the results of scheduled runs nobody watches are seen.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Statuses of runs.
+const (
+	StatusSuccess = "success"
+	StatusFailure = "failure"
+	// StatusDrift reports a dry run that found changes to apply
+	StatusDrift = "drift"
+)
+
+// maxFailures is the number of failures listed in a notification; the others
+// are only counted.
+const maxFailures = 10
+
+// Summary is the outcome of a run.
+type Summary struct {
+	Owner   string
+	Profile string
+	DryRun  bool
+	Status  string
+	// Repositories is the number of target repositories
+	Repositories int
+	// Changed are the repositories in which entries were, or in dry runs
+	// would be, created, updated or deleted
+	Changed []string
+	// Drift are the repositories whose configuration differs from GitHub,
+	// found by a dry run
+	Drift                     []string
+	Created, Updated, Deleted int
+	Failures                  []Failure
+	Duration                  time.Duration
+}
+
+// Failure is an error of a run.
+type Failure struct {
+	// Repo is the repository of the error (empty: the run itself)
+	Repo     string
+	Category string
+	Error    string
+}
+
+// Notifier posts the summary of a run.
+type Notifier interface {
+	Notify(ctx context.Context, summary Summary) error
+}
+
+// Title describes the outcome of the run in one line.
+func (s Summary) Title() string {
+	run := "gajin run"
+	if s.DryRun {
+		run = "gajin dry run"
+	}
+	var title string
+	switch {
+	case s.Status == StatusFailure:
+		title = fmt.Sprintf("%s for %s failed with %d error(s)", run, s.Owner, len(s.Failures))
+	case s.Status == StatusDrift:
+		title = fmt.Sprintf("%s for %s found drift in %d repositories", run, s.Owner, len(s.Drift))
+	case s.DryRun:
+		title = fmt.Sprintf("%s for %s found no drift", run, s.Owner)
+	default:
+		title = fmt.Sprintf("%s for %s succeeded", run, s.Owner)
+	}
+	if s.Profile != "" {
+		title += fmt.Sprintf(" (profile %s)", s.Profile)
+	}
+	return title
+}
+
+// Details describes the changes and failures of the run, one line each.
+func (s Summary) Details() []string {
+	changed := "changed"
+	if s.DryRun {
+		changed = "to change"
+	}
+	lines := []string{fmt.Sprintf("Repositories: %d, %d %s%s", s.Repositories, len(s.Changed), changed, listed(s.Changed))}
+	lines = append(lines, fmt.Sprintf("Secrets and variables: %d created, %d updated, %d deleted", s.Created, s.Updated, s.Deleted))
+	if len(s.Drift) > 0 {
+		lines = append(lines, "Drift: "+strings.Join(s.Drift, ", "))
+	}
+	if len(s.Failures) > 0 {
+		lines = append(lines, "Failures:")
+		for _, line := range failureLines(s.Failures) {
+			lines = append(lines, "  "+line)
+		}
+	}
+	return append(lines, "Duration: "+s.Duration.Round(time.Second).String())
+}
+
+// failureLines describes the first maxFailures failures, one line each, and
+// counts the others.
+func failureLines(failures []Failure) []string {
+	var lines []string
+	for i, failure := range failures {
+		if i == maxFailures {
+			lines = append(lines, fmt.Sprintf("... and %d more", len(failures)-maxFailures))
+			break
+		}
+		repo := failure.Repo
+		if repo == "" {
+			repo = "(run)"
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s", repo, failure.Error))
+	}
+	return lines
+}
+
+// listed returns the repositories in parentheses, or nothing without any.
+func listed(repos []string) string {
+	if len(repos) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(repos, ", ") + ")"
+}
+
+// postJSON posts payload as JSON to webhookURL with headers. Errors do not
+// include the URL, since webhook URLs usually hold a secret token.
+func postJSON(ctx context.Context, client *http.Client, webhookURL string, headers map[string]string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode the notification: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return errors.New("failed to create the notification request: invalid webhook URL")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("failed to send the notification: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook responded with %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newWebhookServer returns a server recording the bodies posted to it and
+// responding with status.
+func newWebhookServer(t *testing.T, status int, bodies *[]string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		body, _ := io.ReadAll(r.Body)
+		*bodies = append(*bodies, string(body))
+		if auth := r.Header.Get("Authorization"); auth != "" {
+			*bodies = append(*bodies, auth)
+		}
+		w.WriteHeader(status)
+		io.WriteString(w, "invalid_payload")
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSummary_Text(t *testing.T) {
+	summary := Summary{
+		Owner:        "my-org",
+		Profile:      "prod",
+		Status:       StatusFailure,
+		Repositories: 3,
+		Changed:      []string{"api", "web"},
+		Created:      2,
+		Updated:      1,
+		Failures:     []Failure{{Repo: "web", Category: "not-found", Error: "environment 'staging' not found"}, {Category: "other", Error: "post_apply hook failed"}},
+		Duration:     2400 * time.Millisecond,
+	}
+	assert.Equal(t, "gajin run for my-org failed with 2 error(s) (profile prod)", summary.Title())
+	assert.Equal(t, []string{
+		"Repositories: 3, 2 changed (api, web)",
+		"Secrets and variables: 2 created, 1 updated, 0 deleted",
+		"Failures:",
+		"  web: environment 'staging' not found",
+		"  (run): post_apply hook failed",
+		"Duration: 2s",
+	}, summary.Details())
+
+	drift := Summary{Owner: "my-org", DryRun: true, Status: StatusDrift, Repositories: 2, Changed: []string{"api"}, Drift: []string{"api"}, Updated: 1}
+	assert.Equal(t, "gajin dry run for my-org found drift in 1 repositories", drift.Title())
+	assert.Contains(t, drift.Details(), "Repositories: 2, 1 to change (api)")
+	assert.Contains(t, drift.Details(), "Drift: api")
+
+	assert.Equal(t, "gajin run for my-org succeeded", Summary{Owner: "my-org", Status: StatusSuccess}.Title())
+}
+
+func TestSummary_TruncatesFailures(t *testing.T) {
+	summary := Summary{Owner: "o", Status: StatusFailure}
+	for i := 0; i < maxFailures+3; i++ {
+		summary.Failures = append(summary.Failures, Failure{Repo: "r", Error: "failed"})
+	}
+	details := summary.Details()
+	assert.Contains(t, details, "  ... and 3 more")
+	assert.Len(t, details, 4+maxFailures+1)
+}
+
+func TestSlack_Notify(t *testing.T) {
+	var bodies []string
+	server := newWebhookServer(t, http.StatusOK, &bodies)
+
+	summary := Summary{Owner: "my-org", Status: StatusSuccess, Repositories: 1, Changed: []string{"api"}, Created: 1}
+	require.NoError(t, NewSlack(server.URL, server.Client()).Notify(context.Background(), summary))
+
+	require.Len(t, bodies, 1)
+	var message map[string]string
+	require.NoError(t, json.Unmarshal([]byte(bodies[0]), &message))
+	assert.Equal(t, ":white_check_mark: *gajin run for my-org succeeded*\nRepositories: 1, 1 changed (api)\nSecrets and variables: 1 created, 0 updated, 0 deleted\nDuration: 0s", message["text"])
+}
+
+func TestSlack_NotifyError(t *testing.T) {
+	var bodies []string
+	server := newWebhookServer(t, http.StatusBadRequest, &bodies)
+	webhookURL := server.URL + "/services/T000/B000/secret-token"
+
+	err := NewSlack(webhookURL, server.Client()).Notify(context.Background(), Summary{Owner: "o", Status: StatusSuccess})
+	require.Error(t, err)
+	assert.Equal(t, "webhook responded with 400 Bad Request: invalid_payload", err.Error())
+
+	// The URL holds a secret token and is never part of errors
+	server.Close()
+	err = NewSlack(webhookURL, server.Client()).Notify(context.Background(), Summary{Owner: "o", Status: StatusSuccess})
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "secret-token")
+}
+
+func TestTeams_Notify(t *testing.T) {
+	var bodies []string
+	server := newWebhookServer(t, http.StatusAccepted, &bodies)
+
+	summary := Summary{
+		Owner:        "my-org",
+		Status:       StatusFailure,
+		Repositories: 2,
+		Changed:      []string{"api"},
+		Updated:      1,
+		Failures:     []Failure{{Repo: "web", Category: "auth", Error: "forbidden"}},
+	}
+	require.NoError(t, NewTeams(server.URL, server.Client()).Notify(context.Background(), summary))
+
+	require.Len(t, bodies, 1)
+	assert.JSONEq(t, `{
+		"type": "message",
+		"attachments": [{
+			"contentType": "application/vnd.microsoft.card.adaptive",
+			"content": {
+				"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+				"type": "AdaptiveCard",
+				"version": "1.4",
+				"body": [
+					{"type": "TextBlock", "text": "gajin run for my-org failed with 1 error(s)", "weight": "Bolder", "size": "Medium", "color": "Attention", "wrap": true},
+					{"type": "FactSet", "facts": [
+						{"title": "Changed repositories", "value": "1 of 2 (api)"},
+						{"title": "Created", "value": "0"},
+						{"title": "Updated", "value": "1"},
+						{"title": "Deleted", "value": "0"},
+						{"title": "Duration", "value": "0s"}
+					]},
+					{"type": "TextBlock", "text": "Failures:\n\n- web: forbidden", "wrap": true}
+				]
+			}
+		}]
+	}`, bodies[0])
+}
+
+func TestWebhook_Notify(t *testing.T) {
+	var bodies []string
+	server := newWebhookServer(t, http.StatusNoContent, &bodies)
+
+	summary := Summary{
+		Owner:        "my-org",
+		Profile:      "prod",
+		DryRun:       true,
+		Status:       StatusDrift,
+		Repositories: 1,
+		Changed:      []string{"api"},
+		Drift:        []string{"api"},
+		Created:      1,
+		Duration:     1500 * time.Millisecond,
+	}
+	webhook := NewWebhook(server.URL, map[string]string{"Authorization": "Bearer t"}, server.Client())
+	require.NoError(t, webhook.Notify(context.Background(), summary))
+
+	require.Equal(t, []string{`{"version":1,"title":"gajin dry run for my-org found drift in 1 repositories (profile prod)","owner":"my-org","profile":"prod","dry_run":true,"status":"drift","repositories":1,"changed":["api"],"drift":["api"],"created":1,"updated":0,"deleted":0,"failures":[],"duration_seconds":1.5}`, "Bearer t"}, bodies)
+}
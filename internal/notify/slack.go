@@ -0,0 +1,37 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// Slack posts summaries to a Slack incoming webhook.
+type Slack struct {
+	webhookURL string
+	client     *http.Client
+}
+
+// NewSlack returns a notifier posting to the incoming webhook at webhookURL
+// with client.
+func NewSlack(webhookURL string, client *http.Client) *Slack {
+	return &Slack{webhookURL: webhookURL, client: client}
+}
+
+// slackMessage is the payload of an incoming webhook.
+type slackMessage struct {
+	Text string `json:"text"`
+}
+
+// slackIcons mark the title of a message by the status of the run.
+var slackIcons = map[string]string{
+	StatusSuccess: ":white_check_mark:",
+	StatusFailure: ":x:",
+	StatusDrift:   ":warning:",
+}
+
+// Notify posts the summary as a message.
+func (s *Slack) Notify(ctx context.Context, summary Summary) error {
+	text := slackIcons[summary.Status] + " *" + summary.Title() + "*\n" + strings.Join(summary.Details(), "\n")
+	return postJSON(ctx, s.client, s.webhookURL, nil, slackMessage{Text: text})
+}
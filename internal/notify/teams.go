@@ -0,0 +1,108 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Teams posts summaries as Adaptive Cards to a Microsoft Teams incoming
+// webhook or a Teams workflow triggered by webhook requests.
+type Teams struct {
+	webhookURL string
+	client     *http.Client
+}
+
+// NewTeams returns a notifier posting to the webhook at webhookURL with client.
+func NewTeams(webhookURL string, client *http.Client) *Teams {
+	return &Teams{webhookURL: webhookURL, client: client}
+}
+
+// teamsMessage is a message holding an Adaptive Card.
+type teamsMessage struct {
+	Type        string            `json:"type"`
+	Attachments []teamsAttachment `json:"attachments"`
+}
+
+type teamsAttachment struct {
+	ContentType string    `json:"contentType"`
+	Content     teamsCard `json:"content"`
+}
+
+type teamsCard struct {
+	Schema  string        `json:"$schema"`
+	Type    string        `json:"type"`
+	Version string        `json:"version"`
+	Body    []interface{} `json:"body"`
+}
+
+type teamsTextBlock struct {
+	Type   string `json:"type"`
+	Text   string `json:"text"`
+	Weight string `json:"weight,omitempty"`
+	Size   string `json:"size,omitempty"`
+	Color  string `json:"color,omitempty"`
+	Wrap   bool   `json:"wrap"`
+}
+
+type teamsFactSet struct {
+	Type  string      `json:"type"`
+	Facts []teamsFact `json:"facts"`
+}
+
+type teamsFact struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+// teamsColors color the title of a card by the status of the run.
+var teamsColors = map[string]string{
+	StatusSuccess: "Good",
+	StatusFailure: "Attention",
+	StatusDrift:   "Warning",
+}
+
+// Notify posts the summary as a card: the title, the counts as facts and the
+// failures as a list.
+func (t *Teams) Notify(ctx context.Context, summary Summary) error {
+	changed := fmt.Sprintf("%d of %d%s", len(summary.Changed), summary.Repositories, listed(summary.Changed))
+	facts := []teamsFact{
+		{Title: "Changed repositories", Value: changed},
+		{Title: "Created", Value: fmt.Sprint(summary.Created)},
+		{Title: "Updated", Value: fmt.Sprint(summary.Updated)},
+		{Title: "Deleted", Value: fmt.Sprint(summary.Deleted)},
+	}
+	if len(summary.Drift) > 0 {
+		facts = append(facts, teamsFact{Title: "Drift", Value: strings.Join(summary.Drift, ", ")})
+	}
+	facts = append(facts, teamsFact{Title: "Duration", Value: summary.Duration.Round(time.Second).String()})
+
+	body := []interface{}{
+		teamsTextBlock{Type: "TextBlock", Text: summary.Title(), Weight: "Bolder", Size: "Medium", Color: teamsColors[summary.Status], Wrap: true},
+		teamsFactSet{Type: "FactSet", Facts: facts},
+	}
+	if len(summary.Failures) > 0 {
+		// Card text is Markdown, in which list items need a blank line before them
+		var lines []string
+		for _, line := range failureLines(summary.Failures) {
+			lines = append(lines, "- "+line)
+		}
+		body = append(body, teamsTextBlock{Type: "TextBlock", Text: "Failures:\n\n" + strings.Join(lines, "\n"), Wrap: true})
+	}
+
+	message := teamsMessage{
+		Type: "message",
+		Attachments: []teamsAttachment{{
+			ContentType: "application/vnd.microsoft.card.adaptive",
+			Content: teamsCard{
+				Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+				Type:    "AdaptiveCard",
+				Version: "1.4",
+				Body:    body,
+			},
+		}},
+	}
+	return postJSON(ctx, t.client, t.webhookURL, nil, message)
+}
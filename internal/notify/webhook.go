@@ -0,0 +1,73 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+)
+
+// webhookVersion is the version of the webhook payload. It only changes when
+// fields are removed or change their meaning; new fields may be added at any
+// time.
+const webhookVersion = 1
+
+// Webhook posts summaries as JSON to any URL, for services without a
+// notifier of their own.
+type Webhook struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+// NewWebhook returns a notifier posting to url with headers and client.
+func NewWebhook(url string, headers map[string]string, client *http.Client) *Webhook {
+	return &Webhook{url: url, headers: headers, client: client}
+}
+
+// webhookPayload is the JSON document posted by Webhook.
+type webhookPayload struct {
+	Version int    `json:"version"`
+	Title   string `json:"title"`
+	Owner   string `json:"owner"`
+	Profile string `json:"profile"`
+	DryRun  bool   `json:"dry_run"`
+	// Status is success, failure or drift
+	Status          string           `json:"status"`
+	Repositories    int              `json:"repositories"`
+	Changed         []string         `json:"changed"`
+	Drift           []string         `json:"drift"`
+	Created         int              `json:"created"`
+	Updated         int              `json:"updated"`
+	Deleted         int              `json:"deleted"`
+	Failures        []webhookFailure `json:"failures"`
+	DurationSeconds float64          `json:"duration_seconds"`
+}
+
+type webhookFailure struct {
+	Repo     string `json:"repo"`
+	Category string `json:"category"`
+	Error    string `json:"error"`
+}
+
+// Notify posts the summary, listing every failure.
+func (w *Webhook) Notify(ctx context.Context, summary Summary) error {
+	payload := webhookPayload{
+		Version:         webhookVersion,
+		Title:           summary.Title(),
+		Owner:           summary.Owner,
+		Profile:         summary.Profile,
+		DryRun:          summary.DryRun,
+		Status:          summary.Status,
+		Repositories:    summary.Repositories,
+		Changed:         append([]string{}, summary.Changed...),
+		Drift:           append([]string{}, summary.Drift...),
+		Created:         summary.Created,
+		Updated:         summary.Updated,
+		Deleted:         summary.Deleted,
+		Failures:        make([]webhookFailure, 0, len(summary.Failures)),
+		DurationSeconds: summary.Duration.Seconds(),
+	}
+	for _, failure := range summary.Failures {
+		payload.Failures = append(payload.Failures, webhookFailure{Repo: failure.Repo, Category: failure.Category, Error: failure.Error})
+	}
+	return postJSON(ctx, w.client, w.url, w.headers, payload)
+}
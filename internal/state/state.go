@@ -0,0 +1,282 @@
+// Package state persists the secrets and variables gajin applied between
+// runs, so that unchanged values can be skipped and entries gajin manages can
+// be told apart from entries created by other means.
+package state
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/azolfagharj/gajin/internal/fsutil"
+)
+
+// Version is the format version of the state file.
+const Version = 1
+
+// file is the JSON document of the state file.
+type file struct {
+	Version int `json:"version"`
+	// Salt keys the hashes, so that a leaked state file does not allow
+	// guessing secret values offline without it
+	Salt      string     `json:"salt"`
+	Resources []Resource `json:"resources"`
+}
+
+// ID identifies a secret or variable.
+type ID struct {
+	Owner string `json:"owner"`
+	Repo  string `json:"repo"`
+	// Environment is empty for repository-level entries
+	Environment string `json:"environment,omitempty"`
+	// Kind is the type of the entry, e.g. repository_secret
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+// key returns the map key of the entry.
+func (id ID) key() string {
+	return strings.Join([]string{id.Owner, id.Repo, id.Environment, id.Kind, id.Name}, "\x00")
+}
+
+// Resource is a secret or variable applied by gajin.
+type Resource struct {
+	ID
+	// Hash is the salted hash of the last applied value
+	Hash string `json:"hash"`
+	// CreatedAt is when gajin first applied the entry
+	CreatedAt time.Time `json:"created_at"`
+	// UpdatedAt is when gajin last applied a different value
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Store holds the resources applied by gajin. It is safe for concurrent use.
+// The methods of a nil Store do nothing, so callers need not check whether
+// the state file is enabled.
+type Store struct {
+	path string
+	// now returns the current time; replaced in tests
+	now func() time.Time
+
+	mu        sync.Mutex
+	salt      []byte
+	resources map[string]Resource
+	// changed holds the resources recorded or forgotten since the store was
+	// loaded, with nil for forgotten ones
+	changed map[string]*Resource
+}
+
+// Load reads the state file at path. A missing file yields an empty store
+// with a new salt.
+func Load(path string) (*Store, error) {
+	store := &Store{path: path, now: time.Now, resources: make(map[string]Resource), changed: make(map[string]*Resource)}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		store.salt, err = newSalt()
+		return store, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	doc, err := decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid state file %s: %w", path, err)
+	}
+	store.salt, _ = hex.DecodeString(doc.Salt)
+	for _, resource := range doc.Resources {
+		store.resources[resource.key()] = resource
+	}
+	return store, nil
+}
+
+// Unchanged reports whether value is the last value applied to id.
+func (s *Store) Unchanged(id ID, value string) bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	resource, ok := s.resources[id.key()]
+	return ok && hmac.Equal([]byte(resource.Hash), []byte(s.hash(value)))
+}
+
+// Changed reports whether a value other than value was last applied to id,
+// e.g. a secret whose value cannot be read changed since it was applied.
+// Nothing was changed for an id without a recorded value.
+func (s *Store) Changed(id ID, value string) bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	resource, ok := s.resources[id.key()]
+	return ok && !hmac.Equal([]byte(resource.Hash), []byte(s.hash(value)))
+}
+
+// Record stores value as the last value applied to id.
+func (s *Store) Record(id ID, value string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now().UTC()
+	hash := s.hash(value)
+	resource, ok := s.resources[id.key()]
+	switch {
+	case !ok:
+		resource = Resource{ID: id, Hash: hash, CreatedAt: now, UpdatedAt: now}
+	case resource.Hash != hash:
+		resource.Hash = hash
+		resource.UpdatedAt = now
+	default:
+		return
+	}
+	s.resources[id.key()] = resource
+	s.changed[id.key()] = &resource
+}
+
+// Forget removes id, e.g. after the entry was deleted or setting it failed.
+func (s *Store) Forget(id ID) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.resources[id.key()]; !ok {
+		return
+	}
+	delete(s.resources, id.key())
+	s.changed[id.key()] = nil
+}
+
+// Resources returns the resources of owner sorted by repository,
+// environment, kind and name, or of every owner if owner is empty.
+func (s *Store) Resources(owner string) []Resource {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var resources []Resource
+	for _, resource := range s.resources {
+		if owner == "" || resource.Owner == owner {
+			resources = append(resources, resource)
+		}
+	}
+	sortResources(resources)
+	return resources
+}
+
+// Save writes the changes to the state file. The file is updated under a
+// lock, so concurrent runs on other repositories keep their changes.
+func (s *Store) Save() error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.changed) == 0 {
+		return nil
+	}
+
+	err := fsutil.UpdateFile(s.path, 0o600, func(current []byte) ([]byte, error) {
+		salt := hex.EncodeToString(s.salt)
+		resources := make(map[string]Resource)
+		if current != nil {
+			// Hashes under another salt cannot be compared and are dropped
+			if existing, err := decode(current); err == nil && existing.Salt == salt {
+				for _, resource := range existing.Resources {
+					resources[resource.key()] = resource
+				}
+			}
+		}
+		for key, resource := range s.changed {
+			if resource == nil {
+				delete(resources, key)
+			} else {
+				resources[key] = *resource
+			}
+		}
+
+		doc := &file{Version: Version, Salt: salt, Resources: make([]Resource, 0, len(resources))}
+		for _, resource := range resources {
+			doc.Resources = append(doc.Resources, resource)
+		}
+		sortResources(doc.Resources)
+		return json.MarshalIndent(doc, "", "  ")
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save state file: %w", err)
+	}
+	s.changed = make(map[string]*Resource)
+	return nil
+}
+
+// Remove deletes the state file and forgets every resource, e.g. a
+// checkpoint once the run it belongs to completed.
+func (s *Store) Remove() error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lock, err := fsutil.LockFile(s.path)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+	if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove state file: %w", err)
+	}
+	s.resources = make(map[string]Resource)
+	s.changed = make(map[string]*Resource)
+	return nil
+}
+
+// hash returns the salted hash of value. The caller holds s.mu.
+func (s *Store) hash(value string) string {
+	mac := hmac.New(sha256.New, s.salt)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// decode parses a state file.
+func decode(data []byte) (*file, error) {
+	var doc file
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Version != Version {
+		return nil, fmt.Errorf("unsupported version %d", doc.Version)
+	}
+	if _, err := hex.DecodeString(doc.Salt); err != nil || doc.Salt == "" {
+		return nil, fmt.Errorf("invalid salt")
+	}
+	return &doc, nil
+}
+
+// sortResources sorts resources by owner, repository, environment, kind and name.
+func sortResources(resources []Resource) {
+	sort.Slice(resources, func(i, j int) bool { return resources[i].key() < resources[j].key() })
+}
+
+func newSalt() ([]byte, error) {
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	return salt, nil
+}
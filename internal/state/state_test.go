@@ -0,0 +1,140 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	store, err := Load(path)
+	require.NoError(t, err)
+	token := ID{Owner: "owner", Repo: "repo", Kind: "repository_secret", Name: "TOKEN"}
+	old := ID{Owner: "owner", Repo: "repo", Environment: "production", Kind: "environment_secret", Name: "OLD"}
+	assert.False(t, store.Unchanged(token, "value"))
+	assert.False(t, store.Changed(token, "value"))
+
+	store.Record(token, "value")
+	store.Record(old, "old")
+	store.Forget(old)
+	require.NoError(t, store.Save())
+
+	// Values are stored as salted hashes only
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), `"value"`)
+	assert.NotContains(t, string(data), "OLD")
+
+	reloaded, err := Load(path)
+	require.NoError(t, err)
+	assert.True(t, reloaded.Unchanged(token, "value"))
+	assert.False(t, reloaded.Unchanged(token, "other"))
+	assert.False(t, reloaded.Changed(token, "value"))
+	assert.True(t, reloaded.Changed(token, "other"))
+	assert.False(t, reloaded.Changed(old, "old"))
+	assert.False(t, reloaded.Unchanged(old, "old"))
+	assert.False(t, reloaded.Unchanged(ID{Owner: "other", Repo: "repo", Kind: "repository_secret", Name: "TOKEN"}, "value"))
+}
+
+func TestStore_Timestamps(t *testing.T) {
+	store, err := Load(filepath.Join(t.TempDir(), "state.json"))
+	require.NoError(t, err)
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	id := ID{Owner: "owner", Repo: "repo", Kind: "repository_variable", Name: "REGION"}
+	store.Record(id, "eu")
+
+	// Applying the same value again keeps the timestamps
+	now = now.Add(time.Hour)
+	store.Record(id, "eu")
+	resources := store.Resources("owner")
+	require.Len(t, resources, 1)
+	assert.Equal(t, id, resources[0].ID)
+	assert.Equal(t, resources[0].CreatedAt, resources[0].UpdatedAt)
+
+	now = now.Add(time.Hour)
+	store.Record(id, "us")
+	resources = store.Resources("owner")
+	assert.Equal(t, now, resources[0].UpdatedAt)
+	assert.Equal(t, now.Add(-2*time.Hour), resources[0].CreatedAt)
+}
+
+func TestStore_Resources(t *testing.T) {
+	store, err := Load(filepath.Join(t.TempDir(), "state.json"))
+	require.NoError(t, err)
+	store.Record(ID{Owner: "owner", Repo: "web", Kind: "repository_secret", Name: "B"}, "b")
+	store.Record(ID{Owner: "owner", Repo: "api", Kind: "repository_secret", Name: "A"}, "a")
+	store.Record(ID{Owner: "other", Repo: "api", Kind: "repository_secret", Name: "C"}, "c")
+
+	var names []string
+	for _, resource := range store.Resources("owner") {
+		names = append(names, resource.Repo+"/"+resource.Name)
+	}
+	assert.Equal(t, []string{"api/A", "web/B"}, names)
+	assert.Len(t, store.Resources(""), 3)
+}
+
+func TestStore_ConcurrentSaves(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	first, err := Load(path)
+	require.NoError(t, err)
+	first.Record(ID{Name: "first"}, "1")
+	require.NoError(t, first.Save())
+
+	// Two runs loading the same file keep each other's changes
+	a, err := Load(path)
+	require.NoError(t, err)
+	b, err := Load(path)
+	require.NoError(t, err)
+	a.Record(ID{Name: "a"}, "1")
+	b.Record(ID{Name: "b"}, "1")
+	require.NoError(t, a.Save())
+	require.NoError(t, b.Save())
+
+	merged, err := Load(path)
+	require.NoError(t, err)
+	assert.True(t, merged.Unchanged(ID{Name: "first"}, "1"))
+	assert.True(t, merged.Unchanged(ID{Name: "a"}, "1"))
+	assert.True(t, merged.Unchanged(ID{Name: "b"}, "1"))
+}
+
+func TestStore_Remove(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	store, err := Load(path)
+	require.NoError(t, err)
+	store.Record(ID{Name: "key"}, "value")
+	require.NoError(t, store.Save())
+	require.FileExists(t, path)
+
+	require.NoError(t, store.Remove())
+	assert.NoFileExists(t, path)
+	assert.False(t, store.Unchanged(ID{Name: "key"}, "value"))
+	// Removing a missing file is not an error
+	assert.NoError(t, store.Remove())
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"version": 99}`), 0o600))
+
+	_, err := Load(path)
+	assert.ErrorContains(t, err, "unsupported version")
+}
+
+func TestNilStore(t *testing.T) {
+	var store *Store
+	store.Record(ID{Name: "key"}, "value")
+	store.Forget(ID{Name: "key"})
+	assert.False(t, store.Unchanged(ID{Name: "key"}, "value"))
+	assert.False(t, store.Changed(ID{Name: "key"}, "value"))
+	assert.Empty(t, store.Resources(""))
+	assert.NoError(t, store.Save())
+	assert.NoError(t, store.Remove())
+}
@@ -0,0 +1,129 @@
+// Package sealedbox implements libsodium-compatible sealed boxes
+// (crypto_box_seal), the format GitHub expects for encrypted Actions,
+// Dependabot and Codespaces secrets.
+//
+// A sealed box is laid out as:
+//
+//	[ephemeral public key (32 bytes)][ciphertext + MAC (16 bytes)]
+//
+// The nonce is derived using BLAKE2b with a 24-byte output over the ephemeral
+// and recipient public keys. This is NOT the same as truncating BLAKE2b-512
+// to 24 bytes, and only the former is accepted by libsodium and GitHub.
+package sealedbox
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/nacl/box"
+)
+
+const (
+	// KeySize is the size of a Curve25519 public or private key.
+	KeySize = 32
+	// NonceSize is the size of the derived nonce.
+	NonceSize = 24
+	// Overhead is the number of bytes a sealed box adds to the plaintext.
+	Overhead = KeySize + box.Overhead
+)
+
+var (
+	// ErrInvalidKey is returned when a public key is not 32 bytes long.
+	ErrInvalidKey = errors.New("sealedbox: public key must be 32 bytes")
+	// ErrOpenFailed is returned when a sealed box cannot be authenticated.
+	ErrOpenFailed = errors.New("sealedbox: message authentication failed")
+)
+
+// Seal encrypts plaintext for the recipient public key using a fresh
+// ephemeral key pair. Every call produces a different ciphertext.
+func Seal(plaintext []byte, recipient *[KeySize]byte) ([]byte, error) {
+	return SealWithRand(rand.Reader, plaintext, recipient)
+}
+
+// SealWithRand is like Seal but reads the ephemeral private key from r.
+// It exists for known-answer tests; use Seal for real secrets.
+func SealWithRand(r io.Reader, plaintext []byte, recipient *[KeySize]byte) ([]byte, error) {
+	ephemeralPublic, ephemeralPrivate, err := box.GenerateKey(r)
+	if err != nil {
+		return nil, fmt.Errorf("sealedbox: failed to generate ephemeral key: %w", err)
+	}
+
+	nonce, err := Nonce(ephemeralPublic, recipient)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, KeySize, KeySize+len(plaintext)+box.Overhead)
+	copy(out, ephemeralPublic[:])
+	return box.Seal(out, plaintext, nonce, recipient, ephemeralPrivate), nil
+}
+
+// Open decrypts a sealed box with the recipient key pair.
+func Open(sealed []byte, publicKey, privateKey *[KeySize]byte) ([]byte, error) {
+	if len(sealed) < Overhead {
+		return nil, ErrOpenFailed
+	}
+
+	var ephemeralPublic [KeySize]byte
+	copy(ephemeralPublic[:], sealed[:KeySize])
+
+	nonce, err := Nonce(&ephemeralPublic, publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, ok := box.Open(nil, sealed[KeySize:], nonce, &ephemeralPublic, privateKey)
+	if !ok {
+		return nil, ErrOpenFailed
+	}
+	return plaintext, nil
+}
+
+// Nonce derives the sealed box nonce as BLAKE2b-24(ephemeral || recipient),
+// matching libsodium's crypto_generichash with outlen=24.
+func Nonce(ephemeralPublic, recipientPublic *[KeySize]byte) (*[NonceSize]byte, error) {
+	h, err := blake2b.New(NonceSize, nil)
+	if err != nil {
+		return nil, fmt.Errorf("sealedbox: failed to create blake2b hash: %w", err)
+	}
+	h.Write(ephemeralPublic[:])
+	h.Write(recipientPublic[:])
+
+	var nonce [NonceSize]byte
+	copy(nonce[:], h.Sum(nil))
+	return &nonce, nil
+}
+
+// ParsePublicKey decodes a base64 public key as returned by the GitHub API.
+func ParsePublicKey(encoded string) (*[KeySize]byte, error) {
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("sealedbox: invalid base64 public key: %w", err)
+	}
+	if len(raw) != KeySize {
+		return nil, ErrInvalidKey
+	}
+
+	var key [KeySize]byte
+	copy(key[:], raw)
+	return &key, nil
+}
+
+// SealBase64 encrypts plaintext for a base64 encoded public key and returns
+// the base64 encoded sealed box, ready to be sent as encrypted_value.
+func SealBase64(plaintext []byte, encodedPublicKey string) (string, error) {
+	key, err := ParsePublicKey(encodedPublicKey)
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := Seal(plaintext, key)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
@@ -0,0 +1,331 @@
+package sealedbox
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/nacl/box"
+)
+
+func mustKey(t *testing.T, h string) *[KeySize]byte {
+	t.Helper()
+	raw, err := hex.DecodeString(h)
+	if err != nil || len(raw) != KeySize {
+		t.Fatalf("invalid test key %q", h)
+	}
+	var key [KeySize]byte
+	copy(key[:], raw)
+	return &key
+}
+
+func TestSealWithRand_KnownAnswer(t *testing.T) {
+	recipient := mustKey(t, katRecipientPublic)
+	ephemeralPrivate, _ := hex.DecodeString(katEphemeralPrivate)
+
+	sealed, err := SealWithRand(bytes.NewReader(ephemeralPrivate), []byte(katPlaintext), recipient)
+	if err != nil {
+		t.Fatalf("SealWithRand failed: %v", err)
+	}
+
+	if got := hex.EncodeToString(sealed); got != katSealed {
+		t.Errorf("sealed box mismatch:\n got %s\nwant %s", got, katSealed)
+	}
+}
+
+func TestNonce_KnownAnswer(t *testing.T) {
+	sealed, _ := hex.DecodeString(katSealed)
+	var ephemeralPublic [KeySize]byte
+	copy(ephemeralPublic[:], sealed[:KeySize])
+
+	nonce, err := Nonce(&ephemeralPublic, mustKey(t, katRecipientPublic))
+	if err != nil {
+		t.Fatalf("Nonce failed: %v", err)
+	}
+
+	if got := hex.EncodeToString(nonce[:]); got != katNonce {
+		t.Errorf("nonce mismatch: got %s, want %s", got, katNonce)
+	}
+}
+
+func TestOpen_KnownAnswer(t *testing.T) {
+	sealed, _ := hex.DecodeString(katSealed)
+
+	plaintext, err := Open(sealed, mustKey(t, katRecipientPublic), mustKey(t, katRecipientPrivate))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if string(plaintext) != katPlaintext {
+		t.Errorf("plaintext mismatch: got %q, want %q", plaintext, katPlaintext)
+	}
+
+	// Any modification must be rejected
+	sealed[len(sealed)-1] ^= 0xff
+	if _, err := Open(sealed, mustKey(t, katRecipientPublic), mustKey(t, katRecipientPrivate)); !errors.Is(err, ErrOpenFailed) {
+		t.Errorf("expected ErrOpenFailed for tampered box, got %v", err)
+	}
+}
+
+func TestSeal_RoundTrip(t *testing.T) {
+	publicKey, privateKey, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate test key: %v", err)
+	}
+
+	encoded, err := SealBase64([]byte("round-trip"), base64.StdEncoding.EncodeToString(publicKey[:]))
+	if err != nil {
+		t.Fatalf("SealBase64 failed: %v", err)
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		t.Fatalf("Failed to decode base64: %v", err)
+	}
+
+	plaintext, err := Open(sealed, publicKey, privateKey)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if string(plaintext) != "round-trip" {
+		t.Errorf("plaintext mismatch: got %q", plaintext)
+	}
+}
+
+func TestParsePublicKey(t *testing.T) {
+	if _, err := ParsePublicKey("not base64!"); err == nil {
+		t.Error("expected error for invalid base64")
+	}
+
+	// base64 encoded "test-public-key" (15 bytes)
+	if _, err := ParsePublicKey("dGVzdC1wdWJsaWMta2V5"); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("expected ErrInvalidKey for short key, got %v", err)
+	}
+
+	key, err := ParsePublicKey(base64.StdEncoding.EncodeToString(make([]byte, KeySize)))
+	if err != nil {
+		t.Fatalf("ParsePublicKey failed: %v", err)
+	}
+	if len(key) != KeySize {
+		t.Errorf("unexpected key length %d", len(key))
+	}
+}
+
+func TestSeal(t *testing.T) {
+	// Generate a test public key
+	publicKeyEphemeral, _, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate test key: %v", err)
+	}
+
+	// Use the ephemeral public key as the recipient public key for testing
+	var publicKey [32]byte
+	copy(publicKey[:], publicKeyEphemeral[:])
+
+	plaintext := []byte("test-secret-value")
+
+	// Encrypt the secret
+	encrypted, err := Seal(plaintext, &publicKey)
+	if err != nil {
+		t.Fatalf("Seal failed: %v", err)
+	}
+
+	// Verify the encrypted data structure
+	// Format: [ephemeral public key (32 bytes)][encrypted ciphertext]
+	if len(encrypted) < 32 {
+		t.Fatalf("encrypted data too short: got %d bytes, expected at least 32", len(encrypted))
+	}
+
+	// Extract ephemeral public key
+	var extractedEphemeralKey [32]byte
+	copy(extractedEphemeralKey[:], encrypted[:32])
+
+	// Verify that ephemeral key is not all zeros
+	allZeros := true
+	for _, b := range extractedEphemeralKey {
+		if b != 0 {
+			allZeros = false
+			break
+		}
+	}
+	if allZeros {
+		t.Error("ephemeral public key is all zeros")
+	}
+
+	// Verify encrypted ciphertext exists
+	if len(encrypted) <= 32 {
+		t.Error("encrypted ciphertext is missing")
+	}
+
+	// Verify that encrypted data is different from plaintext
+	if string(encrypted) == string(plaintext) {
+		t.Error("encrypted data is the same as plaintext")
+	}
+}
+
+func TestSeal_DifferentResults(t *testing.T) {
+	// Generate a test public key
+	publicKeyEphemeral, _, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate test key: %v", err)
+	}
+
+	var publicKey [32]byte
+	copy(publicKey[:], publicKeyEphemeral[:])
+
+	plaintext := []byte("test-secret-value")
+
+	// Encrypt the same plaintext twice
+	encrypted1, err := Seal(plaintext, &publicKey)
+	if err != nil {
+		t.Fatalf("Seal failed: %v", err)
+	}
+
+	encrypted2, err := Seal(plaintext, &publicKey)
+	if err != nil {
+		t.Fatalf("Seal failed: %v", err)
+	}
+
+	// Verify that each encryption produces different results (due to random ephemeral keys)
+	if string(encrypted1) == string(encrypted2) {
+		t.Error("Seal produces the same result for the same input")
+	}
+}
+
+func TestSeal_EmptyInput(t *testing.T) {
+	publicKeyEphemeral, _, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate test key: %v", err)
+	}
+
+	var publicKey [32]byte
+	copy(publicKey[:], publicKeyEphemeral[:])
+
+	// Test with empty input
+	encrypted, err := Seal([]byte{}, &publicKey)
+	if err != nil {
+		t.Fatalf("Seal failed with empty input: %v", err)
+	}
+
+	// Should still produce valid encrypted data
+	if len(encrypted) < 32 {
+		t.Error("encrypted data too short for empty input")
+	}
+}
+
+func TestSeal_Base64Encoding(t *testing.T) {
+	publicKeyEphemeral, _, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate test key: %v", err)
+	}
+
+	var publicKey [32]byte
+	copy(publicKey[:], publicKeyEphemeral[:])
+
+	plaintext := []byte("test-secret-value")
+
+	encrypted, err := Seal(plaintext, &publicKey)
+	if err != nil {
+		t.Fatalf("Seal failed: %v", err)
+	}
+
+	// Verify that encrypted data can be base64 encoded (as it will be in SealBase64)
+	encoded := base64.StdEncoding.EncodeToString(encrypted)
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		t.Fatalf("Failed to decode base64: %v", err)
+	}
+
+	if len(decoded) != len(encrypted) {
+		t.Errorf("Decoded length mismatch: got %d, expected %d", len(decoded), len(encrypted))
+	}
+}
+
+func TestNonceDerivation_Blake2b24(t *testing.T) {
+	// Test that we use BLAKE2b with 24-byte output, NOT truncated 64-byte output
+	// This is critical for compatibility with libsodium crypto_box_seal
+
+	ephemeralPK := make([]byte, 32)
+	recipientPK := make([]byte, 32)
+
+	// Fill with known values
+	for i := range ephemeralPK {
+		ephemeralPK[i] = byte(i)
+	}
+	for i := range recipientPK {
+		recipientPK[i] = byte(i + 32)
+	}
+
+	input := make([]byte, 64)
+	copy(input[:32], ephemeralPK)
+	copy(input[32:], recipientPK)
+
+	// Method 1: WRONG - Sum512 and truncate (old buggy code)
+	hash512 := blake2b.Sum512(input)
+	nonce1 := hash512[:24]
+
+	// Method 2: CORRECT - blake2b with 24-byte output (libsodium behavior)
+	h, err := blake2b.New(24, nil)
+	if err != nil {
+		t.Fatalf("Failed to create blake2b hash: %v", err)
+	}
+	h.Write(input)
+	nonce2 := h.Sum(nil)
+
+	// These MUST be different - if they're equal, our fix is wrong
+	if string(nonce1) == string(nonce2) {
+		t.Error("BLAKE2b-512[:24] and BLAKE2b-24 produced same output - this would break sealed box")
+	}
+
+	// Verify nonce2 is 24 bytes
+	if len(nonce2) != 24 {
+		t.Errorf("Expected nonce length 24, got %d", len(nonce2))
+	}
+}
+
+func TestSeal_OutputFormat(t *testing.T) {
+	// Test that the output format matches libsodium sealed box:
+	// [ephemeral_pk (32 bytes)][ciphertext + MAC (16 bytes)]
+	publicKeyEphemeral, _, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate test key: %v", err)
+	}
+
+	var publicKey [32]byte
+	copy(publicKey[:], publicKeyEphemeral[:])
+
+	plaintext := []byte("hello")
+	encrypted, err := Seal(plaintext, &publicKey)
+	if err != nil {
+		t.Fatalf("Seal failed: %v", err)
+	}
+
+	// Expected length: 32 (ephemeral pk) + len(plaintext) + 16 (MAC)
+	expectedLen := 32 + len(plaintext) + 16
+	if len(encrypted) != expectedLen {
+		t.Errorf("Expected encrypted length %d, got %d", expectedLen, len(encrypted))
+	}
+
+	// First 32 bytes should be a valid public key (non-zero)
+	allZeros := true
+	for i := 0; i < 32; i++ {
+		if encrypted[i] != 0 {
+			allZeros = false
+			break
+		}
+	}
+	if allZeros {
+		t.Error("Ephemeral public key in output is all zeros")
+	}
+}
+
+func TestSelfTest(t *testing.T) {
+	for _, result := range SelfTest() {
+		if result.Err != nil {
+			t.Errorf("self-test %q failed: %v", result.Name, result.Err)
+		}
+	}
+}
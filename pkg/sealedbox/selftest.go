@@ -0,0 +1,168 @@
+package sealedbox
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/nacl/box"
+)
+
+// Known-answer vector generated with libsodium: the recipient and ephemeral
+// key pairs come from crypto_box_seed_keypair with seeds of 0x01 and 0x02
+// repeated, and the result was verified with crypto_box_seal_open.
+const (
+	katRecipientPublic  = "1b1b58dd50ea14b60da17b790cd02754d970c9bab864ebb3c0f3016fe51d3f57"
+	katRecipientPrivate = "5ce86efb75fa4e2c410f46e16de9f6acae1a1703528651b69bc176c088bef3ee"
+	katEphemeralPrivate = "aa3c626bc9c38c8c201878ebb1d5b0b50ac40e8986c78793db1d4ef369fca1ce"
+	katNonce            = "2334198d91d76a3affcb252a124970dc7666191158a1c7bc"
+	katPlaintext        = "hello from gajin"
+	katSealed           = "60346e7c911a5f6ba154129174cafe75b294ac3bbd5549632f48cec6266f8410" +
+		"248eeffb147f3d4845d74ad0757462499c6363fd5ce69895741f57f72771783b"
+)
+
+// CheckResult is the outcome of a single self-test check.
+type CheckResult struct {
+	Name string
+	Err  error
+}
+
+// SelfTest runs known-answer and round-trip checks of the sealed box
+// implementation on the current platform. A nil Err means the check passed.
+func SelfTest() []CheckResult {
+	checks := []struct {
+		name string
+		fn   func() error
+	}{
+		{"blake2b-24 nonce derivation", checkNonce},
+		{"sealed box known answer", checkSealKnownAnswer},
+		{"open known answer", checkOpenKnownAnswer},
+		{"output format", checkFormat},
+		{"round trip with local key pair", checkRoundTrip},
+		{"tampered box rejected", checkTamper},
+	}
+
+	results := make([]CheckResult, 0, len(checks))
+	for _, check := range checks {
+		results = append(results, CheckResult{Name: check.name, Err: check.fn()})
+	}
+	return results
+}
+
+func decodeKey(h string) *[KeySize]byte {
+	var key [KeySize]byte
+	raw, _ := hex.DecodeString(h)
+	copy(key[:], raw)
+	return &key
+}
+
+func checkNonce() error {
+	sealed, _ := hex.DecodeString(katSealed)
+	var ephemeralPublic [KeySize]byte
+	copy(ephemeralPublic[:], sealed[:KeySize])
+	recipient := decodeKey(katRecipientPublic)
+
+	nonce, err := Nonce(&ephemeralPublic, recipient)
+	if err != nil {
+		return err
+	}
+	if got := hex.EncodeToString(nonce[:]); got != katNonce {
+		return fmt.Errorf("got nonce %s, want %s", got, katNonce)
+	}
+
+	// A truncated BLAKE2b-512 digest must not be mistaken for the real nonce
+	truncated := blake2b.Sum512(append(ephemeralPublic[:], recipient[:]...))
+	if bytes.Equal(truncated[:NonceSize], nonce[:]) {
+		return errors.New("nonce equals truncated BLAKE2b-512 output")
+	}
+	return nil
+}
+
+func checkSealKnownAnswer() error {
+	ephemeralPrivate, _ := hex.DecodeString(katEphemeralPrivate)
+	sealed, err := SealWithRand(bytes.NewReader(ephemeralPrivate), []byte(katPlaintext), decodeKey(katRecipientPublic))
+	if err != nil {
+		return err
+	}
+	if got := hex.EncodeToString(sealed); got != katSealed {
+		return fmt.Errorf("got %s, want %s", got, katSealed)
+	}
+	return nil
+}
+
+func checkOpenKnownAnswer() error {
+	sealed, _ := hex.DecodeString(katSealed)
+	plaintext, err := Open(sealed, decodeKey(katRecipientPublic), decodeKey(katRecipientPrivate))
+	if err != nil {
+		return err
+	}
+	if string(plaintext) != katPlaintext {
+		return fmt.Errorf("got plaintext %q, want %q", plaintext, katPlaintext)
+	}
+	return nil
+}
+
+func checkFormat() error {
+	publicKey, _, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+
+	plaintext := []byte("format")
+	first, err := Seal(plaintext, publicKey)
+	if err != nil {
+		return err
+	}
+	second, err := Seal(plaintext, publicKey)
+	if err != nil {
+		return err
+	}
+
+	if len(first) != len(plaintext)+Overhead {
+		return fmt.Errorf("got length %d, want %d", len(first), len(plaintext)+Overhead)
+	}
+	if bytes.Equal(first[:KeySize], make([]byte, KeySize)) {
+		return errors.New("ephemeral public key is all zeros")
+	}
+	if bytes.Equal(first, second) {
+		return errors.New("sealing the same plaintext twice produced identical output")
+	}
+	return nil
+}
+
+func checkRoundTrip() error {
+	publicKey, privateKey, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+
+	plaintext := make([]byte, 1024)
+	if _, err := rand.Read(plaintext); err != nil {
+		return err
+	}
+
+	sealed, err := Seal(plaintext, publicKey)
+	if err != nil {
+		return err
+	}
+	opened, err := Open(sealed, publicKey, privateKey)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(opened, plaintext) {
+		return errors.New("decrypted plaintext does not match")
+	}
+	return nil
+}
+
+func checkTamper() error {
+	sealed, _ := hex.DecodeString(katSealed)
+	sealed[len(sealed)-1] ^= 0xff
+	if _, err := Open(sealed, decodeKey(katRecipientPublic), decodeKey(katRecipientPrivate)); !errors.Is(err, ErrOpenFailed) {
+		return fmt.Errorf("expected authentication failure, got %v", err)
+	}
+	return nil
+}
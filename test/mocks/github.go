@@ -3,31 +3,64 @@ package mocks
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/azolfagharj/gajin/internal/github"
 )
 
+var _ github.Client = (*MockClient)(nil)
+
 // MockClient is a mock implementation of github.Client for testing.
 type MockClient struct {
-	PublicKeys           map[string]*github.PublicKey
-	Secrets              map[string]map[string]*github.SecretMetadata
-	EnvironmentSecrets   map[string]map[string]map[string]*github.SecretMetadata // repo/env/secret
-	Variables            map[string]map[string]*github.VariableMetadata
-	EnvironmentVariables map[string]map[string]map[string]*github.VariableMetadata // repo/env/variable
-	SetErrors            map[string]error
-	RepositoryIDs       map[string]int64 // owner/repo -> ID
+	PublicKeys             map[string]*github.PublicKey
+	Secrets                map[string]map[string]*github.SecretMetadata
+	EnvironmentSecrets     map[string]map[string]map[string]*github.SecretMetadata // repo/env/secret
+	Variables              map[string]map[string]*github.VariableMetadata
+	CodespacesSecrets      map[string]map[string]*github.SecretMetadata               // owner/repo -> secret
+	DependabotSecrets      map[string]map[string]*github.SecretMetadata               // owner/repo -> secret
+	UserCodespacesSecrets  map[string]*github.SecretMetadata                          // secret
+	OrganizationSecrets    map[string]map[string]*github.OrganizationSecretMetadata   // org/secret
+	OrganizationVariables  map[string]map[string]*github.OrganizationVariableMetadata // org/variable
+	SelectedRepoIDs        map[string][]int64                                         // org/name -> repository IDs
+	EnvironmentVariables   map[string]map[string]map[string]*github.VariableMetadata  // repo/env/variable
+	SetErrors              map[string]error
+	DeleteErrors           map[string]error
+	RepositoryIDs          map[string]int64                        // owner/repo -> ID
+	Repositories           map[string][]github.Repository          // owner -> repositories
+	TeamRepositories       map[string][]github.Repository          // org/team -> repositories
+	CreatedEnvironments    map[string][]string                     // owner/repo -> environments
+	EnvironmentProtections map[string]github.EnvironmentProtection // owner/repo/env -> protection
+	ActionsPermissions     map[string]github.ActionsPermissions    // owner/repo -> policy
+	WorkflowPermissions    map[string]github.WorkflowPermissions   // owner/repo -> permissions
+	ActionsDisabled        map[string]bool                         // owner/repo -> Actions disabled
+	AuthenticatedUser      string                                  // login of the token (default: mock-user)
 }
 
 // NewMockClient creates a new mock GitHub client.
 func NewMockClient() *MockClient {
 	return &MockClient{
-		PublicKeys:           make(map[string]*github.PublicKey),
-		Secrets:              make(map[string]map[string]*github.SecretMetadata),
-		EnvironmentSecrets:   make(map[string]map[string]map[string]*github.SecretMetadata),
-		Variables:            make(map[string]map[string]*github.VariableMetadata),
-		EnvironmentVariables: make(map[string]map[string]map[string]*github.VariableMetadata),
-		SetErrors:            make(map[string]error),
-		RepositoryIDs:        make(map[string]int64),
+		PublicKeys:             make(map[string]*github.PublicKey),
+		Secrets:                make(map[string]map[string]*github.SecretMetadata),
+		EnvironmentSecrets:     make(map[string]map[string]map[string]*github.SecretMetadata),
+		Variables:              make(map[string]map[string]*github.VariableMetadata),
+		CodespacesSecrets:      make(map[string]map[string]*github.SecretMetadata),
+		DependabotSecrets:      make(map[string]map[string]*github.SecretMetadata),
+		UserCodespacesSecrets:  make(map[string]*github.SecretMetadata),
+		OrganizationSecrets:    make(map[string]map[string]*github.OrganizationSecretMetadata),
+		OrganizationVariables:  make(map[string]map[string]*github.OrganizationVariableMetadata),
+		SelectedRepoIDs:        make(map[string][]int64),
+		EnvironmentVariables:   make(map[string]map[string]map[string]*github.VariableMetadata),
+		SetErrors:              make(map[string]error),
+		DeleteErrors:           make(map[string]error),
+		RepositoryIDs:          make(map[string]int64),
+		Repositories:           make(map[string][]github.Repository),
+		TeamRepositories:       make(map[string][]github.Repository),
+		CreatedEnvironments:    make(map[string][]string),
+		EnvironmentProtections: make(map[string]github.EnvironmentProtection),
+		ActionsPermissions:     make(map[string]github.ActionsPermissions),
+		WorkflowPermissions:    make(map[string]github.WorkflowPermissions),
+		ActionsDisabled:        make(map[string]bool),
 	}
 }
 
@@ -46,30 +79,33 @@ func (m *MockClient) GetPublicKey(ctx context.Context, owner, repo string) (*git
 
 // SetSecret sets a secret for a repository.
 func (m *MockClient) SetSecret(ctx context.Context, owner, repo, name, secretValue string) error {
+	_, err := m.SetRepositorySecret(ctx, owner, repo, name, secretValue)
+	return err
+}
+
+// GetSecret retrieves metadata about a secret (legacy method).
+func (m *MockClient) GetSecret(ctx context.Context, owner, repo, name string) (*github.SecretMetadata, error) {
+	return m.GetRepositorySecret(ctx, owner, repo, name)
+}
+
+// SetRepositorySecret sets a repository secret, reporting whether it was new.
+func (m *MockClient) SetRepositorySecret(ctx context.Context, owner, repo, name, secretValue string) (bool, error) {
 	key := fmt.Sprintf("%s/%s/%s", owner, repo, name)
 	if err, ok := m.SetErrors[key]; ok {
-		return err
+		return false, err
 	}
 
 	// Store the secret metadata
-	if m.Secrets[fmt.Sprintf("%s/%s", owner, repo)] == nil {
-		m.Secrets[fmt.Sprintf("%s/%s", owner, repo)] = make(map[string]*github.SecretMetadata)
+	repoKey := fmt.Sprintf("%s/%s", owner, repo)
+	if m.Secrets[repoKey] == nil {
+		m.Secrets[repoKey] = make(map[string]*github.SecretMetadata)
 	}
-	m.Secrets[fmt.Sprintf("%s/%s", owner, repo)][name] = &github.SecretMetadata{
+	_, exists := m.Secrets[repoKey][name]
+	m.Secrets[repoKey][name] = &github.SecretMetadata{
 		Name: name,
 	}
 
-	return nil
-}
-
-// GetSecret retrieves metadata about a secret (legacy method).
-func (m *MockClient) GetSecret(ctx context.Context, owner, repo, name string) (*github.SecretMetadata, error) {
-	return m.GetRepositorySecret(ctx, owner, repo, name)
-}
-
-// SetRepositorySecret sets a repository secret.
-func (m *MockClient) SetRepositorySecret(ctx context.Context, owner, repo, name, secretValue string) error {
-	return m.SetSecret(ctx, owner, repo, name, secretValue)
+	return !exists, nil
 }
 
 // GetRepositorySecret retrieves metadata about a repository secret.
@@ -83,6 +119,99 @@ func (m *MockClient) GetRepositorySecret(ctx context.Context, owner, repo, name
 	return nil, fmt.Errorf("secret not found")
 }
 
+// ListRepositorySecrets returns the secrets of a repository sorted by name.
+func (m *MockClient) ListRepositorySecrets(ctx context.Context, owner, repo string) ([]github.SecretMetadata, error) {
+	return sortedSecrets(m.Secrets[fmt.Sprintf("%s/%s", owner, repo)]), nil
+}
+
+// EnvironmentExists reports whether an environment was created or configured,
+// or holds secrets or variables.
+func (m *MockClient) EnvironmentExists(ctx context.Context, owner, repo, environment string) (bool, error) {
+	repoKey := fmt.Sprintf("%s/%s", owner, repo)
+	for _, created := range m.CreatedEnvironments[repoKey] {
+		if created == environment {
+			return true, nil
+		}
+	}
+	_, configured := m.EnvironmentProtections[repoKey+"/"+environment]
+	_, hasSecrets := m.EnvironmentSecrets[repoKey][environment]
+	_, hasVariables := m.EnvironmentVariables[repoKey][environment]
+	return configured || hasSecrets || hasVariables, nil
+}
+
+// ListEnvironments returns the environments that were created or configured,
+// or hold secrets or variables, sorted by name.
+func (m *MockClient) ListEnvironments(ctx context.Context, owner, repo string) ([]string, error) {
+	repoKey := fmt.Sprintf("%s/%s", owner, repo)
+	seen := make(map[string]bool)
+	for _, created := range m.CreatedEnvironments[repoKey] {
+		seen[created] = true
+	}
+	for key := range m.EnvironmentProtections {
+		if environment, ok := strings.CutPrefix(key, repoKey+"/"); ok {
+			seen[environment] = true
+		}
+	}
+	for environment := range m.EnvironmentSecrets[repoKey] {
+		seen[environment] = true
+	}
+	for environment := range m.EnvironmentVariables[repoKey] {
+		seen[environment] = true
+	}
+	names := make([]string, 0, len(seen))
+	for environment := range seen {
+		names = append(names, environment)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// CreateEnvironment records the creation of an environment.
+func (m *MockClient) CreateEnvironment(ctx context.Context, owner, repo, environment string) error {
+	repoKey := fmt.Sprintf("%s/%s", owner, repo)
+	if err, ok := m.SetErrors["environment:"+repoKey+"/"+environment]; ok {
+		return err
+	}
+	m.CreatedEnvironments[repoKey] = append(m.CreatedEnvironments[repoKey], environment)
+	return nil
+}
+
+// SetEnvironmentProtection records the protection rules of an environment.
+func (m *MockClient) SetEnvironmentProtection(ctx context.Context, owner, repo, environment string, protection github.EnvironmentProtection) error {
+	key := fmt.Sprintf("%s/%s/%s", owner, repo, environment)
+	if err, ok := m.SetErrors["environment:"+key]; ok {
+		return err
+	}
+	m.EnvironmentProtections[key] = protection
+	return nil
+}
+
+// GetActionsEnabled reports whether Actions is enabled, which it is unless disabled through ActionsDisabled.
+func (m *MockClient) GetActionsEnabled(ctx context.Context, owner, repo string) (bool, error) {
+	return !m.ActionsDisabled[fmt.Sprintf("%s/%s", owner, repo)], nil
+}
+
+// SetActionsPermissions records the Actions policy of a repository.
+func (m *MockClient) SetActionsPermissions(ctx context.Context, owner, repo string, permissions github.ActionsPermissions) error {
+	key := fmt.Sprintf("%s/%s", owner, repo)
+	if err, ok := m.SetErrors["actions:"+key]; ok {
+		return err
+	}
+	m.ActionsPermissions[key] = permissions
+	m.ActionsDisabled[key] = !permissions.Enabled
+	return nil
+}
+
+// SetWorkflowPermissions records the default workflow permissions of a repository.
+func (m *MockClient) SetWorkflowPermissions(ctx context.Context, owner, repo string, permissions github.WorkflowPermissions) error {
+	key := fmt.Sprintf("%s/%s", owner, repo)
+	if err, ok := m.SetErrors["workflow:"+key]; ok {
+		return err
+	}
+	m.WorkflowPermissions[key] = permissions
+	return nil
+}
+
 // GetRepositoryID retrieves the repository ID.
 func (m *MockClient) GetRepositoryID(ctx context.Context, owner, repo string) (int64, error) {
 	key := fmt.Sprintf("%s/%s", owner, repo)
@@ -94,6 +223,49 @@ func (m *MockClient) GetRepositoryID(ctx context.Context, owner, repo string) (i
 	return 12345, nil
 }
 
+// GetAuthenticatedUser returns the login of the token.
+func (m *MockClient) GetAuthenticatedUser(ctx context.Context) (string, error) {
+	if m.AuthenticatedUser == "" {
+		return "mock-user", nil
+	}
+	return m.AuthenticatedUser, nil
+}
+
+// ListOwnerRepositories returns the repositories registered for an owner.
+func (m *MockClient) ListOwnerRepositories(ctx context.Context, owner string, opts *github.ListRepositoriesOptions) ([]github.Repository, error) {
+	if repos, ok := m.Repositories[owner]; ok {
+		return repos, nil
+	}
+	return nil, fmt.Errorf("owner not found")
+}
+
+// ListTeamRepositories returns the repositories registered for a team.
+func (m *MockClient) ListTeamRepositories(ctx context.Context, org, teamSlug string) ([]github.Repository, error) {
+	if repos, ok := m.TeamRepositories[fmt.Sprintf("%s/%s", org, teamSlug)]; ok {
+		return repos, nil
+	}
+	return nil, fmt.Errorf("team not found")
+}
+
+// GetRepositoriesMetadata returns the metadata of the registered repositories of an owner.
+func (m *MockClient) GetRepositoriesMetadata(ctx context.Context, owner string, repos []string) (map[string]github.RepositoryMetadata, error) {
+	result := make(map[string]github.RepositoryMetadata)
+	for _, repo := range repos {
+		id, err := m.GetRepositoryID(ctx, owner, repo)
+		if err != nil {
+			return nil, err
+		}
+		metadata := github.RepositoryMetadata{ID: id}
+		for _, r := range m.Repositories[owner] {
+			if r.Name == repo {
+				metadata.Archived = r.Archived
+			}
+		}
+		result[repo] = metadata
+	}
+	return result, nil
+}
+
 // GetEnvironmentPublicKey retrieves the public key for an environment.
 func (m *MockClient) GetEnvironmentPublicKey(ctx context.Context, owner, repo, environment string) (*github.PublicKey, error) {
 	key := fmt.Sprintf("%s/%s/%s", owner, repo, environment)
@@ -108,10 +280,10 @@ func (m *MockClient) GetEnvironmentPublicKey(ctx context.Context, owner, repo, e
 }
 
 // SetEnvironmentSecret sets an environment secret.
-func (m *MockClient) SetEnvironmentSecret(ctx context.Context, owner, repo, environment, name, secretValue string) error {
+func (m *MockClient) SetEnvironmentSecret(ctx context.Context, owner, repo, environment, name, secretValue string) (bool, error) {
 	key := fmt.Sprintf("%s/%s/%s/%s", owner, repo, environment, name)
 	if err, ok := m.SetErrors[key]; ok {
-		return err
+		return false, err
 	}
 
 	repoKey := fmt.Sprintf("%s/%s", owner, repo)
@@ -121,11 +293,12 @@ func (m *MockClient) SetEnvironmentSecret(ctx context.Context, owner, repo, envi
 	if m.EnvironmentSecrets[repoKey][environment] == nil {
 		m.EnvironmentSecrets[repoKey][environment] = make(map[string]*github.SecretMetadata)
 	}
+	_, exists := m.EnvironmentSecrets[repoKey][environment][name]
 	m.EnvironmentSecrets[repoKey][environment][name] = &github.SecretMetadata{
 		Name: name,
 	}
 
-	return nil
+	return !exists, nil
 }
 
 // GetEnvironmentSecret retrieves metadata about an environment secret.
@@ -141,23 +314,29 @@ func (m *MockClient) GetEnvironmentSecret(ctx context.Context, owner, repo, envi
 	return nil, fmt.Errorf("environment secret not found")
 }
 
+// ListEnvironmentSecrets returns the secrets of an environment sorted by name.
+func (m *MockClient) ListEnvironmentSecrets(ctx context.Context, owner, repo, environment string) ([]github.SecretMetadata, error) {
+	return sortedSecrets(m.EnvironmentSecrets[fmt.Sprintf("%s/%s", owner, repo)][environment]), nil
+}
+
 // SetRepositoryVariable sets a repository variable.
-func (m *MockClient) SetRepositoryVariable(ctx context.Context, owner, repo, name, value string) error {
+func (m *MockClient) SetRepositoryVariable(ctx context.Context, owner, repo, name, value string) (bool, error) {
 	key := fmt.Sprintf("%s/%s/%s", owner, repo, name)
 	if err, ok := m.SetErrors[key]; ok {
-		return err
+		return false, err
 	}
 
 	repoKey := fmt.Sprintf("%s/%s", owner, repo)
 	if m.Variables[repoKey] == nil {
 		m.Variables[repoKey] = make(map[string]*github.VariableMetadata)
 	}
+	_, exists := m.Variables[repoKey][name]
 	m.Variables[repoKey][name] = &github.VariableMetadata{
 		Name:  name,
 		Value: value,
 	}
 
-	return nil
+	return !exists, nil
 }
 
 // GetRepositoryVariable retrieves a repository variable.
@@ -171,11 +350,16 @@ func (m *MockClient) GetRepositoryVariable(ctx context.Context, owner, repo, nam
 	return nil, fmt.Errorf("variable not found")
 }
 
+// ListRepositoryVariables returns the variables of a repository sorted by name.
+func (m *MockClient) ListRepositoryVariables(ctx context.Context, owner, repo string) ([]github.VariableMetadata, error) {
+	return sortedVariables(m.Variables[fmt.Sprintf("%s/%s", owner, repo)]), nil
+}
+
 // SetEnvironmentVariable sets an environment variable.
-func (m *MockClient) SetEnvironmentVariable(ctx context.Context, owner, repo, environment, name, value string) error {
+func (m *MockClient) SetEnvironmentVariable(ctx context.Context, owner, repo, environment, name, value string) (bool, error) {
 	key := fmt.Sprintf("%s/%s/%s/%s", owner, repo, environment, name)
 	if err, ok := m.SetErrors[key]; ok {
-		return err
+		return false, err
 	}
 
 	repoKey := fmt.Sprintf("%s/%s", owner, repo)
@@ -185,12 +369,13 @@ func (m *MockClient) SetEnvironmentVariable(ctx context.Context, owner, repo, en
 	if m.EnvironmentVariables[repoKey][environment] == nil {
 		m.EnvironmentVariables[repoKey][environment] = make(map[string]*github.VariableMetadata)
 	}
+	_, exists := m.EnvironmentVariables[repoKey][environment][name]
 	m.EnvironmentVariables[repoKey][environment][name] = &github.VariableMetadata{
 		Name:  name,
 		Value: value,
 	}
 
-	return nil
+	return !exists, nil
 }
 
 // GetEnvironmentVariable retrieves an environment variable.
@@ -206,3 +391,364 @@ func (m *MockClient) GetEnvironmentVariable(ctx context.Context, owner, repo, en
 	return nil, fmt.Errorf("environment variable not found")
 }
 
+// ListEnvironmentVariables returns the variables of an environment sorted by name.
+func (m *MockClient) ListEnvironmentVariables(ctx context.Context, owner, repo, environment string) ([]github.VariableMetadata, error) {
+	return sortedVariables(m.EnvironmentVariables[fmt.Sprintf("%s/%s", owner, repo)][environment]), nil
+}
+
+// DeleteRepositorySecret deletes a repository secret.
+func (m *MockClient) DeleteRepositorySecret(ctx context.Context, owner, repo, name string) error {
+	key := fmt.Sprintf("%s/%s/%s", owner, repo, name)
+	if err, ok := m.DeleteErrors[key]; ok {
+		return err
+	}
+	delete(m.Secrets[fmt.Sprintf("%s/%s", owner, repo)], name)
+	return nil
+}
+
+// DeleteEnvironmentSecret deletes an environment secret.
+func (m *MockClient) DeleteEnvironmentSecret(ctx context.Context, owner, repo, environment, name string) error {
+	key := fmt.Sprintf("%s/%s/%s/%s", owner, repo, environment, name)
+	if err, ok := m.DeleteErrors[key]; ok {
+		return err
+	}
+	if envSecrets, ok := m.EnvironmentSecrets[fmt.Sprintf("%s/%s", owner, repo)]; ok {
+		delete(envSecrets[environment], name)
+	}
+	return nil
+}
+
+// DeleteRepositoryVariable deletes a repository variable.
+func (m *MockClient) DeleteRepositoryVariable(ctx context.Context, owner, repo, name string) error {
+	key := fmt.Sprintf("%s/%s/%s", owner, repo, name)
+	if err, ok := m.DeleteErrors[key]; ok {
+		return err
+	}
+	delete(m.Variables[fmt.Sprintf("%s/%s", owner, repo)], name)
+	return nil
+}
+
+// DeleteEnvironmentVariable deletes an environment variable.
+func (m *MockClient) DeleteEnvironmentVariable(ctx context.Context, owner, repo, environment, name string) error {
+	key := fmt.Sprintf("%s/%s/%s/%s", owner, repo, environment, name)
+	if err, ok := m.DeleteErrors[key]; ok {
+		return err
+	}
+	if envVars, ok := m.EnvironmentVariables[fmt.Sprintf("%s/%s", owner, repo)]; ok {
+		delete(envVars[environment], name)
+	}
+	return nil
+}
+
+// GetOrganizationPublicKey retrieves the public key for an organization.
+func (m *MockClient) GetOrganizationPublicKey(ctx context.Context, org string) (*github.PublicKey, error) {
+	if pk, ok := m.PublicKeys[org]; ok {
+		return pk, nil
+	}
+	// Return a default public key
+	return &github.PublicKey{
+		KeyID: "test-org-key-id",
+		Key:   "dGVzdC1vcmctcHVibGljLWtleQ==", // base64 encoded "test-org-public-key"
+	}, nil
+}
+
+// SetOrganizationSecret sets an organization secret.
+func (m *MockClient) SetOrganizationSecret(ctx context.Context, org, name, secretValue, visibility string, selectedRepoIDs []int64) error {
+	key := fmt.Sprintf("%s/%s", org, name)
+	if err, ok := m.SetErrors[key]; ok {
+		return err
+	}
+
+	if m.OrganizationSecrets[org] == nil {
+		m.OrganizationSecrets[org] = make(map[string]*github.OrganizationSecretMetadata)
+	}
+	m.OrganizationSecrets[org][name] = &github.OrganizationSecretMetadata{
+		Name:       name,
+		Visibility: visibility,
+	}
+	m.SelectedRepoIDs[key] = selectedRepoIDs
+
+	return nil
+}
+
+// SetOrganizationSecretRepositories replaces the repositories that can access an organization secret.
+func (m *MockClient) SetOrganizationSecretRepositories(ctx context.Context, org, name string, repoIDs []int64) error {
+	key := fmt.Sprintf("%s/%s", org, name)
+	if err, ok := m.SetErrors[key]; ok {
+		return err
+	}
+	m.SelectedRepoIDs[key] = repoIDs
+	return nil
+}
+
+// ListOrganizationSecretRepositories returns the repositories that can access an organization secret.
+func (m *MockClient) ListOrganizationSecretRepositories(ctx context.Context, org, name string) ([]github.Repository, error) {
+	return m.selectedRepositories(org, name), nil
+}
+
+// GetOrganizationSecret retrieves metadata about an organization secret.
+func (m *MockClient) GetOrganizationSecret(ctx context.Context, org, name string) (*github.OrganizationSecretMetadata, error) {
+	if secret, ok := m.OrganizationSecrets[org][name]; ok {
+		return secret, nil
+	}
+	return nil, fmt.Errorf("organization secret not found")
+}
+
+// DeleteOrganizationSecret deletes an organization secret.
+func (m *MockClient) DeleteOrganizationSecret(ctx context.Context, org, name string) error {
+	key := fmt.Sprintf("%s/%s", org, name)
+	if err, ok := m.DeleteErrors[key]; ok {
+		return err
+	}
+	delete(m.OrganizationSecrets[org], name)
+	delete(m.SelectedRepoIDs, key)
+	return nil
+}
+
+// GetCodespacesPublicKey retrieves the Codespaces public key for a repository.
+func (m *MockClient) GetCodespacesPublicKey(ctx context.Context, owner, repo string) (*github.PublicKey, error) {
+	key := fmt.Sprintf("codespaces:%s/%s", owner, repo)
+	if pk, ok := m.PublicKeys[key]; ok {
+		return pk, nil
+	}
+	// Return a default public key
+	return &github.PublicKey{
+		KeyID: "test-codespaces-key-id",
+		Key:   "dGVzdC1jb2Rlc3BhY2VzLWtleQ==", // base64 encoded "test-codespaces-key"
+	}, nil
+}
+
+// SetCodespacesSecret sets a repository Codespaces secret.
+func (m *MockClient) SetCodespacesSecret(ctx context.Context, owner, repo, name, secretValue string) (bool, error) {
+	key := fmt.Sprintf("codespaces:%s/%s/%s", owner, repo, name)
+	if err, ok := m.SetErrors[key]; ok {
+		return false, err
+	}
+
+	repoKey := fmt.Sprintf("%s/%s", owner, repo)
+	if m.CodespacesSecrets[repoKey] == nil {
+		m.CodespacesSecrets[repoKey] = make(map[string]*github.SecretMetadata)
+	}
+	_, exists := m.CodespacesSecrets[repoKey][name]
+	m.CodespacesSecrets[repoKey][name] = &github.SecretMetadata{
+		Name: name,
+	}
+
+	return !exists, nil
+}
+
+// GetCodespacesSecret retrieves metadata about a repository Codespaces secret.
+func (m *MockClient) GetCodespacesSecret(ctx context.Context, owner, repo, name string) (*github.SecretMetadata, error) {
+	if secret, ok := m.CodespacesSecrets[fmt.Sprintf("%s/%s", owner, repo)][name]; ok {
+		return secret, nil
+	}
+	return nil, fmt.Errorf("codespaces secret not found")
+}
+
+// ListCodespacesSecrets returns the Codespaces secrets of a repository sorted by name.
+func (m *MockClient) ListCodespacesSecrets(ctx context.Context, owner, repo string) ([]github.SecretMetadata, error) {
+	return sortedSecrets(m.CodespacesSecrets[fmt.Sprintf("%s/%s", owner, repo)]), nil
+}
+
+// DeleteCodespacesSecret deletes a repository Codespaces secret.
+func (m *MockClient) DeleteCodespacesSecret(ctx context.Context, owner, repo, name string) error {
+	key := fmt.Sprintf("codespaces:%s/%s/%s", owner, repo, name)
+	if err, ok := m.DeleteErrors[key]; ok {
+		return err
+	}
+	delete(m.CodespacesSecrets[fmt.Sprintf("%s/%s", owner, repo)], name)
+	return nil
+}
+
+// GetUserCodespacesPublicKey retrieves the Codespaces public key of the authenticated user.
+func (m *MockClient) GetUserCodespacesPublicKey(ctx context.Context) (*github.PublicKey, error) {
+	if pk, ok := m.PublicKeys["codespaces:user"]; ok {
+		return pk, nil
+	}
+	// Return a default public key
+	return &github.PublicKey{
+		KeyID: "test-user-codespaces-key-id",
+		Key:   "dGVzdC11c2VyLWNvZGVzcGFjZXMta2V5", // base64 encoded "test-user-codespaces-key"
+	}, nil
+}
+
+// SetUserCodespacesSecret sets a Codespaces secret of the authenticated user.
+func (m *MockClient) SetUserCodespacesSecret(ctx context.Context, name, secretValue string, selectedRepoIDs []int64) error {
+	key := fmt.Sprintf("user/%s", name)
+	if err, ok := m.SetErrors[key]; ok {
+		return err
+	}
+
+	m.UserCodespacesSecrets[name] = &github.SecretMetadata{
+		Name: name,
+	}
+	if selectedRepoIDs != nil {
+		m.SelectedRepoIDs[key] = selectedRepoIDs
+	}
+
+	return nil
+}
+
+// GetUserCodespacesSecret retrieves metadata about a Codespaces secret of the authenticated user.
+func (m *MockClient) GetUserCodespacesSecret(ctx context.Context, name string) (*github.SecretMetadata, error) {
+	if secret, ok := m.UserCodespacesSecrets[name]; ok {
+		return secret, nil
+	}
+	return nil, fmt.Errorf("user codespaces secret not found")
+}
+
+// DeleteUserCodespacesSecret deletes a Codespaces secret of the authenticated user.
+func (m *MockClient) DeleteUserCodespacesSecret(ctx context.Context, name string) error {
+	key := fmt.Sprintf("user/%s", name)
+	if err, ok := m.DeleteErrors[key]; ok {
+		return err
+	}
+	delete(m.UserCodespacesSecrets, name)
+	delete(m.SelectedRepoIDs, key)
+	return nil
+}
+
+// GetDependabotPublicKey retrieves the Dependabot public key for a repository.
+func (m *MockClient) GetDependabotPublicKey(ctx context.Context, owner, repo string) (*github.PublicKey, error) {
+	key := fmt.Sprintf("dependabot:%s/%s", owner, repo)
+	if pk, ok := m.PublicKeys[key]; ok {
+		return pk, nil
+	}
+	// Return a default public key
+	return &github.PublicKey{
+		KeyID: "test-dependabot-key-id",
+		Key:   "dGVzdC1kZXBlbmRhYm90LWtleQ==", // base64 encoded "test-dependabot-key"
+	}, nil
+}
+
+// SetDependabotSecret sets a repository Dependabot secret.
+func (m *MockClient) SetDependabotSecret(ctx context.Context, owner, repo, name, secretValue string) error {
+	key := fmt.Sprintf("dependabot:%s/%s/%s", owner, repo, name)
+	if err, ok := m.SetErrors[key]; ok {
+		return err
+	}
+
+	repoKey := fmt.Sprintf("%s/%s", owner, repo)
+	if m.DependabotSecrets[repoKey] == nil {
+		m.DependabotSecrets[repoKey] = make(map[string]*github.SecretMetadata)
+	}
+	m.DependabotSecrets[repoKey][name] = &github.SecretMetadata{
+		Name: name,
+	}
+
+	return nil
+}
+
+// GetDependabotSecret retrieves metadata about a repository Dependabot secret.
+func (m *MockClient) GetDependabotSecret(ctx context.Context, owner, repo, name string) (*github.SecretMetadata, error) {
+	if secret, ok := m.DependabotSecrets[fmt.Sprintf("%s/%s", owner, repo)][name]; ok {
+		return secret, nil
+	}
+	return nil, fmt.Errorf("dependabot secret not found")
+}
+
+// DeleteDependabotSecret deletes a repository Dependabot secret.
+func (m *MockClient) DeleteDependabotSecret(ctx context.Context, owner, repo, name string) error {
+	key := fmt.Sprintf("dependabot:%s/%s/%s", owner, repo, name)
+	if err, ok := m.DeleteErrors[key]; ok {
+		return err
+	}
+	delete(m.DependabotSecrets[fmt.Sprintf("%s/%s", owner, repo)], name)
+	return nil
+}
+
+// SetOrganizationVariable sets an organization variable.
+func (m *MockClient) SetOrganizationVariable(ctx context.Context, org, name, value, visibility string, selectedRepoIDs []int64) error {
+	key := fmt.Sprintf("%s/%s", org, name)
+	if err, ok := m.SetErrors[key]; ok {
+		return err
+	}
+
+	if m.OrganizationVariables[org] == nil {
+		m.OrganizationVariables[org] = make(map[string]*github.OrganizationVariableMetadata)
+	}
+	m.OrganizationVariables[org][name] = &github.OrganizationVariableMetadata{
+		Name:       name,
+		Value:      value,
+		Visibility: visibility,
+	}
+	m.SelectedRepoIDs[key] = selectedRepoIDs
+
+	return nil
+}
+
+// GetOrganizationVariable retrieves an organization variable.
+func (m *MockClient) GetOrganizationVariable(ctx context.Context, org, name string) (*github.OrganizationVariableMetadata, error) {
+	if variable, ok := m.OrganizationVariables[org][name]; ok {
+		return variable, nil
+	}
+	return nil, fmt.Errorf("organization variable not found")
+}
+
+// ListOrganizationVariables returns the variables of an organization sorted by name.
+func (m *MockClient) ListOrganizationVariables(ctx context.Context, org string) ([]github.OrganizationVariableMetadata, error) {
+	result := make([]github.OrganizationVariableMetadata, 0, len(m.OrganizationVariables[org]))
+	for _, variable := range m.OrganizationVariables[org] {
+		result = append(result, *variable)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+// ListOrganizationVariableRepositories returns the repositories that can access an organization variable.
+func (m *MockClient) ListOrganizationVariableRepositories(ctx context.Context, org, name string) ([]github.Repository, error) {
+	return m.selectedRepositories(org, name), nil
+}
+
+// DeleteOrganizationVariable deletes an organization variable.
+func (m *MockClient) DeleteOrganizationVariable(ctx context.Context, org, name string) error {
+	key := fmt.Sprintf("%s/%s", org, name)
+	if err, ok := m.DeleteErrors[key]; ok {
+		return err
+	}
+	delete(m.OrganizationVariables[org], name)
+	delete(m.SelectedRepoIDs, key)
+	return nil
+}
+
+// sortedSecrets returns the secrets of a map sorted by name.
+func sortedSecrets(secrets map[string]*github.SecretMetadata) []github.SecretMetadata {
+	result := make([]github.SecretMetadata, 0, len(secrets))
+	for _, secret := range secrets {
+		result = append(result, *secret)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// sortedVariables returns the variables of a map sorted by name.
+func sortedVariables(variables map[string]*github.VariableMetadata) []github.VariableMetadata {
+	result := make([]github.VariableMetadata, 0, len(variables))
+	for _, variable := range variables {
+		result = append(result, *variable)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// selectedRepositories returns the repositories recorded for an organization
+// secret or variable, named after the matching RepositoryIDs entries.
+func (m *MockClient) selectedRepositories(org, name string) []github.Repository {
+	var repos []github.Repository
+	for _, id := range m.SelectedRepoIDs[fmt.Sprintf("%s/%s", org, name)] {
+		repo := github.Repository{ID: id}
+		for key, repoID := range m.RepositoryIDs {
+			if repoID == id && strings.HasPrefix(key, org+"/") {
+				repo.Name = strings.TrimPrefix(key, org+"/")
+			}
+		}
+		repos = append(repos, repo)
+	}
+	return repos
+}